@@ -0,0 +1,259 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/mikeboe/research-helper/pkg/config"
+	"github.com/mikeboe/research-helper/pkg/embeddings"
+	"github.com/mikeboe/research-helper/pkg/vectorstore"
+	"github.com/spf13/cobra"
+)
+
+// newExportCmd returns the command that writes a collection to JSONL, one document per line
+func newExportCmd() *cobra.Command {
+	var withEmbeddings bool
+
+	cmd := &cobra.Command{
+		Use:   "export <collection> [file]",
+		Short: "Export a collection as JSONL",
+		Long:  `Writes every document of a collection as one JSON object per line, to the file or stdout. With --embeddings the vectors are included, so the export can be searched offline with search-file.`,
+		Args:  cobra.RangeArgs(1, 2),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := context.Background()
+
+			db := openDB(ctx)
+			defer db.Close()
+
+			store, err := vectorstore.NewPGVectorStore(db.Pool, args[0])
+			if err != nil {
+				slog.Error("Invalid collection", "collection", args[0], "error", err)
+				os.Exit(1)
+			}
+
+			var out io.Writer = os.Stdout
+			if len(args) == 2 {
+				f, err := os.Create(args[1])
+				if err != nil {
+					slog.Error("Failed to create export file", "error", err)
+					os.Exit(1)
+				}
+				defer f.Close()
+				out = f
+			}
+			w := bufio.NewWriter(out)
+			enc := json.NewEncoder(w)
+
+			count := 0
+			err = store.Export(ctx, withEmbeddings, func(doc vectorstore.Document) error {
+				count++
+				return enc.Encode(doc)
+			})
+			if err == nil {
+				err = w.Flush()
+			}
+			if err != nil {
+				slog.Error("Export failed", "collection", args[0], "error", err)
+				os.Exit(1)
+			}
+			slog.Info("Exported collection", "collection", args[0], "documents", count)
+		},
+	}
+	cmd.Flags().BoolVar(&withEmbeddings, "embeddings", false, "Include the embedding of every document (required for search-file)")
+	return cmd
+}
+
+// newPruneCmd returns the command that deletes the documents a job indexed into a collection
+func newPruneCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "prune <collection> <job-id>",
+		Short: "Delete the documents a job indexed",
+		Long:  `Deletes the documents tagged with the job's ID (the job_id metadata field) from a collection. Documents indexed by other jobs, or before jobs were recorded on documents, are kept.`,
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := context.Background()
+
+			jobID, err := uuid.Parse(args[1])
+			if err != nil {
+				slog.Error("Invalid job id", "id", args[1], "error", err)
+				os.Exit(1)
+			}
+
+			db := openDB(ctx)
+			defer db.Close()
+
+			store, err := vectorstore.NewPGVectorStore(db.Pool, args[0])
+			if err != nil {
+				slog.Error("Invalid collection", "collection", args[0], "error", err)
+				os.Exit(1)
+			}
+
+			deleted, err := store.DeleteByMetadata(ctx, map[string]interface{}{"job_id": jobID.String()})
+			if err != nil {
+				slog.Error("Prune failed", "collection", args[0], "error", err)
+				os.Exit(1)
+			}
+			slog.Info("Pruned job documents", "collection", args[0], "job", jobID, "documents", deleted)
+		},
+	}
+}
+
+// newCurateCmd returns the command that marks documents of a collection as curated
+func newCurateCmd() *cobra.Command {
+	var unset bool
+
+	cmd := &cobra.Command{
+		Use:   "curate <collection> <filter>",
+		Short: "Mark documents as curated",
+		Long:  `Sets the curated metadata flag on the documents matching a JSON metadata filter, e.g. '{"source": "http://arxiv.org/pdf/1706.03762v7"}'. Marked documents are what promote copies by default.`,
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := context.Background()
+
+			filter, err := parseFilter(args[1])
+			if err != nil {
+				slog.Error("Invalid filter", "error", err)
+				os.Exit(1)
+			}
+
+			db := openDB(ctx)
+			defer db.Close()
+
+			store, err := vectorstore.NewPGVectorStore(db.Pool, args[0])
+			if err != nil {
+				slog.Error("Invalid collection", "collection", args[0], "error", err)
+				os.Exit(1)
+			}
+
+			updated, err := store.MarkCurated(ctx, filter, !unset)
+			if err != nil {
+				slog.Error("Curate failed", "collection", args[0], "error", err)
+				os.Exit(1)
+			}
+			slog.Info("Marked documents", "collection", args[0], "curated", !unset, "documents", updated)
+		},
+	}
+	cmd.Flags().BoolVar(&unset, "unset", false, "Clear the curated flag instead of setting it")
+	return cmd
+}
+
+// newPromoteCmd returns the command that copies documents into a curated collection
+func newPromoteCmd(c *config.Config) *cobra.Command {
+	var filterJSON string
+
+	cmd := &cobra.Command{
+		Use:   "promote <collection> [target]",
+		Short: "Copy curated documents into another collection",
+		Long:  `Copies the documents marked curated (or those matching --filter) into the target collection, creating it if needed. The target defaults to CHAT_COLLECTION, so chat only searches the promoted documents. Promoting a document again replaces its earlier copy.`,
+		Args:  cobra.RangeArgs(1, 2),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := context.Background()
+
+			target := c.ChatCollection
+			if len(args) == 2 {
+				target = args[1]
+			}
+			filter := vectorstore.CuratedFilter()
+			if filterJSON != "" {
+				var err error
+				if filter, err = parseFilter(filterJSON); err != nil {
+					slog.Error("Invalid filter", "error", err)
+					os.Exit(1)
+				}
+			}
+
+			db := openDB(ctx)
+			defer db.Close()
+
+			promoted, err := db.PromoteDocuments(ctx, args[0], target, embeddings.Dimension, filter)
+			if err != nil {
+				slog.Error("Promote failed", "collection", args[0], "target", target, "error", err)
+				os.Exit(1)
+			}
+			slog.Info("Promoted documents", "collection", args[0], "target", target, "documents", promoted)
+		},
+	}
+	cmd.Flags().StringVar(&filterJSON, "filter", "", "JSON metadata filter selecting the documents to promote (default: those marked curated)")
+	return cmd
+}
+
+// parseFilter decodes a JSON metadata filter given on the command line
+func parseFilter(s string) (map[string]interface{}, error) {
+	var filter map[string]interface{}
+	if err := json.Unmarshal([]byte(s), &filter); err != nil {
+		return nil, err
+	}
+	if len(filter) == 0 {
+		return nil, fmt.Errorf("%w: filter is empty", vectorstore.ErrInvalidFilter)
+	}
+	return filter, nil
+}
+
+// newSearchFileCmd returns the command that searches an exported collection in memory.
+// Only the query is embedded; no database is needed.
+func newSearchFileCmd(c *config.Config) *cobra.Command {
+	var topK int
+
+	cmd := &cobra.Command{
+		Use:   "search-file <corpus.jsonl> <query>",
+		Short: "Search an exported collection without a database",
+		Long:  `Loads a collection exported with "export --embeddings" into memory and ranks its documents by cosine similarity to the query. The query is embedded with the configured embedding model, which must match the one the collection was indexed with.`,
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			requireAPIKey(c)
+			ctx := context.Background()
+
+			f, err := os.Open(args[0])
+			if err != nil {
+				slog.Error("Failed to open corpus", "error", err)
+				os.Exit(1)
+			}
+			docs, err := vectorstore.ReadJSONL(f)
+			f.Close()
+			if err != nil {
+				slog.Error("Failed to read corpus", "file", args[0], "error", err)
+				os.Exit(1)
+			}
+
+			embedder, err := embeddings.NewGoogleEmbedder(ctx, c.EmbeddingModel, c.GoogleApiKey)
+			if err != nil {
+				slog.Error("Failed to create embedder", "error", err)
+				os.Exit(1)
+			}
+			embedder.WithRetry(c.EmbeddingMaxRetries)
+			queryEmbedding, err := embedder.EmbedText(ctx, args[1])
+			if err != nil {
+				slog.Error("Failed to embed query", "error", err)
+				os.Exit(1)
+			}
+
+			results := vectorstore.SearchDocuments(docs, queryEmbedding, topK)
+			if len(results) == 0 {
+				slog.Warn("No documents with matching embeddings; was the corpus exported with --embeddings?", "documents", len(docs))
+				return
+			}
+			for i, r := range results {
+				fmt.Printf("%d. [%.3f] %v\n", i+1, r.Score.Similarity, r.Document.Metadata["source"])
+				fmt.Printf("   %s\n\n", excerpt(r.Document.Content, 300))
+			}
+		},
+	}
+	cmd.Flags().IntVarP(&topK, "top-k", "k", 5, "Number of results")
+	return cmd
+}
+
+// excerpt flattens whitespace in s and cuts it to at most n runes
+func excerpt(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > n {
+		return string(r[:n]) + "..."
+	}
+	return s
+}
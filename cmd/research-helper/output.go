@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mikeboe/research-helper/pkg/research"
+)
+
+// sourcesFormat is the file format the indexed sources are saved in
+type sourcesFormat string
+
+const (
+	sourcesJSON   sourcesFormat = "json"
+	sourcesCSV    sourcesFormat = "csv"
+	sourcesBibTeX sourcesFormat = "bibtex"
+)
+
+// parseSourcesFormat validates the --sources-format flag
+func parseSourcesFormat(s string) (sourcesFormat, error) {
+	switch sourcesFormat(s) {
+	case sourcesJSON, sourcesCSV, sourcesBibTeX:
+		return sourcesFormat(s), nil
+	default:
+		return "", fmt.Errorf("invalid sources format %q: must be one of %s, %s, %s", s, sourcesJSON, sourcesCSV, sourcesBibTeX)
+	}
+}
+
+// extension returns the file extension for the format
+func (f sourcesFormat) extension() string {
+	if f == sourcesBibTeX {
+		return "bib"
+	}
+	return string(f)
+}
+
+// writeArtifacts saves the report and the indexed sources to dir and returns the written paths
+func writeArtifacts(dir string, format sourcesFormat, report string, items []research.SearchResult) (string, string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", "", fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	reportPath := filepath.Join(dir, fmt.Sprintf("report_%d.md", time.Now().Unix()))
+	if err := os.WriteFile(reportPath, []byte(report), 0644); err != nil {
+		return "", "", fmt.Errorf("failed to save report: %w", err)
+	}
+
+	var data []byte
+	var err error
+	switch format {
+	case sourcesCSV:
+		data, err = formatSourcesCSV(items)
+	case sourcesBibTeX:
+		data = []byte(research.FormatBibTeX(items))
+	default:
+		data, err = json.MarshalIndent(items, "", "  ")
+	}
+	if err != nil {
+		return reportPath, "", fmt.Errorf("failed to format sources: %w", err)
+	}
+
+	sourcesPath := filepath.Join(dir, "sources."+format.extension())
+	if err := os.WriteFile(sourcesPath, data, 0644); err != nil {
+		return reportPath, "", fmt.Errorf("failed to save sources: %w", err)
+	}
+	return reportPath, sourcesPath, nil
+}
+
+func formatSourcesCSV(items []research.SearchResult) ([]byte, error) {
+	var sb strings.Builder
+	w := csv.NewWriter(&sb)
+	if err := w.Write([]string{"title", "url", "scraped", "snippet"}); err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if err := w.Write([]string{item.Title, item.URL, fmt.Sprint(item.Scraped), item.Snippet}); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return []byte(sb.String()), nil
+}
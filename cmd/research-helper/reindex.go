@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/google/uuid"
+	"github.com/mikeboe/research-helper/pkg/config"
+	"github.com/mikeboe/research-helper/pkg/database"
+	"github.com/mikeboe/research-helper/pkg/research"
+	"github.com/spf13/cobra"
+)
+
+// newReindexFailedCmd returns the command that retries the sources a job could only index
+// from their snippet
+func newReindexFailedCmd(c *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "reindex-failed <jobid>",
+		Short: "Retry scraping the sources of a job that were indexed from their snippet",
+		Long:  `Scrapes the sources a job failed to scrape again and replaces their snippet-only chunks with the full text. Sources that still fail stay recorded in the job state, so the command can be repeated later. Scraping flags such as --max-pdf-pages apply.`,
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			requireAPIKey(c)
+
+			jobID, err := uuid.Parse(args[0])
+			if err != nil {
+				slog.Error("Invalid job id", "id", args[0], "error", err)
+				os.Exit(1)
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			db := openDB(ctx)
+			defer db.Close()
+
+			rec, err := db.GetJobRecord(ctx, jobID)
+			if errors.Is(err, database.ErrJobNotFound) {
+				slog.Error("Job not found", "job", jobID)
+				os.Exit(1)
+			}
+			if err != nil {
+				slog.Error("Failed to load job", "error", err)
+				os.Exit(1)
+			}
+			if rec.Status == "pending" || rec.Status == "running" {
+				slog.Error("Job is still running", "job", rec.ID, "status", rec.Status)
+				os.Exit(1)
+			}
+
+			state, err := research.LoadJobState(rec)
+			if err != nil {
+				slog.Error("Failed to load job state", "job", rec.ID, "error", err)
+				os.Exit(1)
+			}
+			if state == nil || len(state.ScrapeFailures) == 0 {
+				fmt.Println("No failed sources to reindex.")
+				return
+			}
+
+			cfg, _ := engineConfig()
+			cfg = storedConfig(rec, cfg)
+			cfg.Collection = state.CollectionName
+			engine, err := research.NewEngine(cfg, db, c)
+			if err != nil {
+				slog.Error("Error initializing engine", "error", err)
+				os.Exit(1)
+			}
+			engine.RestoreState(state)
+			engine.JobID = rec.ID.String()
+			engine.OnStateUpdate = research.PersistState(db, rec.ID, slog.Default())
+
+			slog.Info("Reindexing failed sources", "job", rec.ID, "sources", len(state.ScrapeFailures))
+			result, err := engine.ReindexFailed(ctx)
+			if err != nil {
+				slog.Error("Reindexing failed", "error", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Retried %d sources: %d reindexed with their full text, %d still failing.\n", result.Retried, result.Recovered, result.Failed)
+		},
+	}
+}
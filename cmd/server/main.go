@@ -14,6 +14,7 @@ import (
 	"github.com/mikeboe/research-helper/pkg/embeddings"
 	"github.com/mikeboe/research-helper/pkg/research"
 	"github.com/mikeboe/research-helper/pkg/server"
+	"github.com/mikeboe/research-helper/pkg/vectorstore"
 )
 
 func main() {
@@ -22,6 +23,18 @@ func main() {
 	}
 
 	config := config.Load()
+	if err := config.ValidateAPIKey(); err != nil {
+		log.Fatal(err)
+	}
+	if _, err := vectorstore.ParseEviction(config.EvictionPolicy); err != nil {
+		log.Fatal(err)
+	}
+	if _, err := config.MCPCollectionScopes(); err != nil {
+		log.Fatal(err)
+	}
+	if err := vectorstore.ValidateEFSearch(config.HNSWEFSearch); err != nil {
+		log.Fatalf("Invalid HNSW_EF_SEARCH: %v", err)
+	}
 
 	// Database Connection
 	db, err := database.NewPostgresDB(context.Background(), config.DatabaseURL)
@@ -35,17 +48,26 @@ func main() {
 		log.Fatalf("Failed to initialize schema: %v", err)
 	}
 
+	vectorSupport, err := db.CheckVectorSupport(context.Background())
+	if err != nil {
+		log.Fatalf("pgvector check failed: %v", err)
+	}
+	if !vectorSupport.HNSW {
+		log.Printf("pgvector %s does not support HNSW indexes (0.5.0+ required); new collections use IVFFlat, which has lower recall", vectorSupport.Version)
+	}
+
 	// Service Configuration
 	cfg := research.Config{
-		Collection: config.CollectionName,
-		LLMApiKey:  config.GoogleApiKey,
+		Collection: config.ResearchCollection,
 	}
 
 	// Initialize Embedder
-	embedder, err := embeddings.NewGoogleEmbedder(context.Background(), config.EmbeddingModel, cfg.LLMApiKey)
+	embedder, err := embeddings.NewGoogleEmbedder(context.Background(), config.EmbeddingModel, config.GoogleApiKey)
 	if err != nil {
 		log.Fatalf("Failed to init embedder: %v", err)
 	}
+	embedder.WithConcurrency(config.EmbeddingConcurrency, config.EmbeddingRPS)
+	embedder.WithRetry(config.EmbeddingMaxRetries)
 
 	// Initialize Chat Service
 	chatSvc, err := chat.NewService(context.Background(), db, config)
@@ -54,7 +76,7 @@ func main() {
 	}
 
 	// Initialize RAG Tools
-	ragTools := chat.NewRagToolset(db, embedder, config)
+	ragTools := chat.NewRagToolset(db, embedder, config, chat.ConfiguredSearchDefaults(config)).WithClient(chatSvc.Client)
 
 	// Initialize Service & Handler
 	svc := server.NewService(db, cfg, config)
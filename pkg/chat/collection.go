@@ -0,0 +1,72 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/mikeboe/research-helper/pkg/embeddings"
+	"github.com/mikeboe/research-helper/pkg/vectorstore"
+)
+
+// ErrCollectionNotFound is returned by the chat tools when the chat collection does not exist
+var ErrCollectionNotFound = errors.New("collection does not exist")
+
+// ForCollection returns a copy of the toolset whose tools use collection instead of
+// ChatCollection, e.g. for an MCP session scoped to it. Other collections are never
+// auto-created.
+func (t *RagToolset) ForCollection(collection string) *RagToolset {
+	if collection == "" || collection == t.config.ChatCollection {
+		return t
+	}
+	cfg := *t.config
+	cfg.ChatCollection = collection
+	cfg.ChatAutoCreateCollection = false
+	scoped := *t
+	scoped.config = &cfg
+	return &scoped
+}
+
+// openCollection opens the chat collection, creating it empty first if it is missing and
+// ChatAutoCreateCollection is set. A missing collection is reported as ErrCollectionNotFound
+// with a message the agent can pass on, instead of the SQL error of the first query, and an
+// incompatible table of the same name as vectorstore.ErrSchemaMismatch.
+func (t *RagToolset) openCollection(ctx context.Context) (*vectorstore.PGVectorStore, error) {
+	collection := t.config.ChatCollection
+
+	store, err := vectorstore.NewPGVectorStore(t.DB.Pool, collection)
+	if err != nil {
+		return nil, fmt.Errorf("invalid collection name: %w", err)
+	}
+
+	exists, err := t.DB.CollectionExists(ctx, collection)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		if err := store.Verify(ctx); err != nil {
+			return nil, err
+		}
+		return store, nil
+	}
+
+	if !t.config.ChatAutoCreateCollection {
+		return nil, fmt.Errorf("%w: %q has not been created yet. Run a research job into it or check CHAT_COLLECTION", ErrCollectionNotFound, collection)
+	}
+
+	slog.Info("Creating missing chat collection", "collection", collection)
+	if err := t.DB.CreateEmbeddingsTable(ctx, collection, embeddings.Dimension, "", ""); err != nil {
+		return nil, fmt.Errorf("failed to create collection %q: %w", collection, err)
+	}
+	return store, nil
+}
+
+// noResults explains an empty search result, so the agent can tell an empty collection
+// apart from a query that found nothing in the given sources
+func noResults(collection string, sources []string) string {
+	if len(sources) > 0 {
+		return fmt.Sprintf("No content found for the given sources in collection %q.", collection)
+	}
+	return fmt.Sprintf("Collection %q is empty. Index sources into it with a research job before searching.", collection)
+}
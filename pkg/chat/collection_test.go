@@ -0,0 +1,15 @@
+package chat
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNoResults(t *testing.T) {
+	if got := noResults("thesis_db", nil); !strings.Contains(got, `"thesis_db" is empty`) {
+		t.Errorf("noResults without sources = %q, want the empty collection message", got)
+	}
+	if got := noResults("thesis_db", []string{"https://example.org"}); !strings.Contains(got, "given sources") {
+		t.Errorf("noResults with sources = %q, want the sources message", got)
+	}
+}
@@ -0,0 +1,189 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"google.golang.org/adk/tool"
+	"google.golang.org/genai"
+)
+
+// ErrInvalidComparison is returned when compare_sources is called with unusable arguments
+var ErrInvalidComparison = errors.New("invalid comparison")
+
+const (
+	maxCompareSources      = 4 // Sources compared at once, to keep the grouped response readable
+	defaultCompareChunks   = 3 // Chunks returned per source
+	maxCompareChunks       = 8
+	compareDiffTokenBudget = 1024
+)
+
+type CompareSourcesArgs struct {
+	Sources []string `json:"sources" description:"The source URLs to compare (2 to 4)"`
+	Aspect  string   `json:"aspect,omitempty" description:"What to compare, e.g. 'evaluation datasets'. Without it the opening chunks of each source are returned"`
+	TopK    int      `json:"topK,omitempty" description:"Chunks returned per source (default 3, max 8)"`
+	Diff    bool     `json:"diff,omitempty" description:"Also return a short LLM-written summary of how the sources agree and differ on the aspect"`
+}
+
+type CompareSourcesResp struct {
+	Comparison string `json:"comparison"`
+}
+
+// MCPTextContent renders the response as MCP text content
+func (r CompareSourcesResp) MCPTextContent() string {
+	return r.Comparison
+}
+
+// sourceExcerpts are the chunks of one source selected for a comparison
+type sourceExcerpts struct {
+	Source string
+	Chunks []string
+}
+
+// normalize trims and deduplicates the sources and applies the chunk defaults
+func (a *CompareSourcesArgs) normalize() error {
+	seen := make(map[string]bool, len(a.Sources))
+	var sources []string
+	for _, s := range a.Sources {
+		s = strings.TrimSpace(s)
+		if s == "" || seen[s] {
+			continue
+		}
+		seen[s] = true
+		sources = append(sources, s)
+	}
+	if len(sources) < 2 {
+		return fmt.Errorf("%w: need at least 2 distinct sources, got %d", ErrInvalidComparison, len(sources))
+	}
+	if len(sources) > maxCompareSources {
+		return fmt.Errorf("%w: at most %d sources can be compared, got %d", ErrInvalidComparison, maxCompareSources, len(sources))
+	}
+	a.Sources = sources
+	a.Aspect = strings.TrimSpace(a.Aspect)
+
+	if a.TopK <= 0 {
+		a.TopK = defaultCompareChunks
+	}
+	a.TopK = min(a.TopK, maxCompareChunks)
+	return nil
+}
+
+// Wrapper for ADK tool interface
+func (t *RagToolset) compareSourcesTool(ctx tool.Context, args CompareSourcesArgs) (CompareSourcesResp, error) {
+	return t.CompareSources(ctx, args)
+}
+
+// CompareSources returns the chunks of each source most relevant to the aspect, grouped by
+// source, and optionally an LLM summary of their differences, cut off after the chat tool
+// timeout
+func (t *RagToolset) CompareSources(ctx context.Context, args CompareSourcesArgs) (CompareSourcesResp, error) {
+	return callTool(ctx, t.toolTimeout(), "compare_sources", args, t.compareSources)
+}
+
+// compareSources is CompareSources without the timeout
+func (t *RagToolset) compareSources(ctx context.Context, args CompareSourcesArgs) (CompareSourcesResp, error) {
+	if err := args.normalize(); err != nil {
+		return CompareSourcesResp{}, err
+	}
+
+	slog.Info("Compare sources", "sources", args.Sources, "aspect", args.Aspect, "topK", args.TopK)
+
+	store, err := t.openCollection(ctx)
+	if err != nil {
+		return CompareSourcesResp{}, err
+	}
+	store.WithEFSearch(t.config.HNSWEFSearch)
+
+	var queryEmbedding []float32
+	if args.Aspect != "" {
+		queryEmbedding, err = t.Embedder.EmbedText(ctx, args.Aspect)
+		if err != nil {
+			return CompareSourcesResp{}, fmt.Errorf("failed to generate query embedding: %w", err)
+		}
+	}
+
+	groups := make([]sourceExcerpts, len(args.Sources))
+	for i, source := range args.Sources {
+		groups[i].Source = source
+		if queryEmbedding == nil {
+			docs, err := store.GetContentBySource(ctx, source)
+			if err != nil {
+				return CompareSourcesResp{}, fmt.Errorf("failed to read %s: %w", source, err)
+			}
+			for _, doc := range docs[:min(len(docs), args.TopK)] {
+				groups[i].Chunks = append(groups[i].Chunks, doc.Content)
+			}
+			continue
+		}
+
+		results, err := store.SimilaritySearchSources(ctx, queryEmbedding, args.TopK, []string{source})
+		if err != nil {
+			return CompareSourcesResp{}, fmt.Errorf("failed to search %s: %w", source, err)
+		}
+		for _, result := range results {
+			groups[i].Chunks = append(groups[i].Chunks, result.Document.Content)
+		}
+	}
+
+	comparison := formatComparison(args.Aspect, groups)
+	if args.Diff {
+		comparison += "\n\n" + t.compareDiff(ctx, args.Aspect, groups)
+	}
+
+	serialized := t.limit(comparison, "Compare fewer sources, lower topK or skip the diff.")
+	return CompareSourcesResp{Comparison: serialized}, nil
+}
+
+// formatComparison renders the excerpts grouped by source
+func formatComparison(aspect string, groups []sourceExcerpts) string {
+	var sb strings.Builder
+	if aspect != "" {
+		sb.WriteString(fmt.Sprintf("[Aspect]: %s", aspect))
+	} else {
+		sb.WriteString("[Aspect]: none, showing the opening chunks of each source")
+	}
+	for _, g := range groups {
+		sb.WriteString(fmt.Sprintf("\n\n# Source: %s", g.Source))
+		if len(g.Chunks) == 0 {
+			sb.WriteString("\nNo indexed content found for this source.")
+			continue
+		}
+		for i, chunk := range g.Chunks {
+			sb.WriteString(fmt.Sprintf("\n[Excerpt %d]: %s", i+1, chunk))
+		}
+	}
+	return sb.String()
+}
+
+// comparePrompt asks for the agreements and differences between the excerpts
+func comparePrompt(aspect string, groups []sourceExcerpts) string {
+	if aspect == "" {
+		aspect = "their main claims and methods"
+	}
+	return fmt.Sprintf(`Compare the following sources on: %s
+
+Using only the excerpts below, list briefly where the sources agree and where they differ.
+Name the source for every point. Say so if the excerpts do not cover the aspect for a source.
+
+%s`, aspect, formatComparison(aspect, groups))
+}
+
+// compareDiff asks the fast model for a summary of the differences. Failures are reported
+// in the text rather than failing the tool, since the excerpts are still useful on their own.
+func (t *RagToolset) compareDiff(ctx context.Context, aspect string, groups []sourceExcerpts) string {
+	if t.Client == nil {
+		return "[Diff]: unavailable, no language model is configured for tools."
+	}
+
+	resp, err := t.Client.Models.GenerateContent(ctx, t.config.FastModel, []*genai.Content{
+		{Parts: []*genai.Part{{Text: comparePrompt(aspect, groups)}}},
+	}, &genai.GenerateContentConfig{MaxOutputTokens: compareDiffTokenBudget})
+	if err != nil {
+		slog.Warn("Failed to generate source comparison", "error", err)
+		return fmt.Sprintf("[Diff]: unavailable: %v", err)
+	}
+	return "[Diff]:\n" + strings.TrimSpace(resp.Text())
+}
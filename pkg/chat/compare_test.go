@@ -0,0 +1,39 @@
+package chat
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCompareSourcesArgsNormalize(t *testing.T) {
+	args := CompareSourcesArgs{Sources: []string{" a ", "b", "a", ""}, Aspect: "  datasets ", TopK: 20}
+	if err := args.normalize(); err != nil {
+		t.Fatalf("normalize: %v", err)
+	}
+	if len(args.Sources) != 2 || args.Sources[0] != "a" || args.Sources[1] != "b" {
+		t.Errorf("Sources = %q, want [a b]", args.Sources)
+	}
+	if args.Aspect != "datasets" || args.TopK != maxCompareChunks {
+		t.Errorf("Aspect = %q, TopK = %d, want datasets, %d", args.Aspect, args.TopK, maxCompareChunks)
+	}
+
+	for _, sources := range [][]string{{"a", "a"}, {"a", "b", "c", "d", "e"}} {
+		args := CompareSourcesArgs{Sources: sources}
+		if err := args.normalize(); !errors.Is(err, ErrInvalidComparison) {
+			t.Errorf("normalize(%q) = %v, want ErrInvalidComparison", sources, err)
+		}
+	}
+}
+
+func TestFormatComparison(t *testing.T) {
+	got := formatComparison("datasets", []sourceExcerpts{
+		{Source: "a", Chunks: []string{"uses MNIST", "and CIFAR"}},
+		{Source: "b"},
+	})
+	for _, want := range []string{"[Aspect]: datasets", "# Source: a\n[Excerpt 1]: uses MNIST\n[Excerpt 2]: and CIFAR", "# Source: b\nNo indexed content"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("formatComparison missing %q in:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package chat
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidDateRange is returned by ParseDateRange for unparseable or empty ranges
+var ErrInvalidDateRange = errors.New("invalid date range")
+
+// DateRange restricts a listing to rows from From (inclusive) up to To (exclusive).
+// A zero bound leaves that side open; the zero DateRange matches everything.
+type DateRange struct {
+	From time.Time
+	To   time.Time
+}
+
+// ParseDateRange parses the from and to query parameters, each an RFC 3339 timestamp or a
+// date (YYYY-MM-DD, UTC). A date as to includes that whole day. Empty strings leave the
+// bound open.
+func ParseDateRange(from, to string) (DateRange, error) {
+	var r DateRange
+	var err error
+	if from != "" {
+		if r.From, _, err = parseDateBound(from); err != nil {
+			return DateRange{}, fmt.Errorf("%w: from: %v", ErrInvalidDateRange, err)
+		}
+	}
+	if to != "" {
+		var dateOnly bool
+		if r.To, dateOnly, err = parseDateBound(to); err != nil {
+			return DateRange{}, fmt.Errorf("%w: to: %v", ErrInvalidDateRange, err)
+		}
+		if dateOnly {
+			r.To = r.To.AddDate(0, 0, 1)
+		}
+	}
+	if !r.From.IsZero() && !r.To.IsZero() && !r.From.Before(r.To) {
+		return DateRange{}, fmt.Errorf("%w: from must be before to", ErrInvalidDateRange)
+	}
+	return r, nil
+}
+
+func parseDateBound(s string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, false, nil
+	}
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("%q is neither an RFC 3339 timestamp nor a YYYY-MM-DD date", s)
+	}
+	return t, true, nil
+}
+
+// where appends the range conditions on column to a query whose arguments so far are args
+func (r DateRange) where(query, column string, args []interface{}) (string, []interface{}) {
+	if !r.From.IsZero() {
+		args = append(args, r.From)
+		query += fmt.Sprintf(" AND %s >= $%d", column, len(args))
+	}
+	if !r.To.IsZero() {
+		args = append(args, r.To)
+		query += fmt.Sprintf(" AND %s < $%d", column, len(args))
+	}
+	return query, args
+}
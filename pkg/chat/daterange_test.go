@@ -0,0 +1,59 @@
+package chat
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseDateRange(t *testing.T) {
+	day := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		name     string
+		from, to string
+		want     DateRange
+		wantErr  bool
+	}{
+		{"open", "", "", DateRange{}, false},
+		{"dates include the to day", "2026-10-05", "2026-10-11", DateRange{From: day(2026, 10, 5), To: day(2026, 10, 12)}, false},
+		{"timestamp", "2026-10-05T08:30:00Z", "", DateRange{From: time.Date(2026, 10, 5, 8, 30, 0, 0, time.UTC)}, false},
+		{"to only", "", "2026-10-11T00:00:00Z", DateRange{To: day(2026, 10, 11)}, false},
+		{"same day", "2026-10-05", "2026-10-05", DateRange{From: day(2026, 10, 5), To: day(2026, 10, 6)}, false},
+		{"reversed", "2026-10-11", "2026-10-05", DateRange{}, true},
+		{"unparseable", "last week", "", DateRange{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDateRange(tt.from, tt.to)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDateRange() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidDateRange) {
+				t.Errorf("error %v is not ErrInvalidDateRange", err)
+			}
+			if !got.From.Equal(tt.want.From) || !got.To.Equal(tt.want.To) {
+				t.Errorf("ParseDateRange() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDateRangeWhere(t *testing.T) {
+	from := time.Date(2026, 10, 5, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 7)
+
+	query, args := DateRange{From: from, To: to}.where("SELECT 1 WHERE id = $1", "created_at", []interface{}{"x"})
+	if want := "SELECT 1 WHERE id = $1 AND created_at >= $2 AND created_at < $3"; query != want {
+		t.Errorf("query = %q, want %q", query, want)
+	}
+	if want := []interface{}{"x", from, to}; !reflect.DeepEqual(args, want) {
+		t.Errorf("args = %v, want %v", args, want)
+	}
+
+	query, args = DateRange{}.where("SELECT 1 WHERE true", "updated_at", nil)
+	if query != "SELECT 1 WHERE true" || len(args) != 0 {
+		t.Errorf("open range added conditions: %q %v", query, args)
+	}
+}
@@ -0,0 +1,122 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/mikeboe/research-helper/pkg/vectorstore"
+)
+
+const (
+	maxExplainSentences = 12 // Sentences embedded per chunk; longer chunks are explained by their first ones
+	maxHighlights       = 2  // Spans returned per chunk
+	minSentenceRunes    = 20 // Shorter fragments (headings, figure labels) are merged into the next sentence
+)
+
+// highlight is a sentence of a chunk with its similarity to the query
+type highlight struct {
+	Text       string
+	Similarity float64
+}
+
+// splitSentences splits chunk text into sentences on terminal punctuation and line breaks,
+// merging fragments too short to explain a match into the following sentence
+func splitSentences(text string) []string {
+	var sentences []string
+	var current strings.Builder
+	flush := func(force bool) {
+		s := strings.Join(strings.Fields(current.String()), " ")
+		if s == "" {
+			return
+		}
+		if !force && len([]rune(s)) < minSentenceRunes {
+			current.Reset()
+			current.WriteString(s + " ")
+			return
+		}
+		sentences = append(sentences, s)
+		current.Reset()
+	}
+
+	runes := []rune(text)
+	for i, r := range runes {
+		current.WriteRune(r)
+		end := r == '\n'
+		if r == '.' || r == '?' || r == '!' {
+			end = i+1 == len(runes) || runes[i+1] == ' ' || runes[i+1] == '\n'
+		}
+		if end {
+			flush(false)
+		}
+	}
+	flush(true)
+	return sentences
+}
+
+// rankHighlights returns up to maxHighlights sentences most similar to the query, best first
+func rankHighlights(sentences []string, vectors [][]float32, query []float32) []highlight {
+	spans := make([]highlight, 0, len(sentences))
+	for i, s := range sentences {
+		if i < len(vectors) && vectors[i] != nil {
+			spans = append(spans, highlight{Text: s, Similarity: cosineSimilarity(query, vectors[i])})
+		}
+	}
+	sort.SliceStable(spans, func(i, j int) bool { return spans[i].Similarity > spans[j].Similarity })
+	return spans[:min(len(spans), maxHighlights)]
+}
+
+func cosineSimilarity(a, b []float32) float64 {
+	var dot, na, nb float64
+	for i := range min(len(a), len(b)) {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// explainResults finds the sentences of each result most responsible for its match by
+// embedding them and scoring them against the query. All sentences are embedded in one
+// batch. Failures are logged and leave the results unexplained rather than failing the search.
+func (t *RagToolset) explainResults(ctx context.Context, query []float32, results []vectorstore.SimilaritySearchResult) [][]highlight {
+	perResult := make([][]string, len(results))
+	var batch []string
+	for i, result := range results {
+		sentences := splitSentences(result.Document.Content)
+		perResult[i] = sentences[:min(len(sentences), maxExplainSentences)]
+		batch = append(batch, perResult[i]...)
+	}
+	if len(batch) == 0 {
+		return nil
+	}
+
+	vectors, err := t.Embedder.EmbedTexts(ctx, batch)
+	if err != nil {
+		slog.Warn("Failed to embed sentences for search explanation", "sentences", len(batch), "error", err)
+		return nil
+	}
+
+	highlights := make([][]highlight, len(results))
+	offset := 0
+	for i, sentences := range perResult {
+		highlights[i] = rankHighlights(sentences, vectors[offset:offset+len(sentences)], query)
+		offset += len(sentences)
+	}
+	return highlights
+}
+
+// formatHighlights renders the highlighted spans of a result
+func formatHighlights(spans []highlight) string {
+	var sb strings.Builder
+	for _, span := range spans {
+		sb.WriteString(fmt.Sprintf("\n[Highlight]: %q (similarity=%.3f)", span.Text, span.Similarity))
+	}
+	return sb.String()
+}
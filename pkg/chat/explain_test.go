@@ -0,0 +1,44 @@
+package chat
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSplitSentences(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"sentences", "Transformers use attention. They scale well with data! Do they generalize?",
+			[]string{"Transformers use attention.", "They scale well with data!", "Do they generalize?"}},
+		{"short fragments merged", "Results\nOur model improves accuracy by 4 points.",
+			[]string{"Results Our model improves accuracy by 4 points."}},
+		{"decimals kept", "Accuracy rose from 0.81 to 0.85 on the test set.",
+			[]string{"Accuracy rose from 0.81 to 0.85 on the test set."}},
+		{"short trailing text kept", "The method is evaluated on three datasets. Fig. 2",
+			[]string{"The method is evaluated on three datasets.", "Fig. 2"}},
+		{"empty", "  \n ", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitSentences(tt.text); !slices.Equal(got, tt.want) {
+				t.Errorf("splitSentences() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRankHighlights(t *testing.T) {
+	sentences := []string{"unrelated", "exact match", "close match"}
+	vectors := [][]float32{{0, 1}, {1, 0}, {1, 1}}
+
+	got := rankHighlights(sentences, vectors, []float32{1, 0})
+	if len(got) != maxHighlights || got[0].Text != "exact match" || got[1].Text != "close match" {
+		t.Fatalf("rankHighlights() = %+v", got)
+	}
+	if got[0].Similarity < 0.999 || got[1].Similarity < 0.7 || got[1].Similarity > 0.71 {
+		t.Errorf("unexpected similarities: %+v", got)
+	}
+}
@@ -0,0 +1,174 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/genai"
+)
+
+// ToolCall is a tool invocation of the agent, persisted with the model message it led to
+type ToolCall struct {
+	ID       string         `json:"id,omitempty"`
+	Name     string         `json:"name"`
+	Args     map[string]any `json:"args,omitempty"`
+	Response map[string]any `json:"response,omitempty"` // Nil if the tool never returned
+}
+
+// addToolResult attaches a tool response to its call, matched by ID or else by the latest
+// unanswered call of the same tool. Responses without a call are kept as their own entry.
+func addToolResult(calls []ToolCall, resp *genai.FunctionResponse) []ToolCall {
+	for i := len(calls) - 1; i >= 0; i-- {
+		c := &calls[i]
+		if c.Response != nil {
+			continue
+		}
+		if (resp.ID != "" && c.ID == resp.ID) || (resp.ID == "" && c.Name == resp.Name) {
+			c.Response = resp.Response
+			return calls
+		}
+	}
+	return append(calls, ToolCall{ID: resp.ID, Name: resp.Name, Response: resp.Response})
+}
+
+// ExportFormat selects how a conversation export is rendered
+type ExportFormat string
+
+const (
+	ExportMarkdown ExportFormat = "markdown"
+	ExportJSON     ExportFormat = "json"
+)
+
+// ParseExportFormat validates an export format. An empty string selects Markdown.
+func ParseExportFormat(s string) (ExportFormat, error) {
+	switch ExportFormat(s) {
+	case "", "md":
+		return ExportMarkdown, nil
+	case ExportMarkdown, ExportJSON:
+		return ExportFormat(s), nil
+	default:
+		return "", fmt.Errorf("invalid export format %q: must be %s or %s", s, ExportMarkdown, ExportJSON)
+	}
+}
+
+// ConversationExport is a conversation with all of its messages, including the tool calls
+// behind each answer. It is the JSON export as is.
+type ConversationExport struct {
+	Conversation Conversation `json:"conversation"`
+	Messages     []Message    `json:"messages"`
+}
+
+// ExportConversation loads a conversation and its messages for export
+func (s *Service) ExportConversation(ctx context.Context, conversationID uuid.UUID) (*ConversationExport, error) {
+	exp := &ConversationExport{}
+	conv := &exp.Conversation
+	err := s.DB.Pool.QueryRow(ctx,
+		`SELECT `+conversationColumns+` FROM conversations WHERE id = $1`,
+		conversationID).Scan(conv.fields()...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrConversationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation: %w", err)
+	}
+
+	msgs, err := s.GetHistory(ctx, conversationID, DateRange{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get messages: %w", err)
+	}
+	if msgs == nil {
+		msgs = []Message{}
+	}
+	exp.Messages = msgs
+	return exp, nil
+}
+
+// RenderMarkdown renders an export as a Markdown transcript. The tool calls of each answer
+// are shown before it as collapsible blocks, so the transcript reads as a conversation
+// while the research steps stay inspectable.
+func RenderMarkdown(exp *ConversationExport) string {
+	var sb strings.Builder
+	title := exp.Conversation.Title
+	if title == "" {
+		title = "Conversation"
+	}
+	sb.WriteString(fmt.Sprintf("# %s\n\n", title))
+	sb.WriteString(fmt.Sprintf("_Exported conversation %s, started %s_\n", exp.Conversation.ID, exp.Conversation.CreatedAt.Format("2006-01-02 15:04")))
+	if len(exp.Conversation.PinnedSources) > 0 {
+		sb.WriteString("\nPinned sources:\n")
+		for _, src := range exp.Conversation.PinnedSources {
+			sb.WriteString(fmt.Sprintf("- %s\n", src))
+		}
+	}
+
+	for _, m := range exp.Messages {
+		speaker := "User"
+		if m.Role == "model" {
+			speaker = "Assistant"
+		}
+		sb.WriteString(fmt.Sprintf("\n## %s\n\n", speaker))
+		for _, tc := range m.ToolCalls {
+			sb.WriteString(renderToolCallMarkdown(tc))
+			sb.WriteString("\n")
+		}
+		sb.WriteString(strings.TrimSpace(m.Content))
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// renderToolCallMarkdown renders a tool call as a <details> block with its arguments as JSON
+// and its result. Text results (the tools return one string field) are shown verbatim.
+func renderToolCallMarkdown(tc ToolCall) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("<details>\n<summary>Tool call: <code>%s</code></summary>\n\n", tc.Name))
+
+	sb.WriteString("**Arguments**\n\n")
+	sb.WriteString(codeBlock("json", marshalIndent(tc.Args)))
+
+	sb.WriteString("\n**Result**\n\n")
+	switch {
+	case tc.Response == nil:
+		sb.WriteString("_No result_\n")
+	case len(tc.Response) == 1:
+		var only any
+		for _, v := range tc.Response {
+			only = v
+		}
+		if text, ok := only.(string); ok {
+			sb.WriteString(codeBlock("", text))
+			break
+		}
+		sb.WriteString(codeBlock("json", marshalIndent(tc.Response)))
+	default:
+		sb.WriteString(codeBlock("json", marshalIndent(tc.Response)))
+	}
+
+	sb.WriteString("</details>\n")
+	return sb.String()
+}
+
+// codeBlock fences text, using a fence longer than any backtick run inside it
+func codeBlock(lang, text string) string {
+	fence := "```"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	return fmt.Sprintf("%s%s\n%s\n%s\n", fence, lang, strings.TrimRight(text, "\n"), fence)
+}
+
+func marshalIndent(v any) string {
+	if v == nil {
+		return "{}"
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
@@ -0,0 +1,69 @@
+package chat
+
+import (
+	"strings"
+	"testing"
+
+	"google.golang.org/genai"
+)
+
+func TestAddToolResult(t *testing.T) {
+	calls := []ToolCall{
+		{ID: "1", Name: "search_content"},
+		{Name: "find_content_by_source"},
+	}
+	calls = addToolResult(calls, &genai.FunctionResponse{ID: "1", Name: "search_content", Response: map[string]any{"results": "a"}})
+	calls = addToolResult(calls, &genai.FunctionResponse{Name: "find_content_by_source", Response: map[string]any{"results": "b"}})
+	calls = addToolResult(calls, &genai.FunctionResponse{Name: "get_source_page", Response: map[string]any{"results": "c"}})
+
+	if len(calls) != 3 {
+		t.Fatalf("len(calls) = %d, want 3", len(calls))
+	}
+	for i, want := range []string{"a", "b", "c"} {
+		if got := calls[i].Response["results"]; got != want {
+			t.Errorf("calls[%d] result = %v, want %q", i, got, want)
+		}
+	}
+}
+
+func TestRenderToolCallMarkdown(t *testing.T) {
+	text := renderToolCallMarkdown(ToolCall{
+		Name:     "search_content",
+		Args:     map[string]any{"query": "attention"},
+		Response: map[string]any{"results": "[Source]: a\n```code```"},
+	})
+
+	for _, want := range []string{
+		"<details>\n<summary>Tool call: <code>search_content</code></summary>",
+		"```json\n{\n  \"query\": \"attention\"\n}\n```",
+		"````\n[Source]: a\n```code```\n````",
+		"</details>",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("rendered block missing %q:\n%s", want, text)
+		}
+	}
+
+	if text := renderToolCallMarkdown(ToolCall{Name: "search_content"}); !strings.Contains(text, "_No result_") {
+		t.Errorf("unanswered call not marked:\n%s", text)
+	}
+}
+
+func TestParseExportFormat(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    ExportFormat
+		wantErr bool
+	}{
+		{"", ExportMarkdown, false},
+		{"md", ExportMarkdown, false},
+		{"json", ExportJSON, false},
+		{"pdf", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseExportFormat(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseExportFormat(%q) = %q, %v", tt.in, got, err)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package chat
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// defaultResultFields are the metadata keys search results show unless the caller asks for others
+var defaultResultFields = []string{"source", "title"}
+
+// allFields requests every metadata key of a result
+const allFields = "*"
+
+// resultFields returns the metadata keys to show: the requested ones, or the defaults
+func resultFields(requested []string) []string {
+	var fields []string
+	for _, f := range requested {
+		if f = strings.TrimSpace(f); f != "" && !slices.Contains(fields, f) {
+			fields = append(fields, f)
+		}
+	}
+	if len(fields) == 0 {
+		return defaultResultFields
+	}
+	return fields
+}
+
+// formatMetadata renders the given metadata keys of a result as [key]: value lines, in the
+// requested order, skipping keys the result doesn't have and keys listed in omit (rendered
+// elsewhere). With allFields every key is rendered, sorted.
+func formatMetadata(metadata map[string]interface{}, fields []string, omit ...string) string {
+	keys := fields
+	if slices.Contains(fields, allFields) {
+		keys = make([]string, 0, len(metadata))
+		for k := range metadata {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+	}
+
+	var sb strings.Builder
+	for _, k := range keys {
+		v, ok := metadata[k]
+		if !ok || slices.Contains(omit, k) {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("\n[%s]: %v", k, v))
+	}
+	return sb.String()
+}
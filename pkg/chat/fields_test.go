@@ -0,0 +1,31 @@
+package chat
+
+import "testing"
+
+func TestFormatMetadata(t *testing.T) {
+	metadata := map[string]interface{}{
+		"source":      "https://arxiv.org/abs/1706.03762",
+		"title":       "Attention Is All You Need",
+		"year":        "2017",
+		"chunk_index": 3,
+	}
+
+	tests := []struct {
+		name      string
+		requested []string
+		omit      []string
+		want      string
+	}{
+		{"defaults", nil, nil, "\n[source]: https://arxiv.org/abs/1706.03762\n[title]: Attention Is All You Need"},
+		{"defaults without source", nil, []string{"source"}, "\n[title]: Attention Is All You Need"},
+		{"requested order, missing skipped", []string{"year", " venue", "year", "chunk_index"}, nil, "\n[year]: 2017\n[chunk_index]: 3"},
+		{"all sorted", []string{"*"}, []string{"source"}, "\n[chunk_index]: 3\n[title]: Attention Is All You Need\n[year]: 2017"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatMetadata(metadata, resultFields(tt.requested), tt.omit...); got != tt.want {
+				t.Errorf("formatMetadata() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mikeboe/research-helper/pkg/vectorstore"
+	"google.golang.org/adk/tool"
+)
+
+// ErrInvalidGap is returned when record_knowledge_gap is called without a question
+var ErrInvalidGap = errors.New("invalid knowledge gap")
+
+// Origins of a knowledge gap
+const (
+	GapOriginAgent = "agent" // Recorded by the agent through record_knowledge_gap
+	GapOriginAuto  = "auto"  // Recorded for a search below ChatGapSimilarity
+)
+
+// maxListedGaps caps the gaps returned by ListKnowledgeGaps
+const maxListedGaps = 100
+
+// KnowledgeGap is a question the chat could not answer from a collection. Recording the
+// same question again counts another occurrence, so frequent gaps surface first.
+type KnowledgeGap struct {
+	ID             uuid.UUID  `json:"id"`
+	Collection     string     `json:"collection"`
+	Question       string     `json:"question"`
+	Reason         string     `json:"reason"`
+	Origin         string     `json:"origin"`
+	ConversationID *uuid.UUID `json:"conversation_id,omitempty"` // The conversation it was last seen in
+	BestSimilarity *float64   `json:"best_similarity,omitempty"` // Of the closest result, for auto-recorded gaps
+	Occurrences    int        `json:"occurrences"`
+	CreatedAt      time.Time  `json:"created_at"`
+	LastSeenAt     time.Time  `json:"last_seen_at"`
+}
+
+type RecordKnowledgeGapArgs struct {
+	Question string `json:"question" description:"The question the collection could not answer, phrased so it could seed a research job"`
+	Reason   string `json:"reason,omitempty" description:"Why the collection falls short, e.g. 'no sources on evaluation after 2022'"`
+}
+
+type RecordKnowledgeGapResp struct {
+	Recorded string `json:"recorded"`
+}
+
+// MCPTextContent renders the response as MCP text content
+func (r RecordKnowledgeGapResp) MCPTextContent() string {
+	return r.Recorded
+}
+
+// Wrapper for ADK tool interface
+func (t *RagToolset) recordKnowledgeGapTool(ctx tool.Context, args RecordKnowledgeGapArgs) (RecordKnowledgeGapResp, error) {
+	conversationID, _ := uuid.Parse(ctx.SessionID())
+	return callTool(ctx, t.toolTimeout(), "record_knowledge_gap", args, func(ctx context.Context, args RecordKnowledgeGapArgs) (RecordKnowledgeGapResp, error) {
+		return t.recordKnowledgeGap(ctx, args, conversationID)
+	})
+}
+
+// RecordKnowledgeGap records a question the chat collection cannot answer
+func (t *RagToolset) RecordKnowledgeGap(ctx context.Context, args RecordKnowledgeGapArgs) (RecordKnowledgeGapResp, error) {
+	return t.recordKnowledgeGap(ctx, args, uuid.Nil)
+}
+
+func (t *RagToolset) recordKnowledgeGap(ctx context.Context, args RecordKnowledgeGapArgs, conversationID uuid.UUID) (RecordKnowledgeGapResp, error) {
+	args.Question = strings.TrimSpace(args.Question)
+	if args.Question == "" {
+		return RecordKnowledgeGapResp{}, fmt.Errorf("%w: question is required", ErrInvalidGap)
+	}
+
+	gap := KnowledgeGap{
+		Collection: t.config.ChatCollection,
+		Question:   args.Question,
+		Reason:     strings.TrimSpace(args.Reason),
+		Origin:     GapOriginAgent,
+	}
+	if conversationID != uuid.Nil {
+		gap.ConversationID = &conversationID
+	}
+	occurrences, err := t.recordGap(ctx, gap)
+	if err != nil {
+		return RecordKnowledgeGapResp{}, err
+	}
+
+	slog.Info("Recorded knowledge gap", "collection", gap.Collection, "question", gap.Question, "occurrences", occurrences)
+	return RecordKnowledgeGapResp{Recorded: fmt.Sprintf("Recorded the gap in collection %q (asked %d time(s)). Tell the user the collection does not cover this yet.", gap.Collection, occurrences)}, nil
+}
+
+// recordGap stores a gap, or counts another occurrence of a recorded question, and returns
+// the number of occurrences
+func (t *RagToolset) recordGap(ctx context.Context, gap KnowledgeGap) (int, error) {
+	var occurrences int
+	err := t.DB.Pool.QueryRow(ctx, `
+		INSERT INTO knowledge_gaps (collection, question, reason, origin, conversation_id, best_similarity)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (collection, question) DO UPDATE SET
+			occurrences = knowledge_gaps.occurrences + 1,
+			last_seen_at = NOW(),
+			reason = CASE WHEN EXCLUDED.reason <> '' THEN EXCLUDED.reason ELSE knowledge_gaps.reason END,
+			conversation_id = COALESCE(EXCLUDED.conversation_id, knowledge_gaps.conversation_id),
+			best_similarity = COALESCE(EXCLUDED.best_similarity, knowledge_gaps.best_similarity)
+		RETURNING occurrences
+	`, gap.Collection, gap.Question, gap.Reason, gap.Origin, gap.ConversationID, gap.BestSimilarity).Scan(&occurrences)
+	if err != nil {
+		return 0, fmt.Errorf("failed to record knowledge gap: %w", err)
+	}
+	return occurrences, nil
+}
+
+// lowSimilarity reports whether a search found nothing closer than threshold, returning the
+// best similarity if there were results
+func lowSimilarity(results []vectorstore.SimilaritySearchResult, threshold float64) (*float64, bool) {
+	if len(results) == 0 {
+		return nil, true
+	}
+	best := results[0].Score.Similarity
+	for _, r := range results[1:] {
+		best = max(best, r.Score.Similarity)
+	}
+	return &best, best < threshold
+}
+
+// detectGap records the query of a search as a knowledge gap when ChatGapSimilarity is set
+// and the search found nothing close enough. Failures are only logged.
+func (t *RagToolset) detectGap(ctx context.Context, query string, conversationID uuid.UUID, results []vectorstore.SimilaritySearchResult) {
+	threshold := t.config.ChatGapSimilarity
+	if threshold <= 0 {
+		return
+	}
+	best, low := lowSimilarity(results, threshold)
+	if !low {
+		return
+	}
+
+	gap := KnowledgeGap{
+		Collection:     t.config.ChatCollection,
+		Question:       strings.TrimSpace(query),
+		Reason:         fmt.Sprintf("no result reached similarity %.2f", threshold),
+		Origin:         GapOriginAuto,
+		BestSimilarity: best,
+	}
+	if conversationID != uuid.Nil {
+		gap.ConversationID = &conversationID
+	}
+	if _, err := t.recordGap(ctx, gap); err != nil {
+		slog.Warn("Failed to auto-record knowledge gap", "query", query, "error", err)
+	}
+}
+
+// ListKnowledgeGaps returns the gaps recorded for a collection, most frequent and most
+// recent first
+func (t *RagToolset) ListKnowledgeGaps(ctx context.Context, collection string) ([]KnowledgeGap, error) {
+	rows, err := t.DB.Pool.Query(ctx, `
+		SELECT id, collection, question, reason, origin, conversation_id, best_similarity, occurrences, created_at, last_seen_at
+		FROM knowledge_gaps
+		WHERE collection = $1
+		ORDER BY occurrences DESC, last_seen_at DESC
+		LIMIT $2
+	`, collection, maxListedGaps)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list knowledge gaps: %w", err)
+	}
+	defer rows.Close()
+
+	gaps := []KnowledgeGap{}
+	for rows.Next() {
+		var g KnowledgeGap
+		if err := rows.Scan(&g.ID, &g.Collection, &g.Question, &g.Reason, &g.Origin, &g.ConversationID, &g.BestSimilarity, &g.Occurrences, &g.CreatedAt, &g.LastSeenAt); err != nil {
+			return nil, fmt.Errorf("failed to scan knowledge gap: %w", err)
+		}
+		gaps = append(gaps, g)
+	}
+	return gaps, rows.Err()
+}
@@ -0,0 +1,35 @@
+package chat
+
+import (
+	"testing"
+
+	"github.com/mikeboe/research-helper/pkg/vectorstore"
+)
+
+func TestLowSimilarity(t *testing.T) {
+	result := func(similarity float64) vectorstore.SimilaritySearchResult {
+		return vectorstore.SimilaritySearchResult{Score: vectorstore.SimilarityScore{Similarity: similarity}}
+	}
+
+	tests := []struct {
+		name     string
+		results  []vectorstore.SimilaritySearchResult
+		wantBest float64
+		wantLow  bool
+	}{
+		{"no results", nil, 0, true},
+		{"below threshold", []vectorstore.SimilaritySearchResult{result(0.3), result(0.41)}, 0.41, true},
+		{"best result close enough", []vectorstore.SimilaritySearchResult{result(0.4), result(0.72)}, 0.72, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			best, low := lowSimilarity(tt.results, 0.5)
+			if low != tt.wantLow {
+				t.Errorf("low = %v, want %v", low, tt.wantLow)
+			}
+			if (best == nil) != (tt.results == nil) || (best != nil && *best != tt.wantBest) {
+				t.Errorf("best = %v, want %v", best, tt.wantBest)
+			}
+		})
+	}
+}
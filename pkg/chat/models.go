@@ -0,0 +1,80 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/mikeboe/research-helper/pkg/config"
+	"google.golang.org/adk/agent"
+)
+
+// ErrUnsupportedModel is returned when a conversation asks for a model the server doesn't offer
+var ErrUnsupportedModel = errors.New("unsupported model")
+
+// Model aliases accepted for a conversation besides the configured model names
+const (
+	ModelReasoning = "reasoning" // config.ReasoningModel, the default
+	ModelFast      = "fast"      // config.FastModel
+)
+
+// resolveModel maps a requested conversation model to a model name. "" keeps the server
+// default, which is stored as "" so conversations follow a change of REASONING_MODEL.
+func resolveModel(cfg *config.Config, requested string) (string, error) {
+	requested = strings.TrimSpace(requested)
+	switch requested {
+	case "":
+		return "", nil
+	case ModelReasoning, cfg.ReasoningModel:
+		return cfg.ReasoningModel, nil
+	case ModelFast, cfg.FastModel:
+		return cfg.FastModel, nil
+	}
+	return "", fmt.Errorf("%w %q: must be %s, %s, %s or %s", ErrUnsupportedModel, requested, ModelReasoning, ModelFast, cfg.ReasoningModel, cfg.FastModel)
+}
+
+// SetModel changes the model a conversation is answered with and returns the conversation
+func (s *Service) SetModel(ctx context.Context, conversationID uuid.UUID, model string) (*Conversation, error) {
+	model, err := resolveModel(s.config, model)
+	if err != nil {
+		return nil, err
+	}
+
+	conv := &Conversation{}
+	err = s.DB.Pool.QueryRow(ctx,
+		`UPDATE conversations SET model = $2 WHERE id = $1 RETURNING `+conversationColumns,
+		conversationID, model).Scan(conv.fields()...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrConversationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to set model: %w", err)
+	}
+	return conv, nil
+}
+
+// agentFor returns the agent answering with model, building and caching it on first use.
+// An empty model is the default agent.
+func (s *Service) agentFor(ctx context.Context, model string) (agent.Agent, error) {
+	if model == "" || model == s.config.ReasoningModel {
+		return s.Agent, nil
+	}
+
+	s.agentsMu.Lock()
+	defer s.agentsMu.Unlock()
+	if a, ok := s.agents[model]; ok {
+		return a, nil
+	}
+
+	slog.Info("Building chat agent", "model", model)
+	a, err := newAgent(ctx, s.config, model, s.tools)
+	if err != nil {
+		return nil, err
+	}
+	s.agents[model] = a
+	return a, nil
+}
@@ -0,0 +1,33 @@
+package chat
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mikeboe/research-helper/pkg/config"
+)
+
+func TestResolveModel(t *testing.T) {
+	cfg := &config.Config{ReasoningModel: "gemini-pro", FastModel: "gemini-flash"}
+	tests := []struct {
+		requested string
+		want      string
+		wantErr   bool
+	}{
+		{"", "", false},
+		{"reasoning", "gemini-pro", false},
+		{"fast", "gemini-flash", false},
+		{" gemini-flash ", "gemini-flash", false},
+		{"gemini-pro", "gemini-pro", false},
+		{"gpt-4", "", true},
+	}
+	for _, tt := range tests {
+		got, err := resolveModel(cfg, tt.requested)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("resolveModel(%q) = %q, %v, want %q (error %v)", tt.requested, got, err, tt.want, tt.wantErr)
+		}
+		if tt.wantErr && !errors.Is(err, ErrUnsupportedModel) {
+			t.Errorf("resolveModel(%q) error = %v, want ErrUnsupportedModel", tt.requested, err)
+		}
+	}
+}
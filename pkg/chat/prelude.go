@@ -0,0 +1,36 @@
+package chat
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/google/uuid"
+)
+
+// preludeNote introduces the chunks retrieved for a turn to the model
+const preludeNote = "[System note: the following excerpts were retrieved automatically from the research collection for the user's next message. Ground your answer in them and cite their sources; call search_content for anything they don't cover.]\n\n"
+
+// retrievalPrelude searches the chat collection for the user's message and formats the top
+// chunks as a note for the model, so the turn is grounded even if the agent doesn't call
+// search_content. The search is scoped to the pinned sources like the tool. An empty prelude
+// means nothing was found. Its searches aren't recorded as knowledge gaps, since the user's
+// message is often not a question the collection should answer.
+func (s *Service) retrievalPrelude(ctx context.Context, conversationID uuid.UUID, content string) (string, error) {
+	scope, err := pinnedSources(ctx, s.DB, conversationID)
+	if err != nil {
+		slog.Warn("Failed to load pinned sources, retrieving from all sources", "conversation_id", conversationID, "error", err)
+		scope = nil
+	}
+
+	args := SearchContentArgs{Query: content, TopK: s.config.ChatPreludeTopK}
+	resp, err := callTool(ctx, s.tools.toolTimeout(), "retrieval_prelude", args, func(ctx context.Context, args SearchContentArgs) (SearchContentResp, error) {
+		return s.tools.searchContent(ctx, args, scope, conversationID, false)
+	})
+	if err != nil {
+		return "", err
+	}
+	if resp.Results == "" {
+		return "", nil
+	}
+	return preludeNote + resp.Results, nil
+}
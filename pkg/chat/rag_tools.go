@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"log/slog"
 	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/mikeboe/research-helper/pkg/config"
 	"github.com/mikeboe/research-helper/pkg/database"
 	"github.com/mikeboe/research-helper/pkg/embeddings"
@@ -13,22 +15,34 @@ import (
 	"google.golang.org/adk/agent"
 	"google.golang.org/adk/tool"
 	"google.golang.org/adk/tool/functiontool"
+	"google.golang.org/genai"
 )
 
 type RagToolset struct {
 	DB       *database.PostgresDB
 	Embedder *embeddings.GoogleEmbedder
+	Client   *genai.Client // Optional; enables LLM-written diffs in compare_sources
 	config   *config.Config
+	defaults SearchDefaults
 }
 
-func NewRagToolset(db *database.PostgresDB, embedder *embeddings.GoogleEmbedder, config *config.Config) *RagToolset {
+// NewRagToolset creates the chat tools. search_content applies defaults to calls that leave
+// topK or minScore unset; zero fields fall back to 5 results and no threshold.
+func NewRagToolset(db *database.PostgresDB, embedder *embeddings.GoogleEmbedder, config *config.Config, defaults SearchDefaults) *RagToolset {
 	return &RagToolset{
 		DB:       db,
 		Embedder: embedder,
 		config:   config,
+		defaults: defaults.withFallbacks(),
 	}
 }
 
+// WithClient sets the GenAI client used by tools that call a language model
+func (t *RagToolset) WithClient(client *genai.Client) *RagToolset {
+	t.Client = client
+	return t
+}
+
 func (t *RagToolset) Name() string {
 	return "rag_tools"
 }
@@ -67,34 +81,132 @@ func (t *RagToolset) Tools(ctx agent.ReadonlyContext) ([]tool.Tool, error) {
 		return nil, fmt.Errorf("failed to create find_by_metadata tool: %w", err)
 	}
 
-	return []tool.Tool{searchTool, findBySourceTool, findByMetadataTool}, nil
+	getPagesTool, err := functiontool.New[GetSourcePagesArgs, GetSourcePagesResp](
+		functiontool.Config{
+			Name:        "get_source_pages",
+			Description: "Get the original page-by-page text of a source, including figure references, to cite specific pages. Only available for sources indexed with page storage enabled.",
+		},
+		t.getSourcePagesTool,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create get_source_pages tool: %w", err)
+	}
+
+	getPageTool, err := functiontool.New[GetSourcePageArgs, GetSourcePageResp](
+		functiontool.Config{
+			Name:        "get_source_page",
+			Description: "Read the indexed text of a source piece by piece, a few chunks at a time. Use the returned next index to continue reading. Prefer this over find_content_by_source for long sources.",
+		},
+		t.getSourcePageTool,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create get_source_page tool: %w", err)
+	}
+
+	compareTool, err := functiontool.New[CompareSourcesArgs, CompareSourcesResp](
+		functiontool.Config{
+			Name:        "compare_sources",
+			Description: "Compare two to four sources side by side: returns the chunks of each source most relevant to an aspect, grouped by source, and optionally a short summary of where they agree and differ. Use this for questions like 'how do papers A and B differ on X'.",
+		},
+		t.compareSourcesTool,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create compare_sources tool: %w", err)
+	}
+
+	gapTool, err := functiontool.New[RecordKnowledgeGapArgs, RecordKnowledgeGapResp](
+		functiontool.Config{
+			Name:        "record_knowledge_gap",
+			Description: "Record a question the research collection cannot answer, after searching found nothing relevant. Recorded gaps guide further research.",
+		},
+		t.recordKnowledgeGapTool,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create record_knowledge_gap tool: %w", err)
+	}
+
+	return []tool.Tool{searchTool, findBySourceTool, findByMetadataTool, getPagesTool, getPageTool, compareTool, gapTool}, nil
 }
 
 // --- Tool Implementations ---
 
 type SearchContentArgs struct {
 	Query  string `json:"query" description:"The search query"`
-	TopK   int    `json:"topK,omitempty" description:"Number of results to return (default 5)"`
+	TopK   int    `json:"topK,omitempty" description:"Number of results to return (default set by the server, normally 5)"`
 	Source string `json:"source,omitempty" description:"Optional source filter"`
+	// MinScore overrides the configured similarity threshold when set (negative keeps all results)
+	MinScore float64 `json:"minScore,omitempty" description:"Optional minimum similarity of returned results (default set by the server; negative keeps all)"`
+	// RecencyWeight overrides the configured recency boost when set (negative disables it)
+	RecencyWeight float64 `json:"recencyWeight,omitempty" description:"Optional boost for newer documents, added to the similarity of a document published today and halving with age (e.g. 0.05; negative disables the default boost)"`
+	// Rerank overrides SearchDefaults.Rerank when set; it has no effect when RecencyWeight is set
+	Rerank *bool `json:"rerank,omitempty" description:"Re-rank results with the server's recency boost (default set by the server)"`
+	// Explain embeds the sentences of every result to find the spans that matched, at the cost of extra embedding calls
+	Explain bool     `json:"explain,omitempty" description:"Also return the sentences of each result that best match the query, for quoting. Slower, as every sentence is embedded"`
+	Fields  []string `json:"fields,omitempty" description:"Metadata fields to return with each result (default source and title; [\"*\"] returns all)"`
 }
 
 type SearchContentResp struct {
 	Results string `json:"results"`
 }
 
-// Wrapper for ADK tool interface
+// MCPTextContent renders the response as MCP text content
+func (r SearchContentResp) MCPTextContent() string {
+	return r.Results
+}
+
+// Wrapper for ADK tool interface. The session ID is the conversation ID, so searches
+// are scoped to the conversation's pinned sources unless a source is given explicitly.
 func (t *RagToolset) searchContentTool(ctx tool.Context, args SearchContentArgs) (SearchContentResp, error) {
-	return t.SearchContent(ctx, args)
+	convID, convErr := uuid.Parse(ctx.SessionID())
+	return callTool(ctx, t.toolTimeout(), "search_content", args, func(ctx context.Context, args SearchContentArgs) (SearchContentResp, error) {
+		var scope []string
+		if args.Source == "" && convErr == nil {
+			pinned, err := pinnedSources(ctx, t.DB, convID)
+			if err != nil {
+				slog.Warn("Failed to load pinned sources, searching all sources", "conversation_id", convID, "error", err)
+			}
+			scope = pinned
+		}
+		return t.explainedSearch(ctx, args, scope, convID)
+	})
 }
 
-// Public method using standard context
+// Public method using standard context, cut off after the chat tool timeout
 func (t *RagToolset) SearchContent(ctx context.Context, args SearchContentArgs) (SearchContentResp, error) {
-	if args.TopK == 0 {
-		args.TopK = 5
+	return callTool(ctx, t.toolTimeout(), "search_content", args, func(ctx context.Context, args SearchContentArgs) (SearchContentResp, error) {
+		return t.explainedSearch(ctx, args, nil, uuid.Nil)
+	})
+}
+
+// explainedSearch runs searchContent and replaces an empty result with an explanation
+func (t *RagToolset) explainedSearch(ctx context.Context, args SearchContentArgs, scope []string, conversationID uuid.UUID) (SearchContentResp, error) {
+	resp, err := t.searchContent(ctx, args, scope, conversationID, true)
+	if err != nil || resp.Results != "" {
+		return resp, err
 	}
-	collection := t.config.CollectionName
+	return SearchContentResp{Results: noResults(t.config.ChatCollection, searchSources(args, scope))}, nil
+}
+
+// searchSources returns the sources a search is restricted to: args.Source if set, otherwise the scope
+func searchSources(args SearchContentArgs, scope []string) []string {
+	if args.Source != "" {
+		return []string{args.Source}
+	}
+	return scope
+}
 
-	slog.Info("Search content", "query", args.Query, "topK", args.TopK, "source", args.Source)
+// searchContent runs a semantic search. A non-empty scope restricts results to those sources;
+// it is ignored when args.Source is set. With detectGaps, unrestricted searches finding nothing
+// relevant are recorded as knowledge gaps of the conversation (uuid.Nil for none), see
+// detectGap. A search restricted to some sources says nothing about the collection as a whole.
+func (t *RagToolset) searchContent(ctx context.Context, args SearchContentArgs, scope []string, conversationID uuid.UUID, detectGaps bool) (SearchContentResp, error) {
+	if args.TopK <= 0 {
+		args.TopK = t.defaults.TopK
+	}
+	minScore := t.minScore(args.MinScore)
+	sources := searchSources(args, scope)
+
+	slog.Info("Search content", "query", args.Query, "topK", args.TopK, "minScore", minScore, "sources", sources)
 
 	// Generate embedding for query
 	queryEmbedding, err := t.Embedder.EmbedText(ctx, args.Query)
@@ -103,21 +215,40 @@ func (t *RagToolset) SearchContent(ctx context.Context, args SearchContentArgs)
 	}
 
 	// Search vector store
-	store, err := vectorstore.NewPGVectorStore(t.DB.Pool, collection)
+	store, err := t.openCollection(ctx)
 	if err != nil {
-		return SearchContentResp{}, fmt.Errorf("invalid collection name: %w", err)
+		return SearchContentResp{}, err
 	}
+	store.WithEFSearch(t.config.HNSWEFSearch).WithRecency(t.recency(args.RecencyWeight, args.Rerank)).
+		WithRetention(vectorstore.Retention{MaxDocuments: t.config.MaxCollectionDocuments, Evict: vectorstore.Eviction(t.config.EvictionPolicy)})
 
-	results, err := store.SimilaritySearch(ctx, queryEmbedding, args.TopK, args.Source)
+	results, err := store.SimilaritySearchSources(ctx, queryEmbedding, args.TopK, sources)
 	if err != nil {
 		return SearchContentResp{}, fmt.Errorf("failed to search: %w", err)
 	}
 
 	slog.Info("Search results", "results", results)
+	if detectGaps && len(sources) == 0 {
+		t.detectGap(ctx, args.Query, conversationID, results)
+	}
+
+	if kept := aboveMinScore(results, minScore); len(kept) < len(results) {
+		slog.Info("Dropped results below minimum similarity", "minScore", minScore, "dropped", len(results)-len(kept))
+		if len(kept) == 0 {
+			return SearchContentResp{Results: fmt.Sprintf("No content in collection %q is at least %.2f similar to the query. Rephrase the query or lower minScore.", t.config.ChatCollection, minScore)}, nil
+		}
+		results = kept
+	}
+
+	var highlights [][]highlight
+	if args.Explain {
+		highlights = t.explainResults(ctx, queryEmbedding, results)
+	}
 
 	// Format results
+	fields := resultFields(args.Fields)
 	var formattedResults []string
-	for _, result := range results {
+	for i, result := range results {
 		resSource := "unknown"
 		if s, ok := result.Document.Metadata["source"].(string); ok {
 			resSource = s
@@ -125,22 +256,39 @@ func (t *RagToolset) SearchContent(ctx context.Context, args SearchContentArgs)
 
 		var sb strings.Builder
 		sb.WriteString(fmt.Sprintf("[Source]: %s\n[Content]: %s", resSource, result.Document.Content))
-
-		for k, v := range result.Document.Metadata {
-			if k == "source" {
-				continue
-			}
-			// Clean up output if needed
-			sb.WriteString(fmt.Sprintf("\n[%s]: %v", k, v))
+		sb.WriteString(fmt.Sprintf("\n[Score]: similarity=%.3f distance=%.3f (%s, higher similarity is closer)",
+			result.Score.Similarity, result.Score.Distance, result.Score.Metric))
+		if result.Score.Boosted != 0 {
+			sb.WriteString(fmt.Sprintf("\n[Ranking]: boosted=%.3f recency=%.3f", result.Score.Boosted, result.Score.Recency))
+		}
+		if i < len(highlights) {
+			sb.WriteString(formatHighlights(highlights[i]))
 		}
 
+		sb.WriteString(formatMetadata(result.Document.Metadata, fields, "source"))
+
 		formattedResults = append(formattedResults, sb.String())
 	}
 
-	serialized := strings.Join(formattedResults, "\n\n")
+	serialized := t.limit(strings.Join(formattedResults, "\n\n"), "Narrow the query, lower topK or filter by source.")
 	return SearchContentResp{Results: serialized}, nil
 }
 
+// recency returns the recency boost of a search: the requested weight if set, otherwise the
+// configured one when re-ranking is on, as requested or by SearchDefaults.Rerank
+func (t *RagToolset) recency(weight float64, rerank *bool) vectorstore.Recency {
+	if rerank == nil {
+		rerank = &t.defaults.Rerank
+	}
+	if weight == 0 && *rerank {
+		weight = t.config.RecencyWeight
+	}
+	return vectorstore.Recency{
+		Weight:   max(weight, 0),
+		HalfLife: time.Duration(t.config.RecencyHalfLifeYears * 365 * 24 * float64(time.Hour)),
+	}
+}
+
 type FindSourceArgs struct {
 	Source string `json:"source" description:"The source URL to find content for"`
 }
@@ -149,24 +297,35 @@ type FindSourceResp struct {
 	Content string `json:"content"`
 }
 
+// MCPTextContent renders the response as MCP text content
+func (r FindSourceResp) MCPTextContent() string {
+	return r.Content
+}
+
 // Wrapper for ADK tool interface
 func (t *RagToolset) findContentBySourceTool(ctx tool.Context, args FindSourceArgs) (FindSourceResp, error) {
 	return t.FindContentBySource(ctx, args)
 }
 
-// Public method using standard context
+// Public method using standard context, cut off after the chat tool timeout
 func (t *RagToolset) FindContentBySource(ctx context.Context, args FindSourceArgs) (FindSourceResp, error) {
-	collection := t.config.CollectionName
+	return callTool(ctx, t.toolTimeout(), "find_content_by_source", args, t.findContentBySource)
+}
 
-	store, err := vectorstore.NewPGVectorStore(t.DB.Pool, collection)
+// findContentBySource is FindContentBySource without the timeout
+func (t *RagToolset) findContentBySource(ctx context.Context, args FindSourceArgs) (FindSourceResp, error) {
+	store, err := t.openCollection(ctx)
 	if err != nil {
-		return FindSourceResp{}, fmt.Errorf("invalid collection name: %w", err)
+		return FindSourceResp{}, err
 	}
 
 	results, err := store.GetContentBySource(ctx, args.Source)
 	if err != nil {
 		return FindSourceResp{}, fmt.Errorf("failed to find content: %w", err)
 	}
+	if len(results) == 0 {
+		return FindSourceResp{Content: noResults(t.config.ChatCollection, []string{args.Source})}, nil
+	}
 
 	// Format results
 	var formattedResults []string
@@ -174,47 +333,215 @@ func (t *RagToolset) FindContentBySource(ctx context.Context, args FindSourceArg
 		formattedResults = append(formattedResults, result.Content)
 	}
 
-	serialized := strings.Join(formattedResults, "\n\n")
+	serialized := t.limit(strings.Join(formattedResults, "\n\n"), "Use get_source_page to read this source chunk by chunk, or search_content with the source filter.")
 	return FindSourceResp{Content: serialized}, nil
 }
 
 type FindMetadataArgs struct {
-	Filter map[string]interface{} `json:"filter" description:"JSON filter object with logical operators ($and, $or, $not)"`
+	Filter map[string]interface{} `json:"filter" description:"JSON filter object. Keys are metadata fields matched exactly, combined with the logical operators $and, $or (lists of conditions) and $not (one condition). Comparison operators are not supported."`
+	Fields []string               `json:"fields,omitempty" description:"Metadata fields to return with each result (default source and title; [\"*\"] returns all)"`
+	Limit  int                    `json:"limit,omitempty" description:"Maximum number of results per page (default 20, max 100)"`
+	Cursor string                 `json:"cursor,omitempty" description:"nextCursor of the previous page, to continue where it ended"`
 }
 
 type FindMetadataResp struct {
-	Content string `json:"content"`
+	Content    string `json:"content"`
+	NextCursor string `json:"nextCursor,omitempty"` // Set when more results match; pass it as cursor to get them
+}
+
+// MCPTextContent renders the response as MCP text content
+func (r FindMetadataResp) MCPTextContent() string {
+	if r.NextCursor == "" {
+		return r.Content
+	}
+	return fmt.Sprintf("%s\n\n[More results match. Call again with cursor %q for the next page.]", r.Content, r.NextCursor)
 }
 
+const (
+	// defaultMetadataPageSize and maxMetadataPageSize bound a find_content_by_metadata page
+	defaultMetadataPageSize = 20
+	maxMetadataPageSize     = 100
+)
+
 // Wrapper for ADK tool interface
 func (t *RagToolset) findContentByMetadataTool(ctx tool.Context, args FindMetadataArgs) (FindMetadataResp, error) {
 	return t.FindContentByMetadata(ctx, args)
 }
 
-// Public method using standard context
+// Public method using standard context, cut off after the chat tool timeout
 func (t *RagToolset) FindContentByMetadata(ctx context.Context, args FindMetadataArgs) (FindMetadataResp, error) {
-	collection := t.config.CollectionName
+	return callTool(ctx, t.toolTimeout(), "find_content_by_metadata", args, t.findContentByMetadata)
+}
 
-	store, err := vectorstore.NewPGVectorStore(t.DB.Pool, collection)
+// findContentByMetadata is FindContentByMetadata without the timeout
+func (t *RagToolset) findContentByMetadata(ctx context.Context, args FindMetadataArgs) (FindMetadataResp, error) {
+	// Validate before touching the database, so the caller gets the problem and not an SQL error
+	filter, err := vectorstore.NormalizeMetadataFilter(args.Filter)
 	if err != nil {
-		return FindMetadataResp{}, fmt.Errorf("invalid collection name: %w", err)
+		return FindMetadataResp{}, err
 	}
 
-	results, err := store.GetContentByMetadata(ctx, args.Filter)
+	store, err := t.openCollection(ctx)
+	if err != nil {
+		return FindMetadataResp{}, err
+	}
+
+	limit := args.Limit
+	if limit <= 0 {
+		limit = defaultMetadataPageSize
+	}
+	limit = min(limit, maxMetadataPageSize)
+
+	page, err := store.GetContentByMetadataPage(ctx, filter, args.Cursor, limit)
 	if err != nil {
 		return FindMetadataResp{}, fmt.Errorf("failed to find content: %w", err)
 	}
 
+	fields := resultFields(args.Fields)
 	var formattedResults []string
-	for _, result := range results {
+	for _, result := range page.Documents {
 		var sb strings.Builder
 		sb.WriteString(fmt.Sprintf("[Content]: %s", result.Content))
-		for k, v := range result.Metadata {
-			sb.WriteString(fmt.Sprintf("\n[%s]: %v", k, v))
+		sb.WriteString(formatMetadata(result.Metadata, fields))
+		formattedResults = append(formattedResults, sb.String())
+	}
+
+	// Whole documents that don't fit in the response move to the next page instead of
+	// being truncated, so paging with the cursor never skips one
+	nextCursor := page.NextCursor
+	if n := fitResults(formattedResults, t.maxResponseBytes()); n < len(formattedResults) {
+		formattedResults = formattedResults[:n]
+		nextCursor = vectorstore.CursorAfter(page.Documents[n-1].ID)
+	}
+
+	serialized := t.limit(strings.Join(formattedResults, "\n\n"), "Use a more specific filter or a smaller limit.")
+	return FindMetadataResp{Content: serialized, NextCursor: nextCursor}, nil
+}
+
+type GetSourcePagesArgs struct {
+	Source string `json:"source" description:"The source URL to get pages for"`
+	Page   int    `json:"page,omitempty" description:"Optional 1-based page number; all pages are returned if omitted"`
+}
+
+type GetSourcePagesResp struct {
+	Pages string `json:"pages"`
+}
+
+// MCPTextContent renders the response as MCP text content
+func (r GetSourcePagesResp) MCPTextContent() string {
+	return r.Pages
+}
+
+// Wrapper for ADK tool interface
+func (t *RagToolset) getSourcePagesTool(ctx tool.Context, args GetSourcePagesArgs) (GetSourcePagesResp, error) {
+	return t.GetSourcePages(ctx, args)
+}
+
+// Public method using standard context, cut off after the chat tool timeout
+func (t *RagToolset) GetSourcePages(ctx context.Context, args GetSourcePagesArgs) (GetSourcePagesResp, error) {
+	return callTool(ctx, t.toolTimeout(), "get_source_pages", args, t.getSourcePages)
+}
+
+// getSourcePages is GetSourcePages without the timeout
+func (t *RagToolset) getSourcePages(ctx context.Context, args GetSourcePagesArgs) (GetSourcePagesResp, error) {
+	// Pages are stored with the 0-based index returned by OCR
+	var pageIndex *int
+	if args.Page > 0 {
+		idx := args.Page - 1
+		pageIndex = &idx
+	}
+
+	pages, err := t.DB.GetPages(ctx, t.config.ChatCollection, args.Source, pageIndex)
+	if err != nil {
+		return GetSourcePagesResp{}, fmt.Errorf("failed to get pages: %w", err)
+	}
+	if len(pages) == 0 {
+		return GetSourcePagesResp{Pages: "No stored pages found for this source."}, nil
+	}
+
+	var formattedResults []string
+	for _, page := range pages {
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("[Page %d]\n%s", page.PageIndex+1, page.Markdown))
+		if len(page.Images) > 0 {
+			ids := make([]string, len(page.Images))
+			for i, img := range page.Images {
+				ids[i] = img.ID
+			}
+			sb.WriteString(fmt.Sprintf("\n[Figures]: %s", strings.Join(ids, ", ")))
 		}
 		formattedResults = append(formattedResults, sb.String())
 	}
 
-	serialized := strings.Join(formattedResults, "\n\n")
-	return FindMetadataResp{Content: serialized}, nil
+	serialized := t.limit(strings.Join(formattedResults, "\n\n"), "Request a single page.")
+	return GetSourcePagesResp{Pages: serialized}, nil
+}
+
+// maxPageChunks caps how many chunks get_source_page returns at once
+const maxPageChunks = 5
+
+type GetSourcePageArgs struct {
+	Source string `json:"source" description:"The source URL to read"`
+	Index  int    `json:"index,omitempty" description:"0-based index of the first chunk to return (default 0)"`
+	Count  int    `json:"count,omitempty" description:"Number of chunks to return (default 1, max 5)"`
+}
+
+type GetSourcePageResp struct {
+	Content string `json:"content"`
+}
+
+// MCPTextContent renders the response as MCP text content
+func (r GetSourcePageResp) MCPTextContent() string {
+	return r.Content
+}
+
+// Wrapper for ADK tool interface
+func (t *RagToolset) getSourcePageTool(ctx tool.Context, args GetSourcePageArgs) (GetSourcePageResp, error) {
+	return t.GetSourcePage(ctx, args)
+}
+
+// Public method using standard context, cut off after the chat tool timeout
+func (t *RagToolset) GetSourcePage(ctx context.Context, args GetSourcePageArgs) (GetSourcePageResp, error) {
+	return callTool(ctx, t.toolTimeout(), "get_source_page", args, t.getSourcePage)
+}
+
+// getSourcePage is GetSourcePage without the timeout
+func (t *RagToolset) getSourcePage(ctx context.Context, args GetSourcePageArgs) (GetSourcePageResp, error) {
+	if args.Index < 0 {
+		args.Index = 0
+	}
+	if args.Count <= 0 {
+		args.Count = 1
+	}
+	if args.Count > maxPageChunks {
+		args.Count = maxPageChunks
+	}
+
+	store, err := t.openCollection(ctx)
+	if err != nil {
+		return GetSourcePageResp{}, err
+	}
+
+	chunks, total, err := store.GetChunks(ctx, args.Source, args.Index, args.Count)
+	if err != nil {
+		return GetSourcePageResp{}, fmt.Errorf("failed to read source: %w", err)
+	}
+	if len(chunks) == 0 {
+		return GetSourcePageResp{Content: fmt.Sprintf("No content at index %d for this source.", args.Index)}, nil
+	}
+
+	var formattedResults []string
+	for i, chunk := range chunks {
+		formattedResults = append(formattedResults, fmt.Sprintf("[Chunk %d of %d]\n%s", args.Index+i+1, total, chunk.Content))
+	}
+
+	next := args.Index + len(chunks)
+	if next < total {
+		formattedResults = append(formattedResults, fmt.Sprintf("[Next index]: %d", next))
+	} else {
+		formattedResults = append(formattedResults, "[End of source]")
+	}
+
+	serialized := t.limit(strings.Join(formattedResults, "\n\n"), "Request fewer chunks at a time.")
+	return GetSourcePageResp{Content: serialized}, nil
 }
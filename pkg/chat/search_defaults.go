@@ -0,0 +1,66 @@
+package chat
+
+import (
+	"github.com/mikeboe/research-helper/pkg/config"
+	"github.com/mikeboe/research-helper/pkg/vectorstore"
+)
+
+// defaultTopK is the number of search_content results when neither the call nor the
+// toolset's SearchDefaults set one
+const defaultTopK = 5
+
+// SearchDefaults are the search_content settings applied when a call leaves them unset, so
+// retrieval can be tuned per deployment instead of per call
+type SearchDefaults struct {
+	TopK     int     // Results returned (0 = defaultTopK)
+	MinScore float64 // Drop results less similar than this (0 = keep all)
+	Rerank   bool    // Re-rank results with the configured recency boost (see RecencyWeight)
+}
+
+// ConfiguredSearchDefaults returns the search defaults set by CHAT_TOP_K, CHAT_MIN_SCORE and
+// CHAT_RERANK
+func ConfiguredSearchDefaults(c *config.Config) SearchDefaults {
+	return SearchDefaults{
+		TopK:     c.ChatTopK,
+		MinScore: c.ChatMinScore,
+		Rerank:   c.ChatRerank,
+	}
+}
+
+// withFallbacks fills in the defaults left unset
+func (d SearchDefaults) withFallbacks() SearchDefaults {
+	if d.TopK <= 0 {
+		d.TopK = defaultTopK
+	}
+	d.MinScore = max(d.MinScore, 0)
+	return d
+}
+
+// SearchDefaults returns the defaults search_content applies, e.g. to advertise them in a
+// tool schema
+func (t *RagToolset) SearchDefaults() SearchDefaults {
+	return t.defaults
+}
+
+// minScore returns the similarity threshold of a search: the requested one if set (negative
+// keeps all results), otherwise the default
+func (t *RagToolset) minScore(requested float64) float64 {
+	if requested == 0 {
+		return t.defaults.MinScore
+	}
+	return max(requested, 0)
+}
+
+// aboveMinScore returns the results at least minScore similar to the query, in order
+func aboveMinScore(results []vectorstore.SimilaritySearchResult, minScore float64) []vectorstore.SimilaritySearchResult {
+	if minScore <= 0 {
+		return results
+	}
+	var kept []vectorstore.SimilaritySearchResult
+	for _, r := range results {
+		if r.Score.Similarity >= minScore {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,83 @@
+package chat
+
+import (
+	"testing"
+
+	"github.com/mikeboe/research-helper/pkg/config"
+	"github.com/mikeboe/research-helper/pkg/vectorstore"
+)
+
+func TestAboveMinScore(t *testing.T) {
+	result := func(similarity float64) vectorstore.SimilaritySearchResult {
+		return vectorstore.SimilaritySearchResult{Score: vectorstore.SimilarityScore{Similarity: similarity}}
+	}
+	results := []vectorstore.SimilaritySearchResult{result(0.8), result(0.5), result(0.3)}
+
+	tests := []struct {
+		name     string
+		minScore float64
+		want     int
+	}{
+		{"Disabled", 0, 3},
+		{"Threshold is inclusive", 0.5, 2},
+		{"All below", 0.9, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := aboveMinScore(results, tt.minScore); len(got) != tt.want {
+				t.Errorf("aboveMinScore(%v) kept %d results, want %d", tt.minScore, len(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchDefaults(t *testing.T) {
+	tools := NewRagToolset(nil, nil, nil, SearchDefaults{MinScore: 0.4})
+	if got := tools.SearchDefaults().TopK; got != defaultTopK {
+		t.Errorf("TopK = %d, want fallback %d", got, defaultTopK)
+	}
+
+	tests := []struct {
+		name      string
+		requested float64
+		want      float64
+	}{
+		{"Unset uses default", 0, 0.4},
+		{"Override", 0.7, 0.7},
+		{"Negative keeps all", -1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tools.minScore(tt.requested); got != tt.want {
+				t.Errorf("minScore(%v) = %v, want %v", tt.requested, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRerankDefault(t *testing.T) {
+	on, off := true, false
+	cfg := &config.Config{RecencyWeight: 0.05}
+	tests := []struct {
+		name     string
+		defaults SearchDefaults
+		weight   float64
+		rerank   *bool
+		want     float64
+	}{
+		{"Default on", SearchDefaults{Rerank: true}, 0, nil, 0.05},
+		{"Default off", SearchDefaults{}, 0, nil, 0},
+		{"Requested on", SearchDefaults{}, 0, &on, 0.05},
+		{"Requested off", SearchDefaults{Rerank: true}, 0, &off, 0},
+		{"Explicit weight", SearchDefaults{}, 0.2, &off, 0.2},
+		{"Negative weight disables", SearchDefaults{Rerank: true}, -1, nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tools := NewRagToolset(nil, nil, cfg, tt.defaults)
+			if got := tools.recency(tt.weight, tt.rerank).Weight; got != tt.want {
+				t.Errorf("recency(%v).Weight = %v, want %v", tt.weight, got, tt.want)
+			}
+		})
+	}
+}
@@ -3,12 +3,15 @@ package chat
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"iter"
 	"log/slog"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/mikeboe/research-helper/pkg/config"
 	"github.com/mikeboe/research-helper/pkg/database"
 	"github.com/mikeboe/research-helper/pkg/embeddings"
@@ -26,27 +29,43 @@ type Service struct {
 	config *config.Config
 	DB     *database.PostgresDB
 	Client *genai.Client
-	Agent  agent.Agent
+	Agent  agent.Agent // Answers with config.ReasoningModel
+
+	tools *RagToolset // Used directly for the retrieval prelude
+
+	agents   map[string]agent.Agent // Agents for conversations on other models, by model name
+	agentsMu sync.Mutex
 }
 
 type Conversation struct {
-	ID        uuid.UUID `json:"id"`
-	Title     string    `json:"title"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID            uuid.UUID `json:"id"`
+	Title         string    `json:"title"`
+	PinnedSources []string  `json:"pinned_sources"` // Sources that search_content is scoped to, if any
+	Model         string    `json:"model"`          // Model answering the conversation; empty means the server default
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// conversationColumns are the columns scanned by Conversation.fields, in order
+const conversationColumns = "id, title, pinned_sources, model, created_at, updated_at"
+
+// fields returns scan destinations for conversationColumns
+func (c *Conversation) fields() []any {
+	return []any{&c.ID, &c.Title, &c.PinnedSources, &c.Model, &c.CreatedAt, &c.UpdatedAt}
 }
 
 type Message struct {
-	ID             uuid.UUID `json:"id"`
-	ConversationID uuid.UUID `json:"conversation_id"`
-	Role           string    `json:"role"`
-	Content        string    `json:"content"`
-	CreatedAt      time.Time `json:"created_at"`
+	ID             uuid.UUID  `json:"id"`
+	ConversationID uuid.UUID  `json:"conversation_id"`
+	Role           string     `json:"role"`
+	Content        string     `json:"content"`
+	ToolCalls      []ToolCall `json:"tool_calls,omitempty"` // Tools the agent used for a model message
+	CreatedAt      time.Time  `json:"created_at"`
 }
 
 // StreamEvent represents a single event in the chat stream
 type StreamEvent struct {
-	Type    string      `json:"type"` // "content", "tool_call", "tool_result", "error", "done"
+	Type    string      `json:"type"` // "content", "thinking", "tool_call", "tool_result", "error", "done"
 	Payload interface{} `json:"payload"`
 }
 
@@ -59,58 +78,88 @@ func NewService(ctx context.Context, db *database.PostgresDB, config *config.Con
 		return nil, fmt.Errorf("failed to create GenAI client: %w", err)
 	}
 
+	// Initialize Embedder
+	embedder, err := embeddings.NewGoogleEmbedder(ctx, config.EmbeddingModel, config.GoogleApiKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embedder: %w", err)
+	}
+	embedder.WithConcurrency(config.EmbeddingConcurrency, config.EmbeddingRPS)
+	embedder.WithRetry(config.EmbeddingMaxRetries)
+
+	// Initialize RAG Toolset
+	ragTools := NewRagToolset(db, embedder, config, ConfiguredSearchDefaults(config)).WithClient(client)
+
 	// Initialize ADK Agent
-	modelClient, err := gemini.NewModel(ctx, config.ReasoningModel, &genai.ClientConfig{
+	researchAgent, err := newAgent(ctx, config, config.ReasoningModel, ragTools)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		config: config,
+		DB:     db,
+		Client: client,
+		Agent:  researchAgent,
+		tools:  ragTools,
+		agents: make(map[string]agent.Agent),
+	}, nil
+}
+
+// newAgent builds the chat agent on a model
+func newAgent(ctx context.Context, config *config.Config, modelName string, ragTools *RagToolset) (agent.Agent, error) {
+	modelClient, err := gemini.NewModel(ctx, modelName, &genai.ClientConfig{
 		APIKey: config.GoogleApiKey,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create model: %w", err)
 	}
 
-	// Initialize Embedder
-	embedder, err := embeddings.NewGoogleEmbedder(ctx, config.EmbeddingModel, config.GoogleApiKey)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create embedder: %w", err)
+	// Thought summaries are only generated when enabled; SendMessage decides per request whether to forward them
+	var generateConfig *genai.GenerateContentConfig
+	if config.ChatThoughts {
+		generateConfig = &genai.GenerateContentConfig{
+			ThinkingConfig: &genai.ThinkingConfig{IncludeThoughts: true},
+		}
 	}
 
-	// Initialize RAG Toolset
-	ragTools := NewRagToolset(db, embedder, config)
-
 	researchAgent, err := llmagent.New(llmagent.Config{
 		Name:        "research_helper",
 		Model:       modelClient,
 		Description: "A research assistant with access to RAG tools.",
-		Instruction: "You are a helpful research assistant. Use the available tools to search for information and answer the user's questions based on the retrieved content. ALWAYS use search_content tool first. The answer format should be grouped by source, with a unordered list of content pieces supporting the question. the format would be: # Source: <source>, \n\n - <content>\n - <content>\n - <content>....",
+		Instruction: "You are a helpful research assistant. Use the available tools to search for information and answer the user's questions based on the retrieved content. ALWAYS use search_content tool first. If the retrieved content does not answer the question, say so and call record_knowledge_gap with the question. The answer format should be grouped by source, with a unordered list of content pieces supporting the question. the format would be: # Source: <source>, \n\n - <content>\n - <content>\n - <content>....",
 		Toolsets: []tool.Toolset{
 			ragTools,
 		},
+		GenerateContentConfig: generateConfig,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create agent: %w", err)
 	}
-
-	return &Service{
-		DB:     db,
-		Client: client,
-		Agent:  researchAgent,
-	}, nil
+	return researchAgent, nil
 }
 
-func (s *Service) CreateConversation(ctx context.Context) (*Conversation, error) {
+// CreateConversation starts a conversation answered by model (see resolveModel; "" = server default)
+func (s *Service) CreateConversation(ctx context.Context, model string) (*Conversation, error) {
+	model, err := resolveModel(s.config, model)
+	if err != nil {
+		return nil, err
+	}
+
 	id := uuid.New()
-	query := `INSERT INTO conversations (id) VALUES ($1) RETURNING id, title, created_at, updated_at`
+	query := `INSERT INTO conversations (id, model) VALUES ($1, $2) RETURNING ` + conversationColumns
 
 	conv := &Conversation{}
-	err := s.DB.Pool.QueryRow(ctx, query, id).Scan(&conv.ID, &conv.Title, &conv.CreatedAt, &conv.UpdatedAt)
+	err = s.DB.Pool.QueryRow(ctx, query, id, model).Scan(conv.fields()...)
 	if err != nil {
 		return nil, err
 	}
 	return conv, nil
 }
 
-func (s *Service) ListConversations(ctx context.Context) ([]Conversation, error) {
-	query := `SELECT id, title, created_at, updated_at FROM conversations ORDER BY updated_at DESC`
-	rows, err := s.DB.Pool.Query(ctx, query)
+// ListConversations returns the conversations last updated within r, most recent first
+func (s *Service) ListConversations(ctx context.Context, r DateRange) ([]Conversation, error) {
+	query, args := r.where(`SELECT `+conversationColumns+` FROM conversations WHERE true`, "updated_at", nil)
+	rows, err := s.DB.Pool.Query(ctx, query+` ORDER BY updated_at DESC`, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -119,7 +168,7 @@ func (s *Service) ListConversations(ctx context.Context) ([]Conversation, error)
 	var convs []Conversation
 	for rows.Next() {
 		var c Conversation
-		if err := rows.Scan(&c.ID, &c.Title, &c.CreatedAt, &c.UpdatedAt); err != nil {
+		if err := rows.Scan(c.fields()...); err != nil {
 			return nil, err
 		}
 		convs = append(convs, c)
@@ -127,9 +176,11 @@ func (s *Service) ListConversations(ctx context.Context) ([]Conversation, error)
 	return convs, nil
 }
 
-func (s *Service) GetHistory(ctx context.Context, conversationID uuid.UUID) ([]Message, error) {
-	query := `SELECT id, conversation_id, role, content, created_at FROM messages WHERE conversation_id = $1 ORDER BY created_at ASC`
-	rows, err := s.DB.Pool.Query(ctx, query, conversationID)
+// GetHistory returns the messages of a conversation created within r, oldest first
+func (s *Service) GetHistory(ctx context.Context, conversationID uuid.UUID, r DateRange) ([]Message, error) {
+	query, args := r.where(`SELECT id, conversation_id, role, content, tool_calls, created_at FROM messages WHERE conversation_id = $1`,
+		"created_at", []interface{}{conversationID})
+	rows, err := s.DB.Pool.Query(ctx, query+` ORDER BY created_at ASC`, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -138,18 +189,95 @@ func (s *Service) GetHistory(ctx context.Context, conversationID uuid.UUID) ([]M
 	var msgs []Message
 	for rows.Next() {
 		var m Message
-		if err := rows.Scan(&m.ID, &m.ConversationID, &m.Role, &m.Content, &m.CreatedAt); err != nil {
+		var toolCallsJSON []byte
+		if err := rows.Scan(&m.ID, &m.ConversationID, &m.Role, &m.Content, &toolCallsJSON, &m.CreatedAt); err != nil {
 			return nil, err
 		}
+		if toolCallsJSON != nil {
+			if err := json.Unmarshal(toolCallsJSON, &m.ToolCalls); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal tool calls: %w", err)
+			}
+		}
 		msgs = append(msgs, m)
 	}
 	return msgs, nil
 }
 
-func (s *Service) SendMessage(ctx context.Context, conversationID uuid.UUID, content string) (iter.Seq2[StreamEvent, error], error) {
+// ForkConversation creates a new conversation containing a copy of the messages of
+// an existing one up to and including fromMessageID. A nil fromMessageID copies all messages.
+// It returns ErrConversationNotFound if the conversation or the message doesn't exist.
+func (s *Service) ForkConversation(ctx context.Context, conversationID uuid.UUID, fromMessageID *uuid.UUID) (*Conversation, error) {
+	tx, err := s.DB.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// Resolve the cut-off point; messages are ordered by created_at
+	var cutoff *time.Time
+	if fromMessageID != nil {
+		var createdAt time.Time
+		err := tx.QueryRow(ctx,
+			`SELECT created_at FROM messages WHERE id = $1 AND conversation_id = $2`,
+			*fromMessageID, conversationID).Scan(&createdAt)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w: message %s is not in conversation %s", ErrConversationNotFound, fromMessageID, conversationID)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("message %s not found in conversation: %w", fromMessageID, err)
+		}
+		cutoff = &createdAt
+	}
+
+	conv := &Conversation{}
+	err = tx.QueryRow(ctx,
+		`INSERT INTO conversations (id, title, pinned_sources, model)
+		SELECT $2, title || ' (fork)', pinned_sources, model FROM conversations WHERE id = $1
+		RETURNING `+conversationColumns,
+		conversationID, uuid.New()).Scan(conv.fields()...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrConversationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create forked conversation: %w", err)
+	}
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO messages (id, conversation_id, role, content, tool_calls, created_at)
+		SELECT gen_random_uuid(), $2, role, content, tool_calls, created_at FROM messages
+		WHERE conversation_id = $1 AND ($3::timestamptz IS NULL OR created_at <= $3)`,
+		conversationID, conv.ID, cutoff)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy messages: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit fork: %w", err)
+	}
+	return conv, nil
+}
+
+// SendMessage runs the agent on a new user message and streams its response. With
+// includeThinking, the model's thought summaries are streamed as "thinking" events;
+// they are never part of the saved response.
+func (s *Service) SendMessage(ctx context.Context, conversationID uuid.UUID, content string, includeThinking bool) (iter.Seq2[StreamEvent, error], error) {
+	// The conversation's model picks the agent
+	var modelName string
+	err := s.DB.Pool.QueryRow(ctx, `SELECT model FROM conversations WHERE id = $1`, conversationID).Scan(&modelName)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrConversationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation: %w", err)
+	}
+	convAgent, err := s.agentFor(ctx, modelName)
+	if err != nil {
+		return nil, err
+	}
+
 	// 1. Save User Message
 	userMsgID := uuid.New()
-	_, err := s.DB.Pool.Exec(ctx,
+	_, err = s.DB.Pool.Exec(ctx,
 		`INSERT INTO messages (id, conversation_id, role, content) VALUES ($1, $2, 'user', $3)`,
 		userMsgID, conversationID, content)
 	if err != nil {
@@ -175,7 +303,7 @@ func (s *Service) SendMessage(ctx context.Context, conversationID uuid.UUID, con
 	storedSession := createRes.Session
 
 	// Hydrate history from DB
-	history, err := s.GetHistory(ctx, conversationID)
+	history, err := s.GetHistory(ctx, conversationID, DateRange{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch history: %w", err)
 	}
@@ -210,7 +338,7 @@ func (s *Service) SendMessage(ctx context.Context, conversationID uuid.UUID, con
 	// 4. Run Agent
 	runner, err := runner.New(runner.Config{
 		AppName:        appName,
-		Agent:          s.Agent,
+		Agent:          convAgent,
 		SessionService: sessionSvc,
 	})
 	if err != nil {
@@ -224,6 +352,17 @@ func (s *Service) SendMessage(ctx context.Context, conversationID uuid.UUID, con
 		},
 	}
 
+	// Always-retrieve mode: ground the turn in the collection before the agent runs.
+	// The prelude is only part of this turn, the saved message stays as the user wrote it.
+	if s.config.ChatPreludeTopK > 0 {
+		prelude, err := s.retrievalPrelude(ctx, conversationID, content)
+		if err != nil {
+			slog.Warn("Retrieval prelude failed, answering without it", "conversation_id", conversationID, "error", err)
+		} else if prelude != "" {
+			userContent.Parts = append([]*genai.Part{{Text: prelude}}, userContent.Parts...)
+		}
+	}
+
 	// Return iterator
 	return func(yield func(StreamEvent, error) bool) {
 		slog.Info("Starting agent run", "conversation_id", conversationID)
@@ -235,6 +374,7 @@ func (s *Service) SendMessage(ctx context.Context, conversationID uuid.UUID, con
 		next := runner.Run(ctx, userID, sessionID, userContent, runCfg)
 
 		var finalResponse string
+		var toolCalls []ToolCall
 
 		for event, err := range next {
 			if err != nil {
@@ -246,6 +386,14 @@ func (s *Service) SendMessage(ctx context.Context, conversationID uuid.UUID, con
 			// Process event
 			if event.LLMResponse.Content != nil {
 				for _, part := range event.LLMResponse.Content.Parts {
+					if part.Thought {
+						if includeThinking && part.Text != "" {
+							if !yield(StreamEvent{Type: "thinking", Payload: part.Text}, nil) {
+								return
+							}
+						}
+						continue
+					}
 					if part.Text != "" {
 						slog.Debug("Agent output (text)", "text_len", len(part.Text))
 						finalResponse += part.Text
@@ -255,12 +403,14 @@ func (s *Service) SendMessage(ctx context.Context, conversationID uuid.UUID, con
 					}
 					if part.FunctionCall != nil {
 						slog.Info("Agent tool call", "tool", part.FunctionCall.Name)
+						toolCalls = append(toolCalls, ToolCall{ID: part.FunctionCall.ID, Name: part.FunctionCall.Name, Args: part.FunctionCall.Args})
 						if !yield(StreamEvent{Type: "tool_call", Payload: part.FunctionCall}, nil) {
 							return
 						}
 					}
 					if part.FunctionResponse != nil {
 						slog.Info("Agent tool result", "tool", part.FunctionResponse.Name)
+						toolCalls = addToolResult(toolCalls, part.FunctionResponse)
 						if !yield(StreamEvent{Type: "tool_result", Payload: part.FunctionResponse}, nil) {
 							return
 						}
@@ -271,11 +421,15 @@ func (s *Service) SendMessage(ctx context.Context, conversationID uuid.UUID, con
 
 		slog.Info("Agent run completed")
 
-		// 5. Save Model Message to DB after stream completion
+		// 5. Save Model Message to DB after stream completion, with the tool calls behind it
+		var toolCallsJSON []byte
+		if len(toolCalls) > 0 {
+			toolCallsJSON, _ = json.Marshal(toolCalls)
+		}
 		modelMsgID := uuid.New()
 		_, err := s.DB.Pool.Exec(ctx,
-			`INSERT INTO messages (id, conversation_id, role, content) VALUES ($1, $2, 'model', $3)`,
-			modelMsgID, conversationID, finalResponse)
+			`INSERT INTO messages (id, conversation_id, role, content, tool_calls) VALUES ($1, $2, 'model', $3, $4)`,
+			modelMsgID, conversationID, finalResponse, toolCallsJSON)
 
 		if err != nil {
 			slog.Error("Failed to save model message", "error", err)
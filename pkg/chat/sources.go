@@ -0,0 +1,72 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/mikeboe/research-helper/pkg/database"
+)
+
+// ErrConversationNotFound is returned when a conversation does not exist
+var ErrConversationNotFound = errors.New("conversation not found")
+
+// PinSource adds a source to the conversation's pinned sources and returns the updated list.
+// While a conversation has pinned sources, search_content only searches within them.
+func (s *Service) PinSource(ctx context.Context, conversationID uuid.UUID, source string) ([]string, error) {
+	var sources []string
+	err := s.DB.Pool.QueryRow(ctx, `
+		UPDATE conversations
+		SET pinned_sources = CASE WHEN $2 = ANY(pinned_sources) THEN pinned_sources ELSE array_append(pinned_sources, $2) END
+		WHERE id = $1
+		RETURNING pinned_sources
+	`, conversationID, source).Scan(&sources)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrConversationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to pin source: %w", err)
+	}
+	return sources, nil
+}
+
+// UnpinSource removes a source from the conversation's pinned sources and returns the updated list
+func (s *Service) UnpinSource(ctx context.Context, conversationID uuid.UUID, source string) ([]string, error) {
+	var sources []string
+	err := s.DB.Pool.QueryRow(ctx, `
+		UPDATE conversations
+		SET pinned_sources = array_remove(pinned_sources, $2)
+		WHERE id = $1
+		RETURNING pinned_sources
+	`, conversationID, source).Scan(&sources)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrConversationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpin source: %w", err)
+	}
+	return sources, nil
+}
+
+// GetPinnedSources returns the sources pinned to a conversation
+func (s *Service) GetPinnedSources(ctx context.Context, conversationID uuid.UUID) ([]string, error) {
+	sources, err := pinnedSources(ctx, s.DB, conversationID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrConversationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pinned sources: %w", err)
+	}
+	return sources, nil
+}
+
+func pinnedSources(ctx context.Context, db *database.PostgresDB, conversationID uuid.UUID) ([]string, error) {
+	var sources []string
+	err := db.Pool.QueryRow(ctx, `SELECT pinned_sources FROM conversations WHERE id = $1`, conversationID).Scan(&sources)
+	if err != nil {
+		return nil, err
+	}
+	return sources, nil
+}
@@ -0,0 +1,39 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// ErrToolTimeout is returned when a chat tool call exceeds the configured timeout
+var ErrToolTimeout = errors.New("tool call timed out")
+
+// toolTimeout returns the configured cap on a single chat tool call (0 = none)
+func (t *RagToolset) toolTimeout() time.Duration {
+	if t.config == nil {
+		return 0
+	}
+	return time.Duration(t.config.ChatToolTimeoutSeconds) * time.Second
+}
+
+// callTool runs a tool call under the request context, cut off after timeout if it is
+// positive. A call that runs out of time fails with ErrToolTimeout and a hint the agent can
+// act on, rather than the error of whichever query was interrupted.
+func callTool[A, R any](ctx context.Context, timeout time.Duration, name string, args A, call func(context.Context, A) (R, error)) (R, error) {
+	if timeout <= 0 {
+		return call(ctx, args)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	resp, err := call(ctx, args)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		slog.Warn("Chat tool call timed out", "tool", name, "timeout", timeout, "error", err)
+		var zero R
+		return zero, fmt.Errorf("%w: %s did not finish within %s. Try a narrower query, a lower topK or a source filter", ErrToolTimeout, name, timeout)
+	}
+	return resp, err
+}
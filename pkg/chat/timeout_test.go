@@ -0,0 +1,33 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCallTool(t *testing.T) {
+	slow := func(ctx context.Context, _ string) (string, error) {
+		select {
+		case <-time.After(time.Second):
+			return "done", nil
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+	if _, err := callTool(context.Background(), 10*time.Millisecond, "search_content", "q", slow); !errors.Is(err, ErrToolTimeout) {
+		t.Errorf("slow call error = %v, want ErrToolTimeout", err)
+	}
+
+	failing := errors.New("invalid filter")
+	fail := func(context.Context, string) (string, error) { return "", failing }
+	if _, err := callTool(context.Background(), time.Second, "find_content_by_metadata", "q", fail); !errors.Is(err, failing) || errors.Is(err, ErrToolTimeout) {
+		t.Errorf("failing call error = %v, want the call's own error", err)
+	}
+
+	fast := func(context.Context, string) (string, error) { return "ok", nil }
+	if got, err := callTool(context.Background(), 0, "get_source_page", "q", fast); err != nil || got != "ok" {
+		t.Errorf("untimed call = %q, %v, want ok", got, err)
+	}
+}
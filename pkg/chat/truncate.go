@@ -0,0 +1,55 @@
+package chat
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// defaultMaxToolResponseBytes caps serialized tool responses when the config doesn't set a limit
+const defaultMaxToolResponseBytes = 32000
+
+// truncateResponse cuts s to at most maxBytes on a rune boundary and appends a marker
+// telling the model how to narrow its request. A non-positive maxBytes disables the cap.
+func truncateResponse(s string, maxBytes int, hint string) string {
+	if maxBytes <= 0 || len(s) <= maxBytes {
+		return s
+	}
+	cut := maxBytes
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return fmt.Sprintf("%s\n\n[truncated: showing %d of %d bytes. %s]", s[:cut], cut, len(s), hint)
+}
+
+// fitResults returns how many of results, joined by a blank line, fit in maxBytes. At least
+// one result is counted, so a paged response always makes progress; it is truncated like
+// any other response. A non-positive maxBytes fits them all.
+func fitResults(results []string, maxBytes int) int {
+	if maxBytes <= 0 {
+		return len(results)
+	}
+	size := 0
+	for i, r := range results {
+		if i > 0 {
+			size += len("\n\n")
+		}
+		size += len(r)
+		if size > maxBytes {
+			return max(i, 1)
+		}
+	}
+	return len(results)
+}
+
+// maxResponseBytes returns the configured response cap
+func (t *RagToolset) maxResponseBytes() int {
+	if t.config != nil && t.config.MaxToolResponseBytes != 0 {
+		return t.config.MaxToolResponseBytes
+	}
+	return defaultMaxToolResponseBytes
+}
+
+// limit applies the configured response cap to a serialized tool response
+func (t *RagToolset) limit(s, hint string) string {
+	return truncateResponse(s, t.maxResponseBytes(), hint)
+}
@@ -0,0 +1,46 @@
+package chat
+
+import "testing"
+
+func TestTruncateResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       string
+		maxBytes int
+		want     string
+	}{
+		{"under limit", "hello", 10, "hello"},
+		{"disabled", "hello", 0, "hello"},
+		{"ascii", "hello world", 5, "hello\n\n[truncated: showing 5 of 11 bytes. narrow it]"},
+		{"rune boundary", "héllo", 2, "h\n\n[truncated: showing 1 of 6 bytes. narrow it]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncateResponse(tt.in, tt.maxBytes, "narrow it")
+			if got != tt.want {
+				t.Errorf("truncateResponse() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFitResults(t *testing.T) {
+	results := []string{"aaaa", "bbbb", "cccc"}
+	tests := []struct {
+		maxBytes int
+		want     int
+	}{
+		{0, 3},
+		{100, 3},
+		{14, 2}, // "aaaa\n\nbbbb" is 10 bytes, adding the third makes 16
+		{16, 3},
+		{5, 1},
+		{2, 1}, // An oversized first result is still returned
+	}
+	for _, tt := range tests {
+		if got := fitResults(results, tt.maxBytes); got != tt.want {
+			t.Errorf("fitResults(maxBytes %d) = %d, want %d", tt.maxBytes, got, tt.want)
+		}
+	}
+}
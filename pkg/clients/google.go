@@ -3,10 +3,7 @@ package clients
 import (
 	"context"
 	"fmt"
-	"log"
-	"os"
 
-	"github.com/joho/godotenv"
 	"github.com/tmc/langchaingo/llms/googleai"
 )
 
@@ -19,14 +16,13 @@ const (
 	ProModel     ModelType = "gemini-3-pro-preview"
 )
 
-func GoogleAi(model ModelType) (*googleai.GoogleAI, error) {
-	err := godotenv.Load()
-	if err != nil {
-		log.Fatalf("Error loading .env file: %v", err)
-		return nil, err
+// GoogleAi returns a Gemini client for the model. The API key comes from config.Config,
+// which resolves the supported environment variables in one place.
+func GoogleAi(model ModelType, apiKey string) (*googleai.GoogleAI, error) {
+	if apiKey == "" {
+		return nil, fmt.Errorf("missing Google API key")
 	}
 	ctx := context.Background()
-	apiKey := os.Getenv("GOOGLE_API_KEY")
 
 	var modelName string
 	switch model {
@@ -41,8 +37,7 @@ func GoogleAi(model ModelType) (*googleai.GoogleAI, error) {
 	// See https://ai.google.dev/gemini-api/docs/models/gemini for possible models
 	llm, err := googleai.New(ctx, googleai.WithAPIKey(apiKey), googleai.WithDefaultModel(modelName))
 	if err != nil {
-		log.Fatal(err)
-		return nil, err
+		return nil, fmt.Errorf("failed to create Google AI client: %w", err)
 	}
 
 	return llm, nil
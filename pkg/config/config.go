@@ -1,12 +1,22 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"log/slog"
 	"os"
 	"strconv"
+	"strings"
 )
 
+// apiKeyVars are the environment variables holding the Gemini API key, in order of precedence
+var apiKeyVars = []string{"GOOGLE_API_KEY", "GEMINI_API_KEY"}
+
+// ErrMissingAPIKey is returned by ValidateAPIKey when none of apiKeyVars is set
+var ErrMissingAPIKey = errors.New("no Gemini API key: set GOOGLE_API_KEY (or GEMINI_API_KEY)")
+
 type Config struct {
-	GoogleApiKey   string
+	GoogleApiKey   string // From GOOGLE_API_KEY, falling back to GEMINI_API_KEY; used for both the LLM and embeddings
 	DatabaseURL    string
 	ReasoningModel string
 	FastModel      string
@@ -15,34 +25,198 @@ type Config struct {
 	ChunkOverlap   int
 	EmbeddingModel string
 	CollectionName string
+	// ResearchCollection is where research jobs index sources, ChatCollection is what the
+	// chat tools search. Both default to CollectionName; set them apart to let chat search a
+	// curated collection instead of the raw research output.
+	ResearchCollection string
+	ChatCollection     string
+	// ChatAutoCreateCollection creates ChatCollection empty on first use by a chat tool
+	// instead of reporting that it does not exist
+	ChatAutoCreateCollection bool
+	APIKey                   string // Required in the X-API-Key header of protected endpoints
+	EmbedRateLimit           int    // Requests per minute per client for /api/embed (0 = unlimited)
+	MCPMaxBodyBytes          int64  // Largest accepted /mcp request body (0 = unlimited)
+	// MCPCollectionKeys restricts MCP sessions to collections by API key, as
+	// "key:collection,collection;key:collection" (see MCPCollectionScopes; empty = unrestricted)
+	MCPCollectionKeys string
+	// SourceFeed publishes each source a running job finds, filters, skips and indexes to
+	// GET /api/research/:id/sources/stream
+	SourceFeed bool
+	// EmbeddingConcurrency is how many embedding requests EmbedTexts runs in parallel,
+	// EmbeddingRPS caps the request rate across all of them (0 = unlimited).
+	EmbeddingConcurrency int
+	EmbeddingRPS         float64
+	EmbeddingMaxRetries  int // Retries of a transient embedding failure, separate from LLM retries (0 = none)
+	// ChatThoughts asks the chat model for summaries of its reasoning, which clients can
+	// request as "thinking" stream events
+	ChatThoughts bool
+	// MaxToolResponseBytes caps serialized chat tool responses fed back to the model
+	// (negative = unlimited)
+	MaxToolResponseBytes int
+	// ChatToolTimeoutSeconds cuts off a chat tool call that runs longer than this, so a slow
+	// search can't stall a streaming response (0 = only the request context applies)
+	ChatToolTimeoutSeconds int
+	// ChatPreludeTopK retrieves this many chunks for every chat message and gives them to
+	// the agent before it runs, instead of relying on it to call search_content (0 = disabled)
+	ChatPreludeTopK int
+	// ChatGapSimilarity records a chat search as a knowledge gap when its best result is less
+	// similar than this, or nothing is found (0 = disabled)
+	ChatGapSimilarity float64
+	// ChatTopK, ChatMinScore and ChatRerank are the search_content defaults for calls that
+	// don't set them: results returned, minimum similarity (0 = keep all) and whether results
+	// are re-ranked with the recency boost
+	ChatTopK     int
+	ChatMinScore float64
+	ChatRerank   bool
+	// HNSWEFSearch sets hnsw.ef_search for search_content queries, trading latency for
+	// recall on HNSW-indexed collections (0 = pgvector default of 40)
+	HNSWEFSearch int
+	// RecencyWeight boosts newer documents in search_content results by up to this much
+	// similarity, halving every RecencyHalfLifeYears of age (0 = rank by similarity only)
+	RecencyWeight        float64
+	RecencyHalfLifeYears float64
+	// MaxCollectionDocuments caps every collection at this many documents, evicting by
+	// EvictionPolicy ("oldest" or "least_accessed") after each insert (0 = unlimited)
+	MaxCollectionDocuments int
+	EvictionPolicy         string
+}
+
+// ValidateAPIKey reports ErrMissingAPIKey when no API key was found, so commands calling
+// Gemini can fail at startup rather than on the first request
+func (c *Config) ValidateAPIKey() error {
+	if c.GoogleApiKey == "" {
+		return ErrMissingAPIKey
+	}
+	return nil
+}
+
+// MCPCollectionScopes parses MCPCollectionKeys into the collections each API key may use
+// over MCP. It returns nil when no keys are configured.
+func (c *Config) MCPCollectionScopes() (map[string][]string, error) {
+	if strings.TrimSpace(c.MCPCollectionKeys) == "" {
+		return nil, nil
+	}
+	scopes := make(map[string][]string)
+	// Errors name entries by position, since they contain keys
+	for i, entry := range strings.Split(c.MCPCollectionKeys, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		key, list, ok := strings.Cut(entry, ":")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid MCP_COLLECTION_KEYS entry %d: want key:collection,collection", i+1)
+		}
+		var collections []string
+		for _, name := range strings.Split(list, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				collections = append(collections, name)
+			}
+		}
+		if len(collections) == 0 {
+			return nil, fmt.Errorf("invalid MCP_COLLECTION_KEYS entry %d: no collections", i+1)
+		}
+		if _, dup := scopes[key]; dup {
+			return nil, fmt.Errorf("invalid MCP_COLLECTION_KEYS entry %d: key already listed", i+1)
+		}
+		scopes[key] = collections
+	}
+	return scopes, nil
+}
+
+// lookupAPIKey returns the first API key set in apiKeyVars. Conflicting values are logged,
+// since only the first is used.
+func lookupAPIKey() string {
+	var key, from string
+	for _, name := range apiKeyVars {
+		value := os.Getenv(name)
+		switch {
+		case value == "":
+		case key == "":
+			key, from = value, name
+		case value != key:
+			slog.Warn("Conflicting API keys set, ignoring the later one", "using", from, "ignoring", name)
+		}
+	}
+	return key
 }
 
 func Load() *Config {
+	apiKey := lookupAPIKey()
 
-	if os.Getenv("GOOGLE_API_KEY") != "" {
+	if apiKey != "" {
+		collection := getEnv("COLLECTION_NAME", "thesis_db")
 		return &Config{
-			GoogleApiKey:   getEnv("GOOGLE_API_KEY", ""),
-			DatabaseURL:    getEnv("DATABASE_URL", ""),
-			ReasoningModel: getEnv("REASONING_MODEL", "gemini-3-pro-preview"),
-			FastModel:      getEnv("FAST_MODEL", "gemini-3-flash-preview"),
-			Port:           getEnv("PORT", "3000"),
-			ChunkSize:      getEnvAsInt("CHUNK_SIZE", 1000),
-			ChunkOverlap:   getEnvAsInt("CHUNK_OVERLAP", 200),
-			EmbeddingModel: getEnv("EMBEDDING_MODEL", "gemini-embedding-001"),
-			CollectionName: getEnv("COLLECTION_NAME", "thesis_db"),
+			GoogleApiKey:             apiKey,
+			DatabaseURL:              getEnv("DATABASE_URL", ""),
+			ReasoningModel:           getEnv("REASONING_MODEL", "gemini-3-pro-preview"),
+			FastModel:                getEnv("FAST_MODEL", "gemini-3-flash-preview"),
+			Port:                     getEnv("PORT", "3000"),
+			ChunkSize:                getEnvAsInt("CHUNK_SIZE", 1000),
+			ChunkOverlap:             getEnvAsInt("CHUNK_OVERLAP", 200),
+			EmbeddingModel:           getEnv("EMBEDDING_MODEL", "gemini-embedding-001"),
+			CollectionName:           collection,
+			ResearchCollection:       getEnv("RESEARCH_COLLECTION", collection),
+			ChatCollection:           getEnv("CHAT_COLLECTION", collection),
+			ChatAutoCreateCollection: getEnvAsBool("CHAT_AUTO_CREATE_COLLECTION", false),
+			APIKey:                   getEnv("API_KEY", ""),
+			EmbedRateLimit:           getEnvAsInt("EMBED_RATE_LIMIT", 60),
+			MCPMaxBodyBytes:          int64(getEnvAsInt("MCP_MAX_BODY_BYTES", 1<<20)),
+			MCPCollectionKeys:        getEnv("MCP_COLLECTION_KEYS", ""),
+			EmbeddingConcurrency:     getEnvAsInt("EMBEDDING_CONCURRENCY", 1),
+			EmbeddingRPS:             getEnvAsFloat("EMBEDDING_RPS", 0),
+			EmbeddingMaxRetries:      getEnvAsInt("EMBEDDING_MAX_RETRIES", 3),
+			ChatThoughts:             getEnvAsBool("CHAT_THOUGHTS", false),
+			MaxToolResponseBytes:     getEnvAsInt("MAX_TOOL_RESPONSE_BYTES", 32000),
+			ChatToolTimeoutSeconds:   getEnvAsInt("CHAT_TOOL_TIMEOUT_SECONDS", 30),
+			ChatPreludeTopK:          getEnvAsInt("CHAT_PRELUDE_TOP_K", 0),
+			ChatGapSimilarity:        getEnvAsFloat("CHAT_GAP_SIMILARITY", 0),
+			ChatTopK:                 getEnvAsInt("CHAT_TOP_K", 5),
+			ChatMinScore:             getEnvAsFloat("CHAT_MIN_SCORE", 0),
+			ChatRerank:               getEnvAsBool("CHAT_RERANK", true),
+			SourceFeed:               getEnvAsBool("SOURCE_FEED", true),
+			HNSWEFSearch:             getEnvAsInt("HNSW_EF_SEARCH", 0),
+			RecencyWeight:            getEnvAsFloat("RECENCY_WEIGHT", 0),
+			RecencyHalfLifeYears:     getEnvAsFloat("RECENCY_HALF_LIFE_YEARS", 5),
+			MaxCollectionDocuments:   getEnvAsInt("MAX_COLLECTION_DOCUMENTS", 0),
+			EvictionPolicy:           getEnv("EVICTION_POLICY", "oldest"),
 		}
 	}
 
 	return &Config{
-		GoogleApiKey:   "",
-		DatabaseURL:    "",
-		ReasoningModel: "",
-		FastModel:      "",
-		Port:           "",
-		ChunkSize:      1000,
-		ChunkOverlap:   200,
-		EmbeddingModel: "",
-		CollectionName: "",
+		GoogleApiKey:             "",
+		DatabaseURL:              "",
+		ReasoningModel:           "",
+		FastModel:                "",
+		Port:                     "",
+		ChunkSize:                1000,
+		ChunkOverlap:             200,
+		EmbeddingModel:           "",
+		CollectionName:           "",
+		ResearchCollection:       "",
+		ChatCollection:           "",
+		ChatAutoCreateCollection: false,
+		APIKey:                   "",
+		EmbedRateLimit:           60,
+		MCPMaxBodyBytes:          1 << 20,
+		MCPCollectionKeys:        "",
+		EmbeddingConcurrency:     1,
+		EmbeddingRPS:             0,
+		EmbeddingMaxRetries:      3,
+		ChatThoughts:             false,
+		MaxToolResponseBytes:     32000,
+		ChatToolTimeoutSeconds:   30,
+		ChatPreludeTopK:          0,
+		ChatGapSimilarity:        0,
+		ChatTopK:                 5,
+		ChatMinScore:             0,
+		ChatRerank:               true,
+		SourceFeed:               true,
+		HNSWEFSearch:             0,
+		RecencyWeight:            0,
+		RecencyHalfLifeYears:     5,
+		MaxCollectionDocuments:   0,
+		EvictionPolicy:           "oldest",
 	}
 }
 
@@ -64,3 +238,27 @@ func getEnvAsInt(key string, defaultValue int) int {
 	}
 	return value
 }
+
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+	value, err := strconv.ParseFloat(valueStr, 64)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvAsBool(key string, defaultValue bool) bool {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+	value, err := strconv.ParseBool(valueStr)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLookupAPIKey(t *testing.T) {
+	tests := []struct {
+		name   string
+		google string
+		gemini string
+		want   string
+	}{
+		{"none", "", "", ""},
+		{"google only", "g", "", "g"},
+		{"gemini only", "", "m", "m"},
+		{"google takes precedence", "g", "m", "g"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GOOGLE_API_KEY", tt.google)
+			t.Setenv("GEMINI_API_KEY", tt.gemini)
+			if got := lookupAPIKey(); got != tt.want {
+				t.Errorf("lookupAPIKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMCPCollectionScopes(t *testing.T) {
+	tests := []struct {
+		name    string
+		keys    string
+		want    map[string][]string
+		wantErr bool
+	}{
+		{"unset", "", nil, false},
+		{"keys", " k1: a, b ;k2:c;", map[string][]string{"k1": {"a", "b"}, "k2": {"c"}}, false},
+		{"missing collections", "k1:", nil, true},
+		{"missing separator", "k1", nil, true},
+		{"duplicate key", "k1:a;k1:b", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Config{MCPCollectionKeys: tt.keys}
+			got, err := c.MCPCollectionScopes()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("MCPCollectionScopes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MCPCollectionScopes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
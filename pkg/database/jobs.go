@@ -0,0 +1,122 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrJobNotFound is returned when no research job matches a lookup
+var ErrJobNotFound = errors.New("job not found")
+
+// JobRecord is the persisted row of a research job. Config and State are stored as raw
+// JSON so this package doesn't depend on the research types.
+type JobRecord struct {
+	ID        uuid.UUID
+	Topic     string
+	Status    string
+	Config    json.RawMessage
+	State     json.RawMessage
+	UpdatedAt time.Time
+}
+
+// OrphanedAfter is how long a pending or running job may go without a state update before
+// it is considered abandoned by the process that ran it. State is saved after every phase,
+// so a live job is updated well within this.
+const OrphanedAfter = 30 * time.Minute
+
+// Orphaned reports whether the job can be resumed without racing another process: it
+// failed, or it is still marked pending or running but hasn't been updated for OrphanedAfter
+func (r *JobRecord) Orphaned(now time.Time) bool {
+	switch r.Status {
+	case "failed":
+		return true
+	case "pending", "running":
+		return now.Sub(r.UpdatedAt) >= OrphanedAfter
+	default:
+		return false
+	}
+}
+
+// CreateJob inserts a new pending research job and returns its ID
+func (db *PostgresDB) CreateJob(ctx context.Context, topic string, config []byte) (uuid.UUID, error) {
+	id := uuid.New()
+	_, err := db.Pool.Exec(ctx,
+		"INSERT INTO research_jobs (id, topic, status, config) VALUES ($1, $2, 'pending', $3)",
+		id, topic, config)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to create job: %w", err)
+	}
+	return id, nil
+}
+
+// SetJobStatus updates the status of a job
+func (db *PostgresDB) SetJobStatus(ctx context.Context, id uuid.UUID, status string) error {
+	_, err := db.Pool.Exec(ctx, "UPDATE research_jobs SET status = $2, updated_at = NOW() WHERE id = $1", id, status)
+	if err != nil {
+		return fmt.Errorf("failed to update job status: %w", err)
+	}
+	return nil
+}
+
+// SaveJobState persists the serialized research state of a job
+func (db *PostgresDB) SaveJobState(ctx context.Context, id uuid.UUID, state []byte) error {
+	_, err := db.Pool.Exec(ctx, "UPDATE research_jobs SET state = $2, updated_at = NOW() WHERE id = $1", id, state)
+	if err != nil {
+		return fmt.Errorf("failed to save job state: %w", err)
+	}
+	return nil
+}
+
+// CompleteJob stores the final report and marks the job completed
+func (db *PostgresDB) CompleteJob(ctx context.Context, id uuid.UUID, report string) error {
+	_, err := db.Pool.Exec(ctx,
+		"UPDATE research_jobs SET status = 'completed', report = $2, updated_at = NOW() WHERE id = $1",
+		id, report)
+	if err != nil {
+		return fmt.Errorf("failed to complete job: %w", err)
+	}
+	return nil
+}
+
+// GetJobRecord returns the persisted row of a job
+func (db *PostgresDB) GetJobRecord(ctx context.Context, id uuid.UUID) (*JobRecord, error) {
+	return db.scanJobRecord(ctx, "SELECT id, topic, status, config, state, updated_at FROM research_jobs WHERE id = $1", id)
+}
+
+// LatestIncompleteJob returns the most recently updated orphaned job (see
+// JobRecord.Orphaned) that has persisted state to resume from
+func (db *PostgresDB) LatestIncompleteJob(ctx context.Context) (*JobRecord, error) {
+	return db.scanJobRecord(ctx, `
+		SELECT id, topic, status, config, state, updated_at
+		FROM research_jobs
+		WHERE state IS NOT NULL
+			AND (status = 'failed' OR (status IN ('pending', 'running') AND updated_at < NOW() - make_interval(secs => $1)))
+		ORDER BY updated_at DESC
+		LIMIT 1
+	`, OrphanedAfter.Seconds())
+}
+
+func (db *PostgresDB) scanJobRecord(ctx context.Context, query string, args ...any) (*JobRecord, error) {
+	var rec JobRecord
+	var configJSON, stateJSON []byte
+	var updatedAt *time.Time
+	err := db.Pool.QueryRow(ctx, query, args...).Scan(&rec.ID, &rec.Topic, &rec.Status, &configJSON, &stateJSON, &updatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+	rec.Config = configJSON
+	rec.State = stateJSON
+	if updatedAt != nil {
+		rec.UpdatedAt = *updatedAt
+	}
+	return &rec, nil
+}
@@ -0,0 +1,30 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestJobRecordOrphaned(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		status string
+		idle   time.Duration
+		want   bool
+	}{
+		{"Failed", "failed", 0, true},
+		{"Running and updated recently", "running", time.Minute, false},
+		{"Running but abandoned", "running", OrphanedAfter, true},
+		{"Pending and updated recently", "pending", time.Minute, false},
+		{"Completed", "completed", 24 * time.Hour, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &JobRecord{Status: tt.status, UpdatedAt: now.Add(-tt.idle)}
+			if got := rec.Orphaned(now); got != tt.want {
+				t.Errorf("Orphaned() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
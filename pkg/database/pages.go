@@ -0,0 +1,81 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// PageImage is a figure extracted from a document page
+type PageImage struct {
+	ID          string `json:"id"`
+	ImageBase64 string `json:"image_base64,omitempty"`
+}
+
+// DocumentPage is the raw OCR output of a single page of an indexed source
+type DocumentPage struct {
+	Collection string      `json:"collection"`
+	Source     string      `json:"source"`
+	PageIndex  int         `json:"page_index"`
+	Markdown   string      `json:"markdown"`
+	Images     []PageImage `json:"images"`
+	CreatedAt  time.Time   `json:"created_at"`
+}
+
+// SavePages stores the pages of a source, replacing previously stored versions of the same pages
+func (db *PostgresDB) SavePages(ctx context.Context, pages []DocumentPage) error {
+	query := `
+		INSERT INTO document_pages (collection, source, page_index, markdown, images)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (collection, source, page_index)
+		DO UPDATE SET markdown = EXCLUDED.markdown, images = EXCLUDED.images, created_at = NOW()
+	`
+
+	for _, page := range pages {
+		imagesJSON, err := json.Marshal(page.Images)
+		if err != nil {
+			return fmt.Errorf("failed to marshal images: %w", err)
+		}
+		if _, err := db.Pool.Exec(ctx, query, page.Collection, page.Source, page.PageIndex, page.Markdown, imagesJSON); err != nil {
+			return fmt.Errorf("failed to save page %d: %w", page.PageIndex, err)
+		}
+	}
+	return nil
+}
+
+// GetPages returns the stored pages of a source in page order. A non-nil pageIndex selects a single page.
+func (db *PostgresDB) GetPages(ctx context.Context, collection, source string, pageIndex *int) ([]DocumentPage, error) {
+	query := `
+		SELECT collection, source, page_index, markdown, images, created_at
+		FROM document_pages
+		WHERE collection = $1 AND source = $2 AND ($3::int IS NULL OR page_index = $3)
+		ORDER BY page_index ASC
+	`
+	rows, err := db.Pool.Query(ctx, query, collection, source, pageIndex)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pages: %w", err)
+	}
+	defer rows.Close()
+
+	var pages []DocumentPage
+	for rows.Next() {
+		var p DocumentPage
+		var imagesJSON []byte
+		if err := rows.Scan(&p.Collection, &p.Source, &p.PageIndex, &p.Markdown, &imagesJSON, &p.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		if imagesJSON != nil {
+			if err := json.Unmarshal(imagesJSON, &p.Images); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal images: %w", err)
+			}
+		}
+		pages = append(pages, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return pages, nil
+}
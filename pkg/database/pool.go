@@ -0,0 +1,48 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DefaultAcquireTimeout bounds how long Acquire waits for a free connection
+const DefaultAcquireTimeout = 10 * time.Second
+
+// ErrPoolExhausted is returned by Acquire when every connection stays in use for the
+// whole acquire timeout. Callers on a request path should report it as temporary.
+var ErrPoolExhausted = errors.New("database connection pool exhausted")
+
+// Acquire takes a connection from the pool, waiting at most db.AcquireTimeout.
+// Running out of time fails with ErrPoolExhausted rather than a bare context error;
+// cancellation of ctx itself is returned unchanged. Release the connection when done.
+func (db *PostgresDB) Acquire(ctx context.Context) (*pgxpool.Conn, error) {
+	return db.AcquireWithin(ctx, db.AcquireTimeout)
+}
+
+// AcquireWithin is Acquire with an explicit timeout (0 = wait until ctx is done)
+func (db *PostgresDB) AcquireWithin(ctx context.Context, timeout time.Duration) (*pgxpool.Conn, error) {
+	if timeout <= 0 {
+		return db.Pool.Acquire(ctx)
+	}
+
+	acquireCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	conn, err := db.Pool.Acquire(acquireCtx)
+	if err != nil && ctx.Err() == nil && errors.Is(acquireCtx.Err(), context.DeadlineExceeded) {
+		stat := db.Pool.Stat()
+		return nil, fmt.Errorf("%w: no connection free after %s (%d of %d in use)",
+			ErrPoolExhausted, timeout, stat.AcquiredConns(), stat.MaxConns())
+	}
+	return conn, err
+}
+
+// Saturated reports whether every connection of the pool is currently in use
+func (db *PostgresDB) Saturated() bool {
+	stat := db.Pool.Stat()
+	return stat.AcquiredConns() >= stat.MaxConns()
+}
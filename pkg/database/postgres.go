@@ -2,14 +2,23 @@ package database
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
+	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mikeboe/research-helper/pkg/vectorstore"
 )
 
 // PostgresDB wraps the database connection pool
 type PostgresDB struct {
-	Pool *pgxpool.Pool
+	Pool           *pgxpool.Pool
+	AcquireTimeout time.Duration // Bounds Acquire, see DefaultAcquireTimeout
+
+	vectorMu      sync.Mutex
+	vectorSupport *VectorSupport // Set by CheckVectorSupport
 }
 
 // NewPostgresDB creates a new PostgreSQL database connection
@@ -34,7 +43,7 @@ func NewPostgresDB(ctx context.Context, databaseURL string) (*PostgresDB, error)
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	return &PostgresDB{Pool: pool}, nil
+	return &PostgresDB{Pool: pool, AcquireTimeout: DefaultAcquireTimeout}, nil
 }
 
 // Close closes the database connection pool
@@ -48,8 +57,41 @@ func (db *PostgresDB) EnsureVectorExtension(ctx context.Context) error {
 	return err
 }
 
-// CreateEmbeddingsTable creates the embeddings table if it doesn't exist
-func (db *PostgresDB) CreateEmbeddingsTable(ctx context.Context, tableName string, dimension int) error {
+// CreateEmbeddingsTable creates the embeddings table if it doesn't exist and records
+// its distance metric in collection_metadata, so searches use the operator matching the index.
+// An existing collection must be opened with the metric it was created with; an empty metric
+// uses the recorded one, or cosine for a new collection.
+// The index type only applies to new collections (see ParseIndexType for the default); an
+// IVFFlat index is built by BuildIndex once the collection has data.
+func (db *PostgresDB) CreateEmbeddingsTable(ctx context.Context, tableName string, dimension int, metric vectorstore.DistanceMetric, index vectorstore.IndexType) error {
+	// CREATE TABLE IF NOT EXISTS silently accepts an unrelated table of the same name
+	store, err := vectorstore.NewPGVectorStore(db.Pool, tableName)
+	if err != nil {
+		return err
+	}
+	if err := store.Verify(ctx); err != nil {
+		return err
+	}
+
+	recorded, err := db.collectionMetric(ctx, tableName)
+	if err != nil {
+		return err
+	}
+	if metric == "" {
+		metric = recorded
+		if metric == "" {
+			metric = vectorstore.MetricCosine
+		}
+	}
+	if recorded != "" && recorded != metric {
+		return fmt.Errorf("collection %s was created with the %s metric, not %s", tableName, recorded, metric)
+	}
+	if recorded == "" {
+		if index, err = db.resolveIndexType(ctx, index); err != nil {
+			return err
+		}
+	}
+
 	query := fmt.Sprintf(`
 		CREATE TABLE IF NOT EXISTS %s (
 			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
@@ -60,19 +102,34 @@ func (db *PostgresDB) CreateEmbeddingsTable(ctx context.Context, tableName strin
 		)
 	`, tableName, dimension)
 
-	_, err := db.Pool.Exec(ctx, query)
+	_, err = db.Pool.Exec(ctx, query)
 	if err != nil {
 		return fmt.Errorf("failed to create table %s: %w", tableName, err)
 	}
 
+	// Search hits, counted for least-accessed eviction (see vectorstore.EvictLeastAccessed)
+	_, err = db.Pool.Exec(ctx, fmt.Sprintf(`
+		ALTER TABLE %s
+			ADD COLUMN IF NOT EXISTS access_count INTEGER NOT NULL DEFAULT 0,
+			ADD COLUMN IF NOT EXISTS accessed_at TIMESTAMP WITH TIME ZONE
+	`, tableName))
+	if err != nil {
+		return fmt.Errorf("failed to add access columns to %s: %w", tableName, err)
+	}
+
+	if recorded != "" {
+		// Existing collections keep their index
+		return nil
+	}
+
 	// Create index for vector similarity search
 	// HNSW and IVFFlat support up to 2000 dimensions.
 	// If dimensions > 2000, we skip index creation and rely on exact search (slower but works).
-	if dimension <= 2000 {
+	if dimension <= 2000 && index == vectorstore.IndexHNSW {
 		indexQuery := fmt.Sprintf(`
 			CREATE INDEX IF NOT EXISTS %s_embedding_idx
-			ON %s USING hnsw (embedding vector_cosine_ops)
-		`, tableName, tableName)
+			ON %s USING hnsw (embedding %s)
+		`, tableName, tableName, metric.IndexOps())
 
 		_, err = db.Pool.Exec(ctx, indexQuery)
 		if err != nil {
@@ -80,5 +137,58 @@ func (db *PostgresDB) CreateEmbeddingsTable(ctx context.Context, tableName strin
 		}
 	}
 
+	if dimension > 2000 {
+		index = vectorstore.IndexNone
+	}
+	_, err = db.Pool.Exec(ctx, `
+		INSERT INTO collection_metadata (collection, metric, dimension, index_type)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (collection) DO NOTHING
+	`, tableName, string(metric), dimension, string(index))
+	if err != nil {
+		return fmt.Errorf("failed to record metadata for %s: %w", tableName, err)
+	}
+
 	return nil
 }
+
+// CollectionExists reports whether the table of a collection exists
+func (db *PostgresDB) CollectionExists(ctx context.Context, tableName string) (bool, error) {
+	var exists bool
+	if err := db.Pool.QueryRow(ctx, `SELECT to_regclass($1) IS NOT NULL`, tableName).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check for table %s: %w", tableName, err)
+	}
+	return exists, nil
+}
+
+// collectionMetric returns the metric recorded for a collection. Tables that predate
+// collection_metadata were always indexed for cosine, so they are recorded as such.
+// An empty metric means the collection does not exist yet.
+func (db *PostgresDB) collectionMetric(ctx context.Context, tableName string) (vectorstore.DistanceMetric, error) {
+	var metric string
+	err := db.Pool.QueryRow(ctx, `SELECT metric FROM collection_metadata WHERE collection = $1`, tableName).Scan(&metric)
+	if err == nil {
+		return vectorstore.DistanceMetric(metric), nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return "", fmt.Errorf("failed to get metadata for %s: %w", tableName, err)
+	}
+
+	exists, err := db.CollectionExists(ctx, tableName)
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		return "", nil
+	}
+
+	_, err = db.Pool.Exec(ctx, `
+		INSERT INTO collection_metadata (collection, metric)
+		VALUES ($1, $2)
+		ON CONFLICT (collection) DO NOTHING
+	`, tableName, string(vectorstore.MetricCosine))
+	if err != nil {
+		return "", fmt.Errorf("failed to record metadata for %s: %w", tableName, err)
+	}
+	return vectorstore.MetricCosine, nil
+}
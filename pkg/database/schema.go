@@ -54,6 +54,19 @@ func (db *PostgresDB) InitSchema(ctx context.Context) error {
 		return fmt.Errorf("failed to add state column: %w", err)
 	}
 
+	// User-provided tags and notes for organizing jobs
+	_, err = db.Pool.Exec(ctx, `
+		ALTER TABLE research_jobs
+		ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}',
+		ADD COLUMN IF NOT EXISTS metadata JSONB
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to add tags and metadata columns: %w", err)
+	}
+	if _, err := db.Pool.Exec(ctx, "CREATE INDEX IF NOT EXISTS idx_research_jobs_tags ON research_jobs USING GIN (tags)"); err != nil {
+		return fmt.Errorf("failed to create tags index on research_jobs: %w", err)
+	}
+
 	// 4. Conversations Table
 	convQuery := `
 		CREATE TABLE IF NOT EXISTS conversations (
@@ -67,6 +80,19 @@ func (db *PostgresDB) InitSchema(ctx context.Context) error {
 		return fmt.Errorf("failed to create conversations table: %w", err)
 	}
 
+	_, err = db.Pool.Exec(ctx, `
+		ALTER TABLE conversations
+		ADD COLUMN IF NOT EXISTS pinned_sources TEXT[] NOT NULL DEFAULT '{}'
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to add pinned_sources column: %w", err)
+	}
+
+	// Model answering the conversation; empty means the server's reasoning model
+	if _, err := db.Pool.Exec(ctx, "ALTER TABLE conversations ADD COLUMN IF NOT EXISTS model TEXT NOT NULL DEFAULT ''"); err != nil {
+		return fmt.Errorf("failed to add model column: %w", err)
+	}
+
 	// 5. Messages Table
 	msgQuery := `
 		CREATE TABLE IF NOT EXISTS messages (
@@ -81,6 +107,11 @@ func (db *PostgresDB) InitSchema(ctx context.Context) error {
 		return fmt.Errorf("failed to create messages table: %w", err)
 	}
 
+	// Tool calls (name, arguments, result) the agent made for a model message
+	if _, err := db.Pool.Exec(ctx, "ALTER TABLE messages ADD COLUMN IF NOT EXISTS tool_calls JSONB"); err != nil {
+		return fmt.Errorf("failed to add tool_calls column: %w", err)
+	}
+
 	// Indexes for chat
 	if _, err := db.Pool.Exec(ctx, "CREATE INDEX IF NOT EXISTS idx_messages_conversation_id ON messages(conversation_id)"); err != nil {
 		return fmt.Errorf("failed to create index on messages: %w", err)
@@ -89,5 +120,86 @@ func (db *PostgresDB) InitSchema(ctx context.Context) error {
 		return fmt.Errorf("failed to create index on conversations: %w", err)
 	}
 
+	// 6. LLM Traces Table
+	tracesQuery := `
+		CREATE TABLE IF NOT EXISTS llm_traces (
+			id SERIAL PRIMARY KEY,
+			job_id UUID NOT NULL REFERENCES research_jobs(id) ON DELETE CASCADE,
+			timestamp TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			phase TEXT NOT NULL,
+			iteration INT NOT NULL,
+			prompts JSONB NOT NULL,
+			json_mode BOOLEAN NOT NULL DEFAULT FALSE,
+			response TEXT,
+			error TEXT,
+			duration_ms BIGINT
+		);
+	`
+	if _, err := db.Pool.Exec(ctx, tracesQuery); err != nil {
+		return fmt.Errorf("failed to create llm_traces table: %w", err)
+	}
+	if _, err := db.Pool.Exec(ctx, "CREATE INDEX IF NOT EXISTS idx_llm_traces_job_id ON llm_traces(job_id)"); err != nil {
+		return fmt.Errorf("failed to create index on llm_traces: %w", err)
+	}
+
+	// 7. Collection Metadata Table (distance metric each collection was indexed with)
+	collectionsQuery := `
+		CREATE TABLE IF NOT EXISTS collection_metadata (
+			collection TEXT PRIMARY KEY,
+			metric TEXT NOT NULL DEFAULT 'cosine',
+			dimension INT,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+		);
+	`
+	if _, err := db.Pool.Exec(ctx, collectionsQuery); err != nil {
+		return fmt.Errorf("failed to create collection_metadata table: %w", err)
+	}
+
+	// Index type of the collection; NULL for collections that predate the column (HNSW)
+	_, err = db.Pool.Exec(ctx, `
+		ALTER TABLE collection_metadata
+		ADD COLUMN IF NOT EXISTS index_type TEXT
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to add index_type column: %w", err)
+	}
+
+	// 8. Document Pages Table (raw OCR output per page)
+	pagesQuery := `
+		CREATE TABLE IF NOT EXISTS document_pages (
+			id SERIAL PRIMARY KEY,
+			collection TEXT NOT NULL,
+			source TEXT NOT NULL,
+			page_index INT NOT NULL,
+			markdown TEXT NOT NULL,
+			images JSONB,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			UNIQUE (collection, source, page_index)
+		);
+	`
+	if _, err := db.Pool.Exec(ctx, pagesQuery); err != nil {
+		return fmt.Errorf("failed to create document_pages table: %w", err)
+	}
+
+	// 9. Knowledge Gaps Table (questions chat couldn't answer from a collection)
+	gapsQuery := `
+		CREATE TABLE IF NOT EXISTS knowledge_gaps (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			collection TEXT NOT NULL,
+			question TEXT NOT NULL,
+			reason TEXT NOT NULL DEFAULT '',
+			origin TEXT NOT NULL,
+			conversation_id UUID REFERENCES conversations(id) ON DELETE SET NULL,
+			best_similarity DOUBLE PRECISION,
+			occurrences INT NOT NULL DEFAULT 1,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			last_seen_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			UNIQUE (collection, question)
+		);
+	`
+	if _, err := db.Pool.Exec(ctx, gapsQuery); err != nil {
+		return fmt.Errorf("failed to create knowledge_gaps table: %w", err)
+	}
+
 	return nil
 }
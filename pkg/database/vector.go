@@ -0,0 +1,150 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/mikeboe/research-helper/pkg/vectorstore"
+)
+
+// VectorSupport describes the installed pgvector extension
+type VectorSupport struct {
+	Version string
+	HNSW    bool // HNSW indexes are available (pgvector 0.5.0+)
+}
+
+// DefaultIndex returns the index type new collections get when none is requested
+func (s VectorSupport) DefaultIndex() vectorstore.IndexType {
+	if s.HNSW {
+		return vectorstore.IndexHNSW
+	}
+	return vectorstore.IndexIVFFlat
+}
+
+// CheckVectorSupport installs the pgvector extension if needed and reports its version and
+// whether it supports HNSW indexes. The result is cached and decides the default index type of
+// new collections; without HNSW they fall back to IVFFlat instead of failing at index creation.
+func (db *PostgresDB) CheckVectorSupport(ctx context.Context) (VectorSupport, error) {
+	var available bool
+	if err := db.Pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM pg_available_extensions WHERE name = 'vector')`).Scan(&available); err != nil {
+		return VectorSupport{}, fmt.Errorf("failed to check for pgvector: %w", err)
+	}
+	if !available {
+		return VectorSupport{}, errors.New("the pgvector extension is not installed on the database server; see https://github.com/pgvector/pgvector#installation")
+	}
+
+	if err := db.EnsureVectorExtension(ctx); err != nil {
+		return VectorSupport{}, fmt.Errorf("failed to create the pgvector extension: %w", err)
+	}
+
+	var support VectorSupport
+	err := db.Pool.QueryRow(ctx, `
+		SELECT e.extversion, EXISTS (SELECT 1 FROM pg_am WHERE amname = 'hnsw')
+		FROM pg_extension e
+		WHERE e.extname = 'vector'
+	`).Scan(&support.Version, &support.HNSW)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return VectorSupport{}, errors.New("the pgvector extension is not enabled in this database")
+	}
+	if err != nil {
+		return VectorSupport{}, fmt.Errorf("failed to get pgvector version: %w", err)
+	}
+
+	db.vectorMu.Lock()
+	db.vectorSupport = &support
+	db.vectorMu.Unlock()
+	return support, nil
+}
+
+// supportedVectors returns the cached pgvector support, checking it on first use
+func (db *PostgresDB) supportedVectors(ctx context.Context) (VectorSupport, error) {
+	db.vectorMu.Lock()
+	support := db.vectorSupport
+	db.vectorMu.Unlock()
+
+	if support == nil {
+		return db.CheckVectorSupport(ctx)
+	}
+	return *support, nil
+}
+
+// resolveIndexType picks the index for a new collection: the requested type, or the default
+// for the installed pgvector. Requesting HNSW from a pgvector without it fails.
+func (db *PostgresDB) resolveIndexType(ctx context.Context, requested vectorstore.IndexType) (vectorstore.IndexType, error) {
+	if requested == vectorstore.IndexNone || requested == vectorstore.IndexIVFFlat {
+		return requested, nil
+	}
+	support, err := db.supportedVectors(ctx)
+	if err != nil {
+		return "", err
+	}
+	if requested == vectorstore.IndexHNSW && !support.HNSW {
+		return "", fmt.Errorf("pgvector %s does not support HNSW indexes (0.5.0+ required); use the ivfflat index type", support.Version)
+	}
+	return support.DefaultIndex(), nil
+}
+
+// BuildIndex builds the IVFFlat index of a collection from the rows inserted so far, replacing
+// an existing one so the number of lists matches the collection size. IVFFlat derives its lists
+// from the data, so it is not built with the empty table; call this after inserting documents.
+// Collections with other index types are left unchanged.
+func (db *PostgresDB) BuildIndex(ctx context.Context, tableName string) error {
+	var metric string
+	var indexType *string
+	err := db.Pool.QueryRow(ctx, `SELECT metric, index_type FROM collection_metadata WHERE collection = $1`, tableName).Scan(&metric, &indexType)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("collection %s does not exist", tableName)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get metadata for %s: %w", tableName, err)
+	}
+	if indexType == nil || vectorstore.IndexType(*indexType) != vectorstore.IndexIVFFlat {
+		return nil
+	}
+
+	table := pgx.Identifier{tableName}.Sanitize()
+	var rows int
+	if err := db.Pool.QueryRow(ctx, fmt.Sprintf("SELECT count(*) FROM %s", table)).Scan(&rows); err != nil {
+		return fmt.Errorf("failed to count rows of %s: %w", tableName, err)
+	}
+	if rows == 0 {
+		return nil
+	}
+
+	index := pgx.Identifier{tableName + "_embedding_idx"}.Sanitize()
+	if _, err := db.Pool.Exec(ctx, fmt.Sprintf("DROP INDEX IF EXISTS %s", index)); err != nil {
+		return fmt.Errorf("failed to drop index on %s: %w", tableName, err)
+	}
+	query := fmt.Sprintf("CREATE INDEX %s ON %s USING ivfflat (embedding %s) WITH (lists = %d)",
+		index, table, vectorstore.DistanceMetric(metric).IndexOps(), vectorstore.IVFFlatLists(rows))
+	if _, err := db.Pool.Exec(ctx, query); err != nil {
+		return fmt.Errorf("failed to build index on %s: %w", tableName, err)
+	}
+	return nil
+}
+
+// PromoteDocuments copies the documents of the source collection matching filter into the
+// target collection (see vectorstore.PromoteToCollection). A missing target is created with
+// the source's distance metric and the default index.
+func (db *PostgresDB) PromoteDocuments(ctx context.Context, source, target string, dimension int, filter map[string]interface{}) (int64, error) {
+	store, err := vectorstore.NewPGVectorStore(db.Pool, source)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := vectorstore.NewPGVectorStore(db.Pool, target); err != nil {
+		return 0, err
+	}
+	if target == source {
+		return 0, vectorstore.ErrSameCollection
+	}
+	metric, err := store.Metric(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if err := db.CreateEmbeddingsTable(ctx, target, dimension, metric, ""); err != nil {
+		return 0, err
+	}
+	return store.PromoteToCollection(ctx, filter, target)
+}
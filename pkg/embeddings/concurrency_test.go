@@ -0,0 +1,62 @@
+package embeddings
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestEmbedConcurrentlyPreservesOrder(t *testing.T) {
+	texts := make([]string, 50)
+	for i := range texts {
+		texts[i] = strconv.Itoa(i)
+	}
+
+	var inFlight, maxInFlight atomic.Int32
+	embed := func(ctx context.Context, text string) ([]float32, error) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		// Random latency so calls finish out of order
+		time.Sleep(time.Duration(rand.Intn(3)) * time.Millisecond)
+		v, _ := strconv.Atoi(text)
+		return []float32{float32(v)}, nil
+	}
+
+	results, err := embedConcurrently(context.Background(), texts, 4, embed)
+	if err != nil {
+		t.Fatalf("embedConcurrently: %v", err)
+	}
+	for i, vec := range results {
+		if len(vec) != 1 || vec[0] != float32(i) {
+			t.Fatalf("results[%d] = %v, want [%d]", i, vec, i)
+		}
+	}
+	if m := maxInFlight.Load(); m > 4 {
+		t.Errorf("max in-flight calls = %d, want at most 4", m)
+	}
+}
+
+func TestEmbedConcurrentlyReturnsError(t *testing.T) {
+	errBoom := errors.New("boom")
+	embed := func(ctx context.Context, text string) ([]float32, error) {
+		if text == "3" {
+			return nil, errBoom
+		}
+		return []float32{1}, nil
+	}
+
+	texts := []string{"0", "1", "2", "3", "4", "5"}
+	if _, err := embedConcurrently(context.Background(), texts, 2, embed); !errors.Is(err, errBoom) {
+		t.Errorf("err = %v, want %v", err, errBoom)
+	}
+}
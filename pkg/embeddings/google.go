@@ -2,15 +2,37 @@ package embeddings
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"math"
+	"strings"
+	"sync"
 
 	"google.golang.org/genai"
 )
 
+var (
+	// ErrEmptyEmbedding is returned when the API responds without a vector for a text
+	ErrEmptyEmbedding = errors.New("empty embedding returned")
+	// ErrDimensionMismatch is returned when a vector doesn't have the requested number of
+	// dimensions, e.g. the model returned fewer than requested
+	ErrDimensionMismatch = errors.New("embedding dimension mismatch")
+)
+
+// Dimension is the size of the vectors produced by GoogleEmbedder
+const Dimension = 1536
+
 // GoogleEmbedder wraps Google Vertex AI / Gemini embeddings
 type GoogleEmbedder struct {
-	client *genai.Client
-	model  string
+	client      *genai.Client
+	model       string
+	dimension   int
+	concurrency int
+	limiter     *rateLimiter
+	retry       retryPolicy
+
+	truncateWarning sync.Once
 }
 
 // NewGoogleEmbedder creates a new Google Vertex AI embedder
@@ -26,14 +48,41 @@ func NewGoogleEmbedder(ctx context.Context, model, apiKey string) (*GoogleEmbedd
 	}
 
 	return &GoogleEmbedder{
-		client: client,
-		model:  model,
+		client:    client,
+		model:     model,
+		dimension: Dimension,
 	}, nil
 }
 
-// EmbedText generates embeddings for a single text
+// WithConcurrency lets EmbedTexts run up to workers requests in parallel while
+// spacing all requests to at most requestsPerSecond (0 = unlimited).
+func (e *GoogleEmbedder) WithConcurrency(workers int, requestsPerSecond float64) *GoogleEmbedder {
+	e.concurrency = workers
+	e.limiter = newRateLimiter(requestsPerSecond)
+	return e
+}
+
+// Model returns the name of the embedding model
+func (e *GoogleEmbedder) Model() string {
+	return e.model
+}
+
+// EmbedText generates embeddings for a single text. The vector always has the embedder's
+// dimension: models that ignore OutputDimensionality are truncated and renormalized (see fitDimension).
+// Transient failures are retried as configured by WithRetry.
 func (e *GoogleEmbedder) EmbedText(ctx context.Context, text string) ([]float32, error) {
-	outputDim := int32(1536)
+	return e.retry.do(ctx, func() ([]float32, error) {
+		return e.embedOnce(ctx, text)
+	})
+}
+
+// embedOnce makes a single embedding request, waiting for the rate limiter first
+func (e *GoogleEmbedder) embedOnce(ctx context.Context, text string) ([]float32, error) {
+	if err := e.limiter.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	outputDim := int32(e.dimension)
 	res, err := e.client.Models.EmbedContent(ctx, e.model, []*genai.Content{
 		{
 			Parts: []*genai.Part{
@@ -48,25 +97,93 @@ func (e *GoogleEmbedder) EmbedText(ctx context.Context, text string) ([]float32,
 	}
 
 	if res.Embeddings == nil || len(res.Embeddings) == 0 || len(res.Embeddings[0].Values) == 0 {
-		return nil, fmt.Errorf("empty embedding returned")
+		return nil, ErrEmptyEmbedding
+	}
+
+	values := res.Embeddings[0].Values
+	if len(values) > e.dimension {
+		e.truncateWarning.Do(func() {
+			slog.Warn("Embedding model ignored the requested dimension, truncating vectors",
+				"model", e.model, "returned", len(values), "dimension", e.dimension)
+		})
+	}
+	return fitDimension(values, e.dimension)
+}
+
+// fitDimension returns a vector of exactly dim values. Longer vectors are truncated to their
+// first dim values and scaled back to unit length, which is how Matryoshka-trained models
+// like gemini-embedding-001 are meant to be shortened. Vectors of the right size are returned
+// unchanged; shorter ones can't be fitted and fail with ErrDimensionMismatch.
+func fitDimension(values []float32, dim int) ([]float32, error) {
+	switch {
+	case len(values) == dim:
+		return values, nil
+	case len(values) < dim:
+		return nil, fmt.Errorf("%w: got %d, want %d", ErrDimensionMismatch, len(values), dim)
 	}
 
-	return res.Embeddings[0].Values, nil
+	truncated := values[:dim]
+	var sum float64
+	for _, v := range truncated {
+		sum += float64(v) * float64(v)
+	}
+	if sum == 0 {
+		return truncated, nil
+	}
+	norm := math.Sqrt(sum)
+	fitted := make([]float32, dim)
+	for i, v := range truncated {
+		fitted[i] = float32(float64(v) / norm)
+	}
+	return fitted, nil
 }
 
-// EmbedTexts generates embeddings for multiple texts
+// EmbedTexts generates embeddings for multiple texts, in the same order
 func (e *GoogleEmbedder) EmbedTexts(ctx context.Context, texts []string) ([][]float32, error) {
-	// We can implement batching here if needed, but for now sequential is safer
-	// as we don't know the exact batch limits/API of the SDK version.
-	result := make([][]float32, 0, len(texts))
-
-	for _, text := range texts {
-		vec, err := e.EmbedText(ctx, text)
-		if err != nil {
-			return nil, err
-		}
-		result = append(result, vec)
+	return e.EmbedEach(ctx, texts, e.EmbedText)
+}
+
+// EmbedEach calls embed for every text on the embedder's worker pool and returns the
+// vectors in the same order as texts. embed is typically EmbedText or a wrapper around it;
+// it may return nil to skip a text. Vectors of the wrong dimension fail the whole batch
+// with ErrDimensionMismatch, so they never reach a collection.
+func (e *GoogleEmbedder) EmbedEach(ctx context.Context, texts []string, embed func(context.Context, string) ([]float32, error)) ([][]float32, error) {
+	if len(texts) == 0 {
+		return [][]float32{}, nil
+	}
+	vectors, err := embedConcurrently(ctx, texts, e.concurrency, embed)
+	if err != nil {
+		return nil, err
 	}
+	if err := checkDimensions(vectors, e.dimension); err != nil {
+		return nil, err
+	}
+	return vectors, nil
+}
 
-	return result, nil
+// maxReportedMismatches caps the offending vectors listed in a dimension mismatch error
+const maxReportedMismatches = 5
+
+// checkDimensions returns an ErrDimensionMismatch error listing the indexes and sizes of
+// the non-nil vectors that don't have dim values
+func checkDimensions(vectors [][]float32, dim int) error {
+	var bad []string
+	count := 0
+	for i, vec := range vectors {
+		if vec == nil || len(vec) == dim {
+			continue
+		}
+		count++
+		if len(bad) < maxReportedMismatches {
+			bad = append(bad, fmt.Sprintf("%d (%d)", i, len(vec)))
+		}
+	}
+	if count == 0 {
+		return nil
+	}
+	if count > len(bad) {
+		bad = append(bad, fmt.Sprintf("and %d more", count-len(bad)))
+	}
+	return fmt.Errorf("%w: %d of %d vectors don't have %d dimensions, at index (size) %s",
+		ErrDimensionMismatch, count, len(vectors), dim, strings.Join(bad, ", "))
 }
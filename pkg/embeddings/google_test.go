@@ -0,0 +1,60 @@
+package embeddings
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestFitDimension(t *testing.T) {
+	exact := []float32{0.5, 0.5}
+	got, err := fitDimension(exact, 2)
+	if err != nil || &got[0] != &exact[0] {
+		t.Errorf("exact size: got %v, %v; want the input unchanged", got, err)
+	}
+
+	got, err = fitDimension([]float32{3, 4, 12}, 2)
+	if err != nil {
+		t.Fatalf("truncate: unexpected error %v", err)
+	}
+	if len(got) != 2 || math.Abs(float64(got[0])-0.6) > 1e-6 || math.Abs(float64(got[1])-0.8) > 1e-6 {
+		t.Errorf("truncate: got %v, want [0.6 0.8]", got)
+	}
+
+	if _, err := fitDimension([]float32{1}, 2); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("too short: err = %v, want ErrDimensionMismatch", err)
+	}
+}
+
+func TestCheckDimensions(t *testing.T) {
+	ok := []float32{1, 0}
+	tests := []struct {
+		name    string
+		vectors [][]float32
+		want    string // Expected error suffix; empty for no error
+	}{
+		{"all match", [][]float32{ok, ok}, ""},
+		{"skipped texts", [][]float32{ok, nil}, ""},
+		{"short vector", [][]float32{ok, {1}}, "1 of 2 vectors don't have 2 dimensions, at index (size) 1 (1)"},
+		{
+			"capped",
+			[][]float32{{}, {1}, {1, 2, 3}, {1}, {1}, {1}, {1}, ok},
+			"7 of 8 vectors don't have 2 dimensions, at index (size) 0 (0), 1 (1), 2 (3), 3 (1), 4 (1), and 2 more",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkDimensions(tt.vectors, 2)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("checkDimensions() = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrDimensionMismatch) || err.Error() != ErrDimensionMismatch.Error()+": "+tt.want {
+				t.Errorf("checkDimensions() = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
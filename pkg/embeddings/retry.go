@@ -0,0 +1,110 @@
+package embeddings
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"google.golang.org/genai"
+)
+
+const (
+	retryBaseDelay = time.Second // First backoff, doubled on every further retry
+	maxRetryDelay  = time.Minute // Cap on the backoff and on delays requested by the API
+)
+
+// retryPolicy retries embedding calls that failed with a transient error, independently of
+// the LLM retries. The zero value doesn't retry.
+type retryPolicy struct {
+	maxRetries int
+	baseDelay  time.Duration
+}
+
+// WithRetry retries a failed embedding request up to maxRetries times when the error is
+// transient (rate limiting, server errors, network failures), backing off exponentially or
+// as long as the API asks to. 0 disables retries.
+func (e *GoogleEmbedder) WithRetry(maxRetries int) *GoogleEmbedder {
+	e.retry = retryPolicy{maxRetries: maxRetries, baseDelay: retryBaseDelay}
+	return e
+}
+
+// do calls call until it succeeds, fails with a permanent error or the retries run out
+func (p retryPolicy) do(ctx context.Context, call func() ([]float32, error)) ([]float32, error) {
+	for attempt := 0; ; attempt++ {
+		vec, err := call()
+		if err == nil {
+			return vec, nil
+		}
+		hint, ok := retryable(err)
+		if !ok || attempt >= p.maxRetries || ctx.Err() != nil {
+			return nil, err
+		}
+
+		delay := p.delay(attempt, hint)
+		slog.Warn("Retrying embedding", "attempt", attempt+2, "delay", delay, "error", err)
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, err
+		}
+	}
+}
+
+// delay returns the wait before retry attempt+1: the delay the API asked for, if any,
+// otherwise exponential backoff. Both are capped at maxRetryDelay; the backoff stops
+// doubling once it reaches the cap, so high attempts can't overflow.
+func (p retryPolicy) delay(attempt int, hint time.Duration) time.Duration {
+	d := hint
+	if d <= 0 {
+		d = p.baseDelay
+		for i := 0; i < attempt && d > 0 && d < maxRetryDelay; i++ {
+			d <<= 1
+		}
+	}
+	return min(d, maxRetryDelay)
+}
+
+// retryable reports whether err is worth retrying and how long the API asked to wait (0 if
+// it didn't say). Rate limits, server errors and network failures are transient; invalid
+// requests and empty or mismatched embeddings are not.
+func retryable(err error) (time.Duration, bool) {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return 0, false
+	}
+
+	var apiErr genai.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.Code {
+		case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+			http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return retryDelayHint(apiErr.Details), true
+		}
+		return 0, false
+	}
+
+	var netErr net.Error
+	return 0, errors.As(err, &netErr)
+}
+
+// retryDelayHint reads the google.rpc.RetryInfo detail, the Gemini API's counterpart to a
+// Retry-After header, e.g. {"@type": ".../google.rpc.RetryInfo", "retryDelay": "30s"}
+func retryDelayHint(details []map[string]any) time.Duration {
+	for _, detail := range details {
+		typ, _ := detail["@type"].(string)
+		if !strings.HasSuffix(typ, "google.rpc.RetryInfo") {
+			continue
+		}
+		if s, ok := detail["retryDelay"].(string); ok {
+			if d, err := time.ParseDuration(s); err == nil && d > 0 {
+				return d
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,88 @@
+package embeddings
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"google.golang.org/genai"
+)
+
+func TestRetryable(t *testing.T) {
+	rateLimited := genai.APIError{Code: 429, Details: []map[string]any{
+		{"@type": "type.googleapis.com/google.rpc.RetryInfo", "retryDelay": "12s"},
+	}}
+
+	tests := []struct {
+		name     string
+		err      error
+		wantHint time.Duration
+		wantOK   bool
+	}{
+		{"rate limited with retry info", fmt.Errorf("failed to embed text: %w", rateLimited), 12 * time.Second, true},
+		{"unavailable", genai.APIError{Code: 503}, 0, true},
+		{"bad request", genai.APIError{Code: 400}, 0, false},
+		{"empty embedding", ErrEmptyEmbedding, 0, false},
+		{"canceled", context.Canceled, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hint, ok := retryable(tt.err)
+			if hint != tt.wantHint || ok != tt.wantOK {
+				t.Errorf("retryable() = %v, %v, want %v, %v", hint, ok, tt.wantHint, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyDo(t *testing.T) {
+	transient := genai.APIError{Code: 503}
+
+	tests := []struct {
+		name       string
+		maxRetries int
+		failures   []error // Errors returned by the first calls, before succeeding
+		wantCalls  int
+		wantErr    bool
+	}{
+		{"succeeds after retries", 3, []error{transient, transient}, 3, false},
+		{"retries exhausted", 2, []error{transient, transient, transient}, 3, true},
+		{"permanent error", 3, []error{genai.APIError{Code: 400}}, 1, true},
+		{"retries disabled", 0, []error{transient}, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := retryPolicy{maxRetries: tt.maxRetries, baseDelay: time.Millisecond}
+			calls := 0
+			_, err := p.do(context.Background(), func() ([]float32, error) {
+				calls++
+				if calls <= len(tt.failures) {
+					return nil, tt.failures[calls-1]
+				}
+				return []float32{1}, nil
+			})
+			if calls != tt.wantCalls || (err != nil) != tt.wantErr {
+				t.Errorf("calls = %d, err = %v, want %d calls, error %v", calls, err, tt.wantCalls, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	p := retryPolicy{baseDelay: time.Second}
+	if d := p.delay(2, 0); d != 4*time.Second {
+		t.Errorf("backoff = %v, want 4s", d)
+	}
+	if d := p.delay(0, 5*time.Second); d != 5*time.Second {
+		t.Errorf("hinted delay = %v, want 5s", d)
+	}
+	if d := p.delay(10, 0); d != maxRetryDelay {
+		t.Errorf("capped delay = %v, want %v", d, maxRetryDelay)
+	}
+	for _, attempt := range []int{34, 64, 1000} {
+		if d := p.delay(attempt, 0); d != maxRetryDelay {
+			t.Errorf("delay(%d) = %v, want %v", attempt, d, maxRetryDelay)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package research
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// bibtexArxivPattern extracts the arXiv identifier, with version, from an abs or pdf URL
+var bibtexArxivPattern = regexp.MustCompile(`arxiv\.org/(?:abs|pdf)/([^/?#]+?)(?:\.pdf)?$`)
+
+// bibtexEscaper escapes the characters LaTeX treats specially in titles
+var bibtexEscaper = strings.NewReplacer(
+	`\`, `\textbackslash{}`, "{", `\{`, "}", `\}`,
+	"&", `\&`, "%", `\%`, "$", `\$`, "#", `\#`, "_", `\_`,
+)
+
+// FormatBibTeX renders sources as BibTeX @misc entries, keyed by arXiv ID where there is one
+func FormatBibTeX(items []SearchResult) string {
+	var sb strings.Builder
+	for i, item := range items {
+		key := fmt.Sprintf("source%d", i+1)
+		note := ""
+		if m := bibtexArxivPattern.FindStringSubmatch(item.URL); m != nil {
+			key = "arxiv:" + m[1]
+			note = "arXiv:" + m[1]
+		}
+
+		sb.WriteString(fmt.Sprintf("@misc{%s,\n", key))
+		sb.WriteString(fmt.Sprintf("  title = {%s},\n", bibtexEscaper.Replace(item.Title)))
+		if item.URL != "" {
+			sb.WriteString(fmt.Sprintf("  howpublished = {\\url{%s}},\n", item.URL))
+		}
+		if note != "" {
+			sb.WriteString(fmt.Sprintf("  note = {%s},\n", note))
+		}
+		sb.WriteString("}\n\n")
+	}
+	return sb.String()
+}
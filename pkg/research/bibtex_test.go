@@ -0,0 +1,25 @@
+package research
+
+import "testing"
+
+func TestFormatBibTeX(t *testing.T) {
+	got := FormatBibTeX([]SearchResult{
+		{Title: "Retrieval & Reasoning: 100% of {LLMs}", URL: "http://arxiv.org/pdf/2401.01234v2"},
+		{Title: "Untitled Report"},
+	})
+
+	want := `@misc{arxiv:2401.01234v2,
+  title = {Retrieval \& Reasoning: 100\% of \{LLMs\}},
+  howpublished = {\url{http://arxiv.org/pdf/2401.01234v2}},
+  note = {arXiv:2401.01234v2},
+}
+
+@misc{source2,
+  title = {Untitled Report},
+}
+
+`
+	if got != want {
+		t.Errorf("FormatBibTeX() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,93 @@
+package research
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/mikeboe/research-helper/pkg/research/tools"
+	"github.com/mikeboe/research-helper/pkg/vectorstore"
+)
+
+// captionPattern matches the start of a figure caption in OCR markdown, e.g.
+// "Figure 3: ...", "**Fig. 2.** ..." or "Figure 4a | ...".
+var captionPattern = regexp.MustCompile(`^(?:Figure|Fig\.?)\s+(\d+[a-z]?)\s*[:.|]\s*(.*)$`)
+
+// Caption is a figure caption found on a page
+type Caption struct {
+	Label string // e.g. "Figure 3"
+	Text  string // The full caption including the label
+	Page  int    // 1-based page number
+}
+
+// extractCaptions finds the figure captions in the markdown of a page. A caption runs
+// from its "Figure N" line until the next blank line.
+func extractCaptions(markdown string, page int) []Caption {
+	var captions []Caption
+	var current *Caption
+	var parts []string
+
+	flush := func() {
+		if current != nil {
+			current.Text = strings.Join(strings.Fields(strings.Join(parts, " ")), " ")
+			captions = append(captions, *current)
+		}
+		current, parts = nil, nil
+	}
+
+	for _, line := range strings.Split(markdown, "\n") {
+		plain := strings.TrimSpace(strings.NewReplacer("**", "", "__", "").Replace(line))
+		if plain == "" {
+			flush()
+			continue
+		}
+		if m := captionPattern.FindStringSubmatch(plain); m != nil {
+			flush()
+			current = &Caption{Label: "Figure " + m[1], Page: page}
+			parts = []string{plain}
+			continue
+		}
+		if current != nil {
+			parts = append(parts, plain)
+		}
+	}
+	flush()
+
+	return captions
+}
+
+// indexCaptions stores the figure captions of a scraped source as separate documents
+// tagged type: figure_caption, so figures can be searched for on their own.
+func (e *ResearchEngine) indexCaptions(ctx context.Context, item SearchResult, result *tools.ScrapeResult, metadata map[string]interface{}) (int, error) {
+	var captions []Caption
+	for _, page := range result.PageContents {
+		captions = append(captions, extractCaptions(page.Markdown, page.Index+1)...)
+	}
+	if len(captions) == 0 {
+		return 0, nil
+	}
+
+	documents := make([]vectorstore.Document, len(captions))
+	texts := make([]string, len(captions))
+	for i, caption := range captions {
+		captionMeta := make(map[string]interface{}, len(metadata)+3)
+		for k, v := range metadata {
+			captionMeta[k] = v
+		}
+		captionMeta["type"] = "figure_caption"
+		captionMeta["figure"] = caption.Label
+		captionMeta["page"] = caption.Page
+
+		documents[i] = vectorstore.Document{Content: caption.Text, Metadata: captionMeta}
+		if e.Config.DeterministicIDs {
+			documents[i].ID = vectorstore.DocumentID(item.URL+"#captions", i, caption.Text)
+		}
+		texts[i] = caption.Text
+	}
+
+	if err := e.embedAndStore(ctx, documents, texts); err != nil {
+		return 0, fmt.Errorf("failed to index captions: %w", err)
+	}
+	return len(captions), nil
+}
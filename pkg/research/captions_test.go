@@ -0,0 +1,30 @@
+package research
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractCaptions(t *testing.T) {
+	markdown := `## 4 Results
+
+As shown in Figure 3, the model improves on the baseline.
+
+![img-0.jpeg](img-0.jpeg)
+
+**Figure 3:** Accuracy of X compared to Y
+across training steps.
+
+Fig. 4. Ablation of the attention heads.
+
+Table 1: Not a figure.`
+
+	got := extractCaptions(markdown, 5)
+	want := []Caption{
+		{Label: "Figure 3", Text: "Figure 3: Accuracy of X compared to Y across training steps.", Page: 5},
+		{Label: "Figure 4", Text: "Fig. 4. Ablation of the attention heads.", Page: 5},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("extractCaptions() =\n%+v\nwant\n%+v", got, want)
+	}
+}
@@ -0,0 +1,82 @@
+package research
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// minRepeatedLine is how often a short line must occur in a source to be treated as a
+// running header or footer
+const minRepeatedLine = 3
+
+var (
+	// Lines holding nothing but a page number: "12", "Page 12", "12 of 30", "- 12 -"
+	pageNumberLine = regexp.MustCompile(`(?im)^[ \t]*(?:page[ \t]+)?-?[ \t]*\d{1,4}[ \t]*(?:(?:of|/)[ \t]*\d{1,4})?[ \t]*-?[ \t]*$`)
+	// Numeric citation markers such as [12], [3, 4] or [5-7]; the capture tells markdown links apart
+	referenceMarker = regexp.MustCompile(`[ \t]?\[\d{1,3}(?:[ \t]*[,–-][ \t]*\d{1,3})*\](\()?`)
+	// A word split across lines by hyphenation, e.g. "embed-\ndings"
+	lineBreakHyphen = regexp.MustCompile(`(\p{Ll})-[ \t]*\n[ \t]*(\p{Ll})`)
+	horizontalSpace = regexp.MustCompile(`[ \t]+`)
+	blankLines      = regexp.MustCompile(`\n{3,}`)
+	digits          = regexp.MustCompile(`\d+`)
+)
+
+// cleanText removes OCR artifacts that dilute embeddings and clutter cited content:
+// running headers and footers, page numbers, numeric reference markers and hyphenation
+// at line breaks. Whitespace is normalized; paragraph breaks are kept.
+func cleanText(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = removeRepeatedLines(text)
+	text = pageNumberLine.ReplaceAllString(text, "")
+	text = referenceMarker.ReplaceAllStringFunc(text, func(m string) string {
+		if strings.HasSuffix(m, "(") {
+			return m // Markdown link text, e.g. [1](#ref-1)
+		}
+		return ""
+	})
+	text = lineBreakHyphen.ReplaceAllString(text, "$1$2")
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(horizontalSpace.ReplaceAllString(line, " "))
+	}
+	text = strings.Join(lines, "\n")
+	return strings.TrimSpace(blankLines.ReplaceAllString(text, "\n\n"))
+}
+
+// removeRepeatedLines drops short lines that recur at least minRepeatedLine times, which in
+// OCR output are running headers and footers. Digits are ignored when comparing lines, so
+// headers carrying the page number match. Very short lines (e.g. "Proof."), lines without
+// letters and table rows are kept.
+func removeRepeatedLines(text string) string {
+	lines := strings.Split(text, "\n")
+	counts := make(map[string]int)
+	for _, line := range lines {
+		if key, ok := repeatKey(line); ok {
+			counts[key]++
+		}
+	}
+
+	kept := lines[:0]
+	for _, line := range lines {
+		if key, ok := repeatKey(line); ok && counts[key] >= minRepeatedLine {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return strings.Join(kept, "\n")
+}
+
+// repeatKey normalizes a line for header and footer detection; ok is false for lines
+// that are never treated as headers
+func repeatKey(line string) (string, bool) {
+	line = strings.TrimSpace(line)
+	if len(line) < 10 || len(line) > 100 || strings.HasPrefix(line, "|") {
+		return "", false
+	}
+	if !strings.ContainsFunc(line, unicode.IsLetter) {
+		return "", false
+	}
+	return strings.ToLower(digits.ReplaceAllString(line, "#")), true
+}
@@ -0,0 +1,54 @@
+package research
+
+import "testing"
+
+func TestCleanText(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			"page numbers",
+			"First page text.\n\n12\n\nPage 13\n\n14 of 30\n\nMore text.",
+			"First page text.\n\nMore text.",
+		},
+		{
+			"reference markers",
+			"As shown before [12], attention helps [3, 4] and scales [5-7].",
+			"As shown before, attention helps and scales.",
+		},
+		{
+			"markdown links kept",
+			"See [1](#ref-1) for details.",
+			"See [1](#ref-1) for details.",
+		},
+		{
+			"hyphenation",
+			"dense embed-\ndings work",
+			"dense embeddings work",
+		},
+		{
+			"whitespace",
+			"a  \t b\n\n\n\n c ",
+			"a b\n\nc",
+		},
+		{
+			"running headers",
+			"Journal of AI Research 1\nIntro\n\nJournal of AI Research 2\nBody\n\nJournal of AI Research 3\nEnd",
+			"Intro\n\nBody\n\nEnd",
+		},
+		{
+			"short repeated lines kept",
+			"Proof.\na\nProof.\nb\nProof.\nc",
+			"Proof.\na\nProof.\nb\nProof.\nc",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cleanText(tt.in); got != tt.want {
+				t.Errorf("cleanText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package research
+
+// SourceStats counts what happened to the relevant sources of one iteration
+type SourceStats struct {
+	Iteration int `json:"iteration"`
+	Relevant  int `json:"relevant"`  // Sources that passed the filter phase
+	New       int `json:"new"`       // Sources indexed for the first time
+	Duplicate int `json:"duplicate"` // Sources skipped as already processed or near-duplicate content
+	// GroupCapped counts sources left for later iterations because their group reached
+	// Config.GroupLimit
+	GroupCapped int `json:"group_capped,omitempty"`
+	// Blocked counts sources kept out of the collection by the safety check (Config.SafetyBlock)
+	Blocked int `json:"blocked,omitempty"`
+	// IndexFailed counts sources whose chunks could not be embedded or stored, including
+	// those stored asynchronously with Config.EmbeddingWorkers. They aren't recorded as
+	// indexed sources and can be retried by a later search.
+	IndexFailed int `json:"index_failed,omitempty"`
+}
+
+// diminishingReturns reports whether an iteration found fewer new sources than minNew.
+// A non-positive minNew disables the check.
+func diminishingReturns(stats SourceStats, minNew int) bool {
+	return minNew > 0 && stats.New < minNew
+}
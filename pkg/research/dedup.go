@@ -0,0 +1,76 @@
+package research
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// arxivPaperPath matches arXiv abstract and PDF paths, capturing the paper ID without its version
+var arxivPaperPath = regexp.MustCompile(`^/(?:abs|pdf)/(.+?)(?:v\d+)?(?:\.pdf)?$`)
+
+// normalizeURL returns a key identifying the document a URL points to: the scheme, query,
+// fragment, "www." and trailing slashes are dropped and the host is lowercased. arXiv abstract
+// and PDF links of any version map to the same key. Empty for an empty URL.
+func normalizeURL(rawURL string) string {
+	s := strings.TrimSpace(rawURL)
+	if s == "" {
+		return ""
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.Host == "" {
+		return strings.ToLower(s)
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+	path := strings.TrimRight(u.Path, "/")
+
+	if host == "arxiv.org" || host == "export.arxiv.org" {
+		if m := arxivPaperPath.FindStringSubmatch(path); m != nil {
+			return "arxiv.org/abs/" + m[1]
+		}
+		host = "arxiv.org"
+	}
+	return host + path
+}
+
+// normalizeTitle lowercases a title and collapses whitespace and trailing punctuation,
+// so trivially different renderings of the same title compare equal
+func normalizeTitle(title string) string {
+	t := strings.ToLower(strings.Join(strings.Fields(title), " "))
+	return strings.TrimRight(t, ".!?;: ")
+}
+
+// dedupResults drops results whose normalized title or URL was already seen, keeping the first
+func dedupResults(results []SearchResult) []SearchResult {
+	return newResultDeduper().add(results)
+}
+
+// resultDeduper deduplicates results arriving in batches, across all batches added so far
+type resultDeduper struct {
+	seenTitles map[string]bool
+	seenURLs   map[string]bool
+}
+
+func newResultDeduper() *resultDeduper {
+	return &resultDeduper{seenTitles: make(map[string]bool), seenURLs: make(map[string]bool)}
+}
+
+// add returns the results whose normalized title and URL weren't seen before and records them
+func (d *resultDeduper) add(results []SearchResult) []SearchResult {
+	unique := make([]SearchResult, 0, len(results))
+	for _, r := range results {
+		title, u := normalizeTitle(r.Title), normalizeURL(r.URL)
+		if (title != "" && d.seenTitles[title]) || (u != "" && d.seenURLs[u]) {
+			continue
+		}
+		if title != "" {
+			d.seenTitles[title] = true
+		}
+		if u != "" {
+			d.seenURLs[u] = true
+		}
+		unique = append(unique, r)
+	}
+	return unique
+}
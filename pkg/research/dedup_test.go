@@ -0,0 +1,56 @@
+package research
+
+import "testing"
+
+func TestNormalizeURL(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"", ""},
+		{"http://arxiv.org/abs/2101.00001v2", "arxiv.org/abs/2101.00001"},
+		{"https://arxiv.org/pdf/2101.00001v1.pdf", "arxiv.org/abs/2101.00001"},
+		{"https://export.arxiv.org/abs/2101.00001", "arxiv.org/abs/2101.00001"},
+		{"https://arxiv.org/abs/cs/0112017v1", "arxiv.org/abs/cs/0112017"},
+		{"https://WWW.Example.com/paper.pdf?utm_source=x#page=2", "example.com/paper.pdf"},
+		{"https://example.com/papers/", "example.com/papers"},
+		{"https://example.com/Paper", "example.com/Paper"},
+	}
+	for _, tt := range tests {
+		if got := normalizeURL(tt.in); got != tt.want {
+			t.Errorf("normalizeURL(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestDedupResults(t *testing.T) {
+	results := []SearchResult{
+		{Title: "Attention Is All You Need", URL: "http://arxiv.org/abs/1706.03762v5"},
+		{Title: "Attention is all you need.", URL: "https://example.com/a"},
+		{Title: "Attention Is All You Need (v7)", URL: "http://arxiv.org/abs/1706.03762v7"},
+		{Title: "Another Paper", URL: "https://example.com/b"},
+		{Title: "", URL: ""},
+		{Title: "", URL: ""},
+	}
+
+	got := dedupResults(results)
+	if len(got) != 4 {
+		t.Fatalf("got %d results, want 4: %+v", len(got), got)
+	}
+	if got[0].URL != results[0].URL || got[1].Title != "Another Paper" {
+		t.Errorf("unexpected results: %+v", got)
+	}
+}
+
+func TestResultDeduperAcrossBatches(t *testing.T) {
+	d := newResultDeduper()
+	first := d.add([]SearchResult{{Title: "Paper A", URL: "https://example.com/a"}})
+	second := d.add([]SearchResult{
+		{Title: "paper a.", URL: "https://example.com/other"},
+		{Title: "Paper B", URL: "https://example.com/a/"},
+		{Title: "Paper C", URL: "https://example.com/c"},
+	})
+	if len(first) != 1 || len(second) != 1 || second[0].Title != "Paper C" {
+		t.Errorf("got %+v then %+v, want Paper A then Paper C", first, second)
+	}
+}
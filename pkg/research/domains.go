@@ -0,0 +1,69 @@
+package research
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// normalizeDomain lowercases a configured domain and strips wildcard and dot prefixes,
+// so "*.edu", ".edu" and "edu" are equivalent
+func normalizeDomain(domain string) string {
+	d := strings.ToLower(strings.TrimSpace(domain))
+	d = strings.TrimPrefix(d, "*")
+	return strings.Trim(d, ".")
+}
+
+// matchesDomain reports whether host is domain or one of its subdomains
+func matchesDomain(host, domain string) bool {
+	domain = normalizeDomain(domain)
+	if domain == "" {
+		return false
+	}
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+// checkDomain returns an error describing why a URL is excluded by the domain lists.
+// Blocked domains take precedence; an empty allowlist allows every domain.
+func checkDomain(rawURL string, allowed, blocked []string) error {
+	if len(allowed) == 0 && len(blocked) == 0 {
+		return nil
+	}
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Hostname() == "" {
+		return fmt.Errorf("no domain in url")
+	}
+	host := strings.ToLower(u.Hostname())
+
+	for _, d := range blocked {
+		if matchesDomain(host, d) {
+			return fmt.Errorf("domain %s is blocked", host)
+		}
+	}
+	if len(allowed) == 0 {
+		return nil
+	}
+	for _, d := range allowed {
+		if matchesDomain(host, d) {
+			return nil
+		}
+	}
+	return fmt.Errorf("domain %s is not allowed", host)
+}
+
+// filterDomains drops results whose URL is excluded by Config.AllowedDomains or
+// Config.BlockedDomains, logging each skipped source with the reason
+func (e *ResearchEngine) filterDomains(results []SearchResult) []SearchResult {
+	if len(e.Config.AllowedDomains) == 0 && len(e.Config.BlockedDomains) == 0 {
+		return results
+	}
+	kept := make([]SearchResult, 0, len(results))
+	for _, r := range results {
+		if err := checkDomain(r.URL, e.Config.AllowedDomains, e.Config.BlockedDomains); err != nil {
+			e.Logger.Info("Skipping source", "title", r.Title, "url", r.URL, "reason", err)
+			continue
+		}
+		kept = append(kept, r)
+	}
+	return kept
+}
@@ -0,0 +1,34 @@
+package research
+
+import "testing"
+
+func TestCheckDomain(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		allowed []string
+		blocked []string
+		wantErr bool
+	}{
+		{"no lists", "https://example.com/a.pdf", nil, nil, false},
+		{"allowed exact", "https://arxiv.org/pdf/1", []string{"arxiv.org"}, nil, false},
+		{"allowed subdomain", "http://export.arxiv.org/pdf/1", []string{"arxiv.org"}, nil, false},
+		{"allowed suffix", "https://cs.mit.edu/paper.pdf", []string{".edu"}, nil, false},
+		{"wildcard suffix", "https://cs.mit.edu/paper.pdf", []string{"*.edu"}, nil, false},
+		{"not allowed", "https://example.com/a.pdf", []string{"arxiv.org"}, nil, true},
+		{"label boundary", "https://notarxiv.org/a.pdf", []string{"arxiv.org"}, nil, true},
+		{"blocked", "https://junk.example.com/a", nil, []string{"example.com"}, true},
+		{"blocked wins over allowed", "https://junk.edu/a", []string{".edu"}, []string{"junk.edu"}, true},
+		{"case insensitive", "https://ArXiv.ORG/pdf/1", []string{"arxiv.org"}, nil, false},
+		{"missing url", "", []string{"arxiv.org"}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkDomain(tt.url, tt.allowed, tt.blocked)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkDomain(%q) error = %v, wantErr %v", tt.url, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package research
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/mikeboe/research-helper/pkg/vectorstore"
+)
+
+// MaxEmbeddingWorkers is the largest accepted Config.EmbeddingWorkers
+const MaxEmbeddingWorkers = 16
+
+// ValidateEmbeddingWorkers checks that a worker count is between 0 (synchronous) and
+// MaxEmbeddingWorkers
+func ValidateEmbeddingWorkers(n int) error {
+	if n < 0 || n > MaxEmbeddingWorkers {
+		return fmt.Errorf("invalid embedding workers %d: must be between 0 and %d", n, MaxEmbeddingWorkers)
+	}
+	return nil
+}
+
+// embedJob is a batch of prepared documents waiting to be embedded and stored
+type embedJob struct {
+	documents []vectorstore.Document
+	texts     []string
+}
+
+// embedQueue embeds and stores documents on a pool of workers, so scraping and
+// chunking of further sources does not wait for embedding latency.
+type embedQueue struct {
+	jobs chan embedJob
+	wg   sync.WaitGroup
+
+	mu     sync.Mutex
+	failed map[string]bool // Sources whose main text failed to store
+}
+
+// startEmbedQueue starts workers that store documents through e.storeDocuments
+func (e *ResearchEngine) startEmbedQueue(ctx context.Context, workers int) *embedQueue {
+	q := &embedQueue{jobs: make(chan embedJob, workers*4), failed: make(map[string]bool)}
+	for i := 0; i < workers; i++ {
+		q.wg.Add(1)
+		go func() {
+			defer q.wg.Done()
+			for job := range q.jobs {
+				if err := e.storeDocuments(ctx, job.documents, job.texts); err != nil {
+					e.Logger.Error("Failed to store queued documents", "count", len(job.documents), "error", err)
+					q.fail(job)
+				}
+			}
+		}()
+	}
+	return q
+}
+
+// submit enqueues a job, blocking while the queue is full
+func (q *embedQueue) submit(job embedJob) {
+	q.jobs <- job
+}
+
+// fail records the source of a job that could not be stored. Only chunks of a source's
+// main text carry its fingerprint; failed captions don't make the source itself fail.
+func (q *embedQueue) fail(job embedJob) {
+	if len(job.documents) == 0 {
+		return
+	}
+	if _, ok := job.documents[0].Metadata["fingerprint"]; !ok {
+		return
+	}
+	source, _ := job.documents[0].Metadata["source"].(string)
+	q.mu.Lock()
+	q.failed[source] = true
+	q.mu.Unlock()
+}
+
+// close stops accepting jobs and waits until all queued jobs are stored. It returns the
+// URLs of sources whose main text failed to store.
+func (q *embedQueue) close() map[string]bool {
+	close(q.jobs)
+	q.wg.Wait()
+	return q.failed
+}
+
+// queuedSource is a source reported indexed while its chunks were still queued
+type queuedSource struct {
+	item        SearchResult
+	summary     string
+	fingerprint uint64
+}
+
+// forgetSource undoes the state recorded for a queued source whose chunks failed to
+// store: its fingerprint is released, it is removed from the indexed sources, summaries
+// and facts, and its URL is no longer marked processed so a later search can retry it.
+func (e *ResearchEngine) forgetSource(source queuedSource) {
+	e.releaseFingerprint(source.fingerprint)
+	e.State.Mu.Lock()
+	defer e.State.Mu.Unlock()
+	delete(e.State.ProcessedURLs, source.item.URL)
+	e.State.IndexedItems = slices.DeleteFunc(e.State.IndexedItems, func(item SearchResult) bool {
+		return item.URL == source.item.URL
+	})
+	if i := slices.Index(e.State.AccumulatedFacts, source.summary); i >= 0 {
+		e.State.AccumulatedFacts = slices.Delete(e.State.AccumulatedFacts, i, i+1)
+		if i < len(e.State.FactIterations) {
+			e.State.FactIterations = slices.Delete(e.State.FactIterations, i, i+1)
+		}
+	}
+	cited := factSource(source.item)
+	e.State.Facts = slices.DeleteFunc(e.State.Facts, func(f Fact) bool { return f.Source == cited })
+}
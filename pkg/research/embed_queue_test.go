@@ -0,0 +1,44 @@
+package research
+
+import "testing"
+
+func TestForgetSource(t *testing.T) {
+	kept := SearchResult{Title: "Kept", URL: "https://example.org/kept.pdf"}
+	failed := SearchResult{Title: "Failed", URL: "https://example.org/failed.pdf"}
+	fp := Fingerprint("failed source text")
+
+	e := &ResearchEngine{State: &ResearchState{
+		ProcessedURLs:    map[string]bool{kept.URL: true, failed.URL: true},
+		IndexedItems:     []SearchResult{kept, failed},
+		AccumulatedFacts: []string{"kept summary", "failed summary"},
+		FactIterations:   []int{1, 2},
+		Facts: []Fact{
+			{Claim: "a", Source: factSource(kept)},
+			{Claim: "b", Source: factSource(failed)},
+		},
+	}}
+	if _, ok := e.claimFingerprint(fp); !ok {
+		t.Fatal("claim rejected")
+	}
+
+	e.forgetSource(queuedSource{item: failed, summary: "failed summary", fingerprint: fp})
+
+	if _, ok := e.claimFingerprint(fp); !ok {
+		t.Error("fingerprint still claimed")
+	}
+	if e.State.ProcessedURLs[failed.URL] || !e.State.ProcessedURLs[kept.URL] {
+		t.Errorf("ProcessedURLs = %v", e.State.ProcessedURLs)
+	}
+	if len(e.State.IndexedItems) != 1 || e.State.IndexedItems[0].URL != kept.URL {
+		t.Errorf("IndexedItems = %v", e.State.IndexedItems)
+	}
+	if len(e.State.AccumulatedFacts) != 1 || e.State.AccumulatedFacts[0] != "kept summary" {
+		t.Errorf("AccumulatedFacts = %v", e.State.AccumulatedFacts)
+	}
+	if len(e.State.FactIterations) != 1 || e.State.FactIterations[0] != 1 {
+		t.Errorf("FactIterations = %v", e.State.FactIterations)
+	}
+	if len(e.State.Facts) != 1 || e.State.Facts[0].Claim != "a" {
+		t.Errorf("Facts = %v", e.State.Facts)
+	}
+}
@@ -2,11 +2,11 @@ package research
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
-	"os"
 	"regexp"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -17,8 +17,8 @@ import (
 	"github.com/mikeboe/research-helper/pkg/config"
 	"github.com/mikeboe/research-helper/pkg/database"
 	"github.com/mikeboe/research-helper/pkg/embeddings"
+	"github.com/mikeboe/research-helper/pkg/research/schema"
 	"github.com/mikeboe/research-helper/pkg/research/tools"
-	"github.com/mikeboe/research-helper/pkg/splitter"
 	"github.com/mikeboe/research-helper/pkg/vectorstore"
 )
 
@@ -26,29 +26,73 @@ type ResearchEngine struct {
 	Config        Config
 	State         *ResearchState
 	LLM           llms.Model
+	FastLLM       llms.Model // Serves the phases listed in fastPhases; nil uses LLM
 	DB            *database.PostgresDB
 	Embedder      *embeddings.GoogleEmbedder
 	c             *config.Config
 	Logger        *slog.Logger
-	OnStateUpdate func(state ResearchState)
+	OnStateUpdate func(state *ResearchState)
+	OnLLMCall     func(trace LLMTrace)    // Receives every LLM call when Config.Trace is set
+	OnReportDelta func(delta ReportDelta) // Receives the final report as it is generated; nil generates it in one call
+	// OnSourceEvent receives every source as it is found, filtered, skipped and indexed.
+	// It is called from concurrent goroutines and must not block.
+	OnSourceEvent func(event SourceEvent)
+	JobID         string // Stored as job_id in the metadata of indexed documents when set
+	// MetadataExtractors enrich the metadata of indexed sources by source type (see
+	// DefaultMetadataExtractors); add to it to extract fields for new kinds of sources
+	MetadataExtractors map[SourceType][]MetadataExtractor
+
+	embedQueue         *embedQueue         // Set during the acquire phase when Config.EmbeddingWorkers > 0
+	sharedURLs         *sharedURLs         // Sources claimed across sibling sub-topic engines, nil outside sub-topics
+	sharedFingerprints *sharedFingerprints // Content claimed across sibling sub-topic engines, nil outside sub-topics
+
+	fingerprintsLoaded bool // The collection's fingerprints were merged into the state (see loadFingerprints)
+}
+
+// DefaultMaxIterations is the number of research iterations run unless configured
+const DefaultMaxIterations = 5
+
+// MaxIterationsLimit is the largest accepted Config.MaxIterations
+const MaxIterationsLimit = 50
+
+// ValidateMaxIterations checks that an iteration count is between 1 and MaxIterationsLimit
+func ValidateMaxIterations(n int) error {
+	if n < 1 || n > MaxIterationsLimit {
+		return fmt.Errorf("invalid max iterations %d: must be between 1 and %d", n, MaxIterationsLimit)
+	}
+	return nil
 }
 
 func NewEngine(cfg Config, db *database.PostgresDB, c *config.Config) (*ResearchEngine, error) {
+	if _, err := vectorstore.ParseEviction(c.EvictionPolicy); err != nil {
+		return nil, err
+	}
+	maxIterations := cfg.MaxIterations
+	if maxIterations == 0 {
+		maxIterations = DefaultMaxIterations
+	}
+	if err := ValidateMaxIterations(maxIterations); err != nil {
+		return nil, err
+	}
+
 	// Initialize LLM
-	llm, err := clients.GoogleAi(clients.ModelType(c.ReasoningModel))
+	llm, err := clients.GoogleAi(clients.ModelType(c.ReasoningModel), c.GoogleApiKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to init LLM: %w", err)
 	}
 
-	// Initialize Embedder
-	// Assuming LLMApiKey is same for embeddings or we use env var inside NewGoogleEmbedder if empty
-	// Note: pkg/embeddings/google.go NewGoogleEmbedder takes apiKey as argument.
-	// We might need to ensure cfg.LLMApiKey is set or get from env.
+	fastLLM, err := clients.GoogleAi(clients.ModelType(c.FastModel), c.GoogleApiKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init fast LLM: %w", err)
+	}
 
+	// Initialize Embedder with the same key as the LLM
 	embedder, err := embeddings.NewGoogleEmbedder(context.Background(), c.EmbeddingModel, c.GoogleApiKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to init embedder: %w", err)
 	}
+	embedder.WithConcurrency(c.EmbeddingConcurrency, c.EmbeddingRPS)
+	embedder.WithRetry(c.EmbeddingMaxRetries)
 
 	return &ResearchEngine{
 		Config: cfg,
@@ -59,29 +103,61 @@ func NewEngine(cfg Config, db *database.PostgresDB, c *config.Config) (*Research
 			AccumulatedFacts: []string{},
 			IndexedItems:     []SearchResult{},
 			Iteration:        0,
-			MaxIterations:    5,
+			MaxIterations:    maxIterations,
 		},
 		LLM:      llm,
+		FastLLM:  fastLLM,
 		DB:       db,
 		Embedder: embedder,
 		Logger:   slog.Default(),
 		c:        c,
+
+		MetadataExtractors: DefaultMetadataExtractors(),
 	}, nil
 }
 
-// generateWithRetry attempts to generate content and validates it using the provided function.
-// It retries up to 3 times if the LLM fails or the validator returns an error.
-func (e *ResearchEngine) generateWithRetry(ctx context.Context, prompts []llms.MessageContent, validator func(string) error) (string, error) {
+// RestoreState replaces the engine state with a previously persisted one, so Run
+// continues from the stored iteration instead of starting over.
+func (e *ResearchEngine) RestoreState(state *ResearchState) {
+	if state.ProcessedURLs == nil {
+		state.ProcessedURLs = make(map[string]bool)
+	}
+	if state.CollectionName == "" {
+		state.CollectionName = e.Config.Collection
+	}
+	e.State = state
+}
+
+// generateOptions controls a single generateWithRetry call
+type generateOptions struct {
+	JSONMode  bool               // Request JSON output; markdown fences and prose around it are stripped before validation
+	Validator func(string) error // Rejects a response to trigger a retry; nil accepts any non-empty response
+	// Fallback accepts responses rejected by Validator that are still usable. When no
+	// attempt passes Validator, the longest response it accepts is returned with a warning
+	// instead of failing.
+	Fallback func(string) error
+	// Stream receives the response as it is generated. Before a retry it is called with
+	// restart set, as the text streamed so far is discarded.
+	Stream func(text string, restart bool)
+}
+
+// generateWithRetry attempts to generate content and validates it using opts.Validator.
+// It retries up to 3 times if the LLM fails, returns an empty response or the validator returns an error.
+func (e *ResearchEngine) generateWithRetry(ctx context.Context, phase string, prompts []llms.MessageContent, opts generateOptions) (string, error) {
 	maxRetries := 3
 	var lastErr error
+	var fallback, streamed string
 
 	for i := 0; i < maxRetries; i++ {
 		if i > 0 {
 			e.Logger.Warn("Retrying LLM generation", "attempt", i+1, "last_error", lastErr)
 			time.Sleep(time.Second * time.Duration(i)) // Linear backoff
+			if opts.Stream != nil {
+				opts.Stream("", true)
+			}
 		}
 
-		resp, err := e.LLM.GenerateContent(ctx, prompts, llms.WithJSONMode())
+		resp, err := e.generate(ctx, phase, prompts, opts.JSONMode, opts.Stream)
 		if err != nil {
 			lastErr = fmt.Errorf("llm generation failed: %w", err)
 			continue
@@ -93,14 +169,35 @@ func (e *ResearchEngine) generateWithRetry(ctx context.Context, prompts []llms.M
 		}
 
 		content := resp.Choices[0].Content
-		if err := validator(content); err != nil {
-			lastErr = fmt.Errorf("validation failed: %w", err)
+		streamed = content
+		if opts.JSONMode {
+			content = extractJSON(content)
+		}
+		if strings.TrimSpace(content) == "" {
+			lastErr = fmt.Errorf("llm returned an empty response")
 			continue
 		}
+		if opts.Validator != nil {
+			if err := opts.Validator(content); err != nil {
+				lastErr = fmt.Errorf("validation failed: %w", err)
+				if opts.Fallback != nil && opts.Fallback(content) == nil && len(content) > len(fallback) {
+					fallback = content
+				}
+				continue
+			}
+		}
 
 		return content, nil
 	}
 
+	if fallback != "" {
+		e.Logger.Warn("Using the best response that failed validation", "phase", phase, "error", lastErr)
+		if opts.Stream != nil && fallback != streamed {
+			opts.Stream("", true)
+			opts.Stream(fallback, false)
+		}
+		return fallback, nil
+	}
 	return "", fmt.Errorf("operation failed after %d retries: %w", maxRetries, lastErr)
 }
 
@@ -109,58 +206,86 @@ func (e *ResearchEngine) Run(ctx context.Context, topic string) (string, error)
 	e.Logger.Info("Starting research loop", "topic", topic)
 
 	if e.OnStateUpdate != nil {
-		e.OnStateUpdate(*e.State)
+		e.OnStateUpdate(e.State)
 	}
 
-	for e.State.Iteration < e.State.MaxIterations {
+	// Ground the research in the user's known-relevant sources before planning
+	if len(e.Config.SeedSources) > 0 && !e.State.Seeded {
+		if err := e.seedPhase(ctx); err != nil {
+			return "", fmt.Errorf("seeding failed: %w", err)
+		}
+		if e.OnStateUpdate != nil {
+			e.OnStateUpdate(e.State)
+		}
+	}
+
+	if len(e.Config.SubTopics) > 0 {
+		if err := e.runSubTopics(ctx); err != nil {
+			return "", fmt.Errorf("sub-topic research failed: %w", err)
+		}
+	} else if err := e.runLoop(ctx); err != nil {
+		return "", err
+	}
+
+	// IVFFlat indexes need data, so they are built once the research has indexed it
+	if err := e.DB.BuildIndex(ctx, e.State.CollectionName); err != nil {
+		e.Logger.Warn("Failed to build vector index, searches fall back to a full scan", "error", err)
+	}
+
+	// Generate Final Report
+	return e.generateReport(ctx)
+}
+
+// runLoop runs the plan-source-filter-acquire-reflect iterations until reflection,
+// the stop conditions or the iteration limit end the research
+func (e *ResearchEngine) runLoop(ctx context.Context) error {
+	for first := true; e.State.Iteration < e.State.MaxIterations; first = false {
+		if !first {
+			if err := e.pace(ctx); err != nil {
+				return err
+			}
+		}
 		e.State.Iteration++
 		e.Logger.Info("Starting iteration", "iteration", e.State.Iteration, "max", e.State.MaxIterations)
 
 		if e.OnStateUpdate != nil {
-			e.OnStateUpdate(*e.State)
+			e.OnStateUpdate(e.State)
 		}
 
 		// 1. Plan
 		queries, err := e.planPhase(ctx)
 		if err != nil {
-			return "", fmt.Errorf("planning failed: %w", err)
+			return fmt.Errorf("planning failed: %w", err)
 		}
 		if len(queries) == 0 {
 			e.Logger.Warn("No queries generated. Research might be stuck.")
 			break
 		}
 
-		// 2. Source
-		searchResults, err := e.sourcePhase(ctx, queries)
+		indexedBefore := len(e.State.IndexedItems)
+		summaries, stats, err := e.sourceFilterAcquire(ctx, queries)
 		if err != nil {
-			return "", fmt.Errorf("sourcing failed: %w", err)
+			return err
 		}
+		countIndexed(e.State.QueryYields, e.State.Iteration, e.State.IndexedItems[indexedBefore:])
+		stats.Iteration = e.State.Iteration
+		e.State.SourceStats = append(e.State.SourceStats, stats)
+		e.Logger.Info("Iteration sources", "relevant", stats.Relevant, "new", stats.New, "duplicate", stats.Duplicate, "group_capped", stats.GroupCapped)
 
-		// 3. Filter
-		relevantItems, err := e.filterPhase(ctx, searchResults)
-		if err != nil {
-			return "", fmt.Errorf("filtering failed: %w", err)
-		}
-
-		if len(relevantItems) == 0 {
-			e.Logger.Info("No relevant items found in this iteration.")
-			// Don't break, maybe reflection will change direction
-		}
-
-		// 4. Acquire & Index
-		summaries, err := e.acquireAndIndexPhase(ctx, relevantItems)
-		if err != nil {
-			return "", fmt.Errorf("acquire/index failed: %w", err)
+		if e.OnStateUpdate != nil {
+			e.OnStateUpdate(e.State)
 		}
 
-		if e.OnStateUpdate != nil {
-			e.OnStateUpdate(*e.State)
+		// Stop on diminishing returns instead of burning the remaining iterations
+		if diminishingReturns(stats, e.Config.MinNewSources) {
+			e.Logger.Info("Research complete: too few new sources", "new", stats.New, "min_new_sources", e.Config.MinNewSources)
+			break
 		}
 
 		// 5. Reflect
 		shouldContinue, newFocus, err := e.reflectPhase(ctx, summaries)
 		if err != nil {
-			return "", fmt.Errorf("reflection failed: %w", err)
+			return fmt.Errorf("reflection failed: %w", err)
 		}
 
 		if !shouldContinue {
@@ -170,12 +295,49 @@ func (e *ResearchEngine) Run(ctx context.Context, topic string) (string, error)
 
 		if newFocus != "" {
 			e.Logger.Info("Adjusting focus", "focus", newFocus)
-			// Ideally we'd update context or state here
+			e.State.Mu.Lock()
+			e.State.Focus = newFocus
+			e.State.Mu.Unlock()
 		}
 	}
 
-	// Generate Final Report
-	return e.generateReport(ctx)
+	return nil
+}
+
+// sourceFilterAcquire runs the source, filter and acquire phases of an iteration, one after
+// the other or as a pipeline with Config.StreamSources
+func (e *ResearchEngine) sourceFilterAcquire(ctx context.Context, queries []string) ([]string, SourceStats, error) {
+	if e.Config.StreamSources {
+		summaries, stats, err := e.streamPhases(ctx, queries)
+		if err != nil {
+			return nil, stats, fmt.Errorf("acquire/index failed: %w", err)
+		}
+		return summaries, stats, nil
+	}
+
+	// 2. Source
+	searchResults, err := e.sourcePhase(ctx, queries)
+	if err != nil {
+		return nil, SourceStats{}, fmt.Errorf("sourcing failed: %w", err)
+	}
+
+	// 3. Filter
+	relevantItems, err := e.filterPhase(ctx, searchResults)
+	if err != nil {
+		return nil, SourceStats{}, fmt.Errorf("filtering failed: %w", err)
+	}
+
+	if len(relevantItems) == 0 {
+		e.Logger.Info("No relevant items found in this iteration.")
+		// Don't break, maybe reflection will change direction
+	}
+
+	// 4. Acquire & Index
+	summaries, stats, err := e.acquireAndIndexPhase(ctx, relevantItems)
+	if err != nil {
+		return nil, stats, fmt.Errorf("acquire/index failed: %w", err)
+	}
+	return summaries, stats, nil
 }
 
 // --- Phase Implementations ---
@@ -186,35 +348,29 @@ func (e *ResearchEngine) planPhase(ctx context.Context) ([]string, error) {
 	systemPrompt := `You are a research planner.
 Generate 3 specific search queries to gather information about the topic.`
 
-	schema := CreateSearchQueriesSchema()
-
 	input := fmt.Sprintf(`Topic: %s
 Current Iteration: %d
 Accumulated Facts: %d`, e.State.Topic, e.State.Iteration, len(e.State.AccumulatedFacts))
 
-	// Define a struct to match the JSON schema
-	type QueryResponse struct {
-		Queries []string `json:"queries"`
+	if yields := lastYields(e.State.QueryYields, maxPlanYields); len(yields) > 0 {
+		input += fmt.Sprintf("\n\nEarlier queries and how many new sources each indexed. Build on the productive ones and avoid repeating unproductive angles:\n%s",
+			formatQueryYields(yields))
 	}
-	var queryResp QueryResponse
 
-	// Use retry mechanism
-	_, err := e.generateWithRetry(ctx, []llms.MessageContent{
-		llms.TextParts(llms.ChatMessageTypeSystem, systemPrompt+"\n\n# Response Format: \n\n"+schema),
-		llms.TextParts(llms.ChatMessageTypeHuman, input),
-	}, func(content string) error {
-		// Reset for retry
-		queryResp = QueryResponse{}
+	if len(e.State.SeedSummaries) > 0 {
+		input += fmt.Sprintf("\n\nThe user provided these sources as known-relevant starting points. They are already indexed; plan queries that build on them and cover what they leave open:\n\n%s",
+			strings.Join(e.State.SeedSummaries, "\n\n"))
+	}
 
-		if err := json.Unmarshal([]byte(content), &queryResp); err != nil {
-			return fmt.Errorf("json parse error: %w (content: %s)", err, content)
-		}
-		if len(queryResp.Queries) == 0 {
+	queryResp, err := generateStructured[searchQueriesResponse](ctx, e, "plan", []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeSystem, systemPrompt+"\n\n"+schema.ResponseFormat(searchQueriesSchema)),
+		llms.TextParts(llms.ChatMessageTypeHuman, input),
+	}, func(resp searchQueriesResponse) error {
+		if len(resp.Queries) == 0 {
 			return fmt.Errorf("empty queries list")
 		}
 		return nil
 	})
-
 	if err != nil {
 		return nil, err
 	}
@@ -223,24 +379,24 @@ Accumulated Facts: %d`, e.State.Topic, e.State.Iteration, len(e.State.Accumulate
 	return queryResp.Queries, nil
 }
 
-func CreateSearchQueriesSchema() string {
-	return `Return the JSON object directly without any formatting or additional text. The JSON object should have the following structure as defined in the schema. Make sure to answer in valid json and include all necessary properties:{
-  "type": "object",
-  "properties": {
-    "queries": {
-      "type": "array",
-      "items": {
-        "type": "string"
-      },
-      "description": "List of 3 specific search queries"
-    }
-  },
-  "required": ["queries"]
-}`
+// searchQueriesSchema is the response of the planning and query refinement phases
+var searchQueriesSchema = schema.Object(map[string]schema.Schema{
+	"queries": schema.Array(schema.String()).Describe("List of 3 specific search queries"),
+})
+
+// searchQueriesResponse matches searchQueriesSchema
+type searchQueriesResponse struct {
+	Queries []string `json:"queries"`
 }
 
 func (e *ResearchEngine) sourcePhase(ctx context.Context, queries []string) ([]SearchResult, error) {
 	e.Logger.Info("Starting sourcing phase")
+
+	queries = e.filterQueries(ctx, queries)
+	if len(queries) == 0 {
+		e.Logger.Warn("All queries rejected by quality filter")
+		return nil, nil
+	}
 	var allResults []SearchResult
 	var mu sync.Mutex
 	var wg sync.WaitGroup
@@ -251,9 +407,12 @@ func (e *ResearchEngine) sourcePhase(ctx context.Context, queries []string) ([]S
 			defer wg.Done()
 
 			// Call Arxiv directly
-			response, err := tools.SearchArxiv(query, 2)
+			response, err := tools.SearchArxiv(ctx, query, e.arxivResults())
 			if err == nil {
 				parsedResults := parseArxivOutput(response)
+				for i := range parsedResults {
+					parsedResults[i].Query = query
+				}
 				e.Logger.Info("Arxiv search successful", "query", query, "count", len(parsedResults))
 
 				mu.Lock()
@@ -267,17 +426,25 @@ func (e *ResearchEngine) sourcePhase(ctx context.Context, queries []string) ([]S
 	}
 	wg.Wait()
 
-	// remove duplicates based on Title
-	uniqueResults := make([]SearchResult, 0, len(allResults))
-	seen := make(map[string]bool)
-	for _, r := range allResults {
-		if !seen[r.Title] {
-			seen[r.Title] = true
-			uniqueResults = append(uniqueResults, r)
-		}
+	// Remove duplicates across queries by title and URL, so the same paper isn't scraped twice
+	uniqueResults := dedupResults(allResults)
+	if dropped := len(allResults) - len(uniqueResults); dropped > 0 {
+		e.Logger.Info("Removed duplicate search results", "duplicates", dropped)
 	}
+	kept := e.filterDomains(uniqueResults)
+	for _, item := range kept {
+		e.emitSource(SourceEvent{Type: SourceFound, Source: item})
+	}
+
+	yields := queryYields(e.State.Iteration, queries, allResults, kept)
+	for _, y := range yields {
+		e.Logger.Info("Search query yield", "query", y.Query, "found", y.Found, "new", y.Unique)
+	}
+	e.State.Mu.Lock()
+	e.State.QueryYields = append(e.State.QueryYields, yields...)
+	e.State.Mu.Unlock()
 
-	return uniqueResults, nil
+	return kept, nil
 }
 
 func parseArxivOutput(content string) []SearchResult {
@@ -296,17 +463,21 @@ func parseArxivOutput(content string) []SearchResult {
 			continue
 		}
 
-		title := strings.TrimSpace(lines[0])
+		title := strings.Join(strings.Fields(lines[0]), " ")
 		summary := ""
 		pdfLink := ""
 
 		// Use regex for more robust parsing of the summary block
 		summaryRegex := regexp.MustCompile(`## Summary: ([\s\S]*?)(?:\n##|$)`)
 		linkRegex := regexp.MustCompile(`## PDF Link: (.*)`)
+		publishedRegex := regexp.MustCompile(`## Published: (.*)`)
+		authorsRegex := regexp.MustCompile(`## Authors: (.*)`)
+		venueRegex := regexp.MustCompile(`## Journal Ref: (.*)`)
+		categoryRegex := regexp.MustCompile(`## Category: (.*)`)
 
 		sumMatch := summaryRegex.FindStringSubmatch(part)
 		if len(sumMatch) > 1 {
-			summary = strings.TrimSpace(sumMatch[1])
+			summary = strings.Join(strings.Fields(sumMatch[1]), " ")
 		}
 
 		linkMatch := linkRegex.FindStringSubmatch(part)
@@ -314,11 +485,36 @@ func parseArxivOutput(content string) []SearchResult {
 			pdfLink = strings.TrimSpace(linkMatch[1])
 		}
 
+		var authors []string
+		if m := authorsRegex.FindStringSubmatch(part); len(m) > 1 {
+			for _, name := range strings.Split(m[1], ",") {
+				if name = strings.TrimSpace(name); name != "" {
+					authors = append(authors, name)
+				}
+			}
+		}
+		published := ""
+		if m := publishedRegex.FindStringSubmatch(part); len(m) > 1 {
+			published = strings.TrimSpace(m[1])
+		}
+		venue := ""
+		if m := venueRegex.FindStringSubmatch(part); len(m) > 1 {
+			venue = strings.TrimSpace(m[1])
+		}
+		category := ""
+		if m := categoryRegex.FindStringSubmatch(part); len(m) > 1 {
+			category = strings.TrimSpace(m[1])
+		}
+
 		if title != "" {
 			results = append(results, SearchResult{
-				Title:   title,
-				URL:     pdfLink,
-				Snippet: summary,
+				Title:     title,
+				URL:       pdfLink,
+				Snippet:   summary,
+				Authors:   authors,
+				Published: published,
+				Venue:     venue,
+				Category:  category,
 			})
 		}
 	}
@@ -346,37 +542,42 @@ Return a JSON object mapping ID to score.`
 
 	input := fmt.Sprintf("Topic: %s\n\nPapers:\n%s", e.State.Topic, papersList.String())
 
-	schema := `{"type": "object", "properties": {"scores": {"type": "array", "items": {"type": "object", "properties": {"id": {"type": "integer"}, "score": {"type": "integer"}}, "required": ["id", "score"]}}}, "required": ["scores"]}`
+	filterSchema := schema.Object(map[string]schema.Schema{
+		"scores": schema.Array(schema.Object(map[string]schema.Schema{
+			"id":    schema.Integer(),
+			"score": schema.Integer(),
+		})),
+	})
 
-	type ScoreItem struct {
-		ID    int `json:"id"`
-		Score int `json:"score"`
-	}
 	type FilterResponse struct {
-		Scores []ScoreItem `json:"scores"`
+		Scores []filterScore `json:"scores"`
 	}
-	var filterResp FilterResponse
 
-	_, err := e.generateWithRetry(ctx, []llms.MessageContent{
-		llms.TextParts(llms.ChatMessageTypeSystem, systemPrompt+"\n\n# Response Format:\n"+schema),
+	filterResp, err := generateStructured[FilterResponse](ctx, e, "filter", []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeSystem, systemPrompt+"\n\n"+schema.ResponseFormat(filterSchema)),
 		llms.TextParts(llms.ChatMessageTypeHuman, input),
-	}, func(content string) error {
-		filterResp = FilterResponse{}
-		if err := json.Unmarshal([]byte(content), &filterResp); err != nil {
-			return fmt.Errorf("json parse error: %w", err)
-		}
-		return nil
-	})
-
+	}, nil)
 	if err != nil {
 		return nil, fmt.Errorf("llm filtering failed: %w", err)
 	}
 
+	scores, report := resolveFilterScores(filterResp.Scores, len(results))
+	if len(report.Invalid) > 0 {
+		e.Logger.Warn("Filter returned scores for unknown paper IDs, ignoring them", "ids", report.Invalid, "papers", len(results))
+	}
+	if len(report.Duplicates) > 0 {
+		e.Logger.Warn("Filter scored papers more than once, using the first score", "ids", report.Duplicates)
+	}
+	for _, id := range report.Missing {
+		e.Logger.Warn("Filter returned no score for paper, treating it as 0", "id", id, "title", results[id].Title)
+	}
+
 	var relevant []SearchResult
-	for _, item := range filterResp.Scores {
-		if item.Score >= 7 && item.ID < len(results) {
-			relevant = append(relevant, results[item.ID])
-			e.Logger.Info("Keeping paper", "title", results[item.ID].Title, "score", item.Score)
+	for id, score := range scores {
+		e.emitSource(SourceEvent{Type: SourceFiltered, Source: results[id], Score: &score, Kept: score >= filterKeepScore})
+		if score >= filterKeepScore {
+			relevant = append(relevant, results[id])
+			e.Logger.Info("Keeping paper", "title", results[id].Title, "score", score)
 		}
 	}
 
@@ -384,26 +585,82 @@ Return a JSON object mapping ID to score.`
 	return relevant, nil
 }
 
-func (e *ResearchEngine) acquireAndIndexPhase(ctx context.Context, items []SearchResult) ([]string, error) {
+// acquireAndIndexPhase scrapes and indexes the given items and returns their summaries
+// together with how many of them were new or duplicates
+func (e *ResearchEngine) acquireAndIndexPhase(ctx context.Context, items []SearchResult) ([]string, SourceStats, error) {
+	ch := make(chan SearchResult, len(items))
+	for _, item := range items {
+		ch <- item
+	}
+	close(ch)
+	return e.acquireAndIndexStream(ctx, ch)
+}
+
+// acquireAndIndexStream is acquireAndIndexPhase for items arriving on a channel. Scraping
+// starts with the first item and the phase returns once the channel is closed and every
+// item is processed. On a setup error the channel is drained so senders don't block.
+func (e *ResearchEngine) acquireAndIndexStream(ctx context.Context, items <-chan SearchResult) (summaries []string, stats SourceStats, err error) {
 	e.Logger.Info("Starting acquire and index phase")
-	var summaries []string
+	defer func() {
+		if err != nil {
+			go func() {
+				for range items {
+				}
+			}()
+		}
+	}()
 	var wg sync.WaitGroup
 	var mu sync.Mutex // Local mutex for summaries slice
+	queued := make(map[string]queuedSource)
 
 	semaphore := make(chan struct{}, 3) // Limit concurrency to 3
+	groups := newGroupLimiter(e.Config.GroupLimit, e.Config.GroupBy)
 
 	// Ensure DB table exists (can happen once per engine/phase or once globally)
 	// Ideally globally but here is safe too
 	if err := e.DB.EnsureVectorExtension(ctx); err != nil {
 		e.Logger.Error("Failed to ensure vector extension", "error", err)
-		return nil, err
+		return nil, stats, err
 	}
-	if err := e.DB.CreateEmbeddingsTable(ctx, e.State.CollectionName, 1536); err != nil { // 1536 for Google Embeddings
+	if err := e.DB.CreateEmbeddingsTable(ctx, e.State.CollectionName, embeddings.Dimension, e.Config.DistanceMetric, e.Config.IndexType); err != nil {
 		e.Logger.Error("Failed to create embeddings table", "error", err)
-		return nil, err
+		return nil, stats, err
+	}
+	if err := e.loadFingerprints(ctx); err != nil {
+		e.Logger.Warn("Failed to load content fingerprints, deduplication limited to this run", "error", err)
+	}
+	// Deferred first so it runs after the embedding queue has stored everything
+	defer e.pruneCollection(ctx)
+
+	// Embed on a worker pool while sources are scraped; the phase waits for it before returning
+	if e.Config.EmbeddingWorkers > 0 {
+		e.embedQueue = e.startEmbedQueue(ctx, e.Config.EmbeddingWorkers)
+		defer func() {
+			// Sources whose queued chunks failed to store were reported indexed when queued
+			failed := e.embedQueue.close()
+			e.embedQueue = nil
+			for url := range failed {
+				source, ok := queued[url]
+				if !ok {
+					continue
+				}
+				e.Logger.Error("Source failed to store on the embedding workers", "title", source.item.Title, "url", url)
+				e.forgetSource(source)
+				if i := slices.Index(summaries, source.summary); i >= 0 {
+					summaries = slices.Delete(summaries, i, i+1)
+				}
+				stats.IndexFailed++
+				stats.New = max(stats.New-1, 0)
+				e.emitSource(SourceEvent{Type: SourceFailed, Source: source.item, Reason: "indexing failed"})
+			}
+		}()
 	}
 
-	for _, item := range items {
+	for item := range items {
+		mu.Lock()
+		stats.Relevant++
+		mu.Unlock()
+
 		wg.Add(1)
 		go func(item SearchResult) {
 			defer wg.Done()
@@ -414,63 +671,175 @@ func (e *ResearchEngine) acquireAndIndexPhase(ctx context.Context, items []Searc
 			e.State.Mu.Lock()
 			if e.State.ProcessedURLs[item.URL] {
 				e.State.Mu.Unlock()
+				e.emitSource(SourceEvent{Type: SourceSkipped, Source: item, Reason: "already processed"})
+				mu.Lock()
+				stats.Duplicate++
+				mu.Unlock()
+				return
+			}
+			// Capped sources aren't marked processed, so a later iteration can still index them
+			if group, ok := groups.claim(item); !ok {
+				e.State.Mu.Unlock()
+				e.Logger.Info("Skipping source, group limit reached", "title", item.Title, "group", group, "limit", e.Config.GroupLimit)
+				e.emitSource(SourceEvent{Type: SourceSkipped, Source: item, Reason: fmt.Sprintf("group limit reached for %s", group)})
+				mu.Lock()
+				stats.GroupCapped++
+				mu.Unlock()
 				return
 			}
 			e.State.ProcessedURLs[item.URL] = true
 			e.State.Mu.Unlock()
 
+			if e.sharedURLs != nil && !e.sharedURLs.claim(item.URL) {
+				e.emitSource(SourceEvent{Type: SourceSkipped, Source: item, Reason: "already processed by another sub-topic"})
+				mu.Lock()
+				stats.Duplicate++
+				mu.Unlock()
+				return
+			}
+
 			e.Logger.Info("Scraping source", "title", item.Title, "url", item.URL)
 
 			fullText := ""
+			truncated := false
+			var scraped *tools.ScrapeResult
+			var scrapeErr error
 			if item.URL != "" {
 				// 1. Scrape PDF directly
-				text, err := tools.ScrapePDF(item.URL)
-				if err != nil {
+				result, err := tools.ScrapePDF(item.URL, tools.ScrapeOptions{
+					MaxPages: e.Config.MaxPDFPages,
+					MaxBytes: e.Config.MaxPDFBytes,
+				})
+				if errors.Is(err, tools.ErrCircuitOpen) {
+					e.Logger.Info("OCR unavailable, using summary", "url", item.URL)
+					fullText = item.Snippet // Fallback
+					scrapeErr = err
+				} else if err != nil {
 					e.Logger.Warn("Failed to scrape, using summary", "url", item.URL, "error", err)
 					fullText = item.Snippet // Fallback
+					scrapeErr = err
 				} else {
-					fullText = text
+					fullText = result.Text
+					scraped = result
+					item.Scraped = true
+					truncated = result.Truncated
+					if truncated {
+						e.Logger.Info("PDF truncated to page limit", "url", item.URL, "max_pages", e.Config.MaxPDFPages)
+					}
 				}
 			}
 
 			if fullText == "" {
 				fullText = item.Snippet // Fallback
 			}
+			// Seed sources have no snippet to fall back on; indexing nothing would only add an
+			// empty document. The URL is released so a later search can retry it.
+			if strings.TrimSpace(fullText) == "" {
+				e.Logger.Warn("Skipping source without content", "title", item.Title, "url", item.URL, "error", scrapeErr)
+				e.State.Mu.Lock()
+				delete(e.State.ProcessedURLs, item.URL)
+				e.State.Mu.Unlock()
+				e.emitSource(SourceEvent{Type: SourceSkipped, Source: item, Reason: "no content could be extracted"})
+				return
+			}
 
-			// 2. Index to RAG directly
-			// Chunking
-			chunkSize := 1000
-			chunkOverlap := 200
-			textSplitter := splitter.NewRecursiveCharacterTextSplitter(chunkSize, chunkOverlap)
-			chunks, err := textSplitter.SplitText(fullText)
-			if err != nil {
-				e.Logger.Error("Failed to split text", "title", item.Title, "error", err)
-			} else {
-				// Embed and Store
-				embeddings, err := e.Embedder.EmbedTexts(ctx, chunks)
+			// Re-score the full text, since the abstract alone may have oversold the paper.
+			// Snippet-only sources were already scored by the filter phase.
+			if threshold := e.Config.RecheckThreshold; threshold > 0 && item.Scraped {
+				verdict, err := e.checkRelevance(ctx, item, fullText)
 				if err != nil {
-					e.Logger.Error("Failed to generate embeddings", "title", item.Title, "error", err)
+					e.Logger.Warn("Relevance re-check failed, indexing anyway", "title", item.Title, "error", err)
+				} else if verdict.Score < threshold {
+					e.Logger.Info("Skipping source after full-text relevance check", "title", item.Title,
+						"score", verdict.Score, "threshold", threshold, "reason", verdict.Reason)
+					e.emitSource(SourceEvent{Type: SourceSkipped, Source: item, Score: &verdict.Score,
+						Reason: fmt.Sprintf("full text below relevance threshold: %s", verdict.Reason)})
+					return
 				} else {
-					documents := make([]vectorstore.Document, len(chunks))
-					for i, chunk := range chunks {
-						documents[i] = vectorstore.Document{
-							Content: chunk,
-							Metadata: map[string]interface{}{
-								"source": item.URL,
-								"title":  item.Title,
-							},
-							Embedding: embeddings[i],
-						}
-					}
+					e.Logger.Info("Source passed full-text relevance check", "title", item.Title, "score", verdict.Score)
+				}
+			}
 
-					store, err := vectorstore.NewPGVectorStore(e.DB.Pool, e.State.CollectionName)
-					if err != nil {
-						e.Logger.Error("Invalid collection name", "error", err)
-					} else {
-						if err := store.AddDocuments(ctx, documents); err != nil {
-							e.Logger.Error("Failed to add documents to vector store", "title", item.Title, "error", err)
-						}
-					}
+			// Keep disallowed content out of the collection. Failed checks skip the source too,
+			// since the content can't be shown to be allowed.
+			var safetyReview []string
+			if len(e.Config.SafetyBlock) > 0 {
+				verdict, err := e.checkSafety(ctx, item, fullText)
+				if err != nil {
+					e.Logger.Warn("Skipping source, safety check failed", "title", item.Title, "url", item.URL, "error", err)
+					e.emitSource(SourceEvent{Type: SourceSkipped, Source: item, Reason: "safety check failed"})
+					mu.Lock()
+					stats.Blocked++
+					mu.Unlock()
+					return
+				}
+				if len(verdict.Blocked) > 0 {
+					e.Logger.Warn("Skipping source blocked by safety check", "title", item.Title, "url", item.URL, "reason", verdict.reason())
+					e.emitSource(SourceEvent{Type: SourceSkipped, Source: item, Reason: "blocked by safety check: " + verdict.reason()})
+					mu.Lock()
+					stats.Blocked++
+					mu.Unlock()
+					return
+				}
+				if len(verdict.Borderline) > 0 {
+					e.Logger.Info("Indexing borderline source tagged for review", "title", item.Title, "categories", verdict.Borderline)
+					safetyReview = verdict.Borderline
+				}
+			}
+
+			// Skip sources whose content is already indexed under another URL
+			// (e.g. arXiv v1 vs v2, preprint vs conference version)
+			fingerprint := Fingerprint(fullText)
+			if match, ok := e.claimFingerprint(fingerprint); !ok {
+				e.Logger.Info("Skipping near-duplicate source", "title", item.Title, "url", item.URL,
+					"fingerprint", formatFingerprint(fingerprint), "matches", formatFingerprint(match))
+				e.emitSource(SourceEvent{Type: SourceSkipped, Source: item, Reason: "near-duplicate of an indexed source"})
+				mu.Lock()
+				stats.Duplicate++
+				mu.Unlock()
+				return
+			}
+
+			// 2. Index to RAG directly
+			metadata := e.sourceMetadata(item, fullText, fingerprint)
+			if truncated {
+				metadata["truncated"] = true
+				metadata["max_pages"] = e.Config.MaxPDFPages
+			}
+			if safetyReview != nil {
+				metadata["safety_review"] = safetyReview
+			}
+			// A source that isn't in the collection isn't recorded as indexed; its URL is
+			// released so a later search can retry it
+			if err := e.indexDocument(ctx, item, fullText, metadata); err != nil {
+				e.Logger.Error("Failed to index source", "title", item.Title, "error", err)
+				e.releaseFingerprint(fingerprint)
+				e.State.Mu.Lock()
+				delete(e.State.ProcessedURLs, item.URL)
+				e.State.Mu.Unlock()
+				e.emitSource(SourceEvent{Type: SourceSkipped, Source: item, Reason: "indexing failed"})
+				mu.Lock()
+				stats.IndexFailed++
+				mu.Unlock()
+				return
+			}
+			if e.Config.IndexCaptions && scraped != nil {
+				captionMeta := map[string]interface{}{
+					"source": item.URL,
+					"title":  item.Title,
+				}
+				if e.JobID != "" {
+					captionMeta["job_id"] = e.JobID
+				}
+				if n, err := e.indexCaptions(ctx, item, scraped, captionMeta); err != nil {
+					e.Logger.Warn("Failed to index figure captions", "title", item.Title, "error", err)
+				} else if n > 0 {
+					e.Logger.Info("Indexed figure captions", "title", item.Title, "count", n)
+				}
+			}
+			if e.Config.StorePages && scraped != nil {
+				if err := e.storePages(ctx, item, scraped); err != nil {
+					e.Logger.Warn("Failed to store pages", "title", item.Title, "error", err)
 				}
 			}
 
@@ -488,22 +857,48 @@ func (e *ResearchEngine) acquireAndIndexPhase(ctx context.Context, items []Searc
 				item.Snippet,
 				excerpt)
 
+			// Optionally replace the raw excerpt with structured claims for reflection and reporting
+			var facts []Fact
+			if e.Config.ExtractFacts {
+				extracted, err := e.extractFacts(ctx, item, fullText)
+				if err != nil {
+					e.Logger.Warn("Failed to extract facts, using raw summary", "title", item.Title, "error", err)
+				} else if len(extracted) > 0 {
+					facts = extracted
+					summary = fmt.Sprintf("Source: %s\nFacts:\n%s", factSource(item), formatFacts(facts))
+					e.Logger.Info("Extracted facts", "title", item.Title, "count", len(facts))
+				}
+			}
+
 			// Update state
+			for i := range facts {
+				facts[i].Iteration = e.State.Iteration
+			}
 			e.State.Mu.Lock()
 			e.State.AccumulatedFacts = append(e.State.AccumulatedFacts, summary)
+			e.State.FactIterations = append(e.State.FactIterations, e.State.Iteration)
+			e.State.Facts = append(e.State.Facts, facts...)
 			e.State.IndexedItems = append(e.State.IndexedItems, item)
+			if scrapeErr != nil {
+				e.State.ScrapeFailures = append(e.State.ScrapeFailures, ScrapeFailure{URL: item.URL, Error: scrapeErr.Error(), Iteration: e.State.Iteration})
+			}
 			e.State.Mu.Unlock()
 
 			// Update local summaries (for reflection phase return)
 			mu.Lock()
 			summaries = append(summaries, summary)
+			stats.New++
+			if e.embedQueue != nil {
+				queued[item.URL] = queuedSource{item: item, summary: summary, fingerprint: fingerprint}
+			}
 			mu.Unlock()
+			e.emitSource(SourceEvent{Type: SourceIndexed, Source: item})
 
 		}(item)
 	}
 
 	wg.Wait()
-	return summaries, nil
+	return summaries, stats, nil
 }
 
 func (e *ResearchEngine) reflectPhase(ctx context.Context, summaries []string) (bool, string, error) {
@@ -519,18 +914,37 @@ Review the gathered facts and decide if sufficient information has been gathered
 If yes, output "STOP".
 If no, output "CONTINUE" and a brief focus area for the next iteration.`
 
+	// Earlier findings let the decision account for what was already covered, not just the last iteration
+	e.State.Mu.Lock()
+	earlier := priorFindings(e.State.AccumulatedFacts, len(summaries), e.Config.ReflectionLookback)
+	earlierIterations := priorFindings(e.State.FactIterations, len(summaries), e.Config.ReflectionLookback)
+	totalFindings := len(e.State.AccumulatedFacts)
+	e.State.Mu.Unlock()
+
+	earlierText := strings.Join(earlier, "\n\n")
+	if e.Config.ReportProgression && len(earlierIterations) == len(earlier) {
+		earlierText = formatProgression(earlier, earlierIterations)
+	}
+
 	input := fmt.Sprintf("Topic: %s\n\nRecent Findings:\n%s\n\nTotal Iterations: %d/%d",
 		e.State.Topic, strings.Join(summaries, "\n\n"), e.State.Iteration, e.State.MaxIterations)
+	if len(earlier) > 0 {
+		input = fmt.Sprintf("Topic: %s\n\nEarlier Findings (%d most recent of %d before this iteration):\n%s\n\nRecent Findings:\n%s\n\nTotal Iterations: %d/%d",
+			e.State.Topic, len(earlier), totalFindings-len(summaries), earlierText,
+			strings.Join(summaries, "\n\n"), e.State.Iteration, e.State.MaxIterations)
+	}
+	if yields := iterationYields(e.State.QueryYields, e.State.Iteration); len(yields) > 0 {
+		input += "\n\nSearch queries of this iteration:\n" + formatQueryYields(yields)
+	}
 
-	resp, err := e.LLM.GenerateContent(ctx, []llms.MessageContent{
+	content, err := e.generateWithRetry(ctx, "reflect", []llms.MessageContent{
 		llms.TextParts(llms.ChatMessageTypeSystem, systemPrompt),
 		llms.TextParts(llms.ChatMessageTypeHuman, input),
-	})
+	}, generateOptions{})
 	if err != nil {
 		return false, "", err
 	}
 
-	content := resp.Choices[0].Content
 	if strings.Contains(strings.ToUpper(content), "STOP") {
 		return false, "", nil
 	}
@@ -538,49 +952,75 @@ If no, output "CONTINUE" and a brief focus area for the next iteration.`
 	return true, content, nil
 }
 
+// generateReport writes the final report. Persisting it is left to the caller.
 func (e *ResearchEngine) generateReport(ctx context.Context) (string, error) {
 	e.Logger.Info("Compiling final report")
 
-	prompt := fmt.Sprintf(`Write a comprehensive research report on "%s".
-Use the following gathered facts and summaries:
-
-%s
-
-Format as Markdown with Introduction, Key Findings, Methodology/Discussion, and Conclusion. Add inline citations and references, including sources for each fact and summary, and provide a bibliography at the end.`,
-		e.State.Topic, strings.Join(e.State.AccumulatedFacts, "\n\n"))
-
-	resp, err := e.LLM.GenerateContent(ctx, []llms.MessageContent{
-		llms.TextParts(llms.ChatMessageTypeHuman, prompt),
-	})
+	depth, err := ParseReportDepth(string(e.Config.ReportDepth))
 	if err != nil {
 		return "", err
 	}
+	e.Logger.Info("Report depth", "depth", depth)
 
-	report := resp.Choices[0].Content
+	if e.Config.ReportThemes > 0 && len(e.State.AccumulatedFacts) > 1 {
+		report, err := e.themedReport(ctx, depth)
+		if err == nil {
+			if e.OnReportDelta != nil {
+				e.OnReportDelta(ReportDelta{Text: report})
+			}
+			e.Logger.Info("Final report generated", "length", len(report), "themes", e.Config.ReportThemes)
+			return report, nil
+		}
+		e.Logger.Warn("Themed report failed, writing it in one pass", "error", err)
+	}
+
+	findings := strings.Join(e.State.AccumulatedFacts, "\n\n")
+	if e.Config.ReportProgression {
+		findings = formatProgression(e.State.AccumulatedFacts, e.State.FactIterations)
+	}
 
-	// We no longer write to file by default in the library code, the caller (CLI/Server) should handle persistence
-	// But to maintain CLI backward compatibility without major refactor, we can still write if desired,
-	// OR better: we just return the string and let the CLI write it.
+	prompt := fmt.Sprintf(`Write a %s research report on "%s".
+Use the following gathered facts and summaries:
 
-	// Let's keep the side effect for now for the CLI but primarily return the string
-	timestamp := time.Now().Unix()
-	reportFilename := fmt.Sprintf("report_%d.md", timestamp)
+%s
 
-	if err := os.WriteFile(reportFilename, []byte(report), 0644); err != nil {
-		e.Logger.Warn("failed to save report locally", "error", err)
+%s
+Add inline citations and references, including sources for each fact and summary, and provide a bibliography at the end.`,
+		depth, e.State.Topic, findings, reportConstraints(depth))
+
+	if len(e.Config.SubTopics) > 0 {
+		prompt += subTopicReportNote(e.Config.SubTopics)
+	}
+	if e.Config.ReportProgression {
+		prompt += "\nThe findings are grouped by the research iteration they were gathered in, each iteration following up on the gaps left by the previous ones. Organize the report around this progression: show how understanding developed, which later findings confirmed, refined or contradicted earlier ones, and what remains open."
 	}
 
-	// Save sources to JSON
-	sourcesFilename := "sources.json"
-	sourcesData, err := json.MarshalIndent(e.State.IndexedItems, "", "  ")
-	if err == nil {
-		if err := os.WriteFile(sourcesFilename, sourcesData, 0644); err != nil {
-			e.Logger.Error("Failed to save sources.json", "error", err)
-		} else {
-			e.Logger.Info("Saved sources", "filename", sourcesFilename)
+	if len(e.State.Facts) > 0 {
+		prompt += "\nSome sources are given as structured claims with evidence; cite the listed source for every claim you use."
+	}
+
+	// A blank report would otherwise complete the job as if it succeeded. Reports missing
+	// sections are retried, but the longest is kept if no attempt has them, rather than
+	// failing the job.
+	opts := generateOptions{
+		Validator: func(report string) error {
+			return validateReport(report, depth)
+		},
+		Fallback: validateReportLength,
+	}
+	if e.OnReportDelta != nil {
+		opts.Stream = func(text string, restart bool) {
+			e.OnReportDelta(ReportDelta{Text: text, Restart: restart})
 		}
 	}
 
+	report, err := e.generateWithRetry(ctx, "report", []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeHuman, prompt),
+	}, opts)
+	if err != nil {
+		return "", err
+	}
+
 	e.Logger.Info("Final report generated", "length", len(report))
 	return report, nil
 }
@@ -0,0 +1,153 @@
+package research
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// SourceType classifies a source by where it comes from, to pick its metadata extractors
+type SourceType string
+
+const (
+	SourceArxiv SourceType = "arxiv" // arXiv papers, from search or seeds
+	SourceDOI   SourceType = "doi"   // Papers resolved through doi.org
+	SourceWeb   SourceType = "web"   // Any other URL
+)
+
+// sourceTypeOf returns the type of a source from its URL
+func sourceTypeOf(item SearchResult) SourceType {
+	u, err := url.Parse(item.URL)
+	if err != nil {
+		return SourceWeb
+	}
+	host := strings.ToLower(u.Hostname())
+	switch {
+	case host == "arxiv.org" || strings.HasSuffix(host, ".arxiv.org"):
+		return SourceArxiv
+	case host == "doi.org" || host == "dx.doi.org":
+		return SourceDOI
+	}
+	return SourceWeb
+}
+
+// MetadataExtractor derives extra metadata for an indexed source from its search result and
+// the text being indexed. Keys already set on the source are never overwritten.
+type MetadataExtractor func(item SearchResult, text string) map[string]interface{}
+
+// DefaultMetadataExtractors are the extractors a new engine starts with, by source type.
+// Extractors under "" run for every source.
+func DefaultMetadataExtractors() map[SourceType][]MetadataExtractor {
+	return map[SourceType][]MetadataExtractor{
+		"":          {extractKeywords, extractDOI},
+		SourceArxiv: {extractArxivID},
+		SourceDOI:   {extractDOIFromURL},
+	}
+}
+
+// extractMetadata adds the source type and the output of its extractors to metadata
+func (e *ResearchEngine) extractMetadata(item SearchResult, text string, metadata map[string]interface{}) {
+	st := sourceTypeOf(item)
+	metadata["source_type"] = string(st)
+
+	for _, extractors := range [][]MetadataExtractor{e.MetadataExtractors[st], e.MetadataExtractors[""]} {
+		for _, extract := range extractors {
+			for k, v := range extract(item, text) {
+				if _, ok := metadata[k]; !ok {
+					metadata[k] = v
+				}
+			}
+		}
+	}
+}
+
+// arxivIDPattern matches new-style arXiv identifiers with an optional version, e.g. 1706.03762v7
+var arxivIDPattern = regexp.MustCompile(`^(\d{4}\.\d{4,5})(v\d+)?$`)
+
+// extractArxivID stores the arXiv identifier and version from an abs or pdf URL, plus the
+// archive of the primary category (cs for cs.CL) for coarse filtering
+func extractArxivID(item SearchResult, _ string) map[string]interface{} {
+	meta := make(map[string]interface{})
+	if u, err := url.Parse(item.URL); err == nil {
+		id := strings.TrimSuffix(u.Path, ".pdf")
+		id = strings.TrimPrefix(strings.TrimPrefix(id, "/abs/"), "/pdf/")
+		if m := arxivIDPattern.FindStringSubmatch(id); m != nil {
+			meta["arxiv_id"] = m[1]
+			if m[2] != "" {
+				meta["arxiv_version"] = m[2]
+			}
+		}
+	}
+	if archive, _, ok := strings.Cut(item.Category, "."); ok && archive != "" {
+		meta["archive"] = archive
+	}
+	return meta
+}
+
+// extractDOIFromURL stores the DOI of a doi.org URL
+func extractDOIFromURL(item SearchResult, _ string) map[string]interface{} {
+	u, err := url.Parse(item.URL)
+	if err != nil {
+		return nil
+	}
+	doi := strings.TrimPrefix(u.Path, "/")
+	if !doiPattern.MatchString(doi) {
+		return nil
+	}
+	return map[string]interface{}{"doi": strings.ToLower(doi)}
+}
+
+// metadataScanRunes limits the text scanned for keywords and DOIs to the front matter
+const metadataScanRunes = 5000
+
+// frontMatter returns the start of text, where papers list their DOI and keywords
+func frontMatter(text string) string {
+	if runes := []rune(text); len(runes) > metadataScanRunes {
+		return string(runes[:metadataScanRunes])
+	}
+	return text
+}
+
+// textDOIPattern finds a DOI mentioned in a paper's text
+var textDOIPattern = regexp.MustCompile(`(?i)\b(?:doi:\s*|doi\.org/)(10\.\d{4,9}/[^\s"<>]+)`)
+
+// extractDOI stores the first DOI mentioned in the front matter of the text
+func extractDOI(_ SearchResult, text string) map[string]interface{} {
+	m := textDOIPattern.FindStringSubmatch(frontMatter(text))
+	if m == nil {
+		return nil
+	}
+	return map[string]interface{}{"doi": strings.ToLower(strings.TrimRight(m[1], ".,;)"))}
+}
+
+// keywordsPattern matches a "Keywords:" or "Index Terms—" line of a paper
+var keywordsPattern = regexp.MustCompile(`(?im)^[#*_\s]*(?:key\s*words|index terms)[*_]*\s*[:—–-]+\s*(.+)$`)
+
+// maxKeywords caps the keywords stored per source
+const maxKeywords = 10
+
+// extractKeywords stores the author keywords listed in the front matter, lowercased, as a
+// list that metadata filters can match single keywords against
+func extractKeywords(_ SearchResult, text string) map[string]interface{} {
+	m := keywordsPattern.FindStringSubmatch(frontMatter(text))
+	if m == nil {
+		return nil
+	}
+	var keywords []string
+	seen := make(map[string]bool)
+	for _, kw := range strings.FieldsFunc(m[1], func(r rune) bool { return r == ',' || r == ';' || r == '·' || r == '•' }) {
+		kw = strings.ToLower(strings.Trim(kw, " \t.*_"))
+		if kw == "" || seen[kw] {
+			continue
+		}
+		seen[kw] = true
+		keywords = append(keywords, kw)
+		if len(keywords) == maxKeywords {
+			break
+		}
+	}
+	if len(keywords) == 0 {
+		return nil
+	}
+	return map[string]interface{}{"keywords": keywords}
+}
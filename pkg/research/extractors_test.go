@@ -0,0 +1,49 @@
+package research
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSourceTypeOf(t *testing.T) {
+	tests := map[string]SourceType{
+		"http://arxiv.org/pdf/1706.03762v7":    SourceArxiv,
+		"https://export.arxiv.org/abs/2101.1":  SourceArxiv,
+		"https://doi.org/10.1145/3292500.3330": SourceDOI,
+		"https://example.org/paper.pdf":        SourceWeb,
+		"":                                     SourceWeb,
+	}
+	for u, want := range tests {
+		if got := sourceTypeOf(SearchResult{URL: u}); got != want {
+			t.Errorf("sourceTypeOf(%q) = %q, want %q", u, got, want)
+		}
+	}
+}
+
+func TestExtractMetadata(t *testing.T) {
+	e := &ResearchEngine{MetadataExtractors: DefaultMetadataExtractors()}
+	text := "Attention Is All You Need\nDOI: 10.5555/3295222.3295349.\n\n**Keywords:** Transformers; Attention, machine translation, attention\n\n1 Introduction"
+
+	metadata := map[string]interface{}{"source": "http://arxiv.org/pdf/1706.03762v7"}
+	e.extractMetadata(SearchResult{URL: "http://arxiv.org/pdf/1706.03762v7", Category: "cs.CL"}, text, metadata)
+
+	want := map[string]interface{}{
+		"source":        "http://arxiv.org/pdf/1706.03762v7",
+		"source_type":   "arxiv",
+		"arxiv_id":      "1706.03762",
+		"arxiv_version": "v7",
+		"archive":       "cs",
+		"doi":           "10.5555/3295222.3295349",
+		"keywords":      []string{"transformers", "attention", "machine translation"},
+	}
+	if !reflect.DeepEqual(metadata, want) {
+		t.Errorf("metadata = %v, want %v", metadata, want)
+	}
+
+	// Type-specific fields win over generic ones, and existing keys are kept
+	metadata = map[string]interface{}{"title": "kept"}
+	e.extractMetadata(SearchResult{URL: "https://doi.org/10.1145/ABC"}, "doi: 10.9999/other", metadata)
+	if metadata["doi"] != "10.1145/abc" || metadata["title"] != "kept" || metadata["source_type"] != "doi" {
+		t.Errorf("metadata = %v, want the DOI of the URL", metadata)
+	}
+}
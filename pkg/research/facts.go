@@ -0,0 +1,87 @@
+package research
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mikeboe/research-helper/pkg/research/schema"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// maxExtractionRunes caps how much of a source's text is sent to the fact extractor
+const maxExtractionRunes = 20000
+
+// Fact is a single claim extracted from a source together with its supporting evidence
+type Fact struct {
+	Claim     string `json:"claim"`
+	Evidence  string `json:"evidence"`
+	Source    string `json:"source"`
+	Iteration int    `json:"iteration"` // Research iteration the source was acquired in
+}
+
+// factsSchema is the response of the fact extraction phase
+var factsSchema = schema.Object(map[string]schema.Schema{
+	"facts": schema.Array(schema.Object(map[string]schema.Schema{
+		"claim":    schema.String().Describe("A single, self-contained claim or finding stated in the source"),
+		"evidence": schema.String().Describe("The result, quote or data from the source that supports the claim"),
+	})).Describe("List of key claims relevant to the research topic"),
+})
+
+// extractFacts asks the LLM for the key claims in a source that are relevant to the topic.
+// The Source of each returned fact is set from the item, not from the model output.
+func (e *ResearchEngine) extractFacts(ctx context.Context, item SearchResult, text string) ([]Fact, error) {
+	systemPrompt := `You are a research analyst.
+Extract the key claims from the source that are relevant to the research topic.
+Each claim must be supported by evidence stated in the source. Do not add information that is not in the source.`
+
+	runes := []rune(text)
+	if len(runes) > maxExtractionRunes {
+		text = string(runes[:maxExtractionRunes])
+	}
+
+	input := fmt.Sprintf("Topic: %s\n\nSource Title: %s\n\nSource Text:\n%s", e.State.Topic, item.Title, text)
+
+	type FactsResponse struct {
+		Facts []Fact `json:"facts"`
+	}
+
+	factsResp, err := generateStructured[FactsResponse](ctx, e, "extract_facts", []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeSystem, systemPrompt+"\n\n"+schema.ResponseFormat(factsSchema)),
+		llms.TextParts(llms.ChatMessageTypeHuman, input),
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("fact extraction failed: %w", err)
+	}
+
+	source := factSource(item)
+	facts := make([]Fact, 0, len(factsResp.Facts))
+	for _, f := range factsResp.Facts {
+		if strings.TrimSpace(f.Claim) == "" {
+			continue
+		}
+		f.Source = source
+		facts = append(facts, f)
+	}
+	return facts, nil
+}
+
+// factSource renders the citation used for facts extracted from an item
+func factSource(item SearchResult) string {
+	if item.URL == "" {
+		return item.Title
+	}
+	return fmt.Sprintf("%s (%s)", item.Title, item.URL)
+}
+
+// formatFacts renders structured facts as text for the reflection and report prompts
+func formatFacts(facts []Fact) string {
+	var sb strings.Builder
+	for i, f := range facts {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(fmt.Sprintf("- Claim: %s\n  Evidence: %s\n  Source: %s", f.Claim, f.Evidence, f.Source))
+	}
+	return sb.String()
+}
@@ -0,0 +1,31 @@
+package research
+
+// SourceEventType is the pipeline stage a SourceEvent reports
+type SourceEventType string
+
+const (
+	SourceFound    SourceEventType = "found"    // Returned by a search, after deduplication and domain filtering
+	SourceFiltered SourceEventType = "filtered" // Scored by the filter phase; Kept tells whether it proceeds
+	SourceSkipped  SourceEventType = "skipped"  // Dropped while acquiring, see Reason
+	SourceIndexed  SourceEventType = "indexed"  // Scraped (or its snippet used) and added to the collection
+	SourceFailed   SourceEventType = "failed"   // Reported indexed, but its queued chunks failed to store
+)
+
+// SourceEvent reports the progress of a single source through the research pipeline
+type SourceEvent struct {
+	Type      SourceEventType `json:"type"`
+	Iteration int             `json:"iteration"`
+	Source    SearchResult    `json:"source"`
+	Score     *int            `json:"score,omitempty"`  // Filter score (0-10) of filtered events
+	Kept      bool            `json:"kept,omitempty"`   // Filtered events: the score reached the keep threshold
+	Reason    string          `json:"reason,omitempty"` // Skipped and failed events: why the source was dropped
+}
+
+// emitSource stamps event with the current iteration and sends it to OnSourceEvent, if set
+func (e *ResearchEngine) emitSource(event SourceEvent) {
+	if e.OnSourceEvent == nil {
+		return
+	}
+	event.Iteration = e.State.Iteration
+	e.OnSourceEvent(event)
+}
@@ -0,0 +1,158 @@
+package research
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math/bits"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/mikeboe/research-helper/pkg/vectorstore"
+)
+
+// nearDuplicateDistance is the maximum Hamming distance between two
+// fingerprints for their documents to be treated as the same content.
+const nearDuplicateDistance = 3
+
+// fingerprintShingleSize is the number of consecutive words hashed together.
+const fingerprintShingleSize = 3
+
+// Fingerprint computes a 64-bit SimHash of the text. Texts that share most of
+// their word shingles (e.g. arXiv v1 vs v2, or preprint vs conference version)
+// produce fingerprints that differ in only a few bits.
+func Fingerprint(text string) uint64 {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	if len(words) == 0 {
+		return 0
+	}
+
+	var weights [64]int
+	addShingle := func(shingle string) {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(shingle))
+		sum := h.Sum64()
+		for i := 0; i < 64; i++ {
+			if sum&(1<<uint(i)) != 0 {
+				weights[i]++
+			} else {
+				weights[i]--
+			}
+		}
+	}
+
+	if len(words) < fingerprintShingleSize {
+		addShingle(strings.Join(words, " "))
+	} else {
+		for i := 0; i+fingerprintShingleSize <= len(words); i++ {
+			addShingle(strings.Join(words[i:i+fingerprintShingleSize], " "))
+		}
+	}
+
+	var fp uint64
+	for i := 0; i < 64; i++ {
+		if weights[i] > 0 {
+			fp |= 1 << uint(i)
+		}
+	}
+	return fp
+}
+
+// IsNearDuplicate reports whether two fingerprints are close enough to be
+// considered the same document.
+func IsNearDuplicate(a, b uint64) bool {
+	return bits.OnesCount64(a^b) <= nearDuplicateDistance
+}
+
+// formatFingerprint encodes a fingerprint for storage in document metadata.
+// A hex string is used because JSON numbers lose precision above 2^53.
+func formatFingerprint(fp uint64) string {
+	return fmt.Sprintf("%016x", fp)
+}
+
+// parseFingerprint decodes a fingerprint stored by formatFingerprint.
+func parseFingerprint(s string) (uint64, error) {
+	return strconv.ParseUint(s, 16, 64)
+}
+
+// loadFingerprints merges the fingerprints already stored in the collection
+// into the state, so near-duplicates of documents indexed by earlier runs are
+// skipped as well. The collection is read once per engine; later calls are no-ops.
+func (e *ResearchEngine) loadFingerprints(ctx context.Context) error {
+	if e.fingerprintsLoaded {
+		return nil
+	}
+	store, err := vectorstore.NewPGVectorStore(e.DB.Pool, e.State.CollectionName)
+	if err != nil {
+		return fmt.Errorf("invalid collection name: %w", err)
+	}
+
+	values, err := store.GetMetadataValues(ctx, "fingerprint")
+	if err != nil {
+		return fmt.Errorf("failed to load fingerprints: %w", err)
+	}
+
+	e.State.Mu.Lock()
+	defer e.State.Mu.Unlock()
+
+	known := make(map[uint64]bool, len(e.State.Fingerprints))
+	for _, fp := range e.State.Fingerprints {
+		known[fp] = true
+	}
+	for _, v := range values {
+		fp, err := parseFingerprint(v)
+		if err != nil || fp == 0 || known[fp] {
+			continue
+		}
+		known[fp] = true
+		e.State.Fingerprints = append(e.State.Fingerprints, fp)
+	}
+	e.fingerprintsLoaded = true
+	return nil
+}
+
+// claimFingerprint records fp as indexed unless a near-duplicate is already
+// known. It returns the matching fingerprint and false when fp is a duplicate.
+// The claim keeps concurrent near-duplicates from both being indexed; callers
+// release it with releaseFingerprint if indexing fails. Fingerprint 0, the
+// fingerprint of text without words, is never claimed, so empty sources don't
+// collide with each other. Sub-topic engines also claim fp in the set shared with
+// their siblings.
+func (e *ResearchEngine) claimFingerprint(fp uint64) (uint64, bool) {
+	if fp == 0 {
+		return 0, true
+	}
+	e.State.Mu.Lock()
+	defer e.State.Mu.Unlock()
+
+	for _, existing := range e.State.Fingerprints {
+		if IsNearDuplicate(existing, fp) {
+			return existing, false
+		}
+	}
+	if e.sharedFingerprints != nil {
+		if match, ok := e.sharedFingerprints.claim(fp); !ok {
+			return match, false
+		}
+	}
+	e.State.Fingerprints = append(e.State.Fingerprints, fp)
+	return 0, true
+}
+
+// releaseFingerprint withdraws a claim of fp, so a near-duplicate of a source
+// that failed to index can still be indexed later
+func (e *ResearchEngine) releaseFingerprint(fp uint64) {
+	if e.sharedFingerprints != nil {
+		e.sharedFingerprints.release(fp)
+	}
+	e.State.Mu.Lock()
+	defer e.State.Mu.Unlock()
+
+	if i := slices.Index(e.State.Fingerprints, fp); i >= 0 {
+		e.State.Fingerprints = slices.Delete(e.State.Fingerprints, i, i+1)
+	}
+}
@@ -0,0 +1,92 @@
+package research
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mikeboe/research-helper/pkg/vectorstore"
+)
+
+func TestFingerprint(t *testing.T) {
+	paragraph := "Low-rank adaptation freezes the pretrained model weights and injects trainable rank decomposition matrices into each layer of the Transformer architecture. " +
+		"Compared to GPT-3 175B fine-tuned with Adam, LoRA can reduce the number of trainable parameters by 10,000 times and the GPU memory requirement by 3 times. " +
+		"LoRA performs on-par or better than fine-tuning in model quality on RoBERTa, DeBERTa, GPT-2, and GPT-3, despite having fewer trainable parameters, a higher training throughput, and no additional inference latency. " +
+		"We also provide an empirical investigation into rank-deficiency in language model adaptation, which sheds light on the efficacy of LoRA. "
+
+	// Simulate a multi-section paper where a later version edits a single phrase
+	var sb strings.Builder
+	for i := 1; i <= 10; i++ {
+		fmt.Fprintf(&sb, "Section %d. %s", i, paragraph)
+	}
+	base := sb.String()
+	revised := strings.Replace(base, "10,000 times", "ten thousand times", 1)
+	unrelated := "Quantum error correction codes protect logical qubits from decoherence by encoding them redundantly across many physical qubits in a lattice."
+
+	tests := []struct {
+		name string
+		a, b string
+		want bool
+	}{
+		{"Identical text", base, base, true},
+		{"Case and punctuation only", base, strings.ToUpper(strings.ReplaceAll(base, ",", ";")), true},
+		{"Minor revision", base, revised, true},
+		{"Unrelated text", base, unrelated, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsNearDuplicate(Fingerprint(tt.a), Fingerprint(tt.b)); got != tt.want {
+				t.Errorf("IsNearDuplicate() = %v, want %v (distance between %016x and %016x)", got, tt.want, Fingerprint(tt.a), Fingerprint(tt.b))
+			}
+		})
+	}
+}
+
+func TestFingerprintRoundTrip(t *testing.T) {
+	fp := Fingerprint("attention is all you need")
+	got, err := parseFingerprint(formatFingerprint(fp))
+	if err != nil {
+		t.Fatalf("parseFingerprint() error = %v", err)
+	}
+	if got != fp {
+		t.Errorf("parseFingerprint(formatFingerprint(%x)) = %x", fp, got)
+	}
+}
+
+func TestClaimFingerprint(t *testing.T) {
+	e := &ResearchEngine{State: &ResearchState{}}
+	fp := Fingerprint("attention is all you need")
+
+	if _, ok := e.claimFingerprint(fp); !ok {
+		t.Fatal("first claim rejected")
+	}
+	if _, ok := e.claimFingerprint(fp); ok {
+		t.Error("duplicate claim accepted")
+	}
+	e.releaseFingerprint(fp)
+	if _, ok := e.claimFingerprint(fp); !ok {
+		t.Error("claim after release rejected")
+	}
+
+	empty := Fingerprint("")
+	for range 2 {
+		if _, ok := e.claimFingerprint(empty); !ok {
+			t.Error("empty text treated as a duplicate")
+		}
+	}
+}
+
+func TestChunkFingerprints(t *testing.T) {
+	chunks := []vectorstore.Document{
+		{Metadata: map[string]interface{}{"fingerprint": formatFingerprint(0xabc)}},
+		{Metadata: map[string]interface{}{"fingerprint": formatFingerprint(0xabc)}},
+		{Metadata: map[string]interface{}{"fingerprint": formatFingerprint(0x123)}},
+		{Metadata: map[string]interface{}{"fingerprint": "not hex"}},
+		{Metadata: map[string]interface{}{}},
+	}
+	if got, want := chunkFingerprints(chunks), []uint64{0xabc, 0x123}; !reflect.DeepEqual(got, want) {
+		t.Errorf("chunkFingerprints = %x, want %x", got, want)
+	}
+}
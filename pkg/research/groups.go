@@ -0,0 +1,77 @@
+package research
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// SourceGroup is what sources are grouped by for Config.GroupLimit
+type SourceGroup string
+
+const (
+	// GroupByCategory groups sources by subject class (the arXiv primary category)
+	GroupByCategory SourceGroup = "category"
+	// GroupByAuthor groups sources by first author
+	GroupByAuthor SourceGroup = "author"
+	// GroupByVenue groups sources by journal or conference
+	GroupByVenue SourceGroup = "venue"
+)
+
+// ParseSourceGroup validates a source grouping. An empty string selects category.
+func ParseSourceGroup(s string) (SourceGroup, error) {
+	switch SourceGroup(s) {
+	case "":
+		return GroupByCategory, nil
+	case GroupByCategory, GroupByAuthor, GroupByVenue:
+		return SourceGroup(s), nil
+	default:
+		return "", fmt.Errorf("invalid source group %q: must be one of %s, %s, %s", s, GroupByCategory, GroupByAuthor, GroupByVenue)
+	}
+}
+
+// groupKey returns the group of a source, or "" if it has no value for the grouping
+func groupKey(item SearchResult, by SourceGroup) string {
+	var key string
+	switch by {
+	case GroupByAuthor:
+		if len(item.Authors) > 0 {
+			key = item.Authors[0]
+		}
+	case GroupByVenue:
+		key = item.Venue
+	default:
+		key = item.Category
+	}
+	return strings.ToLower(strings.Join(strings.Fields(key), " "))
+}
+
+// groupLimiter caps the sources acquired per group within one iteration
+type groupLimiter struct {
+	limit  int
+	by     SourceGroup
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// newGroupLimiter returns a limiter allowing limit sources per group; 0 allows all
+func newGroupLimiter(limit int, by SourceGroup) *groupLimiter {
+	return &groupLimiter{limit: limit, by: by, counts: make(map[string]int)}
+}
+
+// claim counts item towards its group and reports whether the group had room. Sources
+// without a group value are never limited.
+func (g *groupLimiter) claim(item SearchResult) (string, bool) {
+	key := groupKey(item, g.by)
+	if g.limit <= 0 || key == "" {
+		return key, true
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.counts[key] >= g.limit {
+		return key, false
+	}
+	g.counts[key]++
+	return key, true
+}
@@ -0,0 +1,49 @@
+package research
+
+import "testing"
+
+func TestGroupLimiter(t *testing.T) {
+	items := []SearchResult{
+		{Title: "a", Category: "cs.LG", Authors: []string{"Ada Lovelace"}},
+		{Title: "b", Category: "cs.LG", Authors: []string{"ada  lovelace"}},
+		{Title: "c", Category: "cs.LG", Authors: []string{"Alan Turing"}},
+		{Title: "d", Category: "cs.CL", Authors: []string{"Ada Lovelace"}},
+		{Title: "e"},
+		{Title: "f"},
+	}
+
+	tests := []struct {
+		by   SourceGroup
+		want string
+	}{
+		{GroupByCategory, "abdef"},
+		{GroupByAuthor, "acef"},
+		{GroupByVenue, "abcdef"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.by), func(t *testing.T) {
+			g := newGroupLimiter(2, tt.by)
+			if tt.by == GroupByAuthor {
+				g.limit = 1
+			}
+			var got string
+			for _, item := range items {
+				if _, ok := g.claim(item); ok {
+					got += item.Title
+				}
+			}
+			if got != tt.want {
+				t.Errorf("claimed %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSourceGroup(t *testing.T) {
+	if got, err := ParseSourceGroup(""); err != nil || got != GroupByCategory {
+		t.Errorf("ParseSourceGroup(\"\") = %q, %v, want category", got, err)
+	}
+	if _, err := ParseSourceGroup("institution"); err == nil {
+		t.Error("ParseSourceGroup(institution) succeeded, want error")
+	}
+}
@@ -0,0 +1,319 @@
+package research
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/mikeboe/research-helper/pkg/embeddings"
+	"github.com/mikeboe/research-helper/pkg/splitter"
+	"github.com/mikeboe/research-helper/pkg/vectorstore"
+)
+
+// OversizePolicy decides what happens to a source that exceeds Config.MaxChunksPerSource
+type OversizePolicy string
+
+const (
+	// OversizeTruncate indexes only the first MaxChunksPerSource chunks
+	OversizeTruncate OversizePolicy = "truncate"
+	// OversizeSkip indexes only the source's snippet instead of its full text
+	OversizeSkip OversizePolicy = "skip"
+	// OversizeSelect asks the fast model for the sections most relevant to the topic and
+	// indexes only those, up to MaxChunksPerSource chunks. Falls back to truncation when
+	// the source has no sections or the selection fails.
+	OversizeSelect OversizePolicy = "select"
+)
+
+// TitleEmbedding controls whether a source's title contributes to its vectors.
+//
+// Titles are often the most discriminative text of a paper but are otherwise only
+// stored in metadata. Prepending them shifts every chunk vector towards the title,
+// which improves recall for title-like queries at the cost of slightly blurring
+// chunk-level matches. A separate title document keeps chunk vectors unchanged but
+// adds one extra row (and embedding call) per source that search results may return.
+// Changing the mode for an existing collection mixes vector semantics.
+type TitleEmbedding string
+
+const (
+	TitleEmbeddingNone     TitleEmbedding = "none"
+	TitleEmbeddingPrepend  TitleEmbedding = "prepend"
+	TitleEmbeddingSeparate TitleEmbedding = "separate"
+)
+
+// ParseTitleEmbedding validates a title embedding mode. An empty string selects none.
+func ParseTitleEmbedding(s string) (TitleEmbedding, error) {
+	switch TitleEmbedding(s) {
+	case "":
+		return TitleEmbeddingNone, nil
+	case TitleEmbeddingNone, TitleEmbeddingPrepend, TitleEmbeddingSeparate:
+		return TitleEmbedding(s), nil
+	default:
+		return "", fmt.Errorf("invalid title embedding %q: must be one of %s, %s, %s",
+			s, TitleEmbeddingNone, TitleEmbeddingPrepend, TitleEmbeddingSeparate)
+	}
+}
+
+// indexDocument chunks, embeds and stores the text of a source in the collection.
+// Every chunk receives a copy of metadata.
+func (e *ResearchEngine) indexDocument(ctx context.Context, item SearchResult, text string, metadata map[string]interface{}) error {
+	// Cleaned on the whole text, since running headers are only recognizable across pages
+	if e.Config.CleanChunks {
+		text = cleanText(text)
+		metadata["cleaned"] = true
+	}
+
+	chunkSize := 1000
+	chunkOverlap := 200
+	textSplitter := splitter.NewRecursiveCharacterTextSplitter(chunkSize, chunkOverlap)
+	chunks, err := textSplitter.SplitText(text)
+	if err != nil {
+		return fmt.Errorf("failed to split text: %w", err)
+	}
+
+	if limit := e.Config.MaxChunksPerSource; limit > 0 && len(chunks) > limit {
+		metadata["total_chunks"] = len(chunks)
+		var selected []string
+		if e.Config.OversizePolicy == OversizeSelect {
+			selected = e.selectedChunks(ctx, item, text, textSplitter, chunkSize-chunkOverlap, limit, metadata)
+		}
+		if selected != nil {
+			chunks = selected
+		} else if e.Config.OversizePolicy == OversizeSkip {
+			e.Logger.Warn("Source too large, indexing snippet only", "title", item.Title, "chunks", len(chunks), "limit", limit)
+			metadata["size_status"] = "too_large"
+			if chunks, err = textSplitter.SplitText(item.Snippet); err != nil {
+				return fmt.Errorf("failed to split snippet: %w", err)
+			}
+		} else {
+			e.Logger.Warn("Source too large, truncating", "title", item.Title, "chunks", len(chunks), "limit", limit)
+			metadata["size_status"] = "truncated"
+			chunks = chunks[:limit]
+		}
+	}
+
+	if len(chunks) == 0 {
+		return nil
+	}
+
+	var redactions []map[string]int
+	embedFields := e.Config.EmbedMetadata
+	if len(e.Config.Redact) > 0 {
+		if chunks, redactions, err = e.redactChunks(ctx, item, text, chunks); err != nil {
+			return err
+		}
+	}
+	// Author names would otherwise be stored and embedded next to the redacted chunks
+	if slices.Contains(e.Config.Redact, RedactNames) {
+		delete(metadata, "authors")
+		embedFields = slices.DeleteFunc(slices.Clone(embedFields), func(f string) bool { return f == MetadataAuthors })
+	}
+
+	// The stored content stays the plain chunk; only the embedded text changes
+	texts := chunks
+	switch e.Config.TitleEmbedding {
+	case TitleEmbeddingPrepend:
+		texts = make([]string, len(chunks))
+		for i, chunk := range chunks {
+			texts[i] = fmt.Sprintf("Title: %s\n\n%s", item.Title, chunk)
+		}
+		metadata["title_embedded"] = true
+	case TitleEmbeddingSeparate:
+		if item.Title != "" {
+			chunks = append(chunks, item.Title)
+			texts = chunks
+		}
+	}
+
+	if suffix := metadataText(item, embedFields); suffix != "" {
+		enriched := make([]string, len(texts))
+		for i, text := range texts {
+			enriched[i] = text + "\n\n" + suffix
+		}
+		texts = enriched
+		metadata["metadata_embedded"] = embedFields
+	}
+
+	documents := make([]vectorstore.Document, len(chunks))
+	for i, chunk := range chunks {
+		chunkMeta := make(map[string]interface{}, len(metadata)+2)
+		for k, v := range metadata {
+			chunkMeta[k] = v
+		}
+		if e.Config.TitleEmbedding == TitleEmbeddingSeparate && item.Title != "" && i == len(chunks)-1 {
+			chunkMeta["chunk_type"] = "title"
+		} else {
+			chunkMeta["chunk_index"] = i
+		}
+		if i < len(redactions) && len(redactions[i]) > 0 {
+			chunkMeta["pii_redacted"] = true
+			chunkMeta["pii_redactions"] = redactions[i]
+		}
+		documents[i] = vectorstore.Document{
+			Content:  chunk,
+			Metadata: chunkMeta,
+		}
+		if e.Config.DeterministicIDs {
+			documents[i].ID = vectorstore.DocumentID(item.URL, i, chunk)
+		}
+	}
+
+	return e.embedAndStore(ctx, documents, texts)
+}
+
+// selectedChunks splits the relevant sections of an oversized source (see OversizeSelect)
+// into at most limit chunks and records the selection in metadata. It returns nil when the
+// caller should fall back to truncation.
+func (e *ResearchEngine) selectedChunks(ctx context.Context, item SearchResult, text string, textSplitter *splitter.TextSplitter, chunkSize, limit int, metadata map[string]interface{}) []string {
+	sections := splitSections(text)
+	if len(sections) < 2 {
+		e.Logger.Info("Source has no sections to select from", "title", item.Title)
+		return nil
+	}
+
+	selected, err := e.selectSections(ctx, item, sections, chunkSize, limit)
+	if err != nil {
+		e.Logger.Warn("Section selection failed", "title", item.Title, "error", err)
+		return nil
+	}
+
+	var sb strings.Builder
+	for _, s := range selected {
+		sb.WriteString(s.Text)
+	}
+	chunks, err := textSplitter.SplitText(sb.String())
+	if err != nil || len(chunks) == 0 {
+		return nil
+	}
+	if len(chunks) > limit {
+		chunks = chunks[:limit]
+	}
+
+	titles := sectionTitles(selected)
+	e.Logger.Info("Indexing selected sections", "title", item.Title, "sections", len(selected), "of", len(sections), "chunks", len(chunks))
+	metadata["size_status"] = "selected"
+	metadata["selected_sections"] = titles
+	metadata["total_sections"] = len(sections)
+	return chunks
+}
+
+// embedAndStore embeds texts[i] as the vector of documents[i] and adds the documents to the collection.
+// While an embedding queue is running the documents are handed to it and stored asynchronously.
+func (e *ResearchEngine) embedAndStore(ctx context.Context, documents []vectorstore.Document, texts []string) error {
+	if e.embedQueue != nil {
+		e.embedQueue.submit(embedJob{documents: documents, texts: texts})
+		return nil
+	}
+	return e.storeDocuments(ctx, documents, texts)
+}
+
+// storeDocuments embeds and stores documents synchronously. Documents whose text
+// cannot be embedded are skipped so the rest of the source is still indexed.
+func (e *ResearchEngine) storeDocuments(ctx context.Context, documents []vectorstore.Document, texts []string) error {
+	// Chunks that stay empty after embedChunk's retry come back as nil vectors and are skipped
+	vectors, err := e.Embedder.EmbedEach(ctx, texts, func(ctx context.Context, text string) ([]float32, error) {
+		vec, err := e.embedChunk(ctx, text)
+		if errors.Is(err, embeddings.ErrEmptyEmbedding) {
+			return nil, nil
+		}
+		return vec, err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to generate embeddings: %w", err)
+	}
+
+	embedded := make([]vectorstore.Document, 0, len(documents))
+	for i, doc := range documents {
+		if vectors[i] == nil {
+			e.Logger.Warn("Skipping chunk without embedding", "source", doc.Metadata["source"], "chunk", i, "length", len(texts[i]))
+			continue
+		}
+		doc.Embedding = vectors[i]
+		embedded = append(embedded, doc)
+	}
+	if len(embedded) == 0 {
+		return nil
+	}
+
+	store, err := vectorstore.NewPGVectorStore(e.DB.Pool, e.State.CollectionName)
+	if err != nil {
+		return fmt.Errorf("invalid collection name: %w", err)
+	}
+	if err := store.AddDocuments(ctx, embedded); err != nil {
+		return fmt.Errorf("failed to add documents to vector store: %w", err)
+	}
+	return nil
+}
+
+// pruneCollection applies the configured document limit to the collection. It runs once
+// per batch of indexed sources rather than after every insert, since it counts and sorts
+// the whole collection.
+func (e *ResearchEngine) pruneCollection(ctx context.Context) {
+	if e.c == nil || e.c.MaxCollectionDocuments <= 0 {
+		return
+	}
+	store, err := vectorstore.NewPGVectorStore(e.DB.Pool, e.State.CollectionName)
+	if err != nil {
+		return
+	}
+	store.WithRetention(vectorstore.Retention{MaxDocuments: e.c.MaxCollectionDocuments, Evict: vectorstore.Eviction(e.c.EvictionPolicy)})
+	evicted, err := store.PruneToRetention(ctx)
+	if err != nil {
+		e.Logger.Warn("Failed to prune collection", "collection", e.State.CollectionName, "error", err)
+		return
+	}
+	if evicted > 0 {
+		e.Logger.Info("Pruned collection to its document limit", "collection", e.State.CollectionName, "evicted", evicted)
+	}
+}
+
+// maxEmbeddingRunes bounds the text of a chunk retried after an empty embedding
+const maxEmbeddingRunes = 8000
+
+// embedChunk embeds a single text. An empty embedding is retried once with the text
+// normalized, since OCR output occasionally contains control characters or invalid UTF-8.
+func (e *ResearchEngine) embedChunk(ctx context.Context, text string) ([]float32, error) {
+	vec, err := e.Embedder.EmbedText(ctx, text)
+	if !errors.Is(err, embeddings.ErrEmptyEmbedding) {
+		return vec, err
+	}
+
+	normalized := normalizeForEmbedding(text)
+	if normalized == "" || normalized == text {
+		return nil, err
+	}
+	e.Logger.Warn("Empty embedding, retrying with normalized text", "length", len(text), "normalized_length", len(normalized))
+	return e.Embedder.EmbedText(ctx, normalized)
+}
+
+// normalizeForEmbedding strips invalid UTF-8 and control characters, collapses whitespace
+// and truncates the text to maxEmbeddingRunes
+func normalizeForEmbedding(text string) string {
+	text = strings.ToValidUTF8(text, "")
+	text = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) && !unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, text)
+	text = strings.Join(strings.Fields(text), " ")
+
+	if runes := []rune(text); len(runes) > maxEmbeddingRunes {
+		text = string(runes[:maxEmbeddingRunes])
+	}
+	return text
+}
+
+// ParseOversizePolicy validates an oversize policy name. An empty string selects truncation.
+func ParseOversizePolicy(s string) (OversizePolicy, error) {
+	switch OversizePolicy(s) {
+	case "":
+		return OversizeTruncate, nil
+	case OversizeTruncate, OversizeSkip, OversizeSelect:
+		return OversizePolicy(s), nil
+	default:
+		return "", fmt.Errorf("invalid oversize policy %q: must be %s, %s or %s", s, OversizeTruncate, OversizeSkip, OversizeSelect)
+	}
+}
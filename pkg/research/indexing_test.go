@@ -0,0 +1,18 @@
+package research
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeForEmbedding(t *testing.T) {
+	got := normalizeForEmbedding("  Results\x00 of\x07 the\n\n\tablation \xff study ")
+	if want := "Results of the ablation study"; got != want {
+		t.Errorf("normalizeForEmbedding() = %q, want %q", got, want)
+	}
+
+	long := strings.Repeat("é", maxEmbeddingRunes+10)
+	if got := []rune(normalizeForEmbedding(long)); len(got) != maxEmbeddingRunes {
+		t.Errorf("normalized length = %d runes, want %d", len(got), maxEmbeddingRunes)
+	}
+}
@@ -0,0 +1,155 @@
+package research
+
+import (
+	"time"
+
+	"github.com/mikeboe/research-helper/pkg/vectorstore"
+)
+
+// JobConfig is the research configuration persisted with each job. The server and the CLI
+// both store it, so a job started by one can be resumed or continued with its settings.
+type JobConfig struct {
+	MaxIterations int         `json:"max_iterations"`
+	Collection    string      `json:"collection"`
+	ReportDepth   ReportDepth `json:"report_depth"`
+	ExtractFacts  bool        `json:"extract_facts"`
+	MaxPDFPages   int         `json:"max_pdf_pages"`
+	MaxPDFBytes   int64       `json:"max_pdf_bytes"`
+	Trace         bool        `json:"trace"`
+
+	MinQueryTokens int  `json:"min_query_tokens"`
+	RefineQueries  bool `json:"refine_queries"`
+
+	ReflectionLookback int  `json:"reflection_lookback"`
+	ReportProgression  bool `json:"report_progression"`
+	ReportThemes       int  `json:"report_themes"`
+
+	MaxChunksPerSource int                        `json:"max_chunks_per_source"`
+	OversizePolicy     OversizePolicy             `json:"oversize_policy"`
+	TitleEmbedding     TitleEmbedding             `json:"title_embedding"`
+	StorePages         bool                       `json:"store_pages"`
+	StorePageImages    bool                       `json:"store_page_images"`
+	RecheckThreshold   int                        `json:"recheck_threshold"`
+	DeterministicIDs   bool                       `json:"deterministic_ids"`
+	IndexCaptions      bool                       `json:"index_captions"`
+	CleanChunks        bool                       `json:"clean_chunks"`
+	StreamSources      bool                       `json:"stream_sources"`
+	DistanceMetric     vectorstore.DistanceMetric `json:"distance_metric"`
+	IndexType          vectorstore.IndexType      `json:"index_type"`
+	EmbeddingWorkers   int                        `json:"embedding_workers"`
+
+	AllowedDomains []string `json:"allowed_domains"`
+	BlockedDomains []string `json:"blocked_domains"`
+
+	EmbedMetadata []string `json:"embed_metadata"`
+
+	SeedSources []string `json:"seed_sources"`
+
+	MinNewSources int `json:"min_new_sources"`
+	ArxivResults  int `json:"arxiv_results"`
+
+	GroupLimit int         `json:"group_limit"`
+	GroupBy    SourceGroup `json:"group_by"`
+
+	SafetyBlock []string `json:"safety_block"`
+	Redact      []string `json:"redact"`
+
+	IterationDelayMs  int `json:"iteration_delay_ms"`
+	IterationJitterMs int `json:"iteration_jitter_ms"`
+
+	SubTopics           []string `json:"sub_topics"`
+	SubTopicConcurrency int      `json:"sub_topic_concurrency"`
+}
+
+// ResearchConfig applies the job settings on top of the service defaults
+func (jc JobConfig) ResearchConfig(base Config) Config {
+	cfg := base
+	if jc.Collection != "" {
+		cfg.Collection = jc.Collection
+	}
+	cfg.MaxIterations = jc.MaxIterations
+	cfg.ReportDepth = jc.ReportDepth
+	cfg.ExtractFacts = jc.ExtractFacts
+	cfg.MaxPDFPages = jc.MaxPDFPages
+	cfg.MaxPDFBytes = jc.MaxPDFBytes
+	cfg.Trace = jc.Trace
+	cfg.MinQueryTokens = jc.MinQueryTokens
+	cfg.RefineQueries = jc.RefineQueries
+	cfg.ReflectionLookback = jc.ReflectionLookback
+	cfg.ReportProgression = jc.ReportProgression
+	cfg.ReportThemes = jc.ReportThemes
+	cfg.MaxChunksPerSource = jc.MaxChunksPerSource
+	cfg.OversizePolicy = jc.OversizePolicy
+	cfg.TitleEmbedding = jc.TitleEmbedding
+	cfg.StorePages = jc.StorePages
+	cfg.StorePageImages = jc.StorePageImages
+	cfg.RecheckThreshold = jc.RecheckThreshold
+	cfg.DeterministicIDs = jc.DeterministicIDs
+	cfg.IndexCaptions = jc.IndexCaptions
+	cfg.CleanChunks = jc.CleanChunks
+	cfg.StreamSources = jc.StreamSources
+	cfg.DistanceMetric = jc.DistanceMetric
+	cfg.IndexType = jc.IndexType
+	cfg.EmbeddingWorkers = jc.EmbeddingWorkers
+	cfg.AllowedDomains = jc.AllowedDomains
+	cfg.BlockedDomains = jc.BlockedDomains
+	cfg.EmbedMetadata = jc.EmbedMetadata
+	cfg.SeedSources = jc.SeedSources
+	cfg.MinNewSources = jc.MinNewSources
+	cfg.ArxivResults = jc.ArxivResults
+	cfg.GroupLimit = jc.GroupLimit
+	cfg.GroupBy = jc.GroupBy
+	cfg.SafetyBlock = jc.SafetyBlock
+	cfg.Redact = jc.Redact
+	cfg.IterationDelay = time.Duration(jc.IterationDelayMs) * time.Millisecond
+	cfg.IterationJitter = time.Duration(jc.IterationJitterMs) * time.Millisecond
+	cfg.SubTopics = jc.SubTopics
+	cfg.SubTopicConcurrency = jc.SubTopicConcurrency
+	return cfg
+}
+
+// NewJobConfig records cfg as the settings of a job, so ResearchConfig can rebuild it, e.g.
+// to resume the job
+func NewJobConfig(cfg Config) JobConfig {
+	return JobConfig{
+		Collection:          cfg.Collection,
+		MaxIterations:       cfg.MaxIterations,
+		ReportDepth:         cfg.ReportDepth,
+		ExtractFacts:        cfg.ExtractFacts,
+		MaxPDFPages:         cfg.MaxPDFPages,
+		MaxPDFBytes:         cfg.MaxPDFBytes,
+		Trace:               cfg.Trace,
+		MinQueryTokens:      cfg.MinQueryTokens,
+		RefineQueries:       cfg.RefineQueries,
+		ReflectionLookback:  cfg.ReflectionLookback,
+		ReportProgression:   cfg.ReportProgression,
+		ReportThemes:        cfg.ReportThemes,
+		MaxChunksPerSource:  cfg.MaxChunksPerSource,
+		OversizePolicy:      cfg.OversizePolicy,
+		TitleEmbedding:      cfg.TitleEmbedding,
+		StorePages:          cfg.StorePages,
+		StorePageImages:     cfg.StorePageImages,
+		RecheckThreshold:    cfg.RecheckThreshold,
+		DeterministicIDs:    cfg.DeterministicIDs,
+		IndexCaptions:       cfg.IndexCaptions,
+		CleanChunks:         cfg.CleanChunks,
+		StreamSources:       cfg.StreamSources,
+		DistanceMetric:      cfg.DistanceMetric,
+		IndexType:           cfg.IndexType,
+		EmbeddingWorkers:    cfg.EmbeddingWorkers,
+		AllowedDomains:      cfg.AllowedDomains,
+		BlockedDomains:      cfg.BlockedDomains,
+		EmbedMetadata:       cfg.EmbedMetadata,
+		SeedSources:         cfg.SeedSources,
+		MinNewSources:       cfg.MinNewSources,
+		ArxivResults:        cfg.ArxivResults,
+		GroupLimit:          cfg.GroupLimit,
+		GroupBy:             cfg.GroupBy,
+		SafetyBlock:         cfg.SafetyBlock,
+		Redact:              cfg.Redact,
+		SubTopics:           cfg.SubTopics,
+		SubTopicConcurrency: cfg.SubTopicConcurrency,
+		IterationDelayMs:    int(cfg.IterationDelay / time.Millisecond),
+		IterationJitterMs:   int(cfg.IterationJitter / time.Millisecond),
+	}
+}
@@ -0,0 +1,34 @@
+package research
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestJobConfigRoundTrip(t *testing.T) {
+	cfg := Config{
+		Collection:       "papers",
+		MaxIterations:    8,
+		ReportDepth:      ReportDepthComprehensive,
+		ExtractFacts:     true,
+		AllowedDomains:   []string{"arxiv.org"},
+		IterationDelay:   1500 * time.Millisecond,
+		SubTopics:        []string{"cost"},
+		ArxivResults:     4,
+		RecheckThreshold: 6,
+	}
+	data, err := json.Marshal(NewJobConfig(cfg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stored JobConfig
+	if err := json.Unmarshal(data, &stored); err != nil {
+		t.Fatal(err)
+	}
+	got := stored.ResearchConfig(Config{Collection: "default", ReportDepth: ReportDepthBrief})
+	if !reflect.DeepEqual(got, cfg) {
+		t.Errorf("ResearchConfig() = %+v, want %+v", got, cfg)
+	}
+}
@@ -0,0 +1,39 @@
+package research
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"github.com/mikeboe/research-helper/pkg/database"
+)
+
+// PersistState returns an OnStateUpdate hook that saves the state to the job's row,
+// so an interrupted run can be resumed with LoadJobState
+func PersistState(db *database.PostgresDB, jobID uuid.UUID, logger *slog.Logger) func(state *ResearchState) {
+	return func(state *ResearchState) {
+		stateJSON, err := json.Marshal(state)
+		if err != nil {
+			logger.Error("Failed to marshal state", "error", err)
+			return
+		}
+		if err := db.SaveJobState(context.Background(), jobID, stateJSON); err != nil {
+			logger.Error("Failed to save state to DB", "error", err)
+		}
+	}
+}
+
+// LoadJobState decodes the persisted state of a job. It returns nil when the job has
+// no state yet.
+func LoadJobState(rec *database.JobRecord) (*ResearchState, error) {
+	if len(rec.State) == 0 {
+		return nil, nil
+	}
+	var state ResearchState
+	if err := json.Unmarshal(rec.State, &state); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal state: %w", err)
+	}
+	return &state, nil
+}
@@ -0,0 +1,36 @@
+package research
+
+import "strings"
+
+// extractJSON strips markdown code fences and surrounding prose from an LLM response,
+// returning the JSON object or array it contains. Gemini occasionally wraps JSON in
+// ```json fences despite JSON mode. Content without a JSON value is returned trimmed.
+func extractJSON(content string) string {
+	s := strings.TrimSpace(content)
+
+	if start := strings.Index(s, "```"); start >= 0 {
+		body := s[start+3:]
+		// Drop the language tag on the opening fence line
+		if nl := strings.IndexByte(body, '\n'); nl >= 0 && !strings.ContainsAny(body[:nl], "{[") {
+			body = body[nl+1:]
+		}
+		if end := strings.Index(body, "```"); end >= 0 {
+			body = body[:end]
+		}
+		s = strings.TrimSpace(body)
+	}
+
+	start := strings.IndexAny(s, "{[")
+	if start < 0 {
+		return s
+	}
+	closing := byte('}')
+	if s[start] == '[' {
+		closing = ']'
+	}
+	end := strings.LastIndexByte(s, closing)
+	if end < start {
+		return s
+	}
+	return s[start : end+1]
+}
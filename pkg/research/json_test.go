@@ -0,0 +1,27 @@
+package research
+
+import "testing"
+
+func TestExtractJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"plain", `{"queries": ["a"]}`, `{"queries": ["a"]}`},
+		{"fenced", "```json\n{\"queries\": [\"a\"]}\n```", `{"queries": ["a"]}`},
+		{"fenced without tag", "```\n{\"a\": 1}\n```", `{"a": 1}`},
+		{"prose around", "Here are the scores:\n{\"scores\": []}\nLet me know!", `{"scores": []}`},
+		{"prose and fence", "Sure!\n```json\n{\"a\": {\"b\": 2}}\n```\nDone.", `{"a": {"b": 2}}`},
+		{"array", "```json\n[1, 2]\n```", `[1, 2]`},
+		{"no json", "  STOP  ", "STOP"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractJSON(tt.content); got != tt.want {
+				t.Errorf("extractJSON(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package research
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Metadata fields that can be embedded together with the chunk content
+const (
+	MetadataAuthors = "authors"
+	MetadataYear    = "year"
+	MetadataVenue   = "venue"
+)
+
+// ParseMetadataFields validates a list of metadata fields to embed. Entries are
+// lowercased and deduplicated; "author" is accepted for authors.
+func ParseMetadataFields(fields []string) ([]string, error) {
+	var parsed []string
+	seen := make(map[string]bool)
+	for _, f := range fields {
+		f = strings.ToLower(strings.TrimSpace(f))
+		if f == "author" {
+			f = MetadataAuthors
+		}
+		switch f {
+		case "":
+			continue
+		case MetadataAuthors, MetadataYear, MetadataVenue:
+		default:
+			return nil, fmt.Errorf("invalid metadata field %q: must be %s, %s or %s", f, MetadataAuthors, MetadataYear, MetadataVenue)
+		}
+		if !seen[f] {
+			seen[f] = true
+			parsed = append(parsed, f)
+		}
+	}
+	return parsed, nil
+}
+
+// publicationYear returns the year of a published date such as "2017-06-12T17:57:34Z"
+func publicationYear(published string) string {
+	if len(published) < 4 {
+		return ""
+	}
+	return published[:4]
+}
+
+// bibliographicMetadata returns the stored metadata for the known bibliographic fields of an item
+func bibliographicMetadata(item SearchResult) map[string]interface{} {
+	meta := make(map[string]interface{})
+	if len(item.Authors) > 0 {
+		meta["authors"] = strings.Join(item.Authors, ", ")
+	}
+	if year := publicationYear(item.Published); year != "" {
+		meta["year"] = year
+	}
+	if item.Published != "" {
+		meta["published"] = item.Published
+	}
+	if item.Venue != "" {
+		meta["venue"] = item.Venue
+	}
+	if item.Category != "" {
+		meta["category"] = item.Category
+	}
+	return meta
+}
+
+// metadataText renders the requested metadata fields of an item for embedding, one
+// "Field: value" line each. Fields the item has no value for are left out.
+func metadataText(item SearchResult, fields []string) string {
+	var lines []string
+	for _, f := range fields {
+		switch f {
+		case MetadataAuthors:
+			if len(item.Authors) > 0 {
+				lines = append(lines, "Authors: "+strings.Join(item.Authors, ", "))
+			}
+		case MetadataYear:
+			if year := publicationYear(item.Published); year != "" {
+				lines = append(lines, "Year: "+year)
+			}
+		case MetadataVenue:
+			if item.Venue != "" {
+				lines = append(lines, "Venue: "+item.Venue)
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// sourceMetadata returns the metadata stored with every chunk of an indexed source,
+// including what the extractors for its source type find in text
+func (e *ResearchEngine) sourceMetadata(item SearchResult, text string, fingerprint uint64) map[string]interface{} {
+	metadata := map[string]interface{}{
+		"source":      item.URL,
+		"title":       item.Title,
+		"fingerprint": formatFingerprint(fingerprint),
+	}
+	if e.JobID != "" {
+		metadata["job_id"] = e.JobID
+	}
+	for k, v := range bibliographicMetadata(item) {
+		metadata[k] = v
+	}
+	e.extractMetadata(item, text, metadata)
+	return metadata
+}
@@ -0,0 +1,46 @@
+package research
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMetadataFields(t *testing.T) {
+	got, err := ParseMetadataFields([]string{" Author", "year", "authors", ""})
+	if err != nil {
+		t.Fatalf("ParseMetadataFields: %v", err)
+	}
+	if want := []string{"authors", "year"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseMetadataFields() = %v, want %v", got, want)
+	}
+
+	if _, err := ParseMetadataFields([]string{"doi"}); err == nil {
+		t.Error("ParseMetadataFields(doi) succeeded, want error")
+	}
+}
+
+func TestMetadataText(t *testing.T) {
+	item := SearchResult{
+		Authors:   []string{"Ashish Vaswani", "Noam Shazeer"},
+		Published: "2017-06-12T17:57:34Z",
+	}
+
+	tests := []struct {
+		name   string
+		fields []string
+		want   string
+	}{
+		{"none", nil, ""},
+		{"all", []string{"authors", "year", "venue"}, "Authors: Ashish Vaswani, Noam Shazeer\nYear: 2017"},
+		{"order follows fields", []string{"year", "authors"}, "Year: 2017\nAuthors: Ashish Vaswani, Noam Shazeer"},
+		{"missing value", []string{"venue"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := metadataText(item, tt.fields); got != tt.want {
+				t.Errorf("metadataText(%v) = %q, want %q", tt.fields, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package research
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/mikeboe/research-helper/pkg/database"
+)
+
+// MaxIterationPause is the longest accepted Config.IterationDelay plus Config.IterationJitter.
+// The job state isn't saved while pausing, so the pause stays well below
+// database.OrphanedAfter; otherwise a paused job could be resumed by a second process.
+const MaxIterationPause = database.OrphanedAfter / 3
+
+// ValidateIterationPause checks that an iteration delay and jitter are not negative and add
+// up to at most MaxIterationPause
+func ValidateIterationPause(delay, jitter time.Duration) error {
+	if delay < 0 {
+		return fmt.Errorf("invalid iteration delay %v: must not be negative", delay)
+	}
+	if jitter < 0 {
+		return fmt.Errorf("invalid iteration jitter %v: must not be negative", jitter)
+	}
+	if delay+jitter > MaxIterationPause {
+		return fmt.Errorf("invalid iteration pause: delay %v plus jitter %v exceeds %v", delay, jitter, MaxIterationPause)
+	}
+	return nil
+}
+
+// iterationPause returns the pause before an iteration: delay plus jitter scaled by r in [0, 1)
+func iterationPause(delay, jitter time.Duration, r float64) time.Duration {
+	return delay + time.Duration(float64(max(jitter, 0))*r)
+}
+
+// pace waits Config.IterationDelay plus a random share of Config.IterationJitter before
+// the next iteration, so bursts of LLM and search calls stay under per-minute quotas.
+// It returns early with the context's error when ctx is cancelled.
+func (e *ResearchEngine) pace(ctx context.Context) error {
+	pause := iterationPause(e.Config.IterationDelay, e.Config.IterationJitter, rand.Float64())
+	if pause <= 0 {
+		return nil
+	}
+
+	e.Logger.Info("Pausing before next iteration", "pause", pause.Round(time.Millisecond), "next_iteration", e.State.Iteration+1)
+	timer := time.NewTimer(pause)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
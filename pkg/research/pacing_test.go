@@ -0,0 +1,25 @@
+package research
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIterationPause(t *testing.T) {
+	tests := []struct {
+		delay, jitter time.Duration
+		r             float64
+		want          time.Duration
+	}{
+		{0, 0, 0.5, 0},
+		{10 * time.Second, 0, 0.9, 10 * time.Second},
+		{10 * time.Second, 4 * time.Second, 0.5, 12 * time.Second},
+		{0, 4 * time.Second, 0.25, time.Second},
+		{time.Second, -time.Second, 0.5, time.Second},
+	}
+	for _, tt := range tests {
+		if got := iterationPause(tt.delay, tt.jitter, tt.r); got != tt.want {
+			t.Errorf("iterationPause(%v, %v, %v) = %v, want %v", tt.delay, tt.jitter, tt.r, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package research
+
+import (
+	"context"
+
+	"github.com/mikeboe/research-helper/pkg/database"
+	"github.com/mikeboe/research-helper/pkg/research/tools"
+)
+
+// storePages saves the raw OCR pages of a scraped source so they can be looked up
+// by page later. Figure IDs are always kept since the markdown references them;
+// the image data itself only when Config.StorePageImages is set.
+func (e *ResearchEngine) storePages(ctx context.Context, item SearchResult, result *tools.ScrapeResult) error {
+	pages := make([]database.DocumentPage, len(result.PageContents))
+	for i, p := range result.PageContents {
+		images := make([]database.PageImage, len(p.Images))
+		for j, img := range p.Images {
+			images[j] = database.PageImage{ID: img.ID}
+			if e.Config.StorePageImages {
+				images[j].ImageBase64 = img.ImageBase64
+			}
+		}
+		pages[i] = database.DocumentPage{
+			Collection: e.State.CollectionName,
+			Source:     item.URL,
+			PageIndex:  p.Index,
+			Markdown:   p.Markdown,
+			Images:     images,
+		}
+	}
+	return e.DB.SavePages(ctx, pages)
+}
@@ -0,0 +1,130 @@
+package research
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/mikeboe/research-helper/pkg/research/tools"
+)
+
+// streamPhases runs the source, filter and acquire phases as a pipeline. arXiv entries are
+// decoded as they arrive, each query's results are filtered as soon as its search completes
+// and relevant sources are scraped while other queries are still being searched. Filtering
+// takes one LLM call per query instead of one per iteration; a failed call drops only that
+// query's results.
+func (e *ResearchEngine) streamPhases(ctx context.Context, queries []string) ([]string, SourceStats, error) {
+	e.Logger.Info("Starting streaming source, filter and acquire phases")
+
+	queries = e.filterQueries(ctx, queries)
+	if len(queries) == 0 {
+		e.Logger.Warn("All queries rejected by quality filter")
+		return nil, SourceStats{}, nil
+	}
+
+	relevant := make(chan SearchResult)
+	dedup := newResultDeduper()
+	var dedupMu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, q := range queries {
+		wg.Add(1)
+		go func(query string) {
+			defer wg.Done()
+
+			var found []SearchResult
+			for entry, err := range tools.StreamArxiv(ctx, query, e.arxivResults()) {
+				if err != nil {
+					e.Logger.Error("Arxiv search failed", "query", query, "error", err)
+					break
+				}
+				result := arxivResult(entry)
+				result.Query = query
+				found = append(found, result)
+			}
+			e.Logger.Info("Arxiv search successful", "query", query, "count", len(found))
+
+			dedupMu.Lock()
+			unique := dedup.add(found)
+			dedupMu.Unlock()
+			kept := e.filterDomains(unique)
+			for _, item := range kept {
+				e.emitSource(SourceEvent{Type: SourceFound, Source: item})
+			}
+
+			yields := queryYields(e.State.Iteration, []string{query}, found, kept)
+			e.Logger.Info("Search query yield", "query", query, "found", yields[0].Found, "new", yields[0].Unique)
+			e.State.Mu.Lock()
+			e.State.QueryYields = append(e.State.QueryYields, yields...)
+			e.State.Mu.Unlock()
+
+			items, err := e.filterPhase(ctx, kept)
+			if err != nil {
+				e.Logger.Warn("Filtering failed, dropping the query's results", "query", query, "error", err)
+				return
+			}
+			for _, item := range items {
+				select {
+				case relevant <- item:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}(q)
+	}
+
+	go func() {
+		wg.Wait()
+		close(relevant)
+	}()
+
+	return e.acquireAndIndexStream(ctx, relevant)
+}
+
+// defaultArxivResults is how many arXiv results each search query gathers unless configured
+const defaultArxivResults = 2
+
+// MaxArxivResults is the largest accepted Config.ArxivResults. At tools.ArxivPageSize per
+// page and tools.ArxivPageDelay between pages, a query takes up to half a minute to gather.
+const MaxArxivResults = 500
+
+// ValidateArxivResults checks that a per-query result count is between 0 (the default)
+// and MaxArxivResults
+func ValidateArxivResults(n int) error {
+	if n < 0 || n > MaxArxivResults {
+		return fmt.Errorf("invalid arxiv results %d: must be between 0 and %d", n, MaxArxivResults)
+	}
+	return nil
+}
+
+// arxivResults returns the number of arXiv results to gather per search query
+func (e *ResearchEngine) arxivResults() int {
+	if e.Config.ArxivResults > 0 {
+		return e.Config.ArxivResults
+	}
+	return defaultArxivResults
+}
+
+// arxivResult converts an arXiv entry to a search result, as parseArxivOutput does for
+// the text rendering of SearchArxiv
+func arxivResult(entry tools.ArxivEntry) SearchResult {
+	result := SearchResult{
+		Title:     entry.Title,
+		Snippet:   entry.Summary,
+		Published: entry.Published,
+		Venue:     entry.JournalRef,
+		Category:  entry.PrimaryCategory.Term,
+	}
+	for _, a := range entry.Authors {
+		if a.Name != "" {
+			result.Authors = append(result.Authors, a.Name)
+		}
+	}
+	for _, link := range entry.Link {
+		if link.Type == "application/pdf" {
+			result.URL = link.Href
+			break
+		}
+	}
+	return result
+}
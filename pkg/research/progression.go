@@ -0,0 +1,39 @@
+package research
+
+import (
+	"fmt"
+	"strings"
+)
+
+// iterationOf returns the iteration the finding at index i was gathered in, or 0 when
+// it is unknown (state saved before iterations were recorded).
+func iterationOf(iterations []int, i int) int {
+	if i < len(iterations) {
+		return iterations[i]
+	}
+	return 0
+}
+
+// formatProgression renders findings grouped under a heading for the iteration they were
+// gathered in, in the order they were accumulated. iterations runs parallel to findings.
+func formatProgression(findings []string, iterations []int) string {
+	var sb strings.Builder
+	current := -1
+	for i, f := range findings {
+		if it := iterationOf(iterations, i); it != current {
+			current = it
+			if sb.Len() > 0 {
+				sb.WriteString("\n\n")
+			}
+			if it == 0 {
+				sb.WriteString("## Earlier findings\n\n")
+			} else {
+				sb.WriteString(fmt.Sprintf("## Iteration %d\n\n", it))
+			}
+		} else {
+			sb.WriteString("\n\n")
+		}
+		sb.WriteString(f)
+	}
+	return sb.String()
+}
@@ -0,0 +1,34 @@
+package research
+
+import "testing"
+
+func TestFormatProgression(t *testing.T) {
+	tests := []struct {
+		name       string
+		findings   []string
+		iterations []int
+		want       string
+	}{
+		{"empty", nil, nil, ""},
+		{
+			"grouped",
+			[]string{"a", "b", "c"},
+			[]int{1, 1, 2},
+			"## Iteration 1\n\na\n\nb\n\n## Iteration 2\n\nc",
+		},
+		{
+			"legacy state without iterations",
+			[]string{"a", "b"},
+			[]int{},
+			"## Earlier findings\n\na\n\nb",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatProgression(tt.findings, tt.iterations); got != tt.want {
+				t.Errorf("formatProgression() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
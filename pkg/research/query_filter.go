@@ -0,0 +1,119 @@
+package research
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/mikeboe/research-helper/pkg/research/schema"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// defaultMinQueryTokens is the minimum number of non-stopword tokens a query needs
+const defaultMinQueryTokens = 2
+
+// queryStopwords are words that carry no search intent on their own
+var queryStopwords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true,
+	"by": true, "for": true, "from": true, "how": true, "in": true, "is": true, "it": true,
+	"of": true, "on": true, "or": true, "the": true, "to": true, "what": true, "which": true,
+	"with": true, "why": true, "about": true, "into": true, "using": true, "via": true,
+	// Generic research terms that match almost everything on arXiv
+	"ai": true, "method": true, "methods": true, "approach": true, "approaches": true,
+	"paper": true, "papers": true, "research": true, "study": true, "studies": true,
+	"survey": true, "overview": true, "analysis": true, "model": true, "models": true,
+}
+
+// queryTokens returns the lowercased non-stopword tokens of a query
+func queryTokens(query string) []string {
+	words := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && r != '-'
+	})
+
+	var tokens []string
+	for _, w := range words {
+		w = strings.Trim(w, "-")
+		if w == "" || queryStopwords[w] {
+			continue
+		}
+		tokens = append(tokens, w)
+	}
+	return tokens
+}
+
+// checkQuery returns an error describing why a query is too vague to search for
+func checkQuery(query string, minTokens int) error {
+	if strings.TrimSpace(query) == "" {
+		return fmt.Errorf("empty query")
+	}
+	if tokens := queryTokens(query); len(tokens) < minTokens {
+		return fmt.Errorf("only %d meaningful terms, need at least %d", len(tokens), minTokens)
+	}
+	return nil
+}
+
+// filterQueries drops duplicate and low-quality queries before sourcing.
+// When Config.RefineQueries is set, rejected queries are rewritten by the LLM
+// and kept if the rewrite passes the same checks.
+func (e *ResearchEngine) filterQueries(ctx context.Context, queries []string) []string {
+	minTokens := e.Config.MinQueryTokens
+	if minTokens <= 0 {
+		minTokens = defaultMinQueryTokens
+	}
+
+	seen := make(map[string]bool)
+	var accepted, rejected []string
+	accept := func(q string) bool {
+		key := strings.ToLower(strings.TrimSpace(q))
+		if seen[key] {
+			return false
+		}
+		if err := checkQuery(q, minTokens); err != nil {
+			e.Logger.Warn("Rejected low-quality query", "query", q, "reason", err)
+			return false
+		}
+		seen[key] = true
+		accepted = append(accepted, strings.TrimSpace(q))
+		return true
+	}
+
+	for _, q := range queries {
+		if !accept(q) {
+			rejected = append(rejected, q)
+		}
+	}
+
+	if e.Config.RefineQueries && len(rejected) > 0 {
+		refined, err := e.refineQueries(ctx, rejected)
+		if err != nil {
+			e.Logger.Warn("Query refinement failed", "error", err)
+		}
+		for _, q := range refined {
+			if accept(q) {
+				e.Logger.Info("Accepted refined query", "query", q)
+			}
+		}
+	}
+
+	return accepted
+}
+
+// refineQueries asks the LLM to rewrite vague queries into specific ones
+func (e *ResearchEngine) refineQueries(ctx context.Context, queries []string) ([]string, error) {
+	systemPrompt := `You are a research librarian.
+The following search queries are too vague to return relevant academic papers.
+Rewrite each into a specific search query about the topic, using precise technical terms.`
+
+	input := fmt.Sprintf("Topic: %s\n\nVague Queries:\n- %s", e.State.Topic, strings.Join(queries, "\n- "))
+
+	queryResp, err := generateStructured[searchQueriesResponse](ctx, e, "refine_queries", []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeSystem, systemPrompt+"\n\n"+schema.ResponseFormat(searchQueriesSchema)),
+		llms.TextParts(llms.ChatMessageTypeHuman, input),
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return queryResp.Queries, nil
+}
@@ -0,0 +1,28 @@
+package research
+
+import "testing"
+
+func TestCheckQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		wantErr bool
+	}{
+		{"Specific query", "low-rank adaptation fine-tuning", false},
+		{"Two meaningful terms", "LoRA quantization", false},
+		{"Single word", "AI", true},
+		{"Generic research term", "methods", true},
+		{"Only stopwords", "what is the", true},
+		{"One term with stopwords", "a survey of transformers", true},
+		{"Empty", "   ", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkQuery(tt.query, defaultMinQueryTokens)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkQuery(%q) error = %v, wantErr %v", tt.query, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package research
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxPlanYields caps how many earlier query yields are shown to the planner
+const maxPlanYields = 12
+
+// QueryYield records how productive one search query was in an iteration
+type QueryYield struct {
+	Iteration int    `json:"iteration"`
+	Query     string `json:"query"`
+	Found     int    `json:"found"`   // Results returned by the search backend
+	Unique    int    `json:"unique"`  // Results left after deduplication and domain filtering
+	Indexed   int    `json:"indexed"` // Sources found by the query that were indexed
+}
+
+// queryYields counts the found and kept results of each query, in query order.
+// Results are attributed to queries through SearchResult.Query.
+func queryYields(iteration int, queries []string, found, kept []SearchResult) []QueryYield {
+	yields := make([]QueryYield, len(queries))
+	index := make(map[string]int, len(queries))
+	for i, q := range queries {
+		yields[i] = QueryYield{Iteration: iteration, Query: q}
+		index[q] = i
+	}
+	for _, r := range found {
+		if i, ok := index[r.Query]; ok {
+			yields[i].Found++
+		}
+	}
+	for _, r := range kept {
+		if i, ok := index[r.Query]; ok {
+			yields[i].Unique++
+		}
+	}
+	return yields
+}
+
+// countIndexed adds the indexed items to the yields of the iteration whose query found them
+func countIndexed(yields []QueryYield, iteration int, indexed []SearchResult) {
+	for _, item := range indexed {
+		for i := range yields {
+			if yields[i].Iteration == iteration && yields[i].Query == item.Query {
+				yields[i].Indexed++
+				break
+			}
+		}
+	}
+}
+
+// lastYields returns the n most recent yields
+func lastYields(yields []QueryYield, n int) []QueryYield {
+	if len(yields) > n {
+		return yields[len(yields)-n:]
+	}
+	return yields
+}
+
+// iterationYields returns the yields of one iteration
+func iterationYields(yields []QueryYield, iteration int) []QueryYield {
+	var out []QueryYield
+	for _, y := range yields {
+		if y.Iteration == iteration {
+			out = append(out, y)
+		}
+	}
+	return out
+}
+
+// formatQueryYields renders yields for the planning and reflection prompts, one query per line
+func formatQueryYields(yields []QueryYield) string {
+	var sb strings.Builder
+	for i, y := range yields {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(fmt.Sprintf("- Iteration %d: %q found %d, %d new, %d indexed", y.Iteration, y.Query, y.Found, y.Unique, y.Indexed))
+	}
+	return sb.String()
+}
@@ -0,0 +1,32 @@
+package research
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestQueryYields(t *testing.T) {
+	found := []SearchResult{
+		{Title: "A", Query: "q1"},
+		{Title: "B", Query: "q1"},
+		{Title: "A", Query: "q2"},
+	}
+	kept := found[:2]
+
+	yields := queryYields(2, []string{"q1", "q2", "q3"}, found, kept)
+	countIndexed(yields, 2, []SearchResult{{Title: "B", Query: "q1"}, {Title: "Seed"}})
+
+	want := []QueryYield{
+		{Iteration: 2, Query: "q1", Found: 2, Unique: 2, Indexed: 1},
+		{Iteration: 2, Query: "q2", Found: 1, Unique: 0},
+		{Iteration: 2, Query: "q3"},
+	}
+	if !reflect.DeepEqual(yields, want) {
+		t.Errorf("yields = %+v, want %+v", yields, want)
+	}
+
+	got := formatQueryYields(yields[:1])
+	if got != `- Iteration 2: "q1" found 2, 2 new, 1 indexed` {
+		t.Errorf("formatQueryYields() = %q", got)
+	}
+}
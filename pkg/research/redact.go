@@ -0,0 +1,171 @@
+package research
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/mikeboe/research-helper/pkg/research/schema"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// Kinds of personal data Config.Redact scrubs from indexed content
+const (
+	RedactEmails = "emails"
+	RedactPhones = "phones"
+	RedactNames  = "names" // Person names found by the fast model, plus the source's authors
+)
+
+// RedactKinds lists the kinds accepted by ParseRedactions
+var RedactKinds = []string{RedactEmails, RedactPhones, RedactNames}
+
+// ParseRedactions validates a list of kinds of personal data to redact. Entries are
+// lowercased and deduplicated; singular forms such as "email" are accepted.
+func ParseRedactions(kinds []string) ([]string, error) {
+	var parsed []string
+	for _, kind := range kinds {
+		k := strings.ToLower(strings.TrimSpace(kind))
+		if k == "" {
+			continue
+		}
+		if !strings.HasSuffix(k, "s") {
+			k += "s"
+		}
+		if !slices.Contains(RedactKinds, k) {
+			return nil, fmt.Errorf("invalid redaction %q: must be one of %s", kind, strings.Join(RedactKinds, ", "))
+		}
+		if !slices.Contains(parsed, k) {
+			parsed = append(parsed, k)
+		}
+	}
+	return parsed, nil
+}
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	// phonePattern matches numbers grouped like phone numbers, e.g. +49 30 1234 5678 or
+	// (555) 123-4567, but not years, ranges or decimals
+	phonePattern = regexp.MustCompile(`(?:\+\d{1,3}[\s.-]?)?(?:\(\d{2,4}\)\s?|\b\d{2,4}[\s.-])\d{3,4}[\s.-]\d{3,4}\b`)
+)
+
+// redactionPlaceholders replace the redacted spans, by kind
+var redactionPlaceholders = map[string]string{
+	RedactEmails: "[EMAIL]",
+	RedactPhones: "[PHONE]",
+	RedactNames:  "[NAME]",
+}
+
+// minNameRunes skips names too short to redact without hitting ordinary words
+const minNameRunes = 3
+
+// redactText replaces the personal data of the given kinds in text and counts the
+// replacements by kind. names are the person names to redact for RedactNames.
+func redactText(text string, kinds []string, names []string) (string, map[string]int) {
+	counts := make(map[string]int)
+	replace := func(kind string, pattern *regexp.Regexp) {
+		text = pattern.ReplaceAllStringFunc(text, func(string) string {
+			counts[kind]++
+			return redactionPlaceholders[kind]
+		})
+	}
+
+	// Emails first, so the phone pattern doesn't split digits out of them
+	if slices.Contains(kinds, RedactEmails) {
+		replace(RedactEmails, emailPattern)
+	}
+	if slices.Contains(kinds, RedactPhones) {
+		replace(RedactPhones, phonePattern)
+	}
+	if slices.Contains(kinds, RedactNames) {
+		if pattern := namesPattern(names); pattern != nil {
+			replace(RedactNames, pattern)
+		}
+	}
+	return text, counts
+}
+
+// namesPattern matches any of names as whole words, longest first so a full name is
+// replaced before its parts. It returns nil when there is nothing to match.
+func namesPattern(names []string) *regexp.Regexp {
+	var quoted []string
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if len([]rune(name)) >= minNameRunes {
+			quoted = append(quoted, regexp.QuoteMeta(name))
+		}
+	}
+	if len(quoted) == 0 {
+		return nil
+	}
+	sort.Slice(quoted, func(i, j int) bool { return len(quoted[i]) > len(quoted[j]) })
+	return regexp.MustCompile(`\b(?:` + strings.Join(quoted, "|") + `)\b`)
+}
+
+// redactChunks scrubs the personal data kinds of Config.Redact from chunks, returning the
+// redacted chunks and the replacement counts of each. Names are looked up once in the
+// source text and include the item's authors. A failed lookup fails the source rather than
+// storing unredacted names.
+func (e *ResearchEngine) redactChunks(ctx context.Context, item SearchResult, text string, chunks []string) ([]string, []map[string]int, error) {
+	var names []string
+	if slices.Contains(e.Config.Redact, RedactNames) {
+		var err error
+		if names, err = e.findNames(ctx, item, text); err != nil {
+			return nil, nil, err
+		}
+		names = append(names, item.Authors...)
+	}
+
+	redacted := make([]string, len(chunks))
+	counts := make([]map[string]int, len(chunks))
+	for i, chunk := range chunks {
+		redacted[i], counts[i] = redactText(chunk, e.Config.Redact, names)
+	}
+	return redacted, counts, nil
+}
+
+// namesSchema is the response of the name lookup
+var namesSchema = schema.Object(map[string]schema.Schema{
+	"names": schema.Array(schema.String()).Describe("Every person name as written in the text, including variants such as initials with surname"),
+})
+
+// findNames asks the fast model for the person names in the text. Long texts are read
+// window by window, so names past the first window are found as well.
+func (e *ResearchEngine) findNames(ctx context.Context, item SearchResult, text string) ([]string, error) {
+	var names []string
+	for _, window := range textWindows(text, maxExtractionRunes) {
+		found, err := e.findWindowNames(ctx, item, window)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range found {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	return names, nil
+}
+
+// findWindowNames looks up the person names in one window of a source's text
+func (e *ResearchEngine) findWindowNames(ctx context.Context, item SearchResult, text string) ([]string, error) {
+	systemPrompt := `You are a privacy filter.
+List the names of all people mentioned in the text, exactly as they are written, so they can be redacted.
+Include authors, participants, interviewees and people thanked. Do not list organizations, places, or methods and theorems named after people.`
+
+	input := fmt.Sprintf("Title: %s\n\nText:\n%s", item.Title, text)
+
+	type namesResponse struct {
+		Names []string `json:"names"`
+	}
+	resp, err := generateStructured[namesResponse](ctx, e, "find_names", []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeSystem, systemPrompt+"\n\n"+schema.ResponseFormat(namesSchema)),
+		llms.TextParts(llms.ChatMessageTypeHuman, input),
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("name detection failed: %w", err)
+	}
+	return resp.Names, nil
+}
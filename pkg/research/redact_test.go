@@ -0,0 +1,50 @@
+package research
+
+import (
+	"maps"
+	"slices"
+	"testing"
+)
+
+func TestParseRedactions(t *testing.T) {
+	got, err := ParseRedactions([]string{" Email", "phones", "emails", ""})
+	if err != nil || !slices.Equal(got, []string{RedactEmails, RedactPhones}) {
+		t.Errorf("ParseRedactions() = %v, %v", got, err)
+	}
+	if _, err := ParseRedactions([]string{"addresses"}); err == nil {
+		t.Error("expected an error for an unknown kind")
+	}
+}
+
+func TestRedactText(t *testing.T) {
+	tests := []struct {
+		name       string
+		text       string
+		kinds      []string
+		names      []string
+		want       string
+		wantCounts map[string]int
+	}{
+		{"email", "Contact jane.doe@example.org for data.", []string{RedactEmails}, nil,
+			"Contact [EMAIL] for data.", map[string]int{RedactEmails: 1}},
+		{"phones", "Call +49 30 1234 5678 or (555) 123-4567.", []string{RedactPhones}, nil,
+			"Call [PHONE] or [PHONE].", map[string]int{RedactPhones: 2}},
+		{"years and decimals kept", "From 2019-2021 accuracy rose from 0.812 to 0.845.", []string{RedactPhones}, nil,
+			"From 2019-2021 accuracy rose from 0.812 to 0.845.", map[string]int{}},
+		{"names longest first", "Jane Doe and Doe et al. thank Al.", []string{RedactNames}, []string{"Doe", "Jane Doe", "Al"},
+			"[NAME] and [NAME] et al. thank Al.", map[string]int{RedactNames: 2}},
+		{"kind not requested", "Mail jane@example.org", []string{RedactPhones}, nil,
+			"Mail jane@example.org", map[string]int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, counts := redactText(tt.text, tt.kinds, tt.names)
+			if got != tt.want {
+				t.Errorf("redactText() = %q, want %q", got, tt.want)
+			}
+			if !maps.Equal(counts, tt.wantCounts) {
+				t.Errorf("counts = %v, want %v", counts, tt.wantCounts)
+			}
+		})
+	}
+}
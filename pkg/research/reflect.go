@@ -0,0 +1,16 @@
+package research
+
+// priorFindings returns up to lookback of the most recent findings accumulated before
+// the current iteration. all is the accumulated list, whose last recent entries belong
+// to the current iteration. A negative lookback returns every prior finding.
+func priorFindings[T any](all []T, recent, lookback int) []T {
+	prior := len(all) - recent
+	if prior <= 0 || lookback == 0 {
+		return nil
+	}
+	start := 0
+	if lookback > 0 && lookback < prior {
+		start = prior - lookback
+	}
+	return all[start:prior]
+}
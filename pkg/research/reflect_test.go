@@ -0,0 +1,31 @@
+package research
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPriorFindings(t *testing.T) {
+	all := []string{"a", "b", "c", "d", "e"}
+
+	tests := []struct {
+		name     string
+		recent   int
+		lookback int
+		want     []string
+	}{
+		{"disabled", 2, 0, nil},
+		{"window", 2, 2, []string{"b", "c"}},
+		{"window larger than history", 2, 10, []string{"a", "b", "c"}},
+		{"all", 2, -1, []string{"a", "b", "c"}},
+		{"first iteration", 5, 3, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := priorFindings(all, tt.recent, tt.lookback); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("priorFindings(%d, %d) = %v, want %v", tt.recent, tt.lookback, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package research
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/mikeboe/research-helper/pkg/research/tools"
+	"github.com/mikeboe/research-helper/pkg/vectorstore"
+)
+
+// ScrapeFailure is a source that was indexed from its snippet because its full text could
+// not be scraped
+type ScrapeFailure struct {
+	URL       string `json:"url"`
+	Error     string `json:"error"`
+	Iteration int    `json:"iteration"`
+}
+
+// ReindexResult counts the outcome of ReindexFailed
+type ReindexResult struct {
+	Retried   int `json:"retried"`
+	Recovered int `json:"recovered"` // Scraped and reindexed with their full text
+	Failed    int `json:"failed"`    // Still failing; kept in ScrapeFailures with the new error
+}
+
+// ReindexFailed retries scraping the sources in State.ScrapeFailures and replaces their
+// snippet-only chunks with the full text. Sources that fail again stay recorded with the
+// latest error, so the retry can be repeated later. The state is saved afterwards.
+func (e *ResearchEngine) ReindexFailed(ctx context.Context) (ReindexResult, error) {
+	var result ReindexResult
+	store, err := vectorstore.NewPGVectorStore(e.DB.Pool, e.State.CollectionName)
+	if err != nil {
+		return result, err
+	}
+	if err := e.loadFingerprints(ctx); err != nil {
+		e.Logger.Warn("Failed to load content fingerprints, deduplication limited to this run", "error", err)
+	}
+
+	var remaining []ScrapeFailure
+	for i, failure := range e.State.ScrapeFailures {
+		if err := ctx.Err(); err != nil {
+			remaining = append(remaining, e.State.ScrapeFailures[i:]...)
+			e.State.ScrapeFailures = remaining
+			e.saveState()
+			return result, err
+		}
+
+		idx := slices.IndexFunc(e.State.IndexedItems, func(item SearchResult) bool { return item.URL == failure.URL })
+		if idx < 0 {
+			e.Logger.Warn("Dropping scrape failure of a source that is no longer indexed", "url", failure.URL)
+			continue
+		}
+		result.Retried++
+
+		item := e.State.IndexedItems[idx]
+		if err := e.reindexSource(ctx, store, &item); err != nil {
+			e.Logger.Warn("Source still failing", "title", item.Title, "url", item.URL, "error", err)
+			failure.Error = err.Error()
+			remaining = append(remaining, failure)
+			result.Failed++
+			continue
+		}
+		e.State.IndexedItems[idx] = item
+		result.Recovered++
+		e.Logger.Info("Reindexed source with its full text", "title", item.Title, "url", item.URL)
+	}
+
+	e.State.ScrapeFailures = remaining
+	e.saveState()
+	if result.Recovered > 0 {
+		e.pruneCollection(ctx)
+	}
+	return result, nil
+}
+
+// reindexSource scrapes a source and, if that succeeds, replaces its indexed chunks. The
+// full text passes the same safety check and near-duplicate check as newly acquired
+// sources, and the snippet chunks are only deleted once it is indexed, so a failure
+// leaves the source searchable by its snippet.
+func (e *ResearchEngine) reindexSource(ctx context.Context, store *vectorstore.PGVectorStore, item *SearchResult) error {
+	scraped, err := tools.ScrapePDF(item.URL, tools.ScrapeOptions{
+		MaxPages: e.Config.MaxPDFPages,
+		MaxBytes: e.Config.MaxPDFBytes,
+	})
+	if err != nil {
+		return err
+	}
+
+	var safetyReview []string
+	if len(e.Config.SafetyBlock) > 0 {
+		verdict, err := e.checkSafety(ctx, *item, scraped.Text)
+		if err != nil {
+			return err
+		}
+		if len(verdict.Blocked) > 0 {
+			return fmt.Errorf("blocked by safety check: %s", verdict.reason())
+		}
+		safetyReview = verdict.Borderline
+	}
+
+	// Only this job's chunks of the source are replaced, others sharing the collection stay
+	filter := map[string]interface{}{"source": item.URL}
+	if e.JobID != "" {
+		filter["job_id"] = e.JobID
+	}
+	snippets, err := store.GetContentByMetadata(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("failed to read snippet chunks: %w", err)
+	}
+
+	// The snippet's own fingerprint mustn't make the full text a near-duplicate of itself
+	previous := chunkFingerprints(snippets)
+	for _, fp := range previous {
+		e.releaseFingerprint(fp)
+	}
+	restore := func() {
+		for _, fp := range previous {
+			e.claimFingerprint(fp)
+		}
+	}
+	fingerprint := Fingerprint(scraped.Text)
+	if match, ok := e.claimFingerprint(fingerprint); !ok {
+		restore()
+		return fmt.Errorf("full text is a near-duplicate of an indexed source (fingerprint %s)", formatFingerprint(match))
+	}
+
+	scrapedItem := *item
+	scrapedItem.Scraped = true
+	metadata := e.sourceMetadata(scrapedItem, scraped.Text, fingerprint)
+	if scraped.Truncated {
+		metadata["truncated"] = true
+		metadata["max_pages"] = e.Config.MaxPDFPages
+	}
+	if safetyReview != nil {
+		metadata["safety_review"] = safetyReview
+	}
+	if err := e.indexDocument(ctx, scrapedItem, scraped.Text, metadata); err != nil {
+		e.releaseFingerprint(fingerprint)
+		restore()
+		return fmt.Errorf("failed to index: %w", err)
+	}
+	*item = scrapedItem
+
+	// The full-text chunks carry the new fingerprint; everything else is the snippet
+	filter["$not"] = map[string]interface{}{"fingerprint": formatFingerprint(fingerprint)}
+	if _, err := store.DeleteByMetadata(ctx, filter); err != nil {
+		e.Logger.Warn("Failed to delete snippet chunks of reindexed source", "title", item.Title, "error", err)
+	}
+
+	if e.Config.StorePages {
+		if err := e.storePages(ctx, *item, scraped); err != nil {
+			e.Logger.Warn("Failed to store pages", "title", item.Title, "error", err)
+		}
+	}
+	return nil
+}
+
+// chunkFingerprints returns the distinct fingerprints recorded in the metadata of chunks
+func chunkFingerprints(chunks []vectorstore.Document) []uint64 {
+	var fps []uint64
+	for _, chunk := range chunks {
+		s, _ := chunk.Metadata["fingerprint"].(string)
+		fp, err := parseFingerprint(s)
+		if err != nil || fp == 0 || slices.Contains(fps, fp) {
+			continue
+		}
+		fps = append(fps, fp)
+	}
+	return fps
+}
+
+// saveState calls OnStateUpdate if set
+func (e *ResearchEngine) saveState() {
+	if e.OnStateUpdate != nil {
+		e.OnStateUpdate(e.State)
+	}
+}
@@ -0,0 +1,87 @@
+package research
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mikeboe/research-helper/pkg/research/schema"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// RelevanceResponse is the LLM verdict on a scraped source
+type RelevanceResponse struct {
+	Score  int    `json:"score"`
+	Reason string `json:"reason"`
+}
+
+// checkRelevance scores the full text of a source against the topic from 0-10.
+// The filter phase only sees abstracts, which sometimes oversell a paper.
+func (e *ResearchEngine) checkRelevance(ctx context.Context, item SearchResult, text string) (RelevanceResponse, error) {
+	systemPrompt := `You are a research filter.
+Based on the full text of the paper, evaluate how relevant it is to the research topic.
+Judge the actual content, not the claims made in the abstract.
+Score the paper from 0-10 (10 being most relevant) and give a one sentence reason.`
+
+	runes := []rune(text)
+	if len(runes) > maxExtractionRunes {
+		text = string(runes[:maxExtractionRunes])
+	}
+
+	input := fmt.Sprintf("Topic: %s\n\nPaper Title: %s\n\nPaper Text:\n%s", e.State.Topic, item.Title, text)
+
+	relevanceSchema := schema.Object(map[string]schema.Schema{
+		"score":  schema.Integer(),
+		"reason": schema.String(),
+	})
+
+	resp, err := generateStructured[RelevanceResponse](ctx, e, "recheck_relevance", []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeSystem, systemPrompt+"\n\n"+schema.ResponseFormat(relevanceSchema)),
+		llms.TextParts(llms.ChatMessageTypeHuman, input),
+	}, nil)
+	if err != nil {
+		return RelevanceResponse{}, fmt.Errorf("relevance check failed: %w", err)
+	}
+	return resp, nil
+}
+
+// filterKeepScore is the lowest filter score a paper is kept with
+const filterKeepScore = 7
+
+// filterScore is the score the filter phase gives the paper with the given ID
+type filterScore struct {
+	ID    int `json:"id"`
+	Score int `json:"score"`
+}
+
+// filterScoreReport describes how the scores returned by the model mapped onto the papers
+type filterScoreReport struct {
+	Invalid    []int // IDs outside the list of papers, which the model invented
+	Duplicates []int // IDs scored more than once; the first score is used
+	Missing    []int // Papers the model didn't score, which default to 0
+}
+
+// resolveFilterScores maps the scores returned by the model onto the n papers, so every
+// paper has exactly one score. Scores are clamped to 0-10.
+func resolveFilterScores(items []filterScore, n int) ([]int, filterScoreReport) {
+	var report filterScoreReport
+	scores := make([]int, n)
+	scored := make([]bool, n)
+	for _, item := range items {
+		if item.ID < 0 || item.ID >= n {
+			report.Invalid = append(report.Invalid, item.ID)
+			continue
+		}
+		if scored[item.ID] {
+			report.Duplicates = append(report.Duplicates, item.ID)
+			continue
+		}
+		scored[item.ID] = true
+		scores[item.ID] = min(max(item.Score, 0), 10)
+	}
+	for id, ok := range scored {
+		if !ok {
+			report.Missing = append(report.Missing, id)
+		}
+	}
+	return scores, report
+}
@@ -0,0 +1,35 @@
+package research
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestResolveFilterScores(t *testing.T) {
+	tests := []struct {
+		name       string
+		items      []filterScore
+		n          int
+		want       []int
+		invalid    []int
+		duplicates []int
+		missing    []int
+	}{
+		{"all scored", []filterScore{{0, 8}, {1, 3}}, 2, []int{8, 3}, nil, nil, nil},
+		{"invented ids", []filterScore{{0, 9}, {-1, 10}, {2, 10}, {1, 7}}, 2, []int{9, 7}, []int{-1, 2}, nil, nil},
+		{"missing defaults to 0", []filterScore{{1, 8}}, 3, []int{0, 8, 0}, nil, nil, []int{0, 2}},
+		{"duplicates keep first", []filterScore{{0, 2}, {0, 9}}, 1, []int{2}, nil, []int{0}, nil},
+		{"scores clamped", []filterScore{{0, 15}, {1, -3}}, 2, []int{10, 0}, nil, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, report := resolveFilterScores(tt.items, tt.n)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("scores = %v, want %v", got, tt.want)
+			}
+			if !slices.Equal(report.Invalid, tt.invalid) || !slices.Equal(report.Duplicates, tt.duplicates) || !slices.Equal(report.Missing, tt.missing) {
+				t.Errorf("report = %+v", report)
+			}
+		})
+	}
+}
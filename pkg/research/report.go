@@ -0,0 +1,119 @@
+package research
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ReportDelta is a piece of the final report streamed to OnReportDelta while it is generated
+type ReportDelta struct {
+	Text    string
+	Restart bool // Generation was retried; discard the text received so far
+}
+
+// ReportDepth controls the length and level of detail of the final report
+type ReportDepth string
+
+const (
+	ReportDepthBrief         ReportDepth = "brief"
+	ReportDepthStandard      ReportDepth = "standard"
+	ReportDepthComprehensive ReportDepth = "comprehensive"
+)
+
+// ParseReportDepth validates a report depth name. An empty string selects the standard depth.
+func ParseReportDepth(s string) (ReportDepth, error) {
+	switch ReportDepth(s) {
+	case "":
+		return ReportDepthStandard, nil
+	case ReportDepthBrief, ReportDepthStandard, ReportDepthComprehensive:
+		return ReportDepth(s), nil
+	default:
+		return "", fmt.Errorf("invalid report depth %q: must be one of %s, %s, %s",
+			s, ReportDepthBrief, ReportDepthStandard, ReportDepthComprehensive)
+	}
+}
+
+// reportConstraints returns the length and structure instructions for the report prompt
+func reportConstraints(depth ReportDepth) string {
+	switch depth {
+	case ReportDepthBrief:
+		return `Length: a one-page brief of roughly 400-600 words.
+Structure: Markdown with Summary, Key Findings (bullet points, one or two sentences each) and Conclusion.
+Keep each section short and only cover the most important findings.`
+	case ReportDepthComprehensive:
+		return `Length: a full literature review of at least 4000 words.
+Structure: Markdown with Introduction, Background, Key Findings (organised by theme, with a subsection per theme), Methodology/Discussion (compare approaches and their trade-offs), Open Problems and Conclusion.
+Discuss every source in depth and relate findings to each other.`
+	default:
+		return `Length: roughly 1500-2500 words.
+Structure: Markdown with Introduction, Key Findings, Methodology/Discussion and Conclusion.`
+	}
+}
+
+// reportSections returns the section names reportConstraints asks for at a depth.
+// Alternatives for one section are separated by a slash.
+func reportSections(depth ReportDepth) []string {
+	switch depth {
+	case ReportDepthBrief:
+		return []string{"Summary", "Key Findings", "Conclusion"}
+	case ReportDepthComprehensive:
+		return []string{"Introduction", "Background", "Key Findings", "Methodology/Discussion", "Open Problems", "Conclusion"}
+	default:
+		return []string{"Introduction", "Key Findings", "Methodology/Discussion", "Conclusion"}
+	}
+}
+
+// minReportWords rejects refusals and error messages returned in place of a report
+const minReportWords = 100
+
+// validateReportLength checks that a generated report has at least minReportWords words
+func validateReportLength(report string) error {
+	if words := len(strings.Fields(report)); words < minReportWords {
+		return fmt.Errorf("report has %d words, expected at least %d", words, minReportWords)
+	}
+	return nil
+}
+
+// validateReport checks that a generated report is long enough and has at least half
+// of the sections requested for its depth. Models rename or merge sections, so an
+// exact match is not required.
+func validateReport(report string, depth ReportDepth) error {
+	if err := validateReportLength(report); err != nil {
+		return err
+	}
+
+	var headings []string
+	for _, line := range strings.Split(report, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "#") {
+			headings = append(headings, strings.ToLower(strings.TrimLeft(line, "# ")))
+		}
+	}
+	if len(headings) == 0 {
+		return fmt.Errorf("report has no markdown headings")
+	}
+
+	expected := reportSections(depth)
+	var missing []string
+	for _, section := range expected {
+		if !hasHeading(headings, strings.Split(section, "/")) {
+			missing = append(missing, section)
+		}
+	}
+	if len(missing)*2 > len(expected) {
+		return fmt.Errorf("report is missing sections: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// hasHeading reports whether any heading contains one of names, case-insensitively
+func hasHeading(headings, names []string) bool {
+	for _, h := range headings {
+		for _, name := range names {
+			if strings.Contains(h, strings.ToLower(name)) {
+				return true
+			}
+		}
+	}
+	return false
+}
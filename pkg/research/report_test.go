@@ -0,0 +1,58 @@
+package research
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateReport(t *testing.T) {
+	body := strings.Repeat("word ", minReportWords)
+	tests := []struct {
+		name    string
+		report  string
+		depth   ReportDepth
+		wantErr bool
+	}{
+		{"too short", "# Summary\n\nI cannot write this report.", ReportDepthBrief, true},
+		{"no headings", body, ReportDepthStandard, true},
+		{
+			"all sections",
+			"# Report\n## Introduction\n" + body + "\n## Key Findings\n## Discussion\n## Conclusion\n## Bibliography",
+			ReportDepthStandard, false,
+		},
+		{
+			"renamed section tolerated",
+			"## Overview\n" + body + "\n## Key Findings\n## Methodology\n## Conclusion",
+			ReportDepthStandard, false,
+		},
+		{
+			"most sections missing",
+			"## Overview\n" + body + "\n## Conclusion",
+			ReportDepthComprehensive, true,
+		},
+		{
+			"case insensitive",
+			"### SUMMARY\n" + body + "\n### key findings\n",
+			ReportDepthBrief, false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateReport(tt.report, tt.depth)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateReport() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateReportLength(t *testing.T) {
+	// Missing sections don't matter to the fallback check, only refusals do
+	if err := validateReportLength("## Overview\n" + strings.Repeat("word ", minReportWords)); err != nil {
+		t.Errorf("report without the requested sections rejected: %v", err)
+	}
+	if err := validateReportLength("I cannot write this report."); err == nil {
+		t.Error("refusal accepted")
+	}
+}
@@ -0,0 +1,158 @@
+package research
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mikeboe/research-helper/pkg/research/schema"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// Content categories the safety check classifies sources into
+const (
+	SafetyHate       = "hate"
+	SafetyHarassment = "harassment"
+	SafetySexual     = "sexual"
+	SafetyViolence   = "violence"
+	SafetySelfHarm   = "self_harm"
+	SafetyDangerous  = "dangerous" // Instructions for weapons, drugs or other serious harm
+)
+
+// SafetyCategories lists the categories accepted by ParseSafetyCategories
+var SafetyCategories = []string{SafetyHate, SafetyHarassment, SafetySexual, SafetyViolence, SafetySelfHarm, SafetyDangerous}
+
+// Severities the classifier assigns to a category
+const (
+	severityNone   = "none"
+	severityLow    = "low"
+	severityMedium = "medium" // Borderline: indexed, but tagged for review
+	severityHigh   = "high"   // Blocked when the category is in Config.SafetyBlock
+)
+
+// ParseSafetyCategories validates a list of categories to block. Entries are lowercased
+// and deduplicated; "self-harm" is accepted for self_harm.
+func ParseSafetyCategories(categories []string) ([]string, error) {
+	var parsed []string
+	for _, c := range categories {
+		c = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(c)), "-", "_")
+		if c == "" || slices.Contains(parsed, c) {
+			continue
+		}
+		if !slices.Contains(SafetyCategories, c) {
+			return nil, fmt.Errorf("invalid safety category %q: must be one of %s", c, strings.Join(SafetyCategories, ", "))
+		}
+		parsed = append(parsed, c)
+	}
+	return parsed, nil
+}
+
+// SafetyRating is the classifier verdict for one category
+type SafetyRating struct {
+	Category string `json:"category"`
+	Severity string `json:"severity"`
+	Reason   string `json:"reason"`
+}
+
+// safetyVerdict is the outcome of a safety check against the blocked categories
+type safetyVerdict struct {
+	Blocked    []SafetyRating // Blocked categories rated high
+	Borderline []string       // Blocked categories rated medium, stored as safety_review metadata
+}
+
+// judgeSafety applies the blocked categories to the classifier ratings. Ratings for
+// categories that aren't blocked are ignored.
+func judgeSafety(ratings []SafetyRating, block []string) safetyVerdict {
+	var v safetyVerdict
+	for _, r := range ratings {
+		category := strings.ToLower(strings.TrimSpace(r.Category))
+		if !slices.Contains(block, category) {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(r.Severity)) {
+		case severityHigh:
+			v.Blocked = append(v.Blocked, r)
+		case severityMedium:
+			if !slices.Contains(v.Borderline, category) {
+				v.Borderline = append(v.Borderline, category)
+			}
+		}
+	}
+	return v
+}
+
+// reason summarizes why content was blocked, for the log
+func (v safetyVerdict) reason() string {
+	parts := make([]string, len(v.Blocked))
+	for i, r := range v.Blocked {
+		parts[i] = fmt.Sprintf("%s: %s", r.Category, r.Reason)
+	}
+	return strings.Join(parts, "; ")
+}
+
+var safetySchema = schema.Object(map[string]schema.Schema{
+	"ratings": schema.Array(schema.Object(map[string]schema.Schema{
+		"category": schema.String().Describe("One of the listed categories"),
+		"severity": schema.String().Describe("none, low, medium or high"),
+		"reason":   schema.String().Describe("One sentence on what in the text led to the rating"),
+	})).Describe("One rating per listed category"),
+})
+
+// checkSafety classifies the text of a source into the blocked categories with the fast model.
+// Long texts are classified window by window, so disallowed content past the first window
+// isn't indexed unchecked; the check stops at the first window that is blocked.
+func (e *ResearchEngine) checkSafety(ctx context.Context, item SearchResult, text string) (safetyVerdict, error) {
+	var verdict safetyVerdict
+	for _, window := range textWindows(text, maxExtractionRunes) {
+		v, err := e.classifySafety(ctx, item, window)
+		if err != nil {
+			return safetyVerdict{}, err
+		}
+		verdict.Blocked = append(verdict.Blocked, v.Blocked...)
+		for _, category := range v.Borderline {
+			if !slices.Contains(verdict.Borderline, category) {
+				verdict.Borderline = append(verdict.Borderline, category)
+			}
+		}
+		if len(verdict.Blocked) > 0 {
+			break
+		}
+	}
+	return verdict, nil
+}
+
+// classifySafety classifies one window of a source's text
+func (e *ResearchEngine) classifySafety(ctx context.Context, item SearchResult, text string) (safetyVerdict, error) {
+	systemPrompt := fmt.Sprintf(`You are a content safety classifier for a research library.
+Rate how strongly the text contains each of these categories of disallowed content: %s.
+Use "none" when the category is absent, "low" for passing or academic mentions (e.g. a study about hate speech), "medium" for borderline material and "high" for content that clearly is disallowed material itself.`,
+		strings.Join(e.Config.SafetyBlock, ", "))
+
+	input := fmt.Sprintf("Source Title: %s\n\nText:\n%s", item.Title, text)
+
+	type safetyResponse struct {
+		Ratings []SafetyRating `json:"ratings"`
+	}
+	resp, err := generateStructured[safetyResponse](ctx, e, "safety_check", []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeSystem, systemPrompt+"\n\n"+schema.ResponseFormat(safetySchema)),
+		llms.TextParts(llms.ChatMessageTypeHuman, input),
+	}, nil)
+	if err != nil {
+		return safetyVerdict{}, fmt.Errorf("safety check failed: %w", err)
+	}
+	return judgeSafety(resp.Ratings, e.Config.SafetyBlock), nil
+}
+
+// textWindows splits text into consecutive windows of at most size runes
+func textWindows(text string, size int) []string {
+	runes := []rune(text)
+	if len(runes) <= size {
+		return []string{text}
+	}
+	windows := make([]string, 0, (len(runes)+size-1)/size)
+	for start := 0; start < len(runes); start += size {
+		windows = append(windows, string(runes[start:min(start+size, len(runes))]))
+	}
+	return windows
+}
@@ -0,0 +1,57 @@
+package research
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSafetyCategories(t *testing.T) {
+	got, err := ParseSafetyCategories([]string{"Hate", " self-harm", "hate", ""})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{SafetyHate, SafetySelfHarm}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if _, err := ParseSafetyCategories([]string{"spam"}); err == nil {
+		t.Error("unknown category accepted")
+	}
+}
+
+func TestJudgeSafety(t *testing.T) {
+	ratings := []SafetyRating{
+		{Category: "violence", Severity: "high", Reason: "graphic"},
+		{Category: "Hate", Severity: "Medium"},
+		{Category: "sexual", Severity: "high"}, // Not blocked
+		{Category: "dangerous", Severity: "low"},
+	}
+
+	v := judgeSafety(ratings, []string{SafetyViolence, SafetyHate, SafetyDangerous})
+	if len(v.Blocked) != 1 || v.Blocked[0].Category != "violence" {
+		t.Errorf("Blocked = %+v, want violence", v.Blocked)
+	}
+	if !reflect.DeepEqual(v.Borderline, []string{SafetyHate}) {
+		t.Errorf("Borderline = %q, want hate", v.Borderline)
+	}
+	if got := v.reason(); got != "violence: graphic" {
+		t.Errorf("reason = %q", got)
+	}
+}
+
+func TestTextWindows(t *testing.T) {
+	tests := []struct {
+		text string
+		size int
+		want []string
+	}{
+		{"", 3, []string{""}},
+		{"abc", 3, []string{"abc"}},
+		{"abcdefg", 3, []string{"abc", "def", "g"}},
+		{"äöüß", 2, []string{"äö", "üß"}},
+	}
+	for _, tt := range tests {
+		if got := textWindows(tt.text, tt.size); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("textWindows(%q, %d) = %q, want %q", tt.text, tt.size, got, tt.want)
+		}
+	}
+}
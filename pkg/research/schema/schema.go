@@ -0,0 +1,70 @@
+// Package schema builds the JSON schemas the research phases include in their prompts
+// to request structured output from the LLM.
+package schema
+
+import (
+	"encoding/json"
+	"slices"
+)
+
+// Schema is a JSON schema node
+type Schema map[string]any
+
+// Object returns an object schema with the given properties. Every property is required
+// unless listed in optional.
+func Object(properties map[string]Schema, optional ...string) Schema {
+	skip := make(map[string]bool, len(optional))
+	for _, name := range optional {
+		skip[name] = true
+	}
+	var required []string
+	for name := range properties {
+		if !skip[name] {
+			required = append(required, name)
+		}
+	}
+	// Keep the required list stable, so prompts (and their traces) don't change between calls
+	slices.Sort(required)
+
+	return Schema{"type": "object", "properties": properties, "required": required}
+}
+
+// Array returns an array schema of items
+func Array(items Schema) Schema {
+	return Schema{"type": "array", "items": items}
+}
+
+// String returns a string schema
+func String() Schema {
+	return Schema{"type": "string"}
+}
+
+// Integer returns an integer schema
+func Integer() Schema {
+	return Schema{"type": "integer"}
+}
+
+// Describe returns a copy of s with a description for the LLM
+func (s Schema) Describe(description string) Schema {
+	out := make(Schema, len(s)+1)
+	for k, v := range s {
+		out[k] = v
+	}
+	out["description"] = description
+	return out
+}
+
+// JSON returns the schema as indented JSON
+func (s Schema) JSON() string {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		// Schemas only hold strings, slices and nested schemas
+		panic(err)
+	}
+	return string(b)
+}
+
+// ResponseFormat returns the prompt section asking for a JSON response matching s
+func ResponseFormat(s Schema) string {
+	return "# Response Format:\n\nReturn the JSON object directly without any formatting or additional text. The JSON object should have the following structure as defined in the schema. Make sure to answer in valid json and include all necessary properties:" + s.JSON()
+}
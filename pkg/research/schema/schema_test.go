@@ -0,0 +1,46 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestObject(t *testing.T) {
+	s := Object(map[string]Schema{
+		"score":  Integer(),
+		"reason": String().Describe("One sentence"),
+		"notes":  Array(String()),
+	}, "notes")
+
+	var decoded map[string]any
+	if err := json.Unmarshal([]byte(s.JSON()), &decoded); err != nil {
+		t.Fatalf("JSON() is not valid JSON: %v", err)
+	}
+	if got, want := decoded["required"], []any{"reason", "score"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("required = %v, want %v", got, want)
+	}
+	props := decoded["properties"].(map[string]any)
+	if got := props["reason"].(map[string]any)["description"]; got != "One sentence" {
+		t.Errorf("reason description = %v, want %q", got, "One sentence")
+	}
+	if got := props["notes"].(map[string]any)["items"].(map[string]any)["type"]; got != "string" {
+		t.Errorf("notes item type = %v, want string", got)
+	}
+}
+
+func TestDescribeCopies(t *testing.T) {
+	base := String()
+	_ = base.Describe("x")
+	if _, ok := base["description"]; ok {
+		t.Error("Describe modified the receiver")
+	}
+}
+
+func TestResponseFormat(t *testing.T) {
+	got := ResponseFormat(Object(map[string]Schema{"queries": Array(String())}))
+	if !strings.HasPrefix(got, "# Response Format:") || !strings.Contains(got, `"queries"`) {
+		t.Errorf("ResponseFormat() = %q", got)
+	}
+}
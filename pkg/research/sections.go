@@ -0,0 +1,148 @@
+package research
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/mikeboe/research-helper/pkg/research/schema"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// sectionBreak matches the lines that start a section: markdown headings and the page
+// markers ScrapePDF puts between OCR pages
+var sectionBreak = regexp.MustCompile(`^(#{1,6}\s+\S.*|- Page \d+ -)$`)
+
+const (
+	// sectionPreviewRunes is how much of each section the selection prompt shows
+	sectionPreviewRunes = 300
+	// maxListedSections caps the sections offered for selection; later ones are never selected
+	maxListedSections = 300
+)
+
+// section is a part of a source's text starting at a heading or page marker
+type section struct {
+	Title string
+	Text  string // Including the heading line
+}
+
+// splitSections splits text at headings and page markers. Text before the first break
+// forms an untitled section.
+func splitSections(text string) []section {
+	var sections []section
+	var current section
+	var body strings.Builder
+	flush := func() {
+		current.Text = body.String()
+		if strings.TrimSpace(current.Text) != "" {
+			sections = append(sections, current)
+		}
+		body.Reset()
+	}
+
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if trimmed := strings.TrimSpace(line); sectionBreak.MatchString(trimmed) {
+			flush()
+			current = section{Title: strings.TrimSpace(strings.TrimLeft(trimmed, "#"))}
+			if !strings.HasPrefix(trimmed, "#") {
+				current.Title = strings.Trim(trimmed, "- ") // "- Page 3 -" becomes "Page 3"
+			}
+		}
+		body.WriteString(line)
+	}
+	flush()
+	return sections
+}
+
+var sectionSelectionSchema = schema.Object(map[string]schema.Schema{
+	"sections": schema.Array(schema.Integer()).Describe("Numbers of the selected sections, most relevant first"),
+})
+
+// selectSections asks the fast model which sections of an oversized source are most
+// relevant to the topic, given a budget of chunkBudget chunks of chunkSize characters.
+// The selected sections are returned in document order.
+func (e *ResearchEngine) selectSections(ctx context.Context, item SearchResult, sections []section, chunkSize, chunkBudget int) ([]section, error) {
+	systemPrompt := fmt.Sprintf(`You are a research assistant.
+A source is too long to index in full. Select the sections most relevant to the research topic.
+Each section is listed with its number, title, approximate size in chunks and its opening text.
+Select sections totalling at most %d chunks. Prefer sections with findings, methods and results over front matter, acknowledgements and reference lists.`, chunkBudget)
+
+	listed := sections[:min(len(sections), maxListedSections)]
+	var sb strings.Builder
+	for i, s := range listed {
+		title := s.Title
+		if title == "" {
+			title = "(untitled)"
+		}
+		preview := []rune(strings.TrimSpace(s.Text))
+		if len(preview) > sectionPreviewRunes {
+			preview = preview[:sectionPreviewRunes]
+		}
+		fmt.Fprintf(&sb, "[%d] %s (~%d chunks)\n%s\n\n", i+1, title, sectionChunks(s, chunkSize), string(preview))
+	}
+	input := fmt.Sprintf("Topic: %s\n\nSource Title: %s\n\nSections:\n%s", e.State.Topic, item.Title, sb.String())
+
+	type selectionResponse struct {
+		Sections []int `json:"sections"`
+	}
+	resp, err := generateStructured[selectionResponse](ctx, e, "select_sections", []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeSystem, systemPrompt+"\n\n"+schema.ResponseFormat(sectionSelectionSchema)),
+		llms.TextParts(llms.ChatMessageTypeHuman, input),
+	}, func(r selectionResponse) error {
+		if len(r.Sections) == 0 {
+			return fmt.Errorf("no sections selected")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("section selection failed: %w", err)
+	}
+	return pickSections(listed, resp.Sections, chunkSize, chunkBudget), nil
+}
+
+// pickSections returns the sections with the given 1-based numbers, taken in the given
+// (priority) order while they fit in chunkBudget, then sorted back into document order.
+// Unknown and repeated numbers are ignored; the first section is kept even if it alone
+// exceeds the budget.
+func pickSections(sections []section, numbers []int, chunkSize, chunkBudget int) []section {
+	var picked []int
+	used := 0
+	for _, n := range numbers {
+		i := n - 1
+		if i < 0 || i >= len(sections) || slices.Contains(picked, i) {
+			continue
+		}
+		size := sectionChunks(sections[i], chunkSize)
+		if len(picked) > 0 && used+size > chunkBudget {
+			continue
+		}
+		picked = append(picked, i)
+		used += size
+	}
+	slices.Sort(picked)
+
+	result := make([]section, len(picked))
+	for j, i := range picked {
+		result[j] = sections[i]
+	}
+	return result
+}
+
+// sectionChunks estimates the number of chunks a section splits into
+func sectionChunks(s section, chunkSize int) int {
+	return max(1, (len([]rune(s.Text))+chunkSize-1)/chunkSize)
+}
+
+// sectionTitles returns the titles of sections for metadata, numbering untitled ones
+func sectionTitles(sections []section) []string {
+	titles := make([]string, len(sections))
+	for i, s := range sections {
+		titles[i] = s.Title
+		if titles[i] == "" {
+			titles[i] = "(untitled)"
+		}
+	}
+	return titles
+}
@@ -0,0 +1,59 @@
+package research
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSplitSections(t *testing.T) {
+	text := "Preamble\n# Introduction\nIntro text\n- Page 1 -\nPage text\n## Results\nResult text\n"
+
+	sections := splitSections(text)
+	var titles []string
+	for _, s := range sections {
+		titles = append(titles, s.Title)
+	}
+	if got, want := strings.Join(titles, "|"), "|Introduction|Page 1|Results"; got != want {
+		t.Errorf("titles = %q, want %q", got, want)
+	}
+
+	var joined strings.Builder
+	for _, s := range sections {
+		joined.WriteString(s.Text)
+	}
+	if joined.String() != text {
+		t.Errorf("sections don't reassemble the text:\n%q", joined.String())
+	}
+}
+
+func TestPickSections(t *testing.T) {
+	sections := []section{
+		{Title: "A", Text: strings.Repeat("a", 1000)}, // 1 chunk
+		{Title: "B", Text: strings.Repeat("b", 3000)}, // 3 chunks
+		{Title: "C", Text: strings.Repeat("c", 1500)}, // 2 chunks
+		{Title: "D", Text: strings.Repeat("d", 500)},  // 1 chunk
+	}
+
+	tests := []struct {
+		name    string
+		numbers []int
+		budget  int
+		want    string
+	}{
+		{"document order", []int{3, 1}, 5, "AC"},
+		{"over budget skipped", []int{3, 2, 4}, 3, "CD"},
+		{"invalid and repeated ignored", []int{0, 9, 4, 4}, 5, "D"},
+		{"first kept when too large", []int{2}, 1, "B"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got strings.Builder
+			for _, s := range pickSections(sections, tt.numbers, 1000, tt.budget) {
+				got.WriteString(s.Title)
+			}
+			if got.String() != tt.want {
+				t.Errorf("picked %q, want %q", got.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package research
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// doiPattern matches a bare DOI such as 10.1145/3292500.3330701
+var doiPattern = regexp.MustCompile(`^10\.\d{4,9}/\S+$`)
+
+// arxivDOIPrefix is the DOI prefix arXiv registers its papers under
+const arxivDOIPrefix = "10.48550/arxiv."
+
+// ParseSeedSource turns a user-provided URL or DOI into a search result to acquire.
+// DOIs may be bare or prefixed with "doi:" or a doi.org URL; arXiv DOIs and abstract
+// pages are mapped to the PDF so they can be scraped directly.
+func ParseSeedSource(seed string) (SearchResult, error) {
+	seed = strings.TrimSpace(seed)
+
+	doi := seed
+	for _, prefix := range []string{"doi:", "https://doi.org/", "http://doi.org/", "https://dx.doi.org/", "http://dx.doi.org/"} {
+		if len(doi) >= len(prefix) && strings.EqualFold(doi[:len(prefix)], prefix) {
+			doi = strings.TrimSpace(doi[len(prefix):])
+			break
+		}
+	}
+	if doiPattern.MatchString(doi) {
+		if strings.HasPrefix(strings.ToLower(doi), arxivDOIPrefix) {
+			id := doi[len(arxivDOIPrefix):]
+			return SearchResult{Title: "arXiv:" + id, URL: "https://arxiv.org/pdf/" + id}, nil
+		}
+		return SearchResult{Title: "doi:" + doi, URL: "https://doi.org/" + doi}, nil
+	}
+
+	u, err := url.Parse(seed)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return SearchResult{}, fmt.Errorf("invalid seed source %q: must be an http(s) URL or a DOI", seed)
+	}
+	if strings.HasSuffix(u.Hostname(), "arxiv.org") && strings.HasPrefix(u.Path, "/abs/") {
+		u.Path = "/pdf/" + strings.TrimPrefix(u.Path, "/abs/")
+	}
+	return SearchResult{Title: u.String(), URL: u.String()}, nil
+}
+
+// ParseSeedSources parses every seed, failing on the first invalid one
+func ParseSeedSources(seeds []string) ([]SearchResult, error) {
+	var results []SearchResult
+	for _, seed := range seeds {
+		if strings.TrimSpace(seed) == "" {
+			continue
+		}
+		result, err := ParseSeedSource(seed)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// seedPhase acquires and indexes Config.SeedSources before the first planning iteration.
+// Their summaries are kept in the state so the planner can build on them.
+func (e *ResearchEngine) seedPhase(ctx context.Context) error {
+	e.Logger.Info("Starting seed phase", "count", len(e.Config.SeedSources))
+
+	var items []SearchResult
+	for _, seed := range e.Config.SeedSources {
+		item, err := ParseSeedSource(seed)
+		if err != nil {
+			e.Logger.Warn("Skipping seed source", "seed", seed, "error", err)
+			continue
+		}
+		items = append(items, item)
+	}
+
+	summaries, _, err := e.acquireAndIndexPhase(ctx, items)
+	if err != nil {
+		return err
+	}
+
+	e.State.Mu.Lock()
+	e.State.SeedSummaries = summaries
+	e.State.Seeded = true
+	e.State.Mu.Unlock()
+
+	e.Logger.Info("Seed sources indexed", "seeds", len(items), "indexed", len(summaries))
+	return nil
+}
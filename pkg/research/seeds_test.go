@@ -0,0 +1,33 @@
+package research
+
+import "testing"
+
+func TestParseSeedSource(t *testing.T) {
+	tests := []struct {
+		name    string
+		seed    string
+		wantURL string
+		wantErr bool
+	}{
+		{"url", "https://example.com/paper.pdf", "https://example.com/paper.pdf", false},
+		{"arxiv abstract", "https://arxiv.org/abs/1706.03762", "https://arxiv.org/pdf/1706.03762", false},
+		{"bare doi", "10.1145/3292500.3330701", "https://doi.org/10.1145/3292500.3330701", false},
+		{"doi prefix", "doi: 10.1145/3292500.3330701", "https://doi.org/10.1145/3292500.3330701", false},
+		{"doi url", "https://doi.org/10.1145/3292500.3330701", "https://doi.org/10.1145/3292500.3330701", false},
+		{"arxiv doi", "10.48550/arXiv.1706.03762", "https://arxiv.org/pdf/1706.03762", false},
+		{"not a url", "attention is all you need", "", true},
+		{"unsupported scheme", "ftp://example.com/paper.pdf", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSeedSource(tt.seed)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSeedSource(%q) error = %v, wantErr %v", tt.seed, err, tt.wantErr)
+			}
+			if got.URL != tt.wantURL {
+				t.Errorf("ParseSeedSource(%q).URL = %q, want %q", tt.seed, got.URL, tt.wantURL)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package research
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// generateStructured generates a JSON response in JSON mode and unmarshals it into T,
+// retrying via generateWithRetry when the response doesn't parse or validate rejects it.
+// validate may be nil. The prompts are expected to include a schema.ResponseFormat section.
+func generateStructured[T any](ctx context.Context, e *ResearchEngine, phase string, prompts []llms.MessageContent, validate func(T) error) (T, error) {
+	var result T
+	_, err := e.generateWithRetry(ctx, phase, prompts, generateOptions{JSONMode: true, Validator: func(content string) error {
+		var parsed T
+		if err := json.Unmarshal([]byte(content), &parsed); err != nil {
+			return fmt.Errorf("json parse error: %w (content: %s)", err, content)
+		}
+		if validate != nil {
+			if err := validate(parsed); err != nil {
+				return err
+			}
+		}
+		result = parsed
+		return nil
+	}})
+	return result, err
+}
@@ -0,0 +1,200 @@
+package research
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/mikeboe/research-helper/pkg/embeddings"
+)
+
+// defaultSubTopicConcurrency is how many sub-topics are researched at once by default
+const defaultSubTopicConcurrency = 2
+
+// sharedURLs tracks the sources claimed across the sub-engines of one job, so parallel
+// sub-topics don't scrape and index the same source twice
+type sharedURLs struct {
+	mu   sync.Mutex
+	seen map[string]bool
+}
+
+// claim records url and reports whether it was not claimed before
+func (s *sharedURLs) claim(url string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.seen[url] {
+		return false
+	}
+	s.seen[url] = true
+	return true
+}
+
+// sharedFingerprints tracks the content fingerprints claimed across the sub-engines of one
+// job, so parallel sub-topics don't index near-duplicates found under different URLs
+type sharedFingerprints struct {
+	mu      sync.Mutex
+	claimed []uint64
+}
+
+// claim records fp unless a near-duplicate is already claimed. It returns the matching
+// fingerprint and false when fp is a duplicate.
+func (s *sharedFingerprints) claim(fp uint64) (uint64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.claimed {
+		if IsNearDuplicate(existing, fp) {
+			return existing, false
+		}
+	}
+	s.claimed = append(s.claimed, fp)
+	return 0, true
+}
+
+// release withdraws a claim of fp
+func (s *sharedFingerprints) release(fp uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if i := slices.Index(s.claimed, fp); i >= 0 {
+		s.claimed = slices.Delete(s.claimed, i, i+1)
+	}
+}
+
+// runSubTopics researches Config.SubTopics as parallel sub-loops sharing the collection and
+// merges their findings into the engine state as each completes. Sub-topics completed
+// before a resume are skipped.
+func (e *ResearchEngine) runSubTopics(ctx context.Context) error {
+	concurrency := e.Config.SubTopicConcurrency
+	if concurrency <= 0 {
+		concurrency = defaultSubTopicConcurrency
+	}
+
+	// Create the collection once up front; concurrent CREATE TABLE IF NOT EXISTS can conflict
+	if err := e.DB.EnsureVectorExtension(ctx); err != nil {
+		return err
+	}
+	if err := e.DB.CreateEmbeddingsTable(ctx, e.State.CollectionName, embeddings.Dimension, e.Config.DistanceMetric, e.Config.IndexType); err != nil {
+		return err
+	}
+
+	shared := &sharedURLs{seen: make(map[string]bool)}
+	fingerprints := &sharedFingerprints{}
+	e.State.Mu.Lock()
+	for url := range e.State.ProcessedURLs {
+		shared.seen[url] = true
+	}
+	fingerprints.claimed = slices.Clone(e.State.Fingerprints)
+	e.State.Mu.Unlock()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex // Serializes merges and state updates
+	var errs []error
+	semaphore := make(chan struct{}, concurrency)
+
+	for _, subTopic := range e.Config.SubTopics {
+		if slices.Contains(e.State.CompletedSubTopics, subTopic) {
+			e.Logger.Info("Skipping completed sub-topic", "subtopic", subTopic)
+			continue
+		}
+
+		wg.Add(1)
+		go func(subTopic string) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			sub := e.subEngine(subTopic, shared, fingerprints)
+			e.Logger.Info("Starting sub-topic", "subtopic", subTopic)
+			if err := sub.runLoop(ctx); err != nil {
+				e.Logger.Error("Sub-topic failed", "subtopic", subTopic, "error", err)
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("sub-topic %q: %w", subTopic, err))
+				mu.Unlock()
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			e.mergeSubTopic(subTopic, sub.State)
+			e.Logger.Info("Sub-topic complete", "subtopic", subTopic, "sources", len(sub.State.IndexedItems))
+			if e.OnStateUpdate != nil {
+				e.OnStateUpdate(e.State)
+			}
+		}(subTopic)
+	}
+	wg.Wait()
+
+	// Partial results still make a report; only fail when no sub-topic produced anything
+	if len(errs) > 0 && len(e.State.CompletedSubTopics) == 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// subEngine returns an engine researching subTopic within the parent's topic. It shares the
+// parent's clients, collection and configuration but keeps its own state; sources and
+// content fingerprints are claimed in the sets shared with its siblings.
+func (e *ResearchEngine) subEngine(subTopic string, shared *sharedURLs, fingerprints *sharedFingerprints) *ResearchEngine {
+	cfg := e.Config
+	cfg.SubTopics = nil
+	cfg.SeedSources = nil // Seeds are acquired once by the parent
+
+	return &ResearchEngine{
+		Config: cfg,
+		State: &ResearchState{
+			Topic:            fmt.Sprintf("%s (focus: %s)", e.State.Topic, subTopic),
+			CollectionName:   e.State.CollectionName,
+			ProcessedURLs:    make(map[string]bool),
+			AccumulatedFacts: []string{},
+			IndexedItems:     []SearchResult{},
+			SeedSummaries:    e.State.SeedSummaries,
+			MaxIterations:    e.State.MaxIterations,
+		},
+		LLM:           e.LLM,
+		FastLLM:       e.FastLLM,
+		DB:            e.DB,
+		Embedder:      e.Embedder,
+		Logger:        e.Logger.With("subtopic", subTopic),
+		OnLLMCall:     e.OnLLMCall,
+		OnSourceEvent: e.OnSourceEvent,
+		JobID:         e.JobID,
+		c:             e.c,
+		sharedURLs:    shared,
+
+		sharedFingerprints: fingerprints,
+
+		MetadataExtractors: e.MetadataExtractors,
+	}
+}
+
+// mergeSubTopic appends the results of a finished sub-topic to the engine state.
+// Findings are labelled with their sub-topic so the report can synthesize across them.
+func (e *ResearchEngine) mergeSubTopic(subTopic string, sub *ResearchState) {
+	e.State.Mu.Lock()
+	defer e.State.Mu.Unlock()
+
+	for i, finding := range sub.AccumulatedFacts {
+		e.State.AccumulatedFacts = append(e.State.AccumulatedFacts, fmt.Sprintf("Sub-topic: %s\n%s", subTopic, finding))
+		e.State.FactIterations = append(e.State.FactIterations, iterationOf(sub.FactIterations, i))
+	}
+	e.State.Facts = append(e.State.Facts, sub.Facts...)
+	e.State.IndexedItems = append(e.State.IndexedItems, sub.IndexedItems...)
+	e.State.Fingerprints = append(e.State.Fingerprints, sub.Fingerprints...)
+	e.State.SourceStats = append(e.State.SourceStats, sub.SourceStats...)
+	e.State.QueryYields = append(e.State.QueryYields, sub.QueryYields...)
+	e.State.ScrapeFailures = append(e.State.ScrapeFailures, sub.ScrapeFailures...)
+	for url := range sub.ProcessedURLs {
+		e.State.ProcessedURLs[url] = true
+	}
+	if sub.Iteration > e.State.Iteration {
+		e.State.Iteration = sub.Iteration
+	}
+	e.State.CompletedSubTopics = append(e.State.CompletedSubTopics, subTopic)
+}
+
+// subTopicReportNote tells the report prompt how findings from parallel sub-topics are organized
+func subTopicReportNote(subTopics []string) string {
+	return fmt.Sprintf("\nThe research was split into these sub-topics, researched in parallel: %s. Each finding is labelled with its sub-topic. Synthesize across them into one coherent report instead of a section per sub-topic, and point out where their findings connect or conflict.",
+		strings.Join(subTopics, "; "))
+}
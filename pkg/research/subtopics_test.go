@@ -0,0 +1,60 @@
+package research
+
+import (
+	"log/slog"
+	"reflect"
+	"testing"
+)
+
+func TestMergeSubTopic(t *testing.T) {
+	e := &ResearchEngine{State: &ResearchState{
+		ProcessedURLs:    map[string]bool{"a": true},
+		AccumulatedFacts: []string{"seed"},
+		FactIterations:   []int{0},
+		Iteration:        1,
+	}}
+
+	e.mergeSubTopic("costs", &ResearchState{
+		ProcessedURLs:    map[string]bool{"b": true},
+		AccumulatedFacts: []string{"x", "y"},
+		FactIterations:   []int{1, 3},
+		IndexedItems:     []SearchResult{{URL: "b"}},
+		Iteration:        3,
+	})
+
+	if want := []string{"seed", "Sub-topic: costs\nx", "Sub-topic: costs\ny"}; !reflect.DeepEqual(e.State.AccumulatedFacts, want) {
+		t.Errorf("AccumulatedFacts = %q, want %q", e.State.AccumulatedFacts, want)
+	}
+	if want := []int{0, 1, 3}; !reflect.DeepEqual(e.State.FactIterations, want) {
+		t.Errorf("FactIterations = %v, want %v", e.State.FactIterations, want)
+	}
+	if !e.State.ProcessedURLs["a"] || !e.State.ProcessedURLs["b"] {
+		t.Errorf("ProcessedURLs = %v, want a and b", e.State.ProcessedURLs)
+	}
+	if e.State.Iteration != 3 {
+		t.Errorf("Iteration = %d, want 3", e.State.Iteration)
+	}
+	if want := []string{"costs"}; !reflect.DeepEqual(e.State.CompletedSubTopics, want) {
+		t.Errorf("CompletedSubTopics = %v, want %v", e.State.CompletedSubTopics, want)
+	}
+}
+
+func TestSubEnginesShareFingerprints(t *testing.T) {
+	parent := &ResearchEngine{State: &ResearchState{Topic: "batteries"}, Logger: slog.New(slog.DiscardHandler)}
+	shared := &sharedURLs{seen: make(map[string]bool)}
+	fingerprints := &sharedFingerprints{}
+	a := parent.subEngine("costs", shared, fingerprints)
+	b := parent.subEngine("chemistry", shared, fingerprints)
+
+	fp := Fingerprint("solid state batteries reduce the cost per kilowatt hour")
+	if _, ok := a.claimFingerprint(fp); !ok {
+		t.Fatal("first claim rejected")
+	}
+	if _, ok := b.claimFingerprint(fp); ok {
+		t.Error("sibling claimed content already claimed by another sub-topic")
+	}
+	a.releaseFingerprint(fp)
+	if _, ok := b.claimFingerprint(fp); !ok {
+		t.Error("sibling claim after release rejected")
+	}
+}
@@ -0,0 +1,308 @@
+package research
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/mikeboe/research-helper/pkg/research/schema"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// themePreviewRunes is how much of each finding the clustering prompt shows
+const themePreviewRunes = 300
+
+// otherFindingsTitle collects the findings the clustering left unassigned
+const otherFindingsTitle = "Other Findings"
+
+// theme is a group of findings written up as one subsection of Key Findings
+type theme struct {
+	Title    string `json:"title"`
+	Findings []int  `json:"findings"`
+}
+
+var themeSchema = schema.Object(map[string]schema.Schema{
+	"themes": schema.Array(schema.Object(map[string]schema.Schema{
+		"title":    schema.String().Describe("Short subsection heading naming the theme"),
+		"findings": schema.Array(schema.Integer()).Describe("Numbers of the findings belonging to the theme"),
+	})),
+})
+
+var reportFrameSchema = schema.Object(map[string]schema.Schema{
+	"sections": schema.Array(schema.Object(map[string]schema.Schema{
+		"title":   schema.String(),
+		"content": schema.String().Describe("Markdown body of the section, without its heading"),
+	})),
+})
+
+// themedReport writes the report in parts: the findings are clustered into up to
+// Config.ReportThemes themes, each theme becomes a Key Findings subsection written from
+// its findings alone, and a final call writes the remaining sections around the drafted
+// subsections. No prompt holds all findings at once, so large fact sets fit the context.
+func (e *ResearchEngine) themedReport(ctx context.Context, depth ReportDepth) (string, error) {
+	findings := e.State.AccumulatedFacts
+
+	themes, err := e.clusterFindings(ctx, findings, e.Config.ReportThemes)
+	if err != nil {
+		return "", err
+	}
+	e.Logger.Info("Clustered findings into themes", "findings", len(findings), "themes", len(themes))
+
+	bodies := make([]string, len(themes))
+	errs := make([]error, len(themes))
+	words := max(100, keyFindingsWords(depth)/len(themes))
+	semaphore := make(chan struct{}, 3)
+	var wg sync.WaitGroup
+	for i, t := range themes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			bodies[i], errs[i] = e.writeThemeSection(ctx, depth, t, findings, words)
+		}()
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			return "", fmt.Errorf("failed to write section %q: %w", themes[i].Title, err)
+		}
+	}
+
+	frame, err := e.writeReportFrame(ctx, depth, themes, bodies)
+	if err != nil {
+		return "", err
+	}
+	return assembleThemedReport(e.State.Topic, depth, frame, themes, bodies, formatReferences(e.State.IndexedItems)), nil
+}
+
+// clusterFindings asks the LLM to group findings by theme and returns at most maxThemes
+// themes plus, if needed, one for the findings left unassigned
+func (e *ResearchEngine) clusterFindings(ctx context.Context, findings []string, maxThemes int) ([]theme, error) {
+	systemPrompt := fmt.Sprintf(`You are a research editor planning the Key Findings section of a report.
+Group the numbered findings into at most %d themes, each becoming one subsection.
+Every finding belongs to exactly one theme. Order the themes so the section reads as a coherent argument.`, maxThemes)
+
+	var sb strings.Builder
+	for i, f := range findings {
+		preview := []rune(strings.Join(strings.Fields(f), " "))
+		if len(preview) > themePreviewRunes {
+			preview = preview[:themePreviewRunes]
+		}
+		fmt.Fprintf(&sb, "[%d] %s\n\n", i+1, string(preview))
+	}
+	input := fmt.Sprintf("Topic: %s\n\nFindings:\n%s", e.State.Topic, sb.String())
+
+	type themesResponse struct {
+		Themes []theme `json:"themes"`
+	}
+	resp, err := generateStructured[themesResponse](ctx, e, "cluster_findings", []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeSystem, systemPrompt+"\n\n"+schema.ResponseFormat(themeSchema)),
+		llms.TextParts(llms.ChatMessageTypeHuman, input),
+	}, func(r themesResponse) error {
+		if len(r.Themes) == 0 {
+			return fmt.Errorf("no themes returned")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("clustering findings failed: %w", err)
+	}
+	return assignThemes(resp.Themes, len(findings), maxThemes), nil
+}
+
+// assignThemes turns the 1-based finding numbers of themes into indices into the n findings.
+// Unknown numbers are ignored and a finding listed twice stays with its first theme. Themes
+// beyond maxThemes or left without findings are dropped, and unassigned findings are
+// collected in a final otherFindingsTitle theme.
+func assignThemes(themes []theme, n, maxThemes int) []theme {
+	assigned := make([]bool, n)
+	var result []theme
+	for _, t := range themes {
+		if len(result) == maxThemes {
+			break
+		}
+		var indices []int
+		for _, num := range t.Findings {
+			i := num - 1
+			if i < 0 || i >= n || assigned[i] {
+				continue
+			}
+			assigned[i] = true
+			indices = append(indices, i)
+		}
+		if len(indices) == 0 {
+			continue
+		}
+		slices.Sort(indices)
+		title := strings.TrimSpace(strings.TrimLeft(t.Title, "# "))
+		if title == "" {
+			title = fmt.Sprintf("Theme %d", len(result)+1)
+		}
+		result = append(result, theme{Title: title, Findings: indices})
+	}
+
+	var other []int
+	for i, ok := range assigned {
+		if !ok {
+			other = append(other, i)
+		}
+	}
+	if len(other) > 0 {
+		result = append(result, theme{Title: otherFindingsTitle, Findings: other})
+	}
+	return result
+}
+
+// writeThemeSection writes the body of one Key Findings subsection from the theme's findings
+func (e *ResearchEngine) writeThemeSection(ctx context.Context, depth ReportDepth, t theme, findings []string, words int) (string, error) {
+	selected := make([]string, len(t.Findings))
+	for j, i := range t.Findings {
+		selected[j] = findings[i]
+	}
+
+	prompt := fmt.Sprintf(`Write the "%s" subsection of the Key Findings section of a %s research report on "%s".
+Use only the following gathered facts and summaries:
+
+%s
+
+Length: roughly %d words of Markdown prose, with bullet points where they help.
+Do not include the subsection heading, an introduction, a conclusion or a bibliography; the rest of the report is written separately.
+Cite the source of every fact inline by its title.`,
+		t.Title, depth, e.State.Topic, strings.Join(selected, "\n\n"), words)
+	if len(e.State.Facts) > 0 {
+		prompt += "\nSome sources are given as structured claims with evidence; cite the listed source for every claim you use."
+	}
+
+	body, err := e.generateWithRetry(ctx, "report_section", []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeHuman, prompt),
+	}, generateOptions{})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(body), nil
+}
+
+// writeReportFrame writes the sections of the report other than Key Findings, given the
+// drafted Key Findings subsections. It returns their bodies by section name.
+func (e *ResearchEngine) writeReportFrame(ctx context.Context, depth ReportDepth, themes []theme, bodies []string) (map[string]string, error) {
+	var names []string
+	for _, s := range reportSections(depth) {
+		if s != keyFindingsSection {
+			names = append(names, sectionHeading(s))
+		}
+	}
+
+	systemPrompt := fmt.Sprintf(`You are writing a %s research report on "%s".
+Its Key Findings section has already been drafted and is given below.
+Write the remaining sections, in this order: %s.
+Build on the Key Findings without repeating them at length; compare and relate them, and point out conflicts and open questions.
+Keep them proportionate to the length of the Key Findings and cite sources inline by title.`,
+		depth, e.State.Topic, strings.Join(names, ", "))
+	if len(e.Config.SubTopics) > 0 {
+		systemPrompt += subTopicReportNote(e.Config.SubTopics)
+	}
+
+	var sb strings.Builder
+	for i, t := range themes {
+		fmt.Fprintf(&sb, "### %s\n\n%s\n\n", t.Title, bodies[i])
+	}
+
+	type frameResponse struct {
+		Sections []struct {
+			Title   string `json:"title"`
+			Content string `json:"content"`
+		} `json:"sections"`
+	}
+	frame := make(map[string]string)
+	_, err := generateStructured[frameResponse](ctx, e, "report_frame", []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeSystem, systemPrompt+"\n\n"+schema.ResponseFormat(reportFrameSchema)),
+		llms.TextParts(llms.ChatMessageTypeHuman, "## Key Findings\n\n"+sb.String()),
+	}, func(r frameResponse) error {
+		clear(frame)
+		for _, s := range r.Sections {
+			frame[strings.ToLower(strings.TrimSpace(strings.TrimLeft(s.Title, "# ")))] = strings.TrimSpace(s.Content)
+		}
+		for _, name := range names {
+			if frame[strings.ToLower(name)] == "" {
+				return fmt.Errorf("missing section %q", name)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to write report frame: %w", err)
+	}
+	return frame, nil
+}
+
+// keyFindingsSection is the section of reportSections filled with one subsection per theme
+const keyFindingsSection = "Key Findings"
+
+// sectionHeading turns a reportSections entry into a heading, e.g. "Methodology and Discussion"
+func sectionHeading(section string) string {
+	return strings.ReplaceAll(section, "/", " and ")
+}
+
+// keyFindingsWords is the approximate length of the Key Findings section at a depth,
+// shared among its theme subsections
+func keyFindingsWords(depth ReportDepth) int {
+	switch depth {
+	case ReportDepthBrief:
+		return 300
+	case ReportDepthComprehensive:
+		return 2500
+	default:
+		return 1200
+	}
+}
+
+// assembleThemedReport stitches the theme subsections and the frame sections (keyed by
+// lower-case heading) into a report in the section order of the depth
+func assembleThemedReport(topic string, depth ReportDepth, frame map[string]string, themes []theme, bodies []string, references string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# %s\n", topic)
+	for _, s := range reportSections(depth) {
+		heading := sectionHeading(s)
+		fmt.Fprintf(&sb, "\n## %s\n\n", heading)
+		if s != keyFindingsSection {
+			sb.WriteString(frame[strings.ToLower(heading)] + "\n")
+			continue
+		}
+		for i, t := range themes {
+			if i > 0 {
+				sb.WriteString("\n")
+			}
+			fmt.Fprintf(&sb, "### %s\n\n%s\n", t.Title, bodies[i])
+		}
+	}
+	if references != "" {
+		fmt.Fprintf(&sb, "\n## References\n\n%s", references)
+	}
+	return sb.String()
+}
+
+// formatReferences lists the indexed sources as a Markdown bibliography
+func formatReferences(items []SearchResult) string {
+	var sb strings.Builder
+	for _, item := range items {
+		sb.WriteString("- ")
+		if len(item.Authors) > 0 {
+			sb.WriteString(strings.Join(item.Authors, ", ") + ". ")
+		}
+		sb.WriteString(item.Title + ".")
+		if item.Venue != "" {
+			sb.WriteString(" " + item.Venue + ".")
+		}
+		if year := publicationYear(item.Published); year != "" {
+			sb.WriteString(" " + year + ".")
+		}
+		if item.URL != "" {
+			sb.WriteString(" " + item.URL)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,110 @@
+package research
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAssignThemes(t *testing.T) {
+	tests := []struct {
+		name      string
+		themes    []theme
+		n         int
+		maxThemes int
+		want      []theme
+	}{
+		{
+			"complete",
+			[]theme{{Title: "Scaling", Findings: []int{3, 1}}, {Title: "Cost", Findings: []int{2}}},
+			3, 5,
+			[]theme{{Title: "Scaling", Findings: []int{0, 2}}, {Title: "Cost", Findings: []int{1}}},
+		},
+		{
+			"unknown and repeated numbers",
+			[]theme{{Title: "## Scaling", Findings: []int{0, 1, 9}}, {Title: "Cost", Findings: []int{1, 2}}},
+			2, 5,
+			[]theme{{Title: "Scaling", Findings: []int{0}}, {Title: "Cost", Findings: []int{1}}},
+		},
+		{
+			"unassigned and excess themes",
+			[]theme{{Title: "Scaling", Findings: []int{1}}, {Title: "Cost", Findings: []int{2}}, {Title: "Data", Findings: []int{3}}},
+			4, 2,
+			[]theme{{Title: "Scaling", Findings: []int{0}}, {Title: "Cost", Findings: []int{1}}, {Title: otherFindingsTitle, Findings: []int{2, 3}}},
+		},
+		{
+			"empty and untitled themes",
+			[]theme{{Title: "Empty"}, {Findings: []int{1, 2}}},
+			2, 5,
+			[]theme{{Title: "Theme 1", Findings: []int{0, 1}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := assignThemes(tt.themes, tt.n, tt.maxThemes); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("assignThemes() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAssembleThemedReport(t *testing.T) {
+	frame := map[string]string{
+		"introduction":               "Why it matters.",
+		"methodology and discussion": "How the approaches compare.",
+		"conclusion":                 "What we learned.",
+	}
+	themes := []theme{{Title: "Scaling"}, {Title: "Cost"}}
+	bodies := []string{"Bigger is better.", "Cheaper is better."}
+
+	got := assembleThemedReport("Efficient LLMs", ReportDepthStandard, frame, themes, bodies, "- Paper. 2024.\n")
+	want := `# Efficient LLMs
+
+## Introduction
+
+Why it matters.
+
+## Key Findings
+
+### Scaling
+
+Bigger is better.
+
+### Cost
+
+Cheaper is better.
+
+## Methodology and Discussion
+
+How the approaches compare.
+
+## Conclusion
+
+What we learned.
+
+## References
+
+- Paper. 2024.
+`
+	if got != want {
+		t.Errorf("assembleThemedReport() =\n%s\nwant\n%s", got, want)
+	}
+
+	padded := strings.Replace(got, "Why it matters.", strings.Repeat("word ", minReportWords), 1)
+	if err := validateReport(padded, ReportDepthStandard); err != nil {
+		t.Errorf("assembled report fails validation: %v", err)
+	}
+}
+
+func TestFormatReferences(t *testing.T) {
+	items := []SearchResult{
+		{Title: "Attention Is All You Need", URL: "https://arxiv.org/pdf/1706.03762", Authors: []string{"A. Vaswani", "N. Shazeer"}, Published: "2017-06-12T17:57:34Z"},
+		{Title: "Untitled Preprint", Venue: "NeurIPS 2020"},
+	}
+	want := "- A. Vaswani, N. Shazeer. Attention Is All You Need. 2017. https://arxiv.org/pdf/1706.03762\n" +
+		"- Untitled Preprint. NeurIPS 2020.\n"
+	if got := formatReferences(items); got != want {
+		t.Errorf("formatReferences() = %q, want %q", got, want)
+	}
+}
@@ -1,21 +1,51 @@
 package tools
 
 import (
+	"bytes"
+	"context"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
+	"iter"
 	"log/slog"
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
+	"time"
+)
+
+// atomNamespace is the XML namespace of the arXiv Atom feed
+const atomNamespace = "http://www.w3.org/2005/Atom"
+
+const (
+	// ArxivPageSize is the most results requested in one arXiv API call; larger searches are paged
+	ArxivPageSize = 50
+	// ArxivPageDelay separates paged requests, as the arXiv API terms ask for a 3 second gap
+	ArxivPageDelay = 3 * time.Second
 )
 
 // ArxivEntry struct to hold arXiv entry data
 type ArxivEntry struct {
-	Title     string      `xml:"title"`
-	Summary   string      `xml:"summary"`
-	Published string      `xml:"published"`
-	Link      []ArxivLink `xml:"link"`
+	Title      string        `xml:"title"`
+	Summary    string        `xml:"summary"`
+	Published  string        `xml:"published"`
+	Authors    []ArxivAuthor `xml:"author"`
+	JournalRef string        `xml:"http://arxiv.org/schemas/atom journal_ref"` // Venue of the published version, if any
+	Link       []ArxivLink   `xml:"link"`
+	// PrimaryCategory is the arXiv subject class of the paper, e.g. cs.CL
+	PrimaryCategory ArxivCategory `xml:"http://arxiv.org/schemas/atom primary_category"`
+}
+
+// ArxivCategory struct to hold an arXiv subject class
+type ArxivCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+// ArxivAuthor struct to hold an arXiv author
+type ArxivAuthor struct {
+	Name string `xml:"name"`
 }
 
 // ArxivLink struct to hold arXiv link data
@@ -26,65 +56,215 @@ type ArxivLink struct {
 
 // ArxivFeed struct to hold the entire arXiv feed
 type ArxivFeed struct {
-	XMLName xml.Name     `xml:"feed"`
+	XMLName xml.Name     `xml:"http://www.w3.org/2005/Atom feed"`
 	Entry   []ArxivEntry `xml:"entry"`
 }
 
-// SearchArxiv queries the Arxiv API and returns a formatted string of results.
-func SearchArxiv(query string, maxResults int) (string, error) {
-	if maxResults <= 0 {
-		maxResults = 5
+// parseArxivFeed decodes an arXiv Atom response. arXiv wraps titles and abstracts
+// across lines, so all whitespace in them is collapsed to single spaces.
+func parseArxivFeed(body []byte) (*ArxivFeed, error) {
+	var feed ArxivFeed
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	// Abstracts occasionally contain HTML entities that are not defined in XML
+	decoder.Entity = xml.HTMLEntity
+	if err := decoder.Decode(&feed); err != nil {
+		return nil, err
+	}
+
+	for i := range feed.Entry {
+		normalizeEntry(&feed.Entry[i])
+	}
+	return &feed, nil
+}
+
+// normalizeEntry collapses the whitespace arXiv wraps titles, abstracts and names with
+func normalizeEntry(entry *ArxivEntry) {
+	entry.Title = normalizeSpace(entry.Title)
+	entry.Summary = normalizeSpace(entry.Summary)
+	entry.Published = strings.TrimSpace(entry.Published)
+	entry.JournalRef = normalizeSpace(entry.JournalRef)
+	for j := range entry.Authors {
+		entry.Authors[j].Name = normalizeSpace(entry.Authors[j].Name)
+	}
+}
+
+// decodeArxivEntries decodes the entries of an arXiv Atom feed one at a time as they are
+// read from r, normalized like parseArxivFeed
+func decodeArxivEntries(r io.Reader) iter.Seq2[ArxivEntry, error] {
+	return func(yield func(ArxivEntry, error) bool) {
+		decoder := xml.NewDecoder(r)
+		decoder.Entity = xml.HTMLEntity
+		for {
+			tok, err := decoder.Token()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if err != nil {
+				yield(ArxivEntry{}, fmt.Errorf("failed to decode XML: %w", err))
+				return
+			}
+			start, ok := tok.(xml.StartElement)
+			if !ok || start.Name.Local != "entry" || start.Name.Space != atomNamespace {
+				continue
+			}
+			var entry ArxivEntry
+			if err := decoder.DecodeElement(&entry, &start); err != nil {
+				yield(ArxivEntry{}, fmt.Errorf("failed to decode entry: %w", err))
+				return
+			}
+			normalizeEntry(&entry)
+			if !yield(entry, nil) {
+				return
+			}
+		}
+	}
+}
+
+// StreamArxiv queries the arXiv API like SearchArxiv but yields each entry as soon as it is
+// decoded from the response, instead of after the whole feed has been read. A failed
+// request or malformed feed ends the sequence with an error.
+func StreamArxiv(ctx context.Context, query string, maxResults int) iter.Seq2[ArxivEntry, error] {
+	return pagedArxiv(ctx, maxResults, ArxivPageSize, ArxivPageDelay, func(start, size int) iter.Seq2[ArxivEntry, error] {
+		return func(yield func(ArxivEntry, error) bool) {
+			resp, err := arxivRequest(ctx, query, start, size)
+			if err != nil {
+				yield(ArxivEntry{}, err)
+				return
+			}
+			defer resp.Body.Close()
+
+			for entry, err := range decodeArxivEntries(resp.Body) {
+				if !yield(entry, err) || err != nil {
+					return
+				}
+			}
+		}
+	})
+}
+
+// pagedArxiv yields up to total entries (default 5) from consecutive pages of at most
+// pageSize, waiting delay between requests. A page with fewer entries than requested is
+// the last one, so a query with few matches never loops; an error ends the sequence.
+func pagedArxiv(ctx context.Context, total, pageSize int, delay time.Duration, page func(start, size int) iter.Seq2[ArxivEntry, error]) iter.Seq2[ArxivEntry, error] {
+	if total <= 0 {
+		total = 5
 	}
+	return func(yield func(ArxivEntry, error) bool) {
+		for start := 0; start < total; {
+			if start > 0 {
+				slog.Debug("Waiting before next arXiv page", "start", start, "delay", delay)
+				select {
+				case <-time.After(delay):
+				case <-ctx.Done():
+					yield(ArxivEntry{}, ctx.Err())
+					return
+				}
+			}
+
+			size := min(pageSize, total-start)
+			got := 0
+			for entry, err := range page(start, size) {
+				if !yield(entry, err) || err != nil {
+					return
+				}
+				got++
+			}
+			if got < size {
+				return
+			}
+			start += got
+		}
+	}
+}
 
-	fmt.Printf("Searching arXiv for query: %s, max results: %d\n", query, maxResults)
+// arxivRequest runs an arXiv API query for the results from start on, returning the
+// response of a successful request
+func arxivRequest(ctx context.Context, query string, start, maxResults int) (*http.Response, error) {
+	slog.Debug("Searching arXiv", "query", query, "start", start, "max_results", maxResults)
 
 	// Construct the arXiv API URL
 	baseURL := "https://export.arxiv.org/api/query?"
 	params := url.Values{}
 	params.Add("search_query", query)
 	params.Add("max_results", strconv.Itoa(maxResults))
-	params.Add("start", "0") // Start from the first result
+	params.Add("start", strconv.Itoa(start))
 
 	apiURL := baseURL + params.Encode()
 
 	// Make the API request
-	resp, err := http.Get(apiURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
 	if err != nil {
-		return "", fmt.Errorf("failed to make API request: %w", err)
+		return nil, fmt.Errorf("failed to create API request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make API request: %w", err)
 	}
-	defer resp.Body.Close()
 
 	slog.Info("API request made", "url", apiURL)
 
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
 		slog.Error("API returned non-200 status code", "status", resp.StatusCode, "body", string(bodyBytes))
-		return "", fmt.Errorf("API returned non-200 status code: %d, body: %s", resp.StatusCode, string(bodyBytes))
+		return nil, fmt.Errorf("API returned non-200 status code: %d, body: %s", resp.StatusCode, string(bodyBytes))
 	}
 
 	slog.Info("API response received", "status", resp.StatusCode)
+	return resp, nil
+}
 
-	// Read the response body
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read response body: %w", err)
-	}
+// normalizeSpace trims s and collapses internal runs of whitespace to a single space
+func normalizeSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
 
-	slog.Info("API response body read", "size", len(body))
+// SearchArxiv queries the Arxiv API and returns a formatted string of results. More than
+// ArxivPageSize results are fetched in pages, ArxivPageDelay apart. Cancelling ctx aborts
+// the request and the wait between pages.
+func SearchArxiv(ctx context.Context, query string, maxResults int) (string, error) {
+	pages := pagedArxiv(ctx, maxResults, ArxivPageSize, ArxivPageDelay, func(start, size int) iter.Seq2[ArxivEntry, error] {
+		return func(yield func(ArxivEntry, error) bool) {
+			entries, err := fetchArxivPage(ctx, query, start, size)
+			if err != nil {
+				yield(ArxivEntry{}, err)
+				return
+			}
+			for _, entry := range entries {
+				if !yield(entry, nil) {
+					return
+				}
+			}
+		}
+	})
 
-	// Unmarshal the XML response
-	var feed ArxivFeed
-	err = xml.Unmarshal(body, &feed)
-	if err != nil {
-		return "", fmt.Errorf("failed to unmarshal XML: %w", err)
+	var entries []ArxivEntry
+	for entry, err := range pages {
+		if err != nil {
+			return "", err
+		}
+		entries = append(entries, entry)
 	}
 
 	// Format the response
 	var response string
-	for _, entry := range feed.Entry {
+	for _, entry := range entries {
 		response += fmt.Sprintf("# Title: %s\n", entry.Title)
 		response += fmt.Sprintf("## Summary: %s\n", entry.Summary)
 		response += fmt.Sprintf("## Published: %s\n", entry.Published)
+		if len(entry.Authors) > 0 {
+			names := make([]string, len(entry.Authors))
+			for i, a := range entry.Authors {
+				names[i] = a.Name
+			}
+			response += fmt.Sprintf("## Authors: %s\n", strings.Join(names, ", "))
+		}
+		if entry.JournalRef != "" {
+			response += fmt.Sprintf("## Journal Ref: %s\n", entry.JournalRef)
+		}
+		if entry.PrimaryCategory.Term != "" {
+			response += fmt.Sprintf("## Category: %s\n", entry.PrimaryCategory.Term)
+		}
 		for _, link := range entry.Link {
 			if link.Type == "application/pdf" {
 				response += fmt.Sprintf("## PDF Link: %s\n", link.Href)
@@ -100,3 +280,27 @@ func SearchArxiv(query string, maxResults int) (string, error) {
 
 	return response, nil
 }
+
+// fetchArxivPage reads one page of results in full
+func fetchArxivPage(ctx context.Context, query string, start, maxResults int) ([]ArxivEntry, error) {
+	resp, err := arxivRequest(ctx, query, start, maxResults)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Read the response body
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	slog.Info("API response body read", "size", len(body))
+
+	// Unmarshal the XML response
+	feed, err := parseArxivFeed(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal XML: %w", err)
+	}
+	return feed.Entry, nil
+}
@@ -0,0 +1,151 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"strings"
+	"testing"
+)
+
+func TestParseArxivFeed(t *testing.T) {
+	body := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom" xmlns:arxiv="http://arxiv.org/schemas/atom">
+  <title type="html">ArXiv Query</title>
+  <entry>
+    <title>Attention Is All
+  You  Need</title>
+    <summary>  The dominant sequence transduction models are based on
+complex recurrent&nbsp;networks.
+</summary>
+    <published>2017-06-12T17:57:34Z</published>
+    <author><name>Ashish
+  Vaswani</name></author>
+    <author><name>Noam Shazeer</name></author>
+    <arxiv:journal_ref>NeurIPS 2017</arxiv:journal_ref>
+    <link href="http://arxiv.org/abs/1706.03762v7" rel="alternate" type="text/html"/>
+    <link title="pdf" href="http://arxiv.org/pdf/1706.03762v7" rel="related" type="application/pdf"/>
+    <arxiv:primary_category term="cs.CL"/>
+  </entry>
+</feed>`)
+
+	feed, err := parseArxivFeed(body)
+	if err != nil {
+		t.Fatalf("parseArxivFeed: %v", err)
+	}
+	if len(feed.Entry) != 1 {
+		t.Fatalf("got %d entries, want 1", len(feed.Entry))
+	}
+
+	entry := feed.Entry[0]
+	if want := "Attention Is All You Need"; entry.Title != want {
+		t.Errorf("Title = %q, want %q", entry.Title, want)
+	}
+	if want := "The dominant sequence transduction models are based on complex recurrent networks."; entry.Summary != want {
+		t.Errorf("Summary = %q, want %q", entry.Summary, want)
+	}
+	if len(entry.Authors) != 2 || entry.Authors[0].Name != "Ashish Vaswani" {
+		t.Errorf("Authors = %+v, want Ashish Vaswani first of 2", entry.Authors)
+	}
+	if want := "NeurIPS 2017"; entry.JournalRef != want {
+		t.Errorf("JournalRef = %q, want %q", entry.JournalRef, want)
+	}
+	if want := "cs.CL"; entry.PrimaryCategory.Term != want {
+		t.Errorf("PrimaryCategory = %q, want %q", entry.PrimaryCategory.Term, want)
+	}
+	if len(entry.Link) != 2 || entry.Link[1].Type != "application/pdf" {
+		t.Errorf("Link = %+v, want pdf link second", entry.Link)
+	}
+}
+
+func TestDecodeArxivEntries(t *testing.T) {
+	body := `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title type="html">ArXiv Query</title>
+  <entry><title>First
+  Paper</title></entry>
+  <entry><title>Second Paper</title></entry>
+  <entry><title>Third Paper</title>`
+
+	var titles []string
+	var lastErr error
+	for entry, err := range decodeArxivEntries(strings.NewReader(body)) {
+		if err != nil {
+			lastErr = err
+			break
+		}
+		titles = append(titles, entry.Title)
+	}
+
+	// Entries before the truncated one are delivered before the error
+	if len(titles) != 2 || titles[0] != "First Paper" || titles[1] != "Second Paper" {
+		t.Errorf("titles = %q, want the two complete entries", titles)
+	}
+	if lastErr == nil {
+		t.Error("truncated feed did not end with an error")
+	}
+}
+
+func TestPagedArxiv(t *testing.T) {
+	// fakePages serves available entries and records the requested pages
+	fakePages := func(available int, fail error, requests *[][2]int) func(start, size int) iter.Seq2[ArxivEntry, error] {
+		return func(start, size int) iter.Seq2[ArxivEntry, error] {
+			*requests = append(*requests, [2]int{start, size})
+			return func(yield func(ArxivEntry, error) bool) {
+				if fail != nil && start > 0 {
+					yield(ArxivEntry{}, fail)
+					return
+				}
+				for i := start; i < min(start+size, available); i++ {
+					if !yield(ArxivEntry{Title: string(rune('a' + i%26))}, nil) {
+						return
+					}
+				}
+			}
+		}
+	}
+
+	tests := []struct {
+		name      string
+		total     int
+		available int
+		fail      error
+		want      int
+		requests  [][2]int
+	}{
+		{"single page", 3, 10, nil, 3, [][2]int{{0, 3}}},
+		{"several pages", 10, 20, nil, 10, [][2]int{{0, 4}, {4, 4}, {8, 2}}},
+		{"short page ends", 10, 5, nil, 5, [][2]int{{0, 4}, {4, 4}}},
+		{"exact multiple stops on empty page", 10, 8, nil, 8, [][2]int{{0, 4}, {4, 4}, {8, 2}}},
+		{"error ends", 10, 20, errors.New("rate limited"), 4, [][2]int{{0, 4}, {4, 4}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests [][2]int
+			got := 0
+			var lastErr error
+			for _, err := range pagedArxiv(context.Background(), tt.total, 4, 0, fakePages(tt.available, tt.fail, &requests)) {
+				if err != nil {
+					lastErr = err
+					continue
+				}
+				got++
+			}
+			if got != tt.want {
+				t.Errorf("got %d entries, want %d", got, tt.want)
+			}
+			if !errors.Is(lastErr, tt.fail) {
+				t.Errorf("error = %v, want %v", lastErr, tt.fail)
+			}
+			if len(requests) != len(tt.requests) {
+				t.Fatalf("requests = %v, want %v", requests, tt.requests)
+			}
+			for i := range requests {
+				if requests[i] != tt.requests[i] {
+					t.Errorf("requests = %v, want %v", requests, tt.requests)
+					break
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package tools
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned instead of calling an endpoint whose circuit breaker is open
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// Defaults of the breaker around the Mistral OCR endpoint
+const (
+	DefaultOCRFailureThreshold = 5
+	DefaultOCRCooldown         = time.Minute
+)
+
+// ocrBreaker is shared by all ScrapePDF calls in the process, so concurrent scrapes and
+// jobs stop calling Mistral together during an outage
+var ocrBreaker = NewCircuitBreaker("mistral-ocr", DefaultOCRFailureThreshold, DefaultOCRCooldown)
+
+// breakerState is the state of a CircuitBreaker
+type breakerState int
+
+const (
+	breakerClosed   breakerState = iota // Calls pass through
+	breakerOpen                         // Calls fail with ErrCircuitOpen until the cooldown has passed
+	breakerHalfOpen                     // One probe call is let through to test the endpoint
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case breakerOpen:
+		return "open"
+	case breakerHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// CircuitBreaker stops calls to an endpoint after consecutive failures. It opens after
+// threshold failures in a row, lets a single probe through once cooldown has passed and
+// closes again when the probe succeeds. State transitions are logged.
+type CircuitBreaker struct {
+	name      string
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	state    breakerState
+	failures int // Consecutive failures while closed
+	openedAt time.Time
+}
+
+// NewCircuitBreaker returns a closed breaker. A threshold below 1 is treated as 1.
+func NewCircuitBreaker(name string, threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		name:      name,
+		threshold: max(threshold, 1),
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// Allow reports whether a call may proceed, returning ErrCircuitOpen if not. A nil result
+// must be followed by Record with the call's outcome.
+func (b *CircuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return ErrCircuitOpen
+		}
+		b.transition(breakerHalfOpen)
+		return nil
+	case breakerHalfOpen:
+		return ErrCircuitOpen // A probe is already in flight
+	default:
+		return nil
+	}
+}
+
+// Record reports the outcome of an allowed call. A failure counts towards opening the
+// breaker, or reopens it when the call was the half-open probe.
+func (b *CircuitBreaker) Record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !failed {
+		b.failures = 0
+		if b.state != breakerClosed {
+			b.transition(breakerClosed)
+		}
+		return
+	}
+
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= b.threshold {
+		b.openedAt = b.now()
+		if b.state != breakerOpen {
+			b.transition(breakerOpen)
+		}
+	}
+}
+
+// transition changes the state and logs it. The caller holds mu.
+func (b *CircuitBreaker) transition(to breakerState) {
+	slog.Warn("Circuit breaker state changed", "breaker", b.name, "from", b.state, "to", to,
+		"consecutive_failures", b.failures, "cooldown", b.cooldown)
+	b.state = to
+}
@@ -0,0 +1,59 @@
+package tools
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := NewCircuitBreaker("test", 2, time.Minute)
+	b.now = func() time.Time { return now }
+
+	call := func(failed bool) error {
+		if err := b.Allow(); err != nil {
+			return err
+		}
+		b.Record(failed)
+		return nil
+	}
+
+	// A success resets the count of consecutive failures
+	for _, failed := range []bool{true, false, true} {
+		if err := call(failed); err != nil {
+			t.Fatalf("closed breaker rejected a call: %v", err)
+		}
+	}
+	if err := call(true); err != nil {
+		t.Fatalf("second consecutive failure rejected: %v", err)
+	}
+	if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("open breaker allowed a call: %v", err)
+	}
+
+	// After the cooldown one probe goes through; a failed probe reopens the breaker
+	now = now.Add(time.Minute)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("probe rejected after cooldown: %v", err)
+	}
+	if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("second call allowed during probe: %v", err)
+	}
+	b.Record(true)
+	if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("breaker not reopened by failed probe: %v", err)
+	}
+
+	// A successful probe closes it
+	now = now.Add(time.Minute)
+	if err := call(false); err != nil {
+		t.Fatalf("probe rejected: %v", err)
+	}
+	if b.state != breakerClosed {
+		t.Fatalf("state = %s after successful probe, want closed", b.state)
+	}
+	if err := call(true); err != nil {
+		t.Fatalf("closed breaker rejected a call: %v", err)
+	}
+}
@@ -5,24 +5,57 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
+	"mime"
 	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type PdfScrapeResponsePage struct {
-	Index    int    `json:"index"`
-	Markdown string `json:"markdown"`
+	Index    int        `json:"index"`
+	Markdown string     `json:"markdown"`
+	Images   []OcrImage `json:"images"`
+}
+
+// OcrImage is a figure extracted from a page. The page markdown references it by ID.
+type OcrImage struct {
+	ID          string `json:"id"`
+	ImageBase64 string `json:"image_base64,omitempty"`
 }
 
 type OcrResponse struct {
 	Pages []PdfScrapeResponsePage `json:"pages"`
 }
 
+// DefaultMaxPDFBytes is the size cap applied when ScrapeOptions.MaxBytes is zero
+const DefaultMaxPDFBytes int64 = 50 << 20
+
+// ScrapeOptions controls how a PDF is processed
+type ScrapeOptions struct {
+	// MaxPages limits OCR to the first N pages of the document (0 = unlimited)
+	MaxPages int
+	// MaxBytes rejects documents whose Content-Length exceeds it (0 = DefaultMaxPDFBytes, negative = unlimited)
+	MaxBytes int64
+}
+
+// ScrapeResult holds the extracted text of a PDF
+type ScrapeResult struct {
+	Text  string
+	Pages int
+	// PageContents holds the raw per-page OCR output, including extracted figures
+	PageContents []PdfScrapeResponsePage
+	// Truncated is set when the document has pages beyond the page limit, which were skipped
+	Truncated bool
+}
+
 // ScrapePDF extracts the contents of a PDF file as text using Mistral OCR API.
-func ScrapePDF(url string) (string, error) {
+// After repeated OCR failures (network errors, 429 and 5xx responses) calls fail fast with
+// ErrCircuitOpen until a probe succeeds again; see CircuitBreaker.
+func ScrapePDF(url string, opts ScrapeOptions) (*ScrapeResult, error) {
 	url = strings.Replace(url, "http://", "https://", 1)
 
 	// Ensure env vars are loaded
@@ -33,10 +66,14 @@ func ScrapePDF(url string) (string, error) {
 	apiKey := os.Getenv("MISTRAL_API_KEY")
 
 	if apiKey == "" {
-		return "", fmt.Errorf("MISTRAL_API_KEY is not set")
+		return nil, fmt.Errorf("MISTRAL_API_KEY is not set")
 	}
 
-	fmt.Printf("PDF Scraper called with URL: %s\n", url)
+	slog.Debug("PDF scraper called", "url", url)
+
+	if err := validatePDF(url, opts.MaxBytes); err != nil {
+		return nil, err
+	}
 
 	reqBody := map[string]interface{}{
 		"model": "mistral-ocr-latest",
@@ -46,39 +83,57 @@ func ScrapePDF(url string) (string, error) {
 		},
 		"include_image_base64": true,
 	}
+	if opts.MaxPages > 0 {
+		// One page past the limit is requested to tell whether the document goes on
+		pages := make([]int, opts.MaxPages+1)
+		for i := range pages {
+			pages[i] = i
+		}
+		reqBody["pages"] = pages
+	}
 
 	jsonBody, err := json.Marshal(reqBody)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request body: %w", err)
+		return nil, fmt.Errorf("failed to marshal request body: %w", err)
 	}
 	clientReq, err := http.NewRequest("POST", baseUrl, bytes.NewBuffer(jsonBody))
 	if err != nil {
-		return "", fmt.Errorf("failed to create HTTP request: %w", err)
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
 	}
 
 	clientReq.Header.Set("Content-Type", "application/json")
 	clientReq.Header.Set("Authorization", "Bearer "+apiKey)
 
+	if err := ocrBreaker.Allow(); err != nil {
+		return nil, fmt.Errorf("OCR skipped: %w", err)
+	}
 	resp, err := client.Do(clientReq)
 	if err != nil {
-		return "", fmt.Errorf("failed to make API request: %w", err)
+		ocrBreaker.Record(true)
+		return nil, fmt.Errorf("failed to make API request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	// Read the response body
 	body, err := io.ReadAll(resp.Body)
+	ocrBreaker.Record(err != nil || ocrUnavailable(resp.StatusCode))
 	if err != nil {
-		return "", fmt.Errorf("failed to read response body: %w", err)
+		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("API request failed with status: %s, body: %s", resp.Status, string(body))
+		return nil, fmt.Errorf("API request failed with status: %s, body: %s", resp.Status, string(body))
 	}
 
 	var ocrResponse OcrResponse
 	err = json.Unmarshal(body, &ocrResponse)
 	if err != nil {
-		return "", fmt.Errorf("failed to unmarshal OCR response: %w", err)
+		return nil, fmt.Errorf("failed to unmarshal OCR response: %w", err)
+	}
+
+	truncated := opts.MaxPages > 0 && len(ocrResponse.Pages) > opts.MaxPages
+	if truncated {
+		ocrResponse.Pages = ocrResponse.Pages[:opts.MaxPages]
 	}
 
 	var response string
@@ -89,5 +144,50 @@ func ScrapePDF(url string) (string, error) {
 		response += fmt.Sprintf("- Page %d -\n", page.Index)
 		response += page.Markdown + "\n\n"
 	}
-	return response, nil
+	return &ScrapeResult{
+		Text:         response,
+		Pages:        len(ocrResponse.Pages),
+		PageContents: ocrResponse.Pages,
+		Truncated:    truncated,
+	}, nil
+}
+
+// ocrUnavailable reports whether an OCR response status means the endpoint itself is
+// failing, as opposed to rejecting the document
+func ocrUnavailable(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+// validatePDF sends a HEAD request to check that url points to a PDF below the size
+// cap before it is sent to the (paid) OCR API. Servers that don't support HEAD are
+// not rejected; the OCR call will surface any problem.
+func validatePDF(url string, maxBytes int64) error {
+	if maxBytes == 0 {
+		maxBytes = DefaultMaxPDFBytes
+	}
+
+	client := &http.Client{Timeout: 15 * time.Second}
+	resp, err := client.Head(url)
+	if err != nil {
+		return fmt.Errorf("failed to check document: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented {
+		return nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("document check failed with status: %s", resp.Status)
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if mediaType, _, err := mime.ParseMediaType(contentType); err != nil || mediaType != "application/pdf" {
+		return fmt.Errorf("document is not a PDF (Content-Type: %q)", contentType)
+	}
+
+	if maxBytes > 0 && resp.ContentLength > maxBytes {
+		return fmt.Errorf("document too large: %d bytes exceeds limit of %d bytes", resp.ContentLength, maxBytes)
+	}
+
+	return nil
 }
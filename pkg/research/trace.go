@@ -0,0 +1,100 @@
+package research
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// LLMTrace records the exact prompts and raw response of a single LLM call
+type LLMTrace struct {
+	Phase     string                `json:"phase"`
+	Iteration int                   `json:"iteration"`
+	Prompts   []llms.MessageContent `json:"prompts"`
+	JSONMode  bool                  `json:"json_mode"`
+	Response  string                `json:"response"`
+	Error     string                `json:"error,omitempty"`
+	Duration  time.Duration         `json:"duration"`
+}
+
+// fastPhases are the phases served by the fast model: frequent per-source calls where
+// latency and cost matter more than reasoning depth
+var fastPhases = map[string]bool{
+	"select_sections": true,
+	"safety_check":    true,
+	"find_names":      true,
+}
+
+// UsesFastModel reports whether a phase is served by the fast model, e.g. to replay its traces
+func UsesFastModel(phase string) bool {
+	return fastPhases[phase]
+}
+
+// generate calls the LLM (the fast one for fastPhases) and, when tracing is enabled, records the call.
+// Traces go to OnLLMCall if set, otherwise to the logger. stream, if set, receives the
+// response chunks as they arrive.
+func (e *ResearchEngine) generate(ctx context.Context, phase string, prompts []llms.MessageContent, jsonMode bool, stream func(text string, restart bool)) (*llms.ContentResponse, error) {
+	var opts []llms.CallOption
+	if jsonMode {
+		opts = append(opts, llms.WithJSONMode())
+	}
+	if stream != nil {
+		opts = append(opts, llms.WithStreamingFunc(func(_ context.Context, chunk []byte) error {
+			stream(string(chunk), false)
+			return nil
+		}))
+	}
+
+	start := time.Now()
+	model := e.LLM
+	if fastPhases[phase] && e.FastLLM != nil {
+		model = e.FastLLM
+	}
+	resp, err := model.GenerateContent(ctx, prompts, opts...)
+
+	if e.Config.Trace {
+		trace := LLMTrace{
+			Phase:     phase,
+			Iteration: e.State.Iteration,
+			Prompts:   prompts,
+			JSONMode:  jsonMode,
+			Duration:  time.Since(start),
+		}
+		if err != nil {
+			trace.Error = err.Error()
+		} else if len(resp.Choices) > 0 {
+			trace.Response = resp.Choices[0].Content
+		}
+		e.recordTrace(trace)
+	}
+
+	return resp, err
+}
+
+func (e *ResearchEngine) recordTrace(trace LLMTrace) {
+	if e.OnLLMCall != nil {
+		e.OnLLMCall(trace)
+		return
+	}
+	e.Logger.Info("LLM trace", "phase", trace.Phase, "iteration", trace.Iteration,
+		"prompts", trace.Prompts, "response", trace.Response, "error", trace.Error, "duration", trace.Duration)
+}
+
+// Replay re-sends the prompts of a recorded trace to the given model and returns the new response.
+func Replay(ctx context.Context, llm llms.Model, trace LLMTrace) (string, error) {
+	var opts []llms.CallOption
+	if trace.JSONMode {
+		opts = append(opts, llms.WithJSONMode())
+	}
+
+	resp, err := llm.GenerateContent(ctx, trace.Prompts, opts...)
+	if err != nil {
+		return "", fmt.Errorf("llm generation failed: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("llm returned no choices")
+	}
+	return resp.Choices[0].Content, nil
+}
@@ -1,13 +1,68 @@
 package research
 
-import "sync"
+import (
+	"sync"
+	"time"
+
+	"github.com/mikeboe/research-helper/pkg/vectorstore"
+)
 
 // Config holds runtime configuration
 type Config struct {
-	LLMApiKey   string
-	MCPBaseURL  string
-	RAGEndpoint string
-	Collection  string
+	MCPBaseURL    string
+	RAGEndpoint   string
+	Collection    string
+	ReportDepth   ReportDepth // Length and detail of the final report (default: standard)
+	MaxIterations int         // Research iterations before the report, 1 to MaxIterationsLimit (default 5)
+	ExtractFacts  bool        // Extract structured claims from each source (one extra LLM call per source)
+	MaxPDFPages   int         // OCR at most this many pages per PDF (0 = unlimited)
+	MaxPDFBytes   int64       // Skip PDFs larger than this (0 = tools.DefaultMaxPDFBytes, negative = unlimited)
+	Trace         bool        // Record the prompts and raw responses of every LLM call
+
+	MinQueryTokens int  // Minimum non-stopword terms per search query (default 2)
+	RefineQueries  bool // Ask the LLM to rewrite rejected queries instead of dropping them
+
+	ReflectionLookback int  // Earlier findings shown to the reflection step besides the latest iteration (0 = none, -1 = all)
+	ReportProgression  bool // Group findings by iteration in the reflection and report prompts to show how research progressed
+	ReportThemes       int  // Cluster findings into up to this many themes and write Key Findings per theme (0 = single report prompt)
+
+	MaxChunksPerSource int            // Maximum chunks indexed per source (0 = unlimited)
+	OversizePolicy     OversizePolicy // What to do with sources above MaxChunksPerSource (default: truncate)
+	TitleEmbedding     TitleEmbedding // Whether source titles are embedded with the content (default: none)
+	StorePages         bool           // Keep the raw OCR markdown of every page for later reference
+	StorePageImages    bool           // Also keep extracted figures as base64 (requires StorePages)
+	RecheckThreshold   int            // Re-score scraped full text and skip sources below this 0-10 score (0 = disabled)
+	DeterministicIDs   bool           // Derive chunk IDs from source, position and content so re-indexing upserts
+	IndexCaptions      bool           // Index figure captions from the OCR output as separate documents
+	CleanChunks        bool           // Strip OCR artifacts (headers, page numbers, reference markers, hyphenation) before chunking
+	EmbeddingWorkers   int            // Embed and store chunks on this many background workers (0 = synchronously)
+	StreamSources      bool           // Filter each query's results as its search completes and start scraping before all searches finish
+
+	GroupLimit int         // Index at most this many sources per group in an iteration (0 = unlimited)
+	GroupBy    SourceGroup // What sources are grouped by for GroupLimit (default: category)
+
+	SafetyBlock []string // Content categories (see SafetyCategories) that keep a source out of the collection (empty = no check)
+
+	Redact []string // Kinds of personal data (see RedactKinds) replaced in chunk content before it is embedded and stored
+
+	DistanceMetric vectorstore.DistanceMetric // Metric new collections are indexed for (default: the recorded metric, or cosine)
+	IndexType      vectorstore.IndexType      // Index built for new collections (default: HNSW, or IVFFlat without pgvector support)
+
+	AllowedDomains []string // Only scrape sources on these domains and their subdomains (empty = all)
+	BlockedDomains []string // Never scrape sources on these domains; takes precedence over AllowedDomains
+
+	EmbedMetadata []string // Metadata fields (see ParseMetadataFields) appended to the embedded text of each chunk
+
+	SeedSources []string // URLs or DOIs acquired before the first planning iteration (see ParseSeedSource)
+
+	MinNewSources int // Stop once an iteration indexes fewer new sources than this (0 = disabled)
+	ArxivResults  int // arXiv results gathered per search query, paged above tools.ArxivPageSize (default 2)
+
+	IterationDelay  time.Duration // Pause between iterations to stay under provider quotas (0 = none)
+	IterationJitter time.Duration // Random extra pause of up to this long, so parallel runs don't align
+
+	SubTopics           []string // Research these sub-topics as parallel loops sharing the collection, then report across them
+	SubTopicConcurrency int      // Sub-topics researched at once (default 2)
 }
 
 // SearchResult represents a single search result
@@ -15,18 +70,35 @@ type SearchResult struct {
 	Title   string `json:"title"`
 	URL     string `json:"url"`
 	Snippet string `json:"snippet"`
+	Scraped bool   `json:"scraped"` // Full text was extracted; false means only the snippet was indexed
+
+	Authors   []string `json:"authors,omitempty"`
+	Published string   `json:"published,omitempty"` // Publication date as reported by the search backend
+	Venue     string   `json:"venue,omitempty"`     // Journal or conference of the published version, if known
+	Category  string   `json:"category,omitempty"`  // Subject class, e.g. the arXiv primary category
+	Query     string   `json:"query,omitempty"`     // Search query that found the result; empty for seed sources
 }
 
 // ResearchState tracks the progress of the research
 type ResearchState struct {
-	Topic            string
-	CollectionName   string
-	ProcessedURLs    map[string]bool
-	AccumulatedFacts []string
-	IndexedItems     []SearchResult // Track indexed items for final report
-	Iteration        int
-	MaxIterations    int
-	Mu               sync.Mutex // For thread-safe updates during scraping
+	Topic              string
+	CollectionName     string
+	ProcessedURLs      map[string]bool
+	AccumulatedFacts   []string
+	FactIterations     []int          // Iteration each AccumulatedFacts entry was gathered in
+	Facts              []Fact         // Structured claims, populated when Config.ExtractFacts is set
+	IndexedItems       []SearchResult // Track indexed items for final report
+	Fingerprints       []uint64       // Content fingerprints of indexed documents, for near-duplicate detection
+	Iteration          int
+	MaxIterations      int
+	Focus              string          // Focus area the last reflection suggested for the next iteration
+	Seeded             bool            // Config.SeedSources were acquired; not repeated on resume
+	SeedSummaries      []string        // Summaries of the seed sources, given to the planner
+	SourceStats        []SourceStats   // New vs duplicate source counts per iteration
+	QueryYields        []QueryYield    // Results and indexed sources per search query
+	ScrapeFailures     []ScrapeFailure // Indexed sources whose full text couldn't be scraped (see ReindexFailed)
+	CompletedSubTopics []string        // Sub-topics whose results are merged into this state
+	Mu                 sync.Mutex      `json:"-"` // For thread-safe updates during scraping
 }
 
 // RagPayload defines the structure for indexing documents
@@ -0,0 +1,139 @@
+package server
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+
+	"github.com/google/uuid"
+	"github.com/mikeboe/research-helper/pkg/research"
+	"github.com/mikeboe/research-helper/pkg/vectorstore"
+)
+
+// JobBundle is everything recorded about a job, as downloaded from GET /api/research/:id/bundle
+type JobBundle struct {
+	Job        *Job
+	Collection string
+	Sources    []research.SearchResult
+	Logs       []LogEntry
+	// Documents yields the documents the job indexed, without embeddings. They are read
+	// page by page while the bundle is written, so large jobs aren't held in memory.
+	Documents iter.Seq2[vectorstore.Document, error]
+}
+
+// bundlePageSize is how many documents GetJobBundle reads from the collection at a time
+const bundlePageSize = 500
+
+// GetJobBundle collects the job, its sources and logs and the documents it indexed into its
+// collection. A collection that no longer exists contributes no documents.
+func (s *Service) GetJobBundle(ctx context.Context, jobID uuid.UUID) (*JobBundle, error) {
+	job, err := s.GetJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	var jobCfg research.JobConfig
+	if len(job.Config) > 0 {
+		if err := json.Unmarshal(job.Config, &jobCfg); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+		}
+	}
+	bundle := &JobBundle{Job: job, Collection: jobCfg.ResearchConfig(s.Cfg).Collection}
+
+	if bundle.Sources, err = s.GetJobSources(ctx, jobID); err != nil {
+		return nil, err
+	}
+	if bundle.Logs, err = s.GetJobLogs(ctx, jobID); err != nil {
+		return nil, err
+	}
+
+	exists, err := s.DB.CollectionExists(ctx, bundle.Collection)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		store, err := vectorstore.NewPGVectorStore(s.DB.Pool, bundle.Collection)
+		if err != nil {
+			return nil, err
+		}
+		bundle.Documents = store.StreamContentByMetadata(ctx, map[string]interface{}{"job_id": jobID.String()}, bundlePageSize)
+	}
+	return bundle, nil
+}
+
+// WriteZip writes the bundle as a zip archive: the report as report.md, the job record as
+// job.json, the sources as sources.json and sources.bib, the logs as logs.json and the
+// documents as documents.jsonl in the format of the export command. job.json is written
+// last, since it counts the documents.
+func (b *JobBundle) WriteZip(w io.Writer) error {
+	zw := zip.NewWriter(w)
+
+	report := ""
+	if b.Job.Report != nil {
+		report = *b.Job.Report
+	}
+	sources := b.Sources
+	if sources == nil {
+		sources = []research.SearchResult{}
+	}
+	logs := b.Logs
+	if logs == nil {
+		logs = []LogEntry{}
+	}
+
+	job := struct {
+		*Job
+		Collection string `json:"collection"`
+		Documents  int    `json:"documents"`
+	}{b.Job, b.Collection, 0}
+
+	files := []struct {
+		name  string
+		write func(io.Writer) error
+	}{
+		{"report.md", func(w io.Writer) error { _, err := io.WriteString(w, report); return err }},
+		{"sources.json", writeJSON(sources)},
+		{"sources.bib", func(w io.Writer) error { _, err := io.WriteString(w, research.FormatBibTeX(sources)); return err }},
+		{"logs.json", writeJSON(logs)},
+		{"documents.jsonl", func(w io.Writer) error {
+			if b.Documents == nil {
+				return nil
+			}
+			enc := json.NewEncoder(w)
+			for doc, err := range b.Documents {
+				if err != nil {
+					return err
+				}
+				if err := enc.Encode(doc); err != nil {
+					return err
+				}
+				job.Documents++
+			}
+			return nil
+		}},
+		// Encoded when written, after documents.jsonl has counted the documents
+		{"job.json", func(w io.Writer) error { return writeJSON(job)(w) }},
+	}
+	for _, f := range files {
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: f.name, Method: zip.Deflate, Modified: b.Job.UpdatedAt})
+		if err != nil {
+			return fmt.Errorf("failed to add %s: %w", f.name, err)
+		}
+		if err := f.write(fw); err != nil {
+			return fmt.Errorf("failed to write %s: %w", f.name, err)
+		}
+	}
+	return zw.Close()
+}
+
+// writeJSON returns a writer of v as indented JSON
+func writeJSON(v interface{}) func(io.Writer) error {
+	return func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+}
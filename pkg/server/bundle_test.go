@@ -0,0 +1,66 @@
+package server
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/mikeboe/research-helper/pkg/research"
+	"github.com/mikeboe/research-helper/pkg/vectorstore"
+)
+
+func TestJobBundleWriteZip(t *testing.T) {
+	report := "# Report"
+	bundle := &JobBundle{
+		Job:        &Job{ID: uuid.New(), Topic: "graph neural networks", Status: "completed", Report: &report},
+		Collection: "thesis_db",
+		Sources:    []research.SearchResult{{Title: "GCN", URL: "http://arxiv.org/abs/1609.02907v4"}},
+		Documents: func(yield func(vectorstore.Document, error) bool) {
+			_ = yield(vectorstore.Document{ID: "a", Content: "first chunk"}, nil) &&
+				yield(vectorstore.Document{ID: "b", Content: "second chunk"}, nil)
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := bundle.WriteZip(&buf); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name] = string(data)
+	}
+
+	checks := map[string]string{
+		"report.md":       "# Report",
+		"job.json":        `"documents": 2`,
+		"sources.json":    `"title": "GCN"`,
+		"sources.bib":     "@misc{arxiv:1609.02907v4,",
+		"logs.json":       "[]",
+		"documents.jsonl": "second chunk",
+	}
+	for name, want := range checks {
+		if !strings.Contains(files[name], want) {
+			t.Errorf("%s = %q, want it to contain %q", name, files[name], want)
+		}
+	}
+	if lines := strings.Count(files["documents.jsonl"], "\n"); lines != 2 {
+		t.Errorf("documents.jsonl has %d lines, want 2", lines)
+	}
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mikeboe/research-helper/pkg/splitter"
+)
+
+// maxChunkPreviewBytes caps the request body of POST /api/chunk-preview
+const maxChunkPreviewBytes = 2 << 20
+
+// ChunkPreviewRequest is the body of POST /api/chunk-preview. Omitted sizes use the configured
+// CHUNK_SIZE and CHUNK_OVERLAP.
+type ChunkPreviewRequest struct {
+	Text         string `json:"text"`
+	ChunkSize    int    `json:"chunkSize,omitempty"`
+	ChunkOverlap *int   `json:"chunkOverlap,omitempty"` // A pointer so 0 can be asked for explicitly
+	SplitterType string `json:"splitterType,omitempty"` // recursive (default) or markdown
+}
+
+// PreviewChunk is one chunk of a preview. Length is in characters, as the splitter counts them.
+type PreviewChunk struct {
+	Index   int    `json:"index"`
+	Content string `json:"content"`
+	Length  int    `json:"length"`
+}
+
+// ChunkPreviewResponse lists the chunks the text would be indexed as
+type ChunkPreviewResponse struct {
+	ChunkSize    int            `json:"chunkSize"`
+	ChunkOverlap int            `json:"chunkOverlap"`
+	SplitterType string         `json:"splitterType"`
+	Count        int            `json:"count"`
+	TextLength   int            `json:"textLength"`
+	Chunks       []PreviewChunk `json:"chunks"`
+}
+
+// normalize applies the defaults and checks the chunking parameters. It returns the overlap
+// to use; the default overlap is dropped when it doesn't fit a smaller requested chunk size.
+func (r *ChunkPreviewRequest) normalize(defaultSize, defaultOverlap int) (int, error) {
+	if r.Text == "" {
+		return 0, errors.New("text must not be empty")
+	}
+	if r.ChunkSize == 0 {
+		r.ChunkSize = defaultSize
+	}
+	if r.ChunkSize <= 0 {
+		return 0, fmt.Errorf("chunkSize must be positive, got %d", r.ChunkSize)
+	}
+	if r.SplitterType == "" {
+		r.SplitterType = splitter.TypeRecursive
+	}
+
+	if r.ChunkOverlap == nil {
+		if defaultOverlap >= r.ChunkSize {
+			return 0, nil
+		}
+		return defaultOverlap, nil
+	}
+	if overlap := *r.ChunkOverlap; overlap < 0 || overlap >= r.ChunkSize {
+		return 0, fmt.Errorf("chunkOverlap must be at least 0 and below chunkSize (%d), got %d", r.ChunkSize, overlap)
+	}
+	return *r.ChunkOverlap, nil
+}
+
+// previewChunks splits the text as indexing would
+func previewChunks(req ChunkPreviewRequest, overlap int) (*ChunkPreviewResponse, error) {
+	ts, err := splitter.New(req.SplitterType, req.ChunkSize, overlap)
+	if err != nil {
+		return nil, err
+	}
+	chunks, err := ts.SplitText(req.Text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to split text: %w", err)
+	}
+
+	resp := &ChunkPreviewResponse{
+		ChunkSize:    req.ChunkSize,
+		ChunkOverlap: overlap,
+		SplitterType: req.SplitterType,
+		Count:        len(chunks),
+		TextLength:   utf8.RuneCountInString(req.Text),
+		Chunks:       make([]PreviewChunk, len(chunks)),
+	}
+	for i, chunk := range chunks {
+		resp.Chunks[i] = PreviewChunk{Index: i, Content: chunk, Length: utf8.RuneCountInString(chunk)}
+	}
+	return resp, nil
+}
+
+func (h *Handler) chunkPreview(c *gin.Context) {
+	var req ChunkPreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("request body exceeds %d bytes", tooLarge.Limit)})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	overlap, err := req.normalize(h.Service.c.ChunkSize, h.Service.c.ChunkOverlap)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := previewChunks(req, overlap)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChunkPreviewNormalize(t *testing.T) {
+	intPtr := func(n int) *int { return &n }
+
+	tests := []struct {
+		name        string
+		req         ChunkPreviewRequest
+		wantSize    int
+		wantOverlap int
+		wantErr     bool
+	}{
+		{"defaults", ChunkPreviewRequest{Text: "a"}, 1000, 200, false},
+		{"explicit zero overlap", ChunkPreviewRequest{Text: "a", ChunkOverlap: intPtr(0)}, 1000, 0, false},
+		{"default overlap too large", ChunkPreviewRequest{Text: "a", ChunkSize: 100}, 100, 0, false},
+		{"overlap not below size", ChunkPreviewRequest{Text: "a", ChunkSize: 100, ChunkOverlap: intPtr(100)}, 0, 0, true},
+		{"negative size", ChunkPreviewRequest{Text: "a", ChunkSize: -1}, 0, 0, true},
+		{"empty text", ChunkPreviewRequest{}, 0, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			overlap, err := tt.req.normalize(1000, 200)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (tt.req.ChunkSize != tt.wantSize || overlap != tt.wantOverlap) {
+				t.Errorf("size, overlap = %d, %d, want %d, %d", tt.req.ChunkSize, overlap, tt.wantSize, tt.wantOverlap)
+			}
+		})
+	}
+}
+
+func TestPreviewChunks(t *testing.T) {
+	text := strings.Repeat("word ", 100)
+	resp, err := previewChunks(ChunkPreviewRequest{Text: text, ChunkSize: 100, SplitterType: "recursive"}, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Count < 5 || resp.Count != len(resp.Chunks) || resp.TextLength != len(text) {
+		t.Errorf("unexpected preview: count %d, chunks %d, length %d", resp.Count, len(resp.Chunks), resp.TextLength)
+	}
+	for _, chunk := range resp.Chunks {
+		if chunk.Length > 100 || chunk.Length != len(chunk.Content) {
+			t.Errorf("chunk %d has length %d", chunk.Index, chunk.Length)
+		}
+	}
+
+	if _, err := previewChunks(ChunkPreviewRequest{Text: text, ChunkSize: 100, SplitterType: "token"}, 0); err == nil {
+		t.Error("expected an error for an unknown splitter type")
+	}
+}
@@ -1,21 +1,33 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/mikeboe/research-helper/pkg/chat"
+	"github.com/mikeboe/research-helper/pkg/database"
+	"github.com/mikeboe/research-helper/pkg/embeddings"
+	"github.com/mikeboe/research-helper/pkg/research"
+	"github.com/mikeboe/research-helper/pkg/vectorstore"
 )
 
 // MCPSession represents an MCP session
 type MCPSession struct {
-	ID      string
-	Created int64
+	ID          string
+	Created     int64
+	Collections []string // Collections the session's tools may use; empty allows all
 }
 
 var (
@@ -49,26 +61,75 @@ type Handler struct {
 	Service *Service
 	Chat    *chat.Service
 	Tools   *chat.RagToolset
+
+	embedLimiter *rateLimiter
+	mcpScopes    map[string][]string // Collections by API key from MCP_COLLECTION_KEYS; nil leaves MCP unrestricted
 }
 
 func NewHandler(s *Service, c *chat.Service, tools *chat.RagToolset) *Handler {
-	return &Handler{Service: s, Chat: c, Tools: tools}
+	scopes, err := s.c.MCPCollectionScopes()
+	if err != nil {
+		// Fail closed: with no valid keys every MCP session is rejected
+		slog.Error("Invalid MCP collection keys, rejecting all MCP sessions", "error", err)
+		scopes = map[string][]string{}
+	}
+	return &Handler{
+		Service:      s,
+		Chat:         c,
+		Tools:        tools,
+		embedLimiter: newRateLimiter(s.c.EmbedRateLimit, time.Minute),
+		mcpScopes:    scopes,
+	}
 }
 
+// maxEmbedTexts caps the batch size of /api/embed, since texts are embedded one call at a time
+const maxEmbedTexts = 100
+
 func (h *Handler) RegisterRoutes(r *gin.Engine) {
-	r.POST("/mcp", h.MCPHandler)
+	r.POST("/mcp", limitBody(h.Service.c.MCPMaxBodyBytes), h.MCPHandler)
 	api := r.Group("/api")
 	{
 		api.POST("/research", h.createJob)
 		api.GET("/research", h.listJobs)
 		api.GET("/research/:id", h.getJob)
 		api.GET("/research/:id/logs", h.getJobLogs)
+		api.GET("/research/:id/sources", h.getJobSources)
+		api.GET("/research/:id/state", h.getJobState)
+		api.GET("/research/:id/bundle", h.getJobBundle)
+		api.GET("/research/:id/report/stream", h.streamReport)
+		api.GET("/research/:id/sources/stream", h.streamSources)
+		api.POST("/research/:id/continue", h.continueJob)
+		api.POST("/research/:id/reindex-failed", h.reindexFailed)
+		api.GET("/research/:id/traces", h.getJobTraces)
+		api.POST("/research/:id/traces/:traceId/replay", h.replayTrace)
+		api.DELETE("/research/:id/documents", h.deleteJobDocuments)
+		api.GET("/stats", h.getStats)
 
 		// Chat Routes
 		api.POST("/chat/conversations", h.createConversation)
 		api.GET("/chat/conversations", h.listConversations)
 		api.GET("/chat/conversations/:id/messages", h.getMessages)
 		api.POST("/chat/conversations/:id/messages", h.sendMessage)
+		api.POST("/chat/conversations/:id/fork", h.forkConversation)
+		api.PUT("/chat/conversations/:id/model", h.setConversationModel)
+		api.GET("/chat/conversations/:id/export", h.exportConversation)
+		api.GET("/chat/conversations/:id/sources", h.getPinnedSources)
+		api.POST("/chat/conversations/:id/sources", h.pinSource)
+		api.DELETE("/chat/conversations/:id/sources", h.unpinSource)
+		api.GET("/chat/knowledge-gaps", h.listKnowledgeGaps)
+
+		// Collection Routes
+		api.GET("/collections/:name/documents/:id", h.getDocument)
+		api.POST("/collections/:name/curate", h.curateDocuments)
+		api.POST("/collections/:name/promote", h.promoteDocuments)
+
+		// Embedding service for external clients, producing vectors compatible with the collections
+		api.POST("/embed", requireAPIKey(h.Service.c.APIKey), h.embedLimiter.middleware(), h.embedTexts)
+		api.POST("/chunk-preview", limitBody(maxChunkPreviewBytes), h.chunkPreview)
+
+		// Admin Routes
+		api.POST("/admin/collections/:name/compact", requireAPIKey(h.Service.c.APIKey), h.compactCollection)
+		api.POST("/admin/collections/:name/prune", requireAPIKey(h.Service.c.APIKey), h.pruneCollection)
 	}
 }
 
@@ -78,6 +139,17 @@ func (h *Handler) MCPHandler(c *gin.Context) {
 
 	var req MCPRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, MCPResponse{
+				JSONRPC: "2.0",
+				Error: &MCPError{
+					Code:    -32600,
+					Message: fmt.Sprintf("Request body exceeds %d bytes", tooLarge.Limit),
+				},
+			})
+			return
+		}
 		c.JSON(http.StatusBadRequest, MCPResponse{
 			JSONRPC: "2.0",
 			ID:      nil,
@@ -92,13 +164,40 @@ func (h *Handler) MCPHandler(c *gin.Context) {
 	// Handle initialize request
 	if req.Method == "initialize" {
 		if sessionID == "" {
+			// The scope is fixed for the lifetime of the session
+			var params struct {
+				Collections []string `json:"collections"`
+			}
+			_ = json.Unmarshal(req.Params, &params)
+			requested := params.Collections
+			if len(requested) == 0 {
+				requested = parseCollectionList(c.GetHeader(mcpCollectionsHeader))
+			}
+			scope, err := resolveSessionScope(h.mcpScopes, c.GetHeader("X-API-Key"), requested)
+			if err != nil {
+				status := http.StatusForbidden
+				if errors.Is(err, ErrMCPUnauthorized) {
+					status = http.StatusUnauthorized
+				}
+				c.JSON(status, MCPResponse{
+					JSONRPC: "2.0",
+					ID:      req.ID,
+					Error: &MCPError{
+						Code:    mcpErrForbidden,
+						Message: err.Error(),
+					},
+				})
+				return
+			}
+
 			sessionID = uuid.New().String()
 			c.Header("Mcp-Session-Id", sessionID)
 
 			sessionMu.Lock()
 			mcpSessions[sessionID] = &MCPSession{
-				ID:      sessionID,
-				Created: time.Now().Unix(),
+				ID:          sessionID,
+				Created:     time.Now().Unix(),
+				Collections: scope,
 			}
 			sessionMu.Unlock()
 		}
@@ -134,7 +233,7 @@ func (h *Handler) MCPHandler(c *gin.Context) {
 	}
 
 	sessionMu.RLock()
-	_, exists := mcpSessions[sessionID]
+	session, exists := mcpSessions[sessionID]
 	sessionMu.RUnlock()
 
 	if !exists {
@@ -153,7 +252,7 @@ func (h *Handler) MCPHandler(c *gin.Context) {
 	case "tools/list":
 		h.handleToolsList(c, req)
 	case "tools/call":
-		h.handleToolsCall(c, req)
+		h.handleToolsCall(c, req, session)
 	case "ping":
 		c.JSON(http.StatusOK, MCPResponse{
 			JSONRPC: "2.0",
@@ -172,7 +271,14 @@ func (h *Handler) MCPHandler(c *gin.Context) {
 	}
 }
 
+// mcpCollectionProperty is the optional collection argument of the tools reading a collection
+var mcpCollectionProperty = map[string]interface{}{
+	"type":        "string",
+	"description": "Collection to use. Defaults to the server's chat collection, or the first collection this session is scoped to.",
+}
+
 func (h *Handler) handleToolsList(c *gin.Context, req MCPRequest) {
+	defaults := h.Tools.SearchDefaults()
 	c.JSON(http.StatusOK, MCPResponse{
 		JSONRPC: "2.0",
 		ID:      req.ID,
@@ -184,6 +290,7 @@ func (h *Handler) handleToolsList(c *gin.Context, req MCPRequest) {
 					"inputSchema": map[string]interface{}{
 						"type": "object",
 						"properties": map[string]interface{}{
+							"collection": mcpCollectionProperty,
 							"query": map[string]interface{}{
 								"type":        "string",
 								"description": "The search query.",
@@ -191,12 +298,35 @@ func (h *Handler) handleToolsList(c *gin.Context, req MCPRequest) {
 							"topK": map[string]interface{}{
 								"type":        "number",
 								"description": "The number of top results to return.",
-								"default":     5,
+								"default":     defaults.TopK,
 							},
 							"source": map[string]interface{}{
 								"type":        "string",
 								"description": "The source to filter results by.",
 							},
+							"minScore": map[string]interface{}{
+								"type":        "number",
+								"description": "Minimum similarity of returned results. Overrides the server default; negative returns all.",
+								"default":     defaults.MinScore,
+							},
+							"recencyWeight": map[string]interface{}{
+								"type":        "number",
+								"description": "Boost for newer documents, added to the similarity of a document published today and halving with age (e.g. 0.05). Overrides the server default; negative disables it.",
+							},
+							"rerank": map[string]interface{}{
+								"type":        "boolean",
+								"description": "Re-rank results with the server's recency boost. Ignored when recencyWeight is set.",
+								"default":     defaults.Rerank,
+							},
+							"explain": map[string]interface{}{
+								"type":        "boolean",
+								"description": "Also return the sentences of each result that best match the query. Slower, as every sentence is embedded.",
+							},
+							"fields": map[string]interface{}{
+								"type":        "array",
+								"items":       map[string]interface{}{"type": "string"},
+								"description": "Metadata fields to return with each result. Defaults to source and title; [\"*\"] returns all.",
+							},
 						},
 						"required": []string{"query"},
 					},
@@ -207,6 +337,7 @@ func (h *Handler) handleToolsList(c *gin.Context, req MCPRequest) {
 					"inputSchema": map[string]interface{}{
 						"type": "object",
 						"properties": map[string]interface{}{
+							"collection": mcpCollectionProperty,
 							"source": map[string]interface{}{
 								"type":        "string",
 								"description": "The source to find content for.",
@@ -217,24 +348,174 @@ func (h *Handler) handleToolsList(c *gin.Context, req MCPRequest) {
 				},
 				{
 					"name":        "find_content_by_metadata",
-					"description": "Find content using complex logical filters on metadata.",
+					"description": "Find content using complex logical filters on metadata. Results come in pages; a cursor for the next page is returned while more results match.",
 					"inputSchema": map[string]interface{}{
 						"type": "object",
 						"properties": map[string]interface{}{
+							"collection": mcpCollectionProperty,
 							"filter": map[string]interface{}{
 								"type":        "object",
-								"description": "JSON filter object with logical operators ($and, $or, $not)",
+								"description": "JSON filter object. Keys are metadata fields matched exactly, combined with the logical operators $and, $or (lists of conditions) and $not (one condition). Comparison operators are not supported.",
+							},
+							"fields": map[string]interface{}{
+								"type":        "array",
+								"items":       map[string]interface{}{"type": "string"},
+								"description": "Metadata fields to return with each result. Defaults to source and title; [\"*\"] returns all.",
+							},
+							"limit": map[string]interface{}{
+								"type":        "number",
+								"description": "Maximum number of results per page (max 100).",
+								"default":     20,
+							},
+							"cursor": map[string]interface{}{
+								"type":        "string",
+								"description": "Continuation cursor returned with the previous page, to get the next one.",
 							},
 						},
 						"required": []string{"filter"},
 					},
 				},
+				{
+					"name":        "get_source_pages",
+					"description": "Get the original page-by-page text of a source, including figure references. Only available for sources indexed with page storage enabled.",
+					"inputSchema": map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"collection": mcpCollectionProperty,
+							"source": map[string]interface{}{
+								"type":        "string",
+								"description": "The source to get pages for.",
+							},
+							"page": map[string]interface{}{
+								"type":        "number",
+								"description": "Optional 1-based page number.",
+							},
+						},
+						"required": []string{"source"},
+					},
+				},
+				{
+					"name":        "get_source_page",
+					"description": "Read the indexed text of a source piece by piece. Use the returned next index to continue reading.",
+					"inputSchema": map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"collection": mcpCollectionProperty,
+							"source": map[string]interface{}{
+								"type":        "string",
+								"description": "The source to read.",
+							},
+							"index": map[string]interface{}{
+								"type":        "number",
+								"description": "0-based index of the first chunk to return.",
+								"default":     0,
+							},
+							"count": map[string]interface{}{
+								"type":        "number",
+								"description": "Number of chunks to return (max 5).",
+								"default":     1,
+							},
+						},
+						"required": []string{"source"},
+					},
+				},
+				{
+					"name":        "compare_sources",
+					"description": "Compare two to four sources side by side: the chunks of each source most relevant to an aspect, grouped by source, optionally with a short summary of where they agree and differ.",
+					"inputSchema": map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"collection": mcpCollectionProperty,
+							"sources": map[string]interface{}{
+								"type":        "array",
+								"items":       map[string]interface{}{"type": "string"},
+								"description": "The sources to compare (2 to 4).",
+							},
+							"aspect": map[string]interface{}{
+								"type":        "string",
+								"description": "What to compare, e.g. 'evaluation datasets'. Without it the opening chunks of each source are returned.",
+							},
+							"topK": map[string]interface{}{
+								"type":        "number",
+								"description": "Chunks returned per source (max 8).",
+								"default":     3,
+							},
+							"diff": map[string]interface{}{
+								"type":        "boolean",
+								"description": "Also summarize where the sources agree and differ.",
+								"default":     false,
+							},
+						},
+						"required": []string{"sources"},
+					},
+				},
+				{
+					"name":        "record_knowledge_gap",
+					"description": "Record a question the research collection cannot answer. Recorded gaps are listed by GET /api/chat/knowledge-gaps to guide further research.",
+					"inputSchema": map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"collection": mcpCollectionProperty,
+							"question": map[string]interface{}{
+								"type":        "string",
+								"description": "The unanswered question, phrased so it could seed a research job.",
+							},
+							"reason": map[string]interface{}{
+								"type":        "string",
+								"description": "Why the collection falls short.",
+							},
+						},
+						"required": []string{"question"},
+					},
+				},
+				{
+					"name":        "start_research",
+					"description": "Start an autonomous research job on a topic. Returns the job, whose id can be polled with get_research_status.",
+					"inputSchema": map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"topic": map[string]interface{}{
+								"type":        "string",
+								"description": "The research topic.",
+							},
+							"report_depth": map[string]interface{}{
+								"type":        "string",
+								"description": "Length of the final report.",
+								"enum":        []string{"brief", "standard", "comprehensive"},
+								"default":     "standard",
+							},
+							"tags": map[string]interface{}{
+								"type":        "array",
+								"items":       map[string]interface{}{"type": "string"},
+								"description": "Labels for organizing jobs, e.g. a project name.",
+							},
+						},
+						"required": []string{"topic"},
+					},
+				},
+				{
+					"name":        "get_research_status",
+					"description": "Get the status of a research job, including the report once it is completed.",
+					"inputSchema": map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"id": map[string]interface{}{
+								"type":        "string",
+								"description": "The research job id.",
+							},
+						},
+						"required": []string{"id"},
+					},
+				},
 			},
 		},
 	})
 }
 
-func (h *Handler) handleToolsCall(c *gin.Context, req MCPRequest) {
+// handleToolsCall runs a tool within the collections of the session. The search tools take an
+// optional collection argument, defaulting to CHAT_COLLECTION or, when that is out of scope,
+// the session's first collection. Research jobs are limited to sessions including their collection.
+func (h *Handler) handleToolsCall(c *gin.Context, req MCPRequest, session *MCPSession) {
 	var params struct {
 		Name      string          `json:"name"`
 		Arguments json.RawMessage `json:"arguments"`
@@ -252,6 +533,22 @@ func (h *Handler) handleToolsCall(c *gin.Context, req MCPRequest) {
 		return
 	}
 
+	var scoped struct {
+		Collection string `json:"collection"`
+	}
+	if len(params.Arguments) > 0 {
+		if err := json.Unmarshal(params.Arguments, &scoped); err != nil {
+			h.sendError(c, req.ID, -32602, "Invalid arguments")
+			return
+		}
+	}
+	collection, err := session.collection(scoped.Collection, h.Service.c.ChatCollection)
+	if err != nil {
+		h.sendError(c, req.ID, mcpErrForbidden, err.Error())
+		return
+	}
+	tools := h.Tools.ForCollection(collection)
+
 	switch params.Name {
 	case "search_content":
 		var args chat.SearchContentArgs
@@ -259,7 +556,7 @@ func (h *Handler) handleToolsCall(c *gin.Context, req MCPRequest) {
 			h.sendError(c, req.ID, -32602, "Invalid arguments")
 			return
 		}
-		resp, err := h.Tools.SearchContent(c.Request.Context(), args)
+		resp, err := tools.SearchContent(c.Request.Context(), args)
 		if err != nil {
 			h.sendError(c, req.ID, -32603, err.Error())
 			return
@@ -272,7 +569,7 @@ func (h *Handler) handleToolsCall(c *gin.Context, req MCPRequest) {
 			h.sendError(c, req.ID, -32602, "Invalid arguments")
 			return
 		}
-		resp, err := h.Tools.FindContentBySource(c.Request.Context(), args)
+		resp, err := tools.FindContentBySource(c.Request.Context(), args)
 		if err != nil {
 			h.sendError(c, req.ID, -32603, err.Error())
 			return
@@ -285,18 +582,142 @@ func (h *Handler) handleToolsCall(c *gin.Context, req MCPRequest) {
 			h.sendError(c, req.ID, -32602, "Invalid arguments")
 			return
 		}
-		resp, err := h.Tools.FindContentByMetadata(c.Request.Context(), args)
+		resp, err := tools.FindContentByMetadata(c.Request.Context(), args)
+		if errors.Is(err, vectorstore.ErrInvalidFilter) || errors.Is(err, vectorstore.ErrInvalidCursor) {
+			h.sendError(c, req.ID, -32602, err.Error())
+			return
+		}
+		if err != nil {
+			h.sendError(c, req.ID, -32603, err.Error())
+			return
+		}
+		h.sendResult(c, req.ID, resp)
+
+	case "get_source_pages":
+		var args chat.GetSourcePagesArgs
+		if err := json.Unmarshal(params.Arguments, &args); err != nil {
+			h.sendError(c, req.ID, -32602, "Invalid arguments")
+			return
+		}
+		resp, err := tools.GetSourcePages(c.Request.Context(), args)
+		if err != nil {
+			h.sendError(c, req.ID, -32603, err.Error())
+			return
+		}
+		h.sendResult(c, req.ID, resp)
+
+	case "get_source_page":
+		var args chat.GetSourcePageArgs
+		if err := json.Unmarshal(params.Arguments, &args); err != nil {
+			h.sendError(c, req.ID, -32602, "Invalid arguments")
+			return
+		}
+		resp, err := tools.GetSourcePage(c.Request.Context(), args)
+		if err != nil {
+			h.sendError(c, req.ID, -32603, err.Error())
+			return
+		}
+		h.sendResult(c, req.ID, resp)
+
+	case "compare_sources":
+		var args chat.CompareSourcesArgs
+		if err := json.Unmarshal(params.Arguments, &args); err != nil {
+			h.sendError(c, req.ID, -32602, "Invalid arguments")
+			return
+		}
+		resp, err := tools.CompareSources(c.Request.Context(), args)
+		if errors.Is(err, chat.ErrInvalidComparison) {
+			h.sendError(c, req.ID, -32602, err.Error())
+			return
+		}
+		if err != nil {
+			h.sendError(c, req.ID, -32603, err.Error())
+			return
+		}
+		h.sendResult(c, req.ID, resp)
+
+	case "record_knowledge_gap":
+		var args chat.RecordKnowledgeGapArgs
+		if err := json.Unmarshal(params.Arguments, &args); err != nil {
+			h.sendError(c, req.ID, -32602, "Invalid arguments")
+			return
+		}
+		resp, err := tools.RecordKnowledgeGap(c.Request.Context(), args)
+		if errors.Is(err, chat.ErrInvalidGap) {
+			h.sendError(c, req.ID, -32602, err.Error())
+			return
+		}
 		if err != nil {
 			h.sendError(c, req.ID, -32603, err.Error())
 			return
 		}
 		h.sendResult(c, req.ID, resp)
 
+	case "start_research":
+		var args CreateJobRequest
+		if err := json.Unmarshal(params.Arguments, &args); err != nil {
+			h.sendError(c, req.ID, -32602, "Invalid arguments")
+			return
+		}
+		if strings.TrimSpace(args.Topic) == "" {
+			h.sendError(c, req.ID, -32602, "topic is required")
+			return
+		}
+		if err := args.Validate(); err != nil {
+			h.sendError(c, req.ID, -32602, err.Error())
+			return
+		}
+		if _, err := session.collection(h.Service.c.ResearchCollection, ""); err != nil {
+			h.sendError(c, req.ID, mcpErrForbidden, err.Error())
+			return
+		}
+		job, err := h.Service.CreateJob(c.Request.Context(), args)
+		if err != nil {
+			h.sendError(c, req.ID, -32603, err.Error())
+			return
+		}
+		h.sendResult(c, req.ID, job)
+
+	case "get_research_status":
+		var args struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(params.Arguments, &args); err != nil {
+			h.sendError(c, req.ID, -32602, "Invalid arguments")
+			return
+		}
+		id, err := uuid.Parse(args.ID)
+		if err != nil {
+			h.sendError(c, req.ID, -32602, "invalid uuid")
+			return
+		}
+		job, err := h.Service.GetJob(c.Request.Context(), id)
+		if err != nil {
+			h.sendError(c, req.ID, -32603, err.Error())
+			return
+		}
+		if _, err := session.collection(h.jobCollection(job), ""); err != nil {
+			h.sendError(c, req.ID, mcpErrForbidden, err.Error())
+			return
+		}
+		h.sendResult(c, req.ID, job)
+
 	default:
 		h.sendError(c, req.ID, -32601, fmt.Sprintf("Tool not found: %s", params.Name))
 	}
 }
 
+// jobCollection returns the collection a job indexes into
+func (h *Handler) jobCollection(job *Job) string {
+	var cfg struct {
+		Collection string `json:"collection"`
+	}
+	if err := json.Unmarshal(job.Config, &cfg); err != nil || cfg.Collection == "" {
+		return h.Service.c.ResearchCollection
+	}
+	return cfg.Collection
+}
+
 func (h *Handler) sendError(c *gin.Context, id interface{}, code int, msg string) {
 	c.JSON(http.StatusOK, MCPResponse{
 		JSONRPC: "2.0",
@@ -308,17 +729,24 @@ func (h *Handler) sendError(c *gin.Context, id interface{}, code int, msg string
 	})
 }
 
+// MCPTextContent is implemented by tool responses that render themselves as MCP text content
+type MCPTextContent interface {
+	MCPTextContent() string
+}
+
+// sendResult sends a tool result as MCP text content. Results that don't implement
+// MCPTextContent are serialized as indented JSON.
 func (h *Handler) sendResult(c *gin.Context, id interface{}, result interface{}) {
 	var textContent string
-	switch v := result.(type) {
-	case chat.SearchContentResp:
-		textContent = v.Results
-	case chat.FindSourceResp:
-		textContent = v.Content
-	case chat.FindMetadataResp:
-		textContent = v.Content
-	default:
-		textContent = fmt.Sprintf("%v", result)
+	if v, ok := result.(MCPTextContent); ok {
+		textContent = v.MCPTextContent()
+	} else {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			h.sendError(c, id, -32603, fmt.Sprintf("failed to marshal result: %v", err))
+			return
+		}
+		textContent = string(data)
 	}
 
 	c.JSON(http.StatusOK, MCPResponse{
@@ -335,17 +763,58 @@ func (h *Handler) sendResult(c *gin.Context, id interface{}, result interface{})
 	})
 }
 
+// ConversationModelRequest picks the model of a conversation: "reasoning", "fast" or a
+// configured model name; empty means the server default
+type ConversationModelRequest struct {
+	Model string `json:"model"`
+}
+
 func (h *Handler) createConversation(c *gin.Context) {
-	conv, err := h.Chat.CreateConversation(c.Request.Context())
+	var req ConversationModelRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	conv, err := h.Chat.CreateConversation(c.Request.Context(), req.Model)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		h.conversationError(c, err)
 		return
 	}
 	c.JSON(http.StatusCreated, conv)
 }
 
+func (h *Handler) setConversationModel(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid uuid"})
+		return
+	}
+
+	var req ConversationModelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	conv, err := h.Chat.SetModel(c.Request.Context(), id, req.Model)
+	if err != nil {
+		h.conversationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, conv)
+}
+
+// listConversations lists conversations, optionally only those updated between the from and
+// to query parameters (RFC 3339 timestamps or YYYY-MM-DD dates)
 func (h *Handler) listConversations(c *gin.Context) {
-	convs, err := h.Chat.ListConversations(c.Request.Context())
+	r, err := chat.ParseDateRange(c.Query("from"), c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	convs, err := h.Chat.ListConversations(c.Request.Context(), r)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -356,6 +825,8 @@ func (h *Handler) listConversations(c *gin.Context) {
 	c.JSON(http.StatusOK, convs)
 }
 
+// getMessages returns the messages of a conversation, optionally only those created between
+// the from and to query parameters
 func (h *Handler) getMessages(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := uuid.Parse(idStr)
@@ -363,8 +834,13 @@ func (h *Handler) getMessages(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid uuid"})
 		return
 	}
+	r, err := chat.ParseDateRange(c.Query("from"), c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	msgs, err := h.Chat.GetHistory(c.Request.Context(), id)
+	msgs, err := h.Chat.GetHistory(c.Request.Context(), id, r)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -375,68 +851,254 @@ func (h *Handler) getMessages(c *gin.Context) {
 	c.JSON(http.StatusOK, msgs)
 }
 
-func (h *Handler) sendMessage(c *gin.Context) {
-	idStr := c.Param("id")
-	id, err := uuid.Parse(idStr)
+// exportConversation returns a conversation as a Markdown transcript (default) or JSON,
+// including the tool calls behind each answer
+func (h *Handler) exportConversation(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid uuid"})
 		return
 	}
-
-	var req struct {
-		Content string `json:"content"`
-	}
-	if err := c.ShouldBindJSON(&req); err != nil {
+	format, err := chat.ParseExportFormat(c.Query("format"))
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	next, err := h.Chat.SendMessage(c.Request.Context(), id, req.Content)
+	exp, err := h.Chat.ExportConversation(c.Request.Context(), id)
+	if errors.Is(err, chat.ErrConversationNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.Header("Content-Type", "text/event-stream")
-	c.Header("Cache-Control", "no-cache")
-	c.Header("Connection", "keep-alive")
-	c.Header("Transfer-Encoding", "chunked")
+	if format == chat.ExportJSON {
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="conversation-%s.json"`, id))
+		c.JSON(http.StatusOK, exp)
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="conversation-%s.md"`, id))
+	c.Data(http.StatusOK, "text/markdown; charset=utf-8", []byte(chat.RenderMarkdown(exp)))
+}
 
-	for event, err := range next {
-		if err != nil {
-			// If we encounter an error during the stream, we try to send it as an event
-			errEvent := chat.StreamEvent{
-				Type:    "error",
-				Payload: err.Error(),
-			}
-			if data, err := json.Marshal(errEvent); err == nil {
-				_, _ = c.Writer.Write([]byte("data: "))
-				_, _ = c.Writer.Write(data)
-				_, _ = c.Writer.Write([]byte("\n\n"))
-				c.Writer.Flush()
-			}
-			return
-		}
+func (h *Handler) forkConversation(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid uuid"})
+		return
+	}
 
-		data, err := json.Marshal(event)
+	var fromMessageID *uuid.UUID
+	if fromStr := c.Query("from_message"); fromStr != "" {
+		msgID, err := uuid.Parse(fromStr)
 		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from_message uuid"})
 			return
 		}
-		_, _ = c.Writer.Write([]byte("data: "))
-		_, _ = c.Writer.Write(data)
-		_, _ = c.Writer.Write([]byte("\n\n"))
-		c.Writer.Flush()
+		fromMessageID = &msgID
 	}
-}
 
-func (h *Handler) createJob(c *gin.Context) {
+	conv, err := h.Chat.ForkConversation(c.Request.Context(), id, fromMessageID)
+	if err != nil {
+		h.conversationError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, conv)
+}
+
+func (h *Handler) getPinnedSources(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid uuid"})
+		return
+	}
+
+	sources, err := h.Chat.GetPinnedSources(c.Request.Context(), id)
+	if err != nil {
+		h.conversationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"pinned_sources": sources})
+}
+
+// listKnowledgeGaps lists the questions chat could not answer from a collection
+// (?collection=, default CHAT_COLLECTION)
+func (h *Handler) listKnowledgeGaps(c *gin.Context) {
+	collection := c.DefaultQuery("collection", h.Service.c.ChatCollection)
+	gaps, err := h.Tools.ListKnowledgeGaps(c.Request.Context(), collection)
+	if dbUnavailable(c, err) {
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"collection": collection, "gaps": gaps})
+}
+
+func (h *Handler) pinSource(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid uuid"})
+		return
+	}
+
+	var req struct {
+		Source string `json:"source"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if strings.TrimSpace(req.Source) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "source is required"})
+		return
+	}
+
+	sources, err := h.Chat.PinSource(c.Request.Context(), id, req.Source)
+	if err != nil {
+		h.conversationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"pinned_sources": sources})
+}
+
+// unpinSource takes the source as a query parameter since source URLs don't fit in a path segment
+func (h *Handler) unpinSource(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid uuid"})
+		return
+	}
+
+	source := c.Query("source")
+	if source == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "source query parameter is required"})
+		return
+	}
+
+	sources, err := h.Chat.UnpinSource(c.Request.Context(), id, source)
+	if err != nil {
+		h.conversationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"pinned_sources": sources})
+}
+
+func (h *Handler) conversationError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, chat.ErrConversationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, chat.ErrUnsupportedModel):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func (h *Handler) sendMessage(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid uuid"})
+		return
+	}
+
+	var req struct {
+		Content         string `json:"content"`
+		IncludeThinking bool   `json:"include_thinking"` // Stream the model's thought summaries (requires CHAT_THOUGHTS)
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Cancelling stops the agent run when the stream is dropped (e.g. slow client)
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+
+	next, err := h.Chat.SendMessage(ctx, id, req.Content, req.IncludeThinking)
+	if err != nil {
+		h.conversationError(c, err)
+		return
+	}
+
+	streamSSE(c, next, func(err error) chat.StreamEvent {
+		return chat.StreamEvent{Type: "error", Payload: err.Error()}
+	})
+}
+
+type EmbedRequest struct {
+	Texts []string `json:"texts"`
+}
+
+type EmbedResponse struct {
+	Model      string      `json:"model"`
+	Dimension  int         `json:"dimension"`
+	Embeddings [][]float32 `json:"embeddings"`
+}
+
+func (h *Handler) embedTexts(c *gin.Context) {
+	var req EmbedRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.Texts) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "texts must not be empty"})
+		return
+	}
+	if len(req.Texts) > maxEmbedTexts {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("at most %d texts per request", maxEmbedTexts)})
+		return
+	}
+
+	vectors, err := h.Tools.Embedder.EmbedTexts(c.Request.Context(), req.Texts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, EmbedResponse{
+		Model:      h.Tools.Embedder.Model(),
+		Dimension:  embeddings.Dimension,
+		Embeddings: vectors,
+	})
+}
+
+// dbUnavailable answers 503 with a Retry-After header when err is database.ErrPoolExhausted,
+// so clients back off instead of treating a saturated pool as a failure
+func dbUnavailable(c *gin.Context, err error) bool {
+	if !errors.Is(err, database.ErrPoolExhausted) {
+		return false
+	}
+	c.Header("Retry-After", "5")
+	c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	return true
+}
+
+func (h *Handler) createJob(c *gin.Context) {
 	var req CreateJobRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	job, err := h.Service.CreateJob(c.Request.Context(), req)
+	if dbUnavailable(c, err) {
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -445,8 +1107,78 @@ func (h *Handler) createJob(c *gin.Context) {
 	c.JSON(http.StatusCreated, job)
 }
 
+func (h *Handler) continueJob(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid uuid"})
+		return
+	}
+
+	var req ContinueJobRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.AdditionalIterations <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "additional_iterations must be positive"})
+		return
+	}
+
+	job, err := h.Service.ContinueJob(c.Request.Context(), id, req)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "job not found"})
+			return
+		}
+		if errors.Is(err, ErrJobNotContinuable) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, ErrTooManyIterations) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+func (h *Handler) reindexFailed(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid uuid"})
+		return
+	}
+
+	resp, err := h.Service.ReindexFailed(c.Request.Context(), id)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "job not found"})
+		return
+	case errors.Is(err, ErrJobActive):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if resp.Sources == 0 {
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+	c.JSON(http.StatusAccepted, resp)
+}
+
 func (h *Handler) listJobs(c *gin.Context) {
-	jobs, err := h.Service.ListJobs(c.Request.Context())
+	// ?tag=a&tag=b lists the jobs tagged with both
+	jobs, err := h.Service.ListJobs(c.Request.Context(), c.QueryArray("tag"))
+	if dbUnavailable(c, err) {
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -458,6 +1190,19 @@ func (h *Handler) listJobs(c *gin.Context) {
 	c.JSON(http.StatusOK, jobs)
 }
 
+// getStats returns aggregate statistics over all jobs
+func (h *Handler) getStats(c *gin.Context) {
+	stats, err := h.Service.GetStats(c.Request.Context())
+	if dbUnavailable(c, err) {
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
 func (h *Handler) getJob(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := uuid.Parse(idStr)
@@ -467,6 +1212,9 @@ func (h *Handler) getJob(c *gin.Context) {
 	}
 
 	job, err := h.Service.GetJob(c.Request.Context(), id)
+	if dbUnavailable(c, err) {
+		return
+	}
 	if err != nil {
 		// Differentiate 404 vs 500 later if needed
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -476,6 +1224,54 @@ func (h *Handler) getJob(c *gin.Context) {
 	c.JSON(http.StatusOK, job)
 }
 
+// streamReport streams the report of a job over SSE while it is generated, ending with a
+// done or error event. A completed job's report is sent as a single delta.
+func (h *Handler) streamReport(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid uuid"})
+		return
+	}
+
+	next, err := h.Service.StreamReport(c.Request.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "job not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	streamSSE(c, next, func(err error) ReportStreamEvent {
+		return ReportStreamEvent{Type: "error", Content: err.Error()}
+	})
+}
+
+// streamSources streams the sources of a job over SSE as they are found, filtered, skipped
+// and indexed, ending with a done or error event. A finished job's indexed sources are sent at once.
+func (h *Handler) streamSources(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid uuid"})
+		return
+	}
+
+	next, err := h.Service.StreamSources(c.Request.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "job not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	streamSSE(c, next, func(err error) SourceStreamEvent {
+		return SourceStreamEvent{Type: "error", Content: err.Error()}
+	})
+}
+
 func (h *Handler) getJobLogs(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := uuid.Parse(idStr)
@@ -495,3 +1291,269 @@ func (h *Handler) getJobLogs(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, logs)
 }
+
+func (h *Handler) getJobSources(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid uuid"})
+		return
+	}
+
+	sources, err := h.Service.GetJobSources(c.Request.Context(), id)
+	if dbUnavailable(c, err) {
+		return
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "job not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if sources == nil {
+		sources = []research.SearchResult{}
+	}
+	c.JSON(http.StatusOK, sources)
+}
+
+func (h *Handler) getJobState(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid uuid"})
+		return
+	}
+
+	state, err := h.Service.GetJobState(c.Request.Context(), id)
+	if dbUnavailable(c, err) {
+		return
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "job not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, state)
+}
+
+func (h *Handler) getJobTraces(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid uuid"})
+		return
+	}
+
+	traces, err := h.Service.GetJobTraces(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if traces == nil {
+		traces = []TraceEntry{}
+	}
+	c.JSON(http.StatusOK, traces)
+}
+
+func (h *Handler) replayTrace(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid uuid"})
+		return
+	}
+
+	traceID, err := strconv.Atoi(c.Param("traceId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid trace id"})
+		return
+	}
+
+	response, err := h.Service.ReplayTrace(c.Request.Context(), id, traceID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"response": response})
+}
+
+// getJobBundle downloads the report, sources, logs and documents of a job as one zip
+func (h *Handler) getJobBundle(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid uuid"})
+		return
+	}
+
+	bundle, err := h.Service.GetJobBundle(c.Request.Context(), id)
+	if dbUnavailable(c, err) {
+		return
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "job not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="research-%s.zip"`, id))
+	c.Status(http.StatusOK)
+	if err := bundle.WriteZip(c.Writer); err != nil {
+		slog.Error("Failed to write job bundle", "job_id", id, "error", err)
+	}
+}
+
+// deleteJobDocuments removes the documents indexed by a job from its collection
+func (h *Handler) deleteJobDocuments(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid uuid"})
+		return
+	}
+
+	collection, deleted, err := h.Service.DeleteJobDocuments(c.Request.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "job not found"})
+		case errors.Is(err, ErrJobActive):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"collection": collection,
+		"deleted":    deleted,
+	})
+}
+
+func (h *Handler) getDocument(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid uuid"})
+		return
+	}
+
+	doc, err := h.Service.GetDocument(c.Request.Context(), c.Param("name"), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, vectorstore.ErrInvalidTableName):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, vectorstore.ErrDocumentNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, doc)
+}
+
+// CurateRequest selects the documents to mark; Curated defaults to true
+type CurateRequest struct {
+	Filter  map[string]interface{} `json:"filter" binding:"required"`
+	Curated *bool                  `json:"curated"`
+}
+
+func (h *Handler) curateDocuments(c *gin.Context) {
+	var req CurateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	curated := req.Curated == nil || *req.Curated
+
+	updated, err := h.Service.CurateDocuments(c.Request.Context(), c.Param("name"), req.Filter, curated)
+	if err != nil {
+		collectionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"collection": c.Param("name"),
+		"curated":    curated,
+		"updated":    updated,
+	})
+}
+
+// PromoteRequest selects the documents to copy and where to; both are optional (see
+// Service.PromoteDocuments)
+type PromoteRequest struct {
+	Target string                 `json:"target"`
+	Filter map[string]interface{} `json:"filter"`
+}
+
+func (h *Handler) promoteDocuments(c *gin.Context) {
+	var req PromoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	target, promoted, err := h.Service.PromoteDocuments(c.Request.Context(), c.Param("name"), req.Target, req.Filter)
+	if err != nil {
+		collectionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"collection": c.Param("name"),
+		"target":     target,
+		"promoted":   promoted,
+	})
+}
+
+// collectionError responds 400 for invalid collection names and filters, 500 otherwise
+func collectionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, vectorstore.ErrInvalidTableName),
+		errors.Is(err, vectorstore.ErrInvalidFilter),
+		errors.Is(err, vectorstore.ErrSameCollection):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func (h *Handler) pruneCollection(c *gin.Context) {
+	name := c.Param("name")
+
+	evicted, err := h.Service.PruneCollection(c.Request.Context(), name)
+	if err != nil {
+		collectionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"collection":    name,
+		"max_documents": h.Service.c.MaxCollectionDocuments,
+		"evicted":       evicted,
+	})
+}
+
+func (h *Handler) compactCollection(c *gin.Context) {
+	name := c.Param("name")
+
+	start := time.Now()
+	if err := h.Service.CompactCollection(c.Request.Context(), name); err != nil {
+		collectionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"collection":  name,
+		"status":      "compacted",
+		"duration_ms": time.Since(start).Milliseconds(),
+	})
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mikeboe/research-helper/pkg/chat"
+	"github.com/mikeboe/research-helper/pkg/database"
+)
+
+func TestSendResultSerialization(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &Handler{}
+
+	tests := []struct {
+		name   string
+		result interface{}
+		want   string
+	}{
+		{"text content", chat.SearchContentResp{Results: "[Source]: a"}, "[Source]: a"},
+		{"json fallback", struct {
+			Status string `json:"status"`
+		}{Status: "completed"}, "{\n  \"status\": \"completed\"\n}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			h.sendResult(c, 1, tt.result)
+
+			var resp struct {
+				Result struct {
+					Content []struct {
+						Text string `json:"text"`
+					} `json:"content"`
+				} `json:"result"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("invalid response: %v", err)
+			}
+			if len(resp.Result.Content) != 1 || resp.Result.Content[0].Text != tt.want {
+				t.Errorf("text = %+v, want %q", resp.Result.Content, tt.want)
+			}
+		})
+	}
+}
+
+func TestDBUnavailable(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"other error", errors.New("boom"), false},
+		{"pool exhausted", fmt.Errorf("failed to get job: %w", database.ErrPoolExhausted), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			if got := dbUnavailable(c, tt.err); got != tt.want {
+				t.Fatalf("dbUnavailable() = %v, want %v", got, tt.want)
+			}
+			if tt.want && (w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") == "") {
+				t.Errorf("status = %d, Retry-After = %q", w.Code, w.Header().Get("Retry-After"))
+			}
+		})
+	}
+}
@@ -3,32 +3,62 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"log/slog"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/mikeboe/research-helper/pkg/database"
 )
 
-// DBLogHandler is a slog.Handler that writes records to the database
+// Records are queued and written by a background goroutine, so logging never blocks the
+// research loop on the database. While the connection pool is saturated the writer backs off,
+// so job logging doesn't take connections from the request path. A record that still can't
+// be written, or arrives while logBufferSize records are waiting, is dropped.
+const (
+	logBufferSize     = 1024
+	logWriteAttempts  = 4
+	logAcquireTimeout = 500 * time.Millisecond
+	logBackoff        = 100 * time.Millisecond // Doubled after every attempt
+)
+
+// DBLogHandler is a slog.Handler that writes records to the database. Close it when the
+// job is done to flush the queued records.
 type DBLogHandler struct {
 	DB    *database.PostgresDB
 	JobID uuid.UUID
 
-	// We wrap a text handler for formatting fallback or local console output if desired
-	// But for this specific requirement, we want structured DB inserts.
+	mu      sync.Mutex
+	closed  bool
+	records chan logRecord
+	done    chan struct{}
+}
+
+// logRecord is a record queued for writing, with its attributes already serialized
+type logRecord struct {
+	record   slog.Record
+	metaJSON []byte
 }
 
 func NewDBLogHandler(db *database.PostgresDB, jobID uuid.UUID) *DBLogHandler {
-	return &DBLogHandler{
-		DB:    db,
-		JobID: jobID,
+	h := &DBLogHandler{
+		DB:      db,
+		JobID:   jobID,
+		records: make(chan logRecord, logBufferSize),
+		done:    make(chan struct{}),
 	}
+	go h.run()
+	return h
 }
 
 func (h *DBLogHandler) Enabled(ctx context.Context, level slog.Level) bool {
 	return true // Log everything
 }
 
+// Handle queues the record for the background writer without waiting for the database
 func (h *DBLogHandler) Handle(ctx context.Context, r slog.Record) error {
 	// Extract attributes to JSON
 	attrs := make(map[string]interface{})
@@ -43,6 +73,69 @@ func (h *DBLogHandler) Handle(ctx context.Context, r slog.Record) error {
 		metaJSON = []byte("{}")
 	}
 
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return errLogHandlerClosed
+	}
+	select {
+	case h.records <- logRecord{record: r.Clone(), metaJSON: metaJSON}:
+		return nil
+	default:
+		log.Printf("Dropping log record of job %s, log queue full: %s", h.JobID, r.Message)
+		return fmt.Errorf("%w: log record dropped", errLogQueueFull)
+	}
+}
+
+// Close stops accepting records and waits until the queued ones are written or dropped
+func (h *DBLogHandler) Close() {
+	h.mu.Lock()
+	if !h.closed {
+		h.closed = true
+		close(h.records)
+	}
+	h.mu.Unlock()
+	<-h.done
+}
+
+var (
+	errLogHandlerClosed = errors.New("log handler closed")
+	errLogQueueFull     = errors.New("log queue full")
+)
+
+// run writes queued records until the handler is closed
+func (h *DBLogHandler) run() {
+	defer close(h.done)
+	for rec := range h.records {
+		h.write(rec)
+	}
+}
+
+// write inserts a record, backing off while the pool is exhausted
+func (h *DBLogHandler) write(rec logRecord) {
+	backoff := logBackoff
+	for attempt := 1; ; attempt++ {
+		if !h.DB.Saturated() {
+			err := h.insert(rec.record, rec.metaJSON)
+			if err == nil {
+				return
+			}
+			if !errors.Is(err, database.ErrPoolExhausted) {
+				log.Printf("Dropping log record of job %s: %v: %s", h.JobID, err, rec.record.Message)
+				return
+			}
+		}
+		if attempt == logWriteAttempts {
+			log.Printf("Dropping log record of job %s, database pool exhausted: %s", h.JobID, rec.record.Message)
+			return
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// insert writes a record, waiting at most logAcquireTimeout for a connection
+func (h *DBLogHandler) insert(r slog.Record, metaJSON []byte) error {
 	query := `
 		INSERT INTO research_logs (job_id, timestamp, level, message, metadata)
 		VALUES ($1, $2, $3, $4, $5)
@@ -50,7 +143,14 @@ func (h *DBLogHandler) Handle(ctx context.Context, r slog.Record) error {
 
 	// Use background context for insert to ensure logs persist even if request context cancels
 	// (though usually we are running in a background worker context anyway)
-	_, err = h.DB.Pool.Exec(context.Background(), query, h.JobID, r.Time, r.Level.String(), r.Message, metaJSON)
+	ctx := context.Background()
+	conn, err := h.DB.AcquireWithin(ctx, logAcquireTimeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	_, err = conn.Exec(ctx, query, h.JobID, r.Time, r.Level.String(), r.Message, metaJSON)
 	return err
 }
 
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var (
+	// ErrMCPUnauthorized is returned when MCP_COLLECTION_KEYS is set and a session is
+	// initialized without one of its keys
+	ErrMCPUnauthorized = errors.New("invalid or missing API key")
+	// ErrMCPForbidden is returned when an MCP session uses a collection outside its scope
+	ErrMCPForbidden = errors.New("collection not accessible in this session")
+)
+
+// mcpErrForbidden is the JSON-RPC error code of ErrMCPUnauthorized and ErrMCPForbidden
+const mcpErrForbidden = -32001
+
+// mcpCollectionsHeader narrows a session to a comma-separated list of collections at initialize
+const mcpCollectionsHeader = "Mcp-Collections"
+
+// resolveSessionScope returns the collections a new MCP session may use, nil meaning all.
+// With scopes configured (API key to collections) the apiKey must be one of them, and the
+// session gets the key's collections or the requested subset of them. Without, a session
+// can still narrow itself to the requested collections.
+func resolveSessionScope(scopes map[string][]string, apiKey string, requested []string) ([]string, error) {
+	if scopes == nil {
+		if len(requested) == 0 {
+			return nil, nil
+		}
+		return requested, nil
+	}
+
+	var allowed []string
+	found := false
+	for key, collections := range scopes {
+		// Compare against every key so timing doesn't reveal which keys exist
+		if subtle.ConstantTimeCompare([]byte(apiKey), []byte(key)) == 1 {
+			allowed, found = collections, true
+		}
+	}
+	if !found {
+		return nil, ErrMCPUnauthorized
+	}
+	if len(requested) == 0 {
+		return allowed, nil
+	}
+	for _, name := range requested {
+		if !slices.Contains(allowed, name) {
+			return nil, fmt.Errorf("%w: %q", ErrMCPForbidden, name)
+		}
+	}
+	return requested, nil
+}
+
+// parseCollectionList splits a comma-separated list of collection names, dropping empty entries
+func parseCollectionList(s string) []string {
+	var names []string
+	for _, name := range strings.Split(s, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// collection resolves the collection a tool call uses: the requested one, or else
+// defaultCollection if the session may use it, or else the first collection in scope.
+// Requests outside the scope fail with ErrMCPForbidden.
+func (s *MCPSession) collection(requested, defaultCollection string) (string, error) {
+	if len(s.Collections) == 0 {
+		if requested == "" {
+			return defaultCollection, nil
+		}
+		return requested, nil
+	}
+	if requested == "" {
+		if slices.Contains(s.Collections, defaultCollection) {
+			return defaultCollection, nil
+		}
+		return s.Collections[0], nil
+	}
+	if !slices.Contains(s.Collections, requested) {
+		return "", fmt.Errorf("%w: %q", ErrMCPForbidden, requested)
+	}
+	return requested, nil
+}
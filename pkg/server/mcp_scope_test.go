@@ -0,0 +1,77 @@
+package server
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestResolveSessionScope(t *testing.T) {
+	scopes := map[string][]string{"k1": {"a", "b"}, "k2": {"c"}}
+	tests := []struct {
+		name      string
+		scopes    map[string][]string
+		key       string
+		requested []string
+		want      []string
+		wantErr   error
+	}{
+		{"unrestricted", nil, "", nil, nil, nil},
+		{"self-scoped", nil, "", []string{"a"}, []string{"a"}, nil},
+		{"key scope", scopes, "k1", nil, []string{"a", "b"}, nil},
+		{"narrowed key scope", scopes, "k1", []string{"b"}, []string{"b"}, nil},
+		{"outside key scope", scopes, "k2", []string{"a"}, nil, ErrMCPForbidden},
+		{"unknown key", scopes, "k3", nil, nil, ErrMCPUnauthorized},
+		{"missing key", scopes, "", nil, nil, ErrMCPUnauthorized},
+		{"invalid configuration", map[string][]string{}, "k1", nil, nil, ErrMCPUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveSessionScope(tt.scopes, tt.key, tt.requested)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("resolveSessionScope() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("resolveSessionScope() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSessionCollection(t *testing.T) {
+	tests := []struct {
+		name      string
+		scope     []string
+		requested string
+		want      string
+		wantErr   bool
+	}{
+		{"unrestricted default", nil, "", "chat", false},
+		{"unrestricted request", nil, "other", "other", false},
+		{"default in scope", []string{"x", "chat"}, "", "chat", false},
+		{"default out of scope", []string{"x", "y"}, "", "x", false},
+		{"request in scope", []string{"x", "y"}, "y", "y", false},
+		{"request out of scope", []string{"x"}, "chat", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &MCPSession{Collections: tt.scope}
+			got, err := s.collection(tt.requested, "chat")
+			if (err != nil) != tt.wantErr || (err != nil && !errors.Is(err, ErrMCPForbidden)) {
+				t.Fatalf("collection() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("collection() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCollectionList(t *testing.T) {
+	if got, want := parseCollectionList(" a, ,b "), []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("parseCollectionList() = %v, want %v", got, want)
+	}
+	if got := parseCollectionList(""); got != nil {
+		t.Errorf("parseCollectionList(\"\") = %v, want nil", got)
+	}
+}
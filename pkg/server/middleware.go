@@ -0,0 +1,109 @@
+package server
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requireAPIKey rejects requests whose X-API-Key header does not match key.
+// With no key configured the guarded routes are disabled entirely.
+func requireAPIKey(key string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if key == "" {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "endpoint disabled: API_KEY is not configured"})
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(c.GetHeader("X-API-Key")), []byte(key)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid or missing API key"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// limitBody rejects request bodies larger than maxBytes. Bodies declaring a larger length are
+// rejected up front; otherwise reading fails with *http.MaxBytesError once the limit is passed.
+// A non-positive limit disables the check.
+func limitBody(maxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if maxBytes <= 0 {
+			c.Next()
+			return
+		}
+		if c.Request.ContentLength > maxBytes {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("request body exceeds %d bytes", maxBytes)})
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+		c.Next()
+	}
+}
+
+// rateLimiter allows each client a fixed number of requests per window
+type rateLimiter struct {
+	limit  int
+	window time.Duration
+
+	mu      sync.Mutex
+	clients map[string]*rateWindow
+}
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+	return &rateLimiter{
+		limit:   limit,
+		window:  window,
+		clients: make(map[string]*rateWindow),
+	}
+}
+
+// allow records a request from client at now. It returns false and the time until
+// the client's window resets once the limit is exceeded.
+func (rl *rateLimiter) allow(client string, now time.Time) (bool, time.Duration) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	w, ok := rl.clients[client]
+	if !ok || now.Sub(w.start) >= rl.window {
+		// Drop expired windows so the map doesn't grow with every client ever seen
+		for k, other := range rl.clients {
+			if now.Sub(other.start) >= rl.window {
+				delete(rl.clients, k)
+			}
+		}
+		w = &rateWindow{start: now}
+		rl.clients[client] = w
+	}
+
+	if w.count >= rl.limit {
+		return false, w.start.Add(rl.window).Sub(now)
+	}
+	w.count++
+	return true, 0
+}
+
+// middleware limits requests per client IP. A non-positive limit disables limiting.
+func (rl *rateLimiter) middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if rl.limit <= 0 {
+			c.Next()
+			return
+		}
+		if ok, retryAfter := rl.allow(c.ClientIP(), time.Now()); !ok {
+			c.Header("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded"})
+			return
+		}
+		c.Next()
+	}
+}
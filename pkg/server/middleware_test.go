@@ -0,0 +1,75 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRateLimiterAllow(t *testing.T) {
+	rl := newRateLimiter(2, time.Minute)
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := rl.allow("a", now); !ok {
+			t.Fatalf("request %d rejected within limit", i+1)
+		}
+	}
+
+	ok, retryAfter := rl.allow("a", now.Add(10*time.Second))
+	if ok {
+		t.Fatal("request over limit allowed")
+	}
+	if retryAfter != 50*time.Second {
+		t.Errorf("retryAfter = %v, want 50s", retryAfter)
+	}
+
+	if ok, _ := rl.allow("b", now); !ok {
+		t.Error("limit applied across clients")
+	}
+
+	if ok, _ := rl.allow("a", now.Add(time.Minute)); !ok {
+		t.Error("request rejected after window reset")
+	}
+}
+
+func TestLimitBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/", limitBody(8), func(c *gin.Context) {
+		if _, err := io.ReadAll(c.Request.Body); err != nil {
+			c.Status(http.StatusRequestEntityTooLarge)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		name          string
+		body          string
+		unknownLength bool
+		want          int
+	}{
+		{"within limit", "12345678", false, http.StatusOK},
+		{"declared too large", "123456789", false, http.StatusRequestEntityTooLarge},
+		{"read too large", "123456789", true, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			if tt.unknownLength {
+				req.ContentLength = -1
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"github.com/mikeboe/research-helper/pkg/research"
+)
+
+// ReindexFailedResponse reports how many failed sources a reindex run retries
+type ReindexFailedResponse struct {
+	JobID   uuid.UUID `json:"job_id"`
+	Sources int       `json:"sources"` // 0 means there was nothing to retry and no run was started
+}
+
+// ReindexFailed starts retrying the sources a finished job could only index from their
+// snippet. The job is marked running while the sources are scraped again and returns to its
+// previous status afterwards; progress is written to the job's logs and state.
+func (s *Service) ReindexFailed(ctx context.Context, jobID uuid.UUID) (*ReindexFailedResponse, error) {
+	var status string
+	var configJSON, stateJSON []byte
+	err := s.DB.Pool.QueryRow(ctx, "SELECT status, config, state FROM research_jobs WHERE id = $1", jobID).Scan(&status, &configJSON, &stateJSON)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+	if status == "pending" || status == "running" {
+		return nil, fmt.Errorf("%w: status %s", ErrJobActive, status)
+	}
+
+	var state research.ResearchState
+	if stateJSON != nil {
+		if err := json.Unmarshal(stateJSON, &state); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal state: %w", err)
+		}
+	}
+	if len(state.ScrapeFailures) == 0 {
+		return &ReindexFailedResponse{JobID: jobID}, nil
+	}
+
+	var jobCfg research.JobConfig
+	if configJSON != nil {
+		if err := json.Unmarshal(configJSON, &jobCfg); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+		}
+	}
+
+	// Claim the job only if its status hasn't changed, so a concurrent continue can't run alongside
+	tag, err := s.DB.Pool.Exec(ctx,
+		`UPDATE research_jobs SET status = 'running', updated_at = NOW() WHERE id = $1 AND status = $2`,
+		jobID, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim job: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, fmt.Errorf("%w: status changed", ErrJobActive)
+	}
+
+	go s.runReindex(jobID, status, jobCfg.ResearchConfig(s.Cfg), &state)
+
+	return &ReindexFailedResponse{JobID: jobID, Sources: len(state.ScrapeFailures)}, nil
+}
+
+// runReindex retries the failed sources of a job, then restores its status
+func (s *Service) runReindex(jobID uuid.UUID, previousStatus string, cfg research.Config, state *research.ResearchState) {
+	ctx := context.Background()
+	logHandler := NewDBLogHandler(s.DB, jobID)
+	defer logHandler.Close()
+	dbLogger := slog.New(logHandler)
+	defer func() {
+		if err := s.DB.SetJobStatus(ctx, jobID, previousStatus); err != nil {
+			dbLogger.Error("Failed to restore job status", "status", previousStatus, "error", err)
+		}
+	}()
+
+	engine, err := research.NewEngine(cfg, s.DB, s.c)
+	if err != nil {
+		dbLogger.Error("Failed to init engine for reindexing", "error", err)
+		return
+	}
+	engine.Logger = dbLogger
+	engine.JobID = jobID.String()
+	engine.RestoreState(state)
+	engine.OnStateUpdate = research.PersistState(s.DB, jobID, dbLogger)
+
+	dbLogger.Info("Reindexing failed sources", "sources", len(state.ScrapeFailures))
+	result, err := engine.ReindexFailed(ctx)
+	if err != nil {
+		dbLogger.Error("Reindexing failed", "error", err)
+		return
+	}
+	dbLogger.Info("Reindexing complete", "retried", result.Retried, "recovered", result.Recovered, "failed", result.Failed)
+}
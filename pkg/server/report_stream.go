@@ -0,0 +1,164 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/mikeboe/research-helper/pkg/research"
+)
+
+// reportSubscriberBuffer is how many report events may queue up for a subscriber before it is dropped
+const reportSubscriberBuffer = 256
+
+// ReportStreamEvent is an event of GET /api/research/:id/report/stream
+type ReportStreamEvent struct {
+	Type    string `json:"type"` // "delta", "reset" (discard the text so far), "done" or "error"
+	Content string `json:"content,omitempty"`
+}
+
+// reportHub fans the report deltas of running jobs out to their stream subscribers.
+// It keeps the text generated so far, so subscribers joining mid-report catch up first.
+type reportHub struct {
+	mu      sync.Mutex
+	streams map[uuid.UUID]*reportStream
+}
+
+type reportStream struct {
+	text strings.Builder
+	subs map[chan ReportStreamEvent]struct{}
+}
+
+func newReportHub() *reportHub {
+	return &reportHub{streams: make(map[uuid.UUID]*reportStream)}
+}
+
+func (h *reportHub) stream(jobID uuid.UUID) *reportStream {
+	st, ok := h.streams[jobID]
+	if !ok {
+		st = &reportStream{subs: make(map[chan ReportStreamEvent]struct{})}
+		h.streams[jobID] = st
+	}
+	return st
+}
+
+// subscribe returns the report text generated so far and a channel of the following events.
+// The channel is closed after a done or error event, or when the subscriber falls too far
+// behind. cancel must be called once the subscriber is done.
+func (h *reportHub) subscribe(jobID uuid.UUID) (string, <-chan ReportStreamEvent, func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	st := h.stream(jobID)
+	ch := make(chan ReportStreamEvent, reportSubscriberBuffer)
+	st.subs[ch] = struct{}{}
+
+	cancel := func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := st.subs[ch]; ok {
+			delete(st.subs, ch)
+			close(ch)
+		}
+		if len(st.subs) == 0 && st.text.Len() == 0 && h.streams[jobID] == st {
+			delete(h.streams, jobID)
+		}
+	}
+	return st.text.String(), ch, cancel
+}
+
+// publish records a report delta of a job and sends it to the subscribers
+func (h *reportHub) publish(jobID uuid.UUID, delta research.ReportDelta) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	st := h.stream(jobID)
+	event := ReportStreamEvent{Type: "delta", Content: delta.Text}
+	if delta.Restart {
+		st.text.Reset()
+		event = ReportStreamEvent{Type: "reset"}
+	} else {
+		st.text.WriteString(delta.Text)
+	}
+	st.send(event)
+}
+
+// finish sends the final event of a job's report to the subscribers and forgets the stream
+func (h *reportHub) finish(jobID uuid.UUID, event ReportStreamEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	st, ok := h.streams[jobID]
+	if !ok {
+		return
+	}
+	st.send(event)
+	for ch := range st.subs {
+		delete(st.subs, ch)
+		close(ch)
+	}
+	delete(h.streams, jobID)
+}
+
+// send delivers event without blocking the engine; subscribers with a full buffer are dropped
+func (st *reportStream) send(event ReportStreamEvent) {
+	for ch := range st.subs {
+		select {
+		case ch <- event:
+		default:
+			delete(st.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// StreamReport streams the report of a job as it is generated. A completed job yields its
+// report as a single delta. Subscribing before reading the job status ensures a report that
+// completes in between is not missed.
+func (s *Service) StreamReport(ctx context.Context, jobID uuid.UUID) (iter.Seq2[ReportStreamEvent, error], error) {
+	text, events, cancel := s.reports.subscribe(jobID)
+
+	job, err := s.GetJob(ctx, jobID)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	return func(yield func(ReportStreamEvent, error) bool) {
+		defer cancel()
+
+		switch job.Status {
+		case "completed":
+			if job.Report != nil && !yield(ReportStreamEvent{Type: "delta", Content: *job.Report}, nil) {
+				return
+			}
+			yield(ReportStreamEvent{Type: "done"}, nil)
+			return
+		case "failed":
+			yield(ReportStreamEvent{Type: "error", Content: "job failed"}, nil)
+			return
+		}
+
+		if text != "" && !yield(ReportStreamEvent{Type: "delta", Content: text}, nil) {
+			return
+		}
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					// Dropped for falling too far behind
+					yield(ReportStreamEvent{}, fmt.Errorf("report stream interrupted; fetch the job to get the report"))
+					return
+				}
+				if !yield(event, nil) || event.Type == "done" || event.Type == "error" {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}, nil
+}
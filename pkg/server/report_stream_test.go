@@ -0,0 +1,67 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/mikeboe/research-helper/pkg/research"
+)
+
+func TestReportHub(t *testing.T) {
+	hub := newReportHub()
+	job := uuid.New()
+
+	hub.publish(job, research.ReportDelta{Text: "# Rep"})
+	text, events, cancel := hub.subscribe(job)
+	defer cancel()
+	if text != "# Rep" {
+		t.Fatalf("catch-up text = %q, want %q", text, "# Rep")
+	}
+
+	hub.publish(job, research.ReportDelta{Text: "ort"})
+	hub.publish(job, research.ReportDelta{Restart: true})
+	hub.publish(job, research.ReportDelta{Text: "# Report"})
+	hub.finish(job, ReportStreamEvent{Type: "done"})
+
+	var got []ReportStreamEvent
+	for event := range events {
+		got = append(got, event)
+	}
+	want := []ReportStreamEvent{
+		{Type: "delta", Content: "ort"},
+		{Type: "reset"},
+		{Type: "delta", Content: "# Report"},
+		{Type: "done"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("events = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("event %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	if _, ok := hub.streams[job]; ok {
+		t.Error("finished stream was not removed")
+	}
+}
+
+func TestReportHubDropsSlowSubscriber(t *testing.T) {
+	hub := newReportHub()
+	job := uuid.New()
+
+	_, events, cancel := hub.subscribe(job)
+	defer cancel()
+	for i := 0; i <= reportSubscriberBuffer; i++ {
+		hub.publish(job, research.ReportDelta{Text: "x"})
+	}
+
+	n := 0
+	for range events {
+		n++
+	}
+	if n != reportSubscriberBuffer {
+		t.Errorf("received %d events before the drop, want %d", n, reportSubscriberBuffer)
+	}
+}
@@ -3,27 +3,37 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/mikeboe/research-helper/pkg/config"
 	"github.com/mikeboe/research-helper/pkg/database"
+	"github.com/mikeboe/research-helper/pkg/embeddings"
 	"github.com/mikeboe/research-helper/pkg/research"
+	"github.com/mikeboe/research-helper/pkg/vectorstore"
 )
 
 type Service struct {
 	DB  *database.PostgresDB
 	Cfg research.Config
 	c   *config.Config
+
+	reports *reportHub // Report deltas of the jobs running in this process
+	sources *sourceHub // Source events of the jobs running in this process
 }
 
 func NewService(db *database.PostgresDB, cfg research.Config, c *config.Config) *Service {
 	return &Service{
-		DB:  db,
-		Cfg: cfg,
-		c:   c,
+		DB:      db,
+		Cfg:     cfg,
+		c:       c,
+		reports: newReportHub(),
+		sources: newSourceHub(),
 	}
 }
 
@@ -35,63 +45,352 @@ type Job struct {
 	CreatedAt time.Time       `json:"created_at"`
 	UpdatedAt time.Time       `json:"updated_at"`
 	Config    json.RawMessage `json:"config"`
+	Tags      []string        `json:"tags"`
+	Metadata  json.RawMessage `json:"metadata,omitempty"`
+}
+
+// jobColumns are the research_jobs columns scanned by scanJob
+const jobColumns = "id, topic, status, report, created_at, updated_at, config, tags, metadata"
+
+func scanJob(row pgx.Row) (*Job, error) {
+	job := &Job{}
+	err := row.Scan(&job.ID, &job.Topic, &job.Status, &job.Report, &job.CreatedAt, &job.UpdatedAt, &job.Config, &job.Tags, &job.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// normalizeTags trims tags and drops empty and duplicate ones, keeping the first occurrence
+func normalizeTags(tags []string) []string {
+	out := []string{}
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		out = append(out, tag)
+	}
+	return out
 }
 
 type CreateJobRequest struct {
-	Topic string `json:"topic"`
+	Topic         string `json:"topic"`
+	ReportDepth   string `json:"report_depth,omitempty"`
+	MaxIterations int    `json:"max_iterations,omitempty"` // Research iterations, 1 to 50 (default 5)
+	ExtractFacts  bool   `json:"extract_facts,omitempty"`
+	MaxPDFPages   int    `json:"max_pdf_pages,omitempty"`
+	MaxPDFBytes   int64  `json:"max_pdf_bytes,omitempty"`
+	Trace         bool   `json:"trace,omitempty"`
+
+	Tags     []string               `json:"tags,omitempty"`     // Labels for organizing jobs, e.g. a project name; GET /api/research?tag= filters by them
+	Metadata map[string]interface{} `json:"metadata,omitempty"` // Free-form notes returned with the job
+
+	MinQueryTokens int  `json:"min_query_tokens,omitempty"`
+	RefineQueries  bool `json:"refine_queries,omitempty"`
+
+	ReflectionLookback int  `json:"reflection_lookback,omitempty"`
+	ReportProgression  bool `json:"report_progression,omitempty"`
+	ReportThemes       int  `json:"report_themes,omitempty"` // Write Key Findings per theme, clustering findings into up to this many (0 = one report prompt)
+
+	MaxChunksPerSource int    `json:"max_chunks_per_source,omitempty"`
+	OversizePolicy     string `json:"oversize_policy,omitempty"`
+	TitleEmbedding     string `json:"title_embedding,omitempty"`
+	StorePages         bool   `json:"store_pages,omitempty"`
+	StorePageImages    bool   `json:"store_page_images,omitempty"`
+	RecheckThreshold   int    `json:"recheck_threshold,omitempty"`
+	DeterministicIDs   bool   `json:"deterministic_ids,omitempty"`
+	IndexCaptions      bool   `json:"index_captions,omitempty"`
+	CleanChunks        bool   `json:"clean_chunks,omitempty"`
+	StreamSources      bool   `json:"stream_sources,omitempty"`
+	DistanceMetric     string `json:"distance_metric,omitempty"`
+	IndexType          string `json:"index_type,omitempty"`
+	EmbeddingWorkers   int    `json:"embedding_workers,omitempty"`
+
+	AllowedDomains []string `json:"allowed_domains,omitempty"`
+	BlockedDomains []string `json:"blocked_domains,omitempty"`
+
+	EmbedMetadata []string `json:"embed_metadata,omitempty"`
+
+	SeedSources []string `json:"seed_sources,omitempty"` // URLs or DOIs acquired before the first iteration
+
+	MinNewSources int `json:"min_new_sources,omitempty"`
+	ArxivResults  int `json:"arxiv_results,omitempty"` // arXiv results per search query (default 2)
+
+	GroupLimit int    `json:"group_limit,omitempty"`
+	GroupBy    string `json:"group_by,omitempty"`
+
+	SafetyBlock []string `json:"safety_block,omitempty"`
+	Redact      []string `json:"redact,omitempty"`
+
+	IterationDelayMs  int `json:"iteration_delay_ms,omitempty"`
+	IterationJitterMs int `json:"iteration_jitter_ms,omitempty"`
+
+	SubTopics           []string `json:"sub_topics,omitempty"`
+	SubTopicConcurrency int      `json:"sub_topic_concurrency,omitempty"`
+}
+
+// Validate checks the enumerated options of the request
+func (r CreateJobRequest) Validate() error {
+	if err := research.ValidateEmbeddingWorkers(r.EmbeddingWorkers); err != nil {
+		return err
+	}
+	if err := research.ValidateArxivResults(r.ArxivResults); err != nil {
+		return err
+	}
+	delay := time.Duration(r.IterationDelayMs) * time.Millisecond
+	jitter := time.Duration(r.IterationJitterMs) * time.Millisecond
+	if err := research.ValidateIterationPause(delay, jitter); err != nil {
+		return err
+	}
+	if r.MaxIterations != 0 {
+		if err := research.ValidateMaxIterations(r.MaxIterations); err != nil {
+			return err
+		}
+	}
+	if _, err := research.ParseReportDepth(r.ReportDepth); err != nil {
+		return err
+	}
+	if _, err := research.ParseOversizePolicy(r.OversizePolicy); err != nil {
+		return err
+	}
+	if _, err := research.ParseSourceGroup(r.GroupBy); err != nil {
+		return err
+	}
+	if _, err := research.ParseSafetyCategories(r.SafetyBlock); err != nil {
+		return err
+	}
+	if _, err := research.ParseRedactions(r.Redact); err != nil {
+		return err
+	}
+	if _, err := research.ParseTitleEmbedding(r.TitleEmbedding); err != nil {
+		return err
+	}
+	if _, err := vectorstore.ParseDistanceMetric(r.DistanceMetric); err != nil {
+		return err
+	}
+	if _, err := vectorstore.ParseIndexType(r.IndexType); err != nil {
+		return err
+	}
+	if _, err := research.ParseMetadataFields(r.EmbedMetadata); err != nil {
+		return err
+	}
+	if _, err := research.ParseSeedSources(r.SeedSources); err != nil {
+		return err
+	}
+	return nil
+}
+
+// researchConfig applies the request settings on top of base. The request must be valid.
+func (r CreateJobRequest) researchConfig(base research.Config) research.Config {
+	depth, _ := research.ParseReportDepth(r.ReportDepth)
+	oversize, _ := research.ParseOversizePolicy(r.OversizePolicy)
+	titleEmbedding, _ := research.ParseTitleEmbedding(r.TitleEmbedding)
+	metric, _ := vectorstore.ParseDistanceMetric(r.DistanceMetric)
+	indexType, _ := vectorstore.ParseIndexType(r.IndexType)
+	embedMetadata, _ := research.ParseMetadataFields(r.EmbedMetadata)
+	groupBy, _ := research.ParseSourceGroup(r.GroupBy)
+	safetyBlock, _ := research.ParseSafetyCategories(r.SafetyBlock)
+	redact, _ := research.ParseRedactions(r.Redact)
+	maxIterations := r.MaxIterations
+	if maxIterations == 0 {
+		maxIterations = research.DefaultMaxIterations
+	}
+
+	cfg := base
+	cfg.MaxIterations = maxIterations
+	cfg.ReportDepth = depth
+	cfg.ExtractFacts = r.ExtractFacts
+	cfg.MaxPDFPages = r.MaxPDFPages
+	cfg.MaxPDFBytes = r.MaxPDFBytes
+	cfg.Trace = r.Trace
+	cfg.MinQueryTokens = r.MinQueryTokens
+	cfg.RefineQueries = r.RefineQueries
+	cfg.ReflectionLookback = r.ReflectionLookback
+	cfg.ReportProgression = r.ReportProgression
+	cfg.ReportThemes = r.ReportThemes
+	cfg.MaxChunksPerSource = r.MaxChunksPerSource
+	cfg.OversizePolicy = oversize
+	cfg.TitleEmbedding = titleEmbedding
+	cfg.StorePages = r.StorePages
+	cfg.StorePageImages = r.StorePageImages
+	cfg.RecheckThreshold = r.RecheckThreshold
+	cfg.DeterministicIDs = r.DeterministicIDs
+	cfg.IndexCaptions = r.IndexCaptions
+	cfg.CleanChunks = r.CleanChunks
+	cfg.StreamSources = r.StreamSources
+	cfg.DistanceMetric = metric
+	cfg.IndexType = indexType
+	cfg.EmbeddingWorkers = r.EmbeddingWorkers
+	cfg.AllowedDomains = r.AllowedDomains
+	cfg.BlockedDomains = r.BlockedDomains
+	cfg.EmbedMetadata = embedMetadata
+	cfg.SeedSources = r.SeedSources
+	cfg.MinNewSources = r.MinNewSources
+	cfg.ArxivResults = r.ArxivResults
+	cfg.GroupLimit = r.GroupLimit
+	cfg.GroupBy = groupBy
+	cfg.SafetyBlock = safetyBlock
+	cfg.Redact = redact
+	cfg.IterationDelay = time.Duration(r.IterationDelayMs) * time.Millisecond
+	cfg.IterationJitter = time.Duration(r.IterationJitterMs) * time.Millisecond
+	cfg.SubTopics = r.SubTopics
+	cfg.SubTopicConcurrency = r.SubTopicConcurrency
+	return cfg
 }
 
 func (s *Service) CreateJob(ctx context.Context, req CreateJobRequest) (*Job, error) {
-	configJSON, _ := json.Marshal(map[string]interface{}{
-		"max_iterations": 5,
-		"collection":     s.c.CollectionName,
-	})
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	cfg := req.researchConfig(s.Cfg)
+	if s.c.ResearchCollection != "" {
+		cfg.Collection = s.c.ResearchCollection
+	}
+	// Stored through NewJobConfig, so it has exactly the fields ResearchConfig restores on
+	// resume and continue
+	jobCfg := research.NewJobConfig(cfg)
+
+	configJSON, _ := json.Marshal(jobCfg)
+	var metadataJSON []byte
+	if req.Metadata != nil {
+		metadataJSON, _ = json.Marshal(req.Metadata)
+	}
 
 	jobID := uuid.New()
 	query := `
-		INSERT INTO research_jobs (id, topic, status, config)
-		VALUES ($1, $2, 'pending', $3)
-		RETURNING id, topic, status, created_at, updated_at
-	`
+		INSERT INTO research_jobs (id, topic, status, config, tags, metadata)
+		VALUES ($1, $2, 'pending', $3, $4, $5)
+		RETURNING ` + jobColumns
 
-	job := &Job{}
-	err := s.DB.Pool.QueryRow(ctx, query, jobID, req.Topic, configJSON).Scan(
-		&job.ID, &job.Topic, &job.Status, &job.CreatedAt, &job.UpdatedAt,
-	)
+	conn, err := s.DB.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create job: %w", err)
+	}
+	defer conn.Release()
+
+	job, err := scanJob(conn.QueryRow(ctx, query, jobID, req.Topic, configJSON, normalizeTags(req.Tags), metadataJSON))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create job: %w", err)
 	}
 
 	// Start background worker
-	go s.runWorker(job.ID, req.Topic)
+	go s.runWorker(job.ID, req.Topic, cfg, nil)
 
 	return job, nil
 }
 
-func (s *Service) GetJob(ctx context.Context, id uuid.UUID) (*Job, error) {
+// ErrJobNotContinuable is returned when a job is still active, has no persisted state or
+// was split into sub-topics
+var ErrJobNotContinuable = errors.New("job cannot be continued")
+
+// ErrJobActive is returned for operations that need the job to be finished
+var ErrJobActive = errors.New("job is still active")
+
+// ErrTooManyIterations is returned when continuing a job would take it past
+// research.MaxIterationsLimit
+var ErrTooManyIterations = errors.New("too many iterations")
+
+type ContinueJobRequest struct {
+	AdditionalIterations int `json:"additional_iterations"`
+}
+
+// ContinueJob resumes a finished job from its persisted state with more iterations,
+// reusing the already indexed collection. The iterations are added to those the job has
+// run, which may be fewer than it was configured for if it stopped early. Jobs split into
+// sub-topics can't be continued, since every sub-topic is already complete.
+func (s *Service) ContinueJob(ctx context.Context, id uuid.UUID, req ContinueJobRequest) (*Job, error) {
+	if req.AdditionalIterations <= 0 {
+		return nil, fmt.Errorf("additional_iterations must be positive")
+	}
+
+	var configJSON, stateJSON []byte
+	err := s.DB.Pool.QueryRow(ctx, "SELECT config, state FROM research_jobs WHERE id = $1", id).Scan(&configJSON, &stateJSON)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+	if stateJSON == nil {
+		return nil, fmt.Errorf("%w: no persisted state", ErrJobNotContinuable)
+	}
+
+	var state research.ResearchState
+	if err := json.Unmarshal(stateJSON, &state); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal state: %w", err)
+	}
+	var jobCfg research.JobConfig
+	if configJSON != nil {
+		if err := json.Unmarshal(configJSON, &jobCfg); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+		}
+	}
+
+	if len(jobCfg.SubTopics) > 0 {
+		return nil, fmt.Errorf("%w: sub-topic jobs can't be continued", ErrJobNotContinuable)
+	}
+
+	total := state.Iteration + req.AdditionalIterations
+	if err := research.ValidateMaxIterations(total); err != nil {
+		return nil, fmt.Errorf("%w: job has run %d iterations: %v", ErrTooManyIterations, state.Iteration, err)
+	}
+	state.MaxIterations = total
+	jobCfg.MaxIterations = state.MaxIterations
+	newConfigJSON, err := json.Marshal(jobCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	// Only claim jobs that are no longer running, so concurrent continues can't start two workers
 	query := `
-		SELECT id, topic, status, report, created_at, updated_at, config
-		FROM research_jobs
-		WHERE id = $1
-	`
-	job := &Job{}
-	err := s.DB.Pool.QueryRow(ctx, query, id).Scan(
-		&job.ID, &job.Topic, &job.Status, &job.Report, &job.CreatedAt, &job.UpdatedAt, &job.Config,
-	)
+		UPDATE research_jobs
+		SET status = 'pending', config = $2, updated_at = NOW()
+		WHERE id = $1 AND status IN ('completed', 'failed')
+		RETURNING ` + jobColumns
+	job, err := scanJob(s.DB.Pool.QueryRow(ctx, query, id, newConfigJSON))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("%w: job is still running", ErrJobNotContinuable)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to continue job: %w", err)
+	}
+
+	go s.runWorker(job.ID, job.Topic, jobCfg.ResearchConfig(s.Cfg), &state)
+
+	return job, nil
+}
+
+func (s *Service) GetJob(ctx context.Context, id uuid.UUID) (*Job, error) {
+	conn, err := s.DB.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+	defer conn.Release()
+
+	query := `SELECT ` + jobColumns + ` FROM research_jobs WHERE id = $1`
+	job, err := scanJob(conn.QueryRow(ctx, query, id))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get job: %w", err)
 	}
 	return job, nil
 }
 
-func (s *Service) ListJobs(ctx context.Context) ([]Job, error) {
+// ListJobs returns the 50 most recent jobs, limited to jobs carrying all of tags if any are given
+func (s *Service) ListJobs(ctx context.Context, tags []string) ([]Job, error) {
 	query := `
-		SELECT id, topic, status, report, created_at, updated_at, config
+		SELECT ` + jobColumns + `
 		FROM research_jobs
+		WHERE tags @> $1
 		ORDER BY created_at DESC
 		LIMIT 50
 	`
-	rows, err := s.DB.Pool.Query(ctx, query)
+	conn, err := s.DB.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, query, normalizeTags(tags))
 	if err != nil {
 		return nil, fmt.Errorf("failed to list jobs: %w", err)
 	}
@@ -99,15 +398,35 @@ func (s *Service) ListJobs(ctx context.Context) ([]Job, error) {
 
 	var jobs []Job
 	for rows.Next() {
-		var job Job
-		if err := rows.Scan(&job.ID, &job.Topic, &job.Status, &job.Report, &job.CreatedAt, &job.UpdatedAt, &job.Config); err != nil {
+		job, err := scanJob(rows)
+		if err != nil {
 			continue
 		}
-		jobs = append(jobs, job)
+		jobs = append(jobs, *job)
 	}
 	return jobs, nil
 }
 
+// GetJobSources returns the sources indexed by a job, read from its persisted state
+func (s *Service) GetJobSources(ctx context.Context, jobID uuid.UUID) ([]research.SearchResult, error) {
+	var stateJSON []byte
+	err := s.DB.Pool.QueryRow(ctx, "SELECT state FROM research_jobs WHERE id = $1", jobID).Scan(&stateJSON)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job state: %w", err)
+	}
+	if stateJSON == nil {
+		return nil, nil
+	}
+
+	var state struct {
+		IndexedItems []research.SearchResult
+	}
+	if err := json.Unmarshal(stateJSON, &state); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal state: %w", err)
+	}
+	return state.IndexedItems, nil
+}
+
 type LogEntry struct {
 	ID        int             `json:"id"`
 	Timestamp time.Time       `json:"timestamp"`
@@ -140,16 +459,19 @@ func (s *Service) GetJobLogs(ctx context.Context, jobID uuid.UUID) ([]LogEntry,
 	return logs, nil
 }
 
-func (s *Service) runWorker(jobID uuid.UUID, topic string) {
+// runWorker runs the research loop for a job. A non-nil state resumes a previous run.
+func (s *Service) runWorker(jobID uuid.UUID, topic string, cfg research.Config, state *research.ResearchState) {
 	ctx := context.Background()
 
 	// Update status to running
-	_, _ = s.DB.Pool.Exec(ctx, "UPDATE research_jobs SET status = 'running', updated_at = NOW() WHERE id = $1", jobID)
+	_ = s.DB.SetJobStatus(ctx, jobID, "running")
 
 	// Configure engine with DB logger
-	dbLogger := slog.New(NewDBLogHandler(s.DB, jobID))
+	logHandler := NewDBLogHandler(s.DB, jobID)
+	defer logHandler.Close()
+	dbLogger := slog.New(logHandler)
 
-	engine, err := research.NewEngine(s.Cfg, s.DB, s.c)
+	engine, err := research.NewEngine(cfg, s.DB, s.c)
 	if err != nil {
 		s.failJob(ctx, jobID, fmt.Sprintf("Failed to init engine: %v", err))
 		return
@@ -157,21 +479,32 @@ func (s *Service) runWorker(jobID uuid.UUID, topic string) {
 
 	// Override logger
 	engine.Logger = dbLogger
+	engine.JobID = jobID.String()
+
+	if state != nil {
+		engine.RestoreState(state)
+		dbLogger.Info("Resuming research", "iteration", state.Iteration, "max_iterations", state.MaxIterations)
+	}
 
 	// Hook for state persistence
-	engine.OnStateUpdate = func(state research.ResearchState) {
-		stateJSON, err := json.Marshal(state)
-		if err != nil {
-			dbLogger.Error("Failed to marshal state", "error", err)
-			return
+	engine.OnStateUpdate = research.PersistState(s.DB, jobID, dbLogger)
+
+	// Hook for LLM trace persistence
+	engine.OnLLMCall = func(trace research.LLMTrace) {
+		if err := s.saveTrace(jobID, trace); err != nil {
+			dbLogger.Error("Failed to save LLM trace", "error", err)
 		}
+	}
 
-		_, err = s.DB.Pool.Exec(context.Background(),
-			"UPDATE research_jobs SET state = $2, updated_at = NOW() WHERE id = $1",
-			jobID, stateJSON)
+	// Hook for streaming the report to GET /api/research/:id/report/stream
+	engine.OnReportDelta = func(delta research.ReportDelta) {
+		s.reports.publish(jobID, delta)
+	}
 
-		if err != nil {
-			dbLogger.Error("Failed to save state to DB", "error", err)
+	// Hook for the research feed of GET /api/research/:id/sources/stream
+	if s.c.SourceFeed {
+		engine.OnSourceEvent = func(event research.SourceEvent) {
+			s.sources.publish(jobID, event)
 		}
 	}
 
@@ -182,20 +515,111 @@ func (s *Service) runWorker(jobID uuid.UUID, topic string) {
 	}
 
 	// Update job with report
-	_, err = s.DB.Pool.Exec(ctx,
-		"UPDATE research_jobs SET status = 'completed', report = $2, updated_at = NOW() WHERE id = $1",
-		jobID, report)
-
-	if err != nil {
+	if err := s.DB.CompleteJob(ctx, jobID, report); err != nil {
 		dbLogger.Error("Failed to save final report to DB", "error", err)
 	}
+	s.reports.finish(jobID, ReportStreamEvent{Type: "done"})
+	s.sources.finish(jobID, SourceStreamEvent{Type: "done"})
 }
 
 func (s *Service) failJob(ctx context.Context, jobID uuid.UUID, reason string) {
 	// Log the failure
-	dbLogger := slog.New(NewDBLogHandler(s.DB, jobID))
-	dbLogger.Error(reason)
+	logHandler := NewDBLogHandler(s.DB, jobID)
+	slog.New(logHandler).Error(reason)
+	logHandler.Close()
 
 	// Update status
-	_, _ = s.DB.Pool.Exec(ctx, "UPDATE research_jobs SET status = 'failed', updated_at = NOW() WHERE id = $1", jobID)
+	_ = s.DB.SetJobStatus(ctx, jobID, "failed")
+	s.reports.finish(jobID, ReportStreamEvent{Type: "error", Content: reason})
+	s.sources.finish(jobID, SourceStreamEvent{Type: "error", Content: reason})
+}
+
+// GetDocument returns a single document of a collection by ID
+func (s *Service) GetDocument(ctx context.Context, collection string, id uuid.UUID) (vectorstore.Document, error) {
+	store, err := vectorstore.NewPGVectorStore(s.DB.Pool, collection)
+	if err != nil {
+		return vectorstore.Document{}, err
+	}
+	return store.GetByID(ctx, id.String())
+}
+
+// DeleteJobDocuments removes the documents a job indexed (tagged with its job_id) from the
+// job's collection, leaving other jobs' documents in place. Running jobs are rejected with ErrJobActive.
+func (s *Service) DeleteJobDocuments(ctx context.Context, jobID uuid.UUID) (string, int64, error) {
+	var status string
+	var configJSON []byte
+	err := s.DB.Pool.QueryRow(ctx, "SELECT status, config FROM research_jobs WHERE id = $1", jobID).Scan(&status, &configJSON)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to get job: %w", err)
+	}
+	if status == "pending" || status == "running" {
+		return "", 0, fmt.Errorf("%w: status %s", ErrJobActive, status)
+	}
+
+	var jobCfg research.JobConfig
+	if configJSON != nil {
+		if err := json.Unmarshal(configJSON, &jobCfg); err != nil {
+			return "", 0, fmt.Errorf("failed to unmarshal config: %w", err)
+		}
+	}
+	collection := jobCfg.ResearchConfig(s.Cfg).Collection
+
+	store, err := vectorstore.NewPGVectorStore(s.DB.Pool, collection)
+	if err != nil {
+		return "", 0, err
+	}
+	deleted, err := store.DeleteByMetadata(ctx, map[string]interface{}{"job_id": jobID.String()})
+	if err != nil {
+		return "", 0, err
+	}
+	return collection, deleted, nil
+}
+
+// CurateDocuments sets the curated flag on the documents of a collection matching filter.
+// An empty filter is rejected rather than marking the whole collection.
+func (s *Service) CurateDocuments(ctx context.Context, collection string, filter map[string]interface{}, curated bool) (int64, error) {
+	if len(filter) == 0 {
+		return 0, fmt.Errorf("%w: a filter is required", vectorstore.ErrInvalidFilter)
+	}
+	store, err := vectorstore.NewPGVectorStore(s.DB.Pool, collection)
+	if err != nil {
+		return 0, err
+	}
+	return store.MarkCurated(ctx, filter, curated)
+}
+
+// PromoteDocuments copies the documents of a collection matching filter into target,
+// defaulting to the documents marked curated and to the chat collection
+func (s *Service) PromoteDocuments(ctx context.Context, collection, target string, filter map[string]interface{}) (string, int64, error) {
+	if target == "" {
+		target = s.c.ChatCollection
+	}
+	if len(filter) == 0 {
+		filter = vectorstore.CuratedFilter()
+	}
+	promoted, err := s.DB.PromoteDocuments(ctx, collection, target, embeddings.Dimension, filter)
+	if err != nil {
+		return "", 0, err
+	}
+	return target, promoted, nil
+}
+
+// PruneCollection applies the configured document limit to a collection, e.g. after
+// lowering MAX_COLLECTION_DOCUMENTS, and returns how many documents were evicted
+func (s *Service) PruneCollection(ctx context.Context, collection string) (int64, error) {
+	store, err := vectorstore.NewPGVectorStore(s.DB.Pool, collection)
+	if err != nil {
+		return 0, err
+	}
+	return store.Prune(ctx, vectorstore.Retention{MaxDocuments: s.c.MaxCollectionDocuments, Evict: vectorstore.Eviction(s.c.EvictionPolicy)})
+}
+
+// CompactCollection rebuilds the indexes of a collection and reclaims the space left by
+// deleted and replaced documents, see vectorstore.PGVectorStore.Compact
+func (s *Service) CompactCollection(ctx context.Context, collection string) error {
+	store, err := vectorstore.NewPGVectorStore(s.DB.Pool, collection)
+	if err != nil {
+		return err
+	}
+	return store.Compact(ctx)
 }
@@ -0,0 +1,127 @@
+package server
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mikeboe/research-helper/pkg/research"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []string
+		want []string
+	}{
+		{"Nil", nil, []string{}},
+		{"Trim and drop empty", []string{" thesis ", "", "  "}, []string{"thesis"}},
+		{"Dedupe keeps first", []string{"b", "a", "b", " a"}, []string{"b", "a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeTags(tt.in); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("normalizeTags(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateJobRequestMaxIterations(t *testing.T) {
+	tests := []struct {
+		name    string
+		n       int
+		wantErr bool
+	}{
+		{"Unset uses default", 0, false},
+		{"Minimum", 1, false},
+		{"Maximum", 50, false},
+		{"Negative", -1, true},
+		{"Above limit", 51, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CreateJobRequest{Topic: "t", MaxIterations: tt.n}.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() with max_iterations %d error = %v, wantErr %v", tt.n, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCreateJobRequestEmbeddingWorkers(t *testing.T) {
+	tests := []struct {
+		name    string
+		n       int
+		wantErr bool
+	}{
+		{"Synchronous", 0, false},
+		{"Maximum", 16, false},
+		{"Negative", -1, true},
+		{"Above limit", 10000, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CreateJobRequest{Topic: "t", EmbeddingWorkers: tt.n}.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() with embedding_workers %d error = %v, wantErr %v", tt.n, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCreateJobRequestArxivResults(t *testing.T) {
+	for n, wantErr := range map[int]bool{0: false, 120: false, 500: false, -1: true, 100000: true} {
+		err := CreateJobRequest{Topic: "t", ArxivResults: n}.Validate()
+		if (err != nil) != wantErr {
+			t.Errorf("Validate() with arxiv_results %d error = %v, wantErr %v", n, err, wantErr)
+		}
+	}
+}
+
+func TestCreateJobRequestIterationPause(t *testing.T) {
+	tests := []struct {
+		name          string
+		delay, jitter int
+		wantErr       bool
+	}{
+		{"None", 0, 0, false},
+		{"Delay and jitter", 30000, 10000, false},
+		{"Negative delay", -1, 0, true},
+		{"Negative jitter", 0, -1, true},
+		{"Delay above limit", 2 * 3600 * 1000, 0, true},
+		{"Delay plus jitter above limit", 8 * 60 * 1000, 3 * 60 * 1000, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CreateJobRequest{Topic: "t", IterationDelayMs: tt.delay, IterationJitterMs: tt.jitter}.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() with delay %d, jitter %d error = %v, wantErr %v", tt.delay, tt.jitter, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCreateJobRequestConfigSurvivesResume(t *testing.T) {
+	req := CreateJobRequest{
+		Topic:            "t",
+		MaxIterations:    7,
+		ReportDepth:      "brief",
+		ExtractFacts:     true,
+		SeedSources:      []string{"https://arxiv.org/abs/1706.03762"},
+		ArxivResults:     10,
+		Redact:           []string{"emails"},
+		IterationDelayMs: 2000,
+		SubTopics:        []string{"cost", "latency"},
+	}
+	base := research.Config{Collection: "papers", MaxPDFPages: 30}
+	cfg := req.researchConfig(base)
+
+	resumed := research.NewJobConfig(cfg).ResearchConfig(base)
+	if !reflect.DeepEqual(resumed, cfg) {
+		t.Errorf("resumed config = %+v, want %+v", resumed, cfg)
+	}
+	if cfg.MaxIterations != 7 || cfg.IterationDelay != 2*time.Second || cfg.Collection != "papers" {
+		t.Errorf("request settings not applied: %+v", cfg)
+	}
+}
@@ -0,0 +1,179 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/mikeboe/research-helper/pkg/research"
+)
+
+// sourceFeedHistory is how many source events of a running job are kept for subscribers
+// joining mid-run; older events are only reflected in the job's state
+const sourceFeedHistory = 1000
+
+// sourceSubscriberBuffer is how many source events may queue up for a subscriber before it is dropped
+const sourceSubscriberBuffer = 256
+
+// SourceStreamEvent is an event of GET /api/research/:id/sources/stream. Source events
+// carry the fields of research.SourceEvent; "done" and "error" end the stream.
+type SourceStreamEvent struct {
+	*research.SourceEvent
+	Type    string `json:"type"` // "found", "filtered", "skipped", "indexed", "done" or "error"
+	Content string `json:"content,omitempty"`
+}
+
+func sourceStreamEvent(event research.SourceEvent) SourceStreamEvent {
+	return SourceStreamEvent{SourceEvent: &event, Type: string(event.Type)}
+}
+
+// sourceHub fans the source events of running jobs out to their feed subscribers,
+// keeping the recent ones so subscribers joining mid-run catch up first
+type sourceHub struct {
+	mu    sync.Mutex
+	feeds map[uuid.UUID]*sourceFeed
+}
+
+type sourceFeed struct {
+	history []SourceStreamEvent
+	subs    map[chan SourceStreamEvent]struct{}
+}
+
+func newSourceHub() *sourceHub {
+	return &sourceHub{feeds: make(map[uuid.UUID]*sourceFeed)}
+}
+
+func (h *sourceHub) feed(jobID uuid.UUID) *sourceFeed {
+	f, ok := h.feeds[jobID]
+	if !ok {
+		f = &sourceFeed{subs: make(map[chan SourceStreamEvent]struct{})}
+		h.feeds[jobID] = f
+	}
+	return f
+}
+
+// subscribe returns the recent events of a job and a channel of the following ones.
+// The channel is closed after a done or error event, or when the subscriber falls too far
+// behind. cancel must be called once the subscriber is done.
+func (h *sourceHub) subscribe(jobID uuid.UUID) ([]SourceStreamEvent, <-chan SourceStreamEvent, func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	f := h.feed(jobID)
+	ch := make(chan SourceStreamEvent, sourceSubscriberBuffer)
+	f.subs[ch] = struct{}{}
+
+	cancel := func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := f.subs[ch]; ok {
+			delete(f.subs, ch)
+			close(ch)
+		}
+		if len(f.subs) == 0 && len(f.history) == 0 && h.feeds[jobID] == f {
+			delete(h.feeds, jobID)
+		}
+	}
+	return append([]SourceStreamEvent(nil), f.history...), ch, cancel
+}
+
+// publish records a source event of a job and sends it to the subscribers
+func (h *sourceHub) publish(jobID uuid.UUID, event research.SourceEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	f := h.feed(jobID)
+	e := sourceStreamEvent(event)
+	if len(f.history) >= sourceFeedHistory {
+		f.history = f.history[1:]
+	}
+	f.history = append(f.history, e)
+	f.send(e)
+}
+
+// finish sends the final event of a job's feed to the subscribers and forgets the feed
+func (h *sourceHub) finish(jobID uuid.UUID, event SourceStreamEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	f, ok := h.feeds[jobID]
+	if !ok {
+		return
+	}
+	f.send(event)
+	for ch := range f.subs {
+		delete(f.subs, ch)
+		close(ch)
+	}
+	delete(h.feeds, jobID)
+}
+
+// send delivers event without blocking the engine; subscribers with a full buffer are dropped
+func (f *sourceFeed) send(event SourceStreamEvent) {
+	for ch := range f.subs {
+		select {
+		case ch <- event:
+		default:
+			delete(f.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// StreamSources streams the sources of a job as they are found, filtered, skipped and
+// indexed. A finished job yields its indexed sources; only running jobs have the full feed.
+func (s *Service) StreamSources(ctx context.Context, jobID uuid.UUID) (iter.Seq2[SourceStreamEvent, error], error) {
+	history, events, cancel := s.sources.subscribe(jobID)
+
+	job, err := s.GetJob(ctx, jobID)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	var indexed []research.SearchResult
+	if job.Status == "completed" || job.Status == "failed" {
+		if indexed, err = s.GetJobSources(ctx, jobID); err != nil {
+			cancel()
+			return nil, err
+		}
+	}
+
+	return func(yield func(SourceStreamEvent, error) bool) {
+		defer cancel()
+
+		switch job.Status {
+		case "completed", "failed":
+			for _, item := range indexed {
+				if !yield(sourceStreamEvent(research.SourceEvent{Type: research.SourceIndexed, Source: item}), nil) {
+					return
+				}
+			}
+			yield(SourceStreamEvent{Type: "done"}, nil)
+			return
+		}
+
+		for _, event := range history {
+			if !yield(event, nil) {
+				return
+			}
+		}
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					// Dropped for falling too far behind
+					yield(SourceStreamEvent{}, fmt.Errorf("source stream interrupted; fetch the job state to get its sources"))
+					return
+				}
+				if !yield(event, nil) || event.Type == "done" || event.Type == "error" {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}, nil
+}
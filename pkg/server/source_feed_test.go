@@ -0,0 +1,89 @@
+package server
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/mikeboe/research-helper/pkg/research"
+)
+
+func TestSourceHub(t *testing.T) {
+	hub := newSourceHub()
+	job := uuid.New()
+	paper := research.SearchResult{Title: "Paper", URL: "https://arxiv.org/pdf/1"}
+
+	hub.publish(job, research.SourceEvent{Type: research.SourceFound, Source: paper})
+	history, events, cancel := hub.subscribe(job)
+	defer cancel()
+	if len(history) != 1 || history[0].Type != "found" {
+		t.Fatalf("history = %+v, want the found event", history)
+	}
+
+	score := 8
+	hub.publish(job, research.SourceEvent{Type: research.SourceFiltered, Source: paper, Score: &score, Kept: true})
+	hub.publish(job, research.SourceEvent{Type: research.SourceIndexed, Source: paper})
+	hub.finish(job, SourceStreamEvent{Type: "done"})
+
+	var got []string
+	for event := range events {
+		got = append(got, event.Type)
+	}
+	want := []string{"filtered", "indexed", "done"}
+	if len(got) != len(want) {
+		t.Fatalf("events = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("event %d = %s, want %s", i, got[i], want[i])
+		}
+	}
+	if _, ok := hub.feeds[job]; ok {
+		t.Error("finished feed was not removed")
+	}
+}
+
+func TestSourceHubHistoryIsBounded(t *testing.T) {
+	hub := newSourceHub()
+	job := uuid.New()
+	for i := 0; i < sourceFeedHistory+10; i++ {
+		hub.publish(job, research.SourceEvent{Type: research.SourceFound, Iteration: i})
+	}
+
+	history, _, cancel := hub.subscribe(job)
+	defer cancel()
+	if len(history) != sourceFeedHistory {
+		t.Fatalf("history has %d events, want %d", len(history), sourceFeedHistory)
+	}
+	if history[0].Iteration != 10 {
+		t.Errorf("oldest kept event has iteration %d, want 10", history[0].Iteration)
+	}
+}
+
+func TestSourceStreamEventJSON(t *testing.T) {
+	score := 3
+	tests := []struct {
+		name  string
+		event SourceStreamEvent
+		want  string
+	}{
+		{
+			"source event",
+			sourceStreamEvent(research.SourceEvent{Type: research.SourceFiltered, Iteration: 2, Source: research.SearchResult{Title: "Paper"}, Score: &score}),
+			`{"iteration":2,"source":{"title":"Paper","url":"","snippet":"","scraped":false},"score":3,"type":"filtered"}`,
+		},
+		{"done", SourceStreamEvent{Type: "done"}, `{"type":"done"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.event)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("json = %s, want %s", data, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sseHeartbeatInterval is how long a stream may stay silent before a heartbeat
+// comment is sent, keeping proxies and load balancers from dropping it.
+const sseHeartbeatInterval = 15 * time.Second
+
+// sseWriteTimeout is how long a single frame may take to reach the client before
+// the client is considered too slow and the stream is dropped.
+const sseWriteTimeout = 10 * time.Second
+
+// sseBufferSize is how many events may queue up between the producer and a slow client
+const sseBufferSize = 64
+
+func setSSEHeaders(c *gin.Context) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("Transfer-Encoding", "chunked")
+}
+
+// sseWriter writes SSE frames with a per-frame write deadline
+type sseWriter struct {
+	w  gin.ResponseWriter
+	rc *http.ResponseController
+}
+
+func newSSEWriter(w gin.ResponseWriter) *sseWriter {
+	return &sseWriter{w: w, rc: http.NewResponseController(w)}
+}
+
+func (s *sseWriter) writeFrame(frame []byte) error {
+	if err := s.rc.SetWriteDeadline(time.Now().Add(sseWriteTimeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return fmt.Errorf("failed to set write deadline: %w", err)
+	}
+	if _, err := s.w.Write(frame); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+// writeData writes v as a single JSON data frame
+func (s *sseWriter) writeData(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+	return s.writeFrame([]byte(fmt.Sprintf("data: %s\n\n", data)))
+}
+
+// writeHeartbeat writes a comment frame, which EventSource clients ignore
+func (s *sseWriter) writeHeartbeat() error {
+	return s.writeFrame([]byte(":heartbeat\n\n"))
+}
+
+// streamSSE relays events from next to the client as SSE data frames. The iterator
+// runs in its own goroutine so heartbeats can be sent while it is waiting, e.g. on a
+// long tool call. An iterator error is sent as the event returned by onError and ends the stream.
+//
+// Events are buffered up to sseBufferSize; once a frame cannot be written within
+// sseWriteTimeout the stream is dropped and the iterator is stopped. Callers should
+// cancel the context driving the iterator when streamSSE returns.
+func streamSSE[T any](c *gin.Context, next iter.Seq2[T, error], onError func(error) T) {
+	setSSEHeaders(c)
+	w := newSSEWriter(c.Writer)
+	// Clear the deadline so it doesn't apply to later requests on a kept-alive connection
+	defer func() { _ = w.rc.SetWriteDeadline(time.Time{}) }()
+
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+
+	type streamItem struct {
+		event T
+		err   error
+	}
+	items := make(chan streamItem, sseBufferSize)
+
+	go func() {
+		defer close(items)
+		for event, err := range next {
+			select {
+			case items <- streamItem{event: event, err: err}:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(sseHeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case item, ok := <-items:
+			if !ok {
+				return
+			}
+			if item.err != nil {
+				// If we encounter an error during the stream, we try to send it as an event
+				_ = w.writeData(onError(item.err))
+				return
+			}
+			if err := w.writeData(item.event); err != nil {
+				slog.Warn("Dropping SSE stream", "error", err)
+				return
+			}
+			ticker.Reset(sseHeartbeatInterval)
+		case <-ticker.C:
+			if err := w.writeHeartbeat(); err != nil {
+				slog.Warn("Dropping SSE stream", "error", err)
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/mikeboe/research-helper/pkg/research"
+)
+
+// JobState is the structured progress of a job, read from its persisted ResearchState.
+// The state is saved at the start and end of every iteration, so a running job's state
+// trails its logs by at most one phase.
+type JobState struct {
+	JobID              uuid.UUID                `json:"job_id"`
+	Status             string                   `json:"status"`
+	Topic              string                   `json:"topic"`
+	Iteration          int                      `json:"iteration"`
+	MaxIterations      int                      `json:"max_iterations"`
+	Focus              string                   `json:"focus,omitempty"` // Suggested by the last reflection
+	FactCount          int                      `json:"fact_count"`
+	SourceCount        int                      `json:"source_count"`
+	Seeded             bool                     `json:"seeded"`
+	IndexedItems       []research.SearchResult  `json:"indexed_items"`
+	SourceStats        []research.SourceStats   `json:"source_stats"`
+	QueryYields        []research.QueryYield    `json:"query_yields"`
+	ScrapeFailures     []research.ScrapeFailure `json:"scrape_failures"` // Sources indexed from their snippet only
+	CompletedSubTopics []string                 `json:"completed_sub_topics"`
+}
+
+// GetJobState returns the progress of a job. Jobs that haven't persisted a state yet report
+// their status with an empty state.
+func (s *Service) GetJobState(ctx context.Context, jobID uuid.UUID) (*JobState, error) {
+	conn, err := s.DB.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job state: %w", err)
+	}
+	defer conn.Release()
+
+	var status, topic string
+	var stateJSON []byte
+	err = conn.QueryRow(ctx, "SELECT status, topic, state FROM research_jobs WHERE id = $1", jobID).Scan(&status, &topic, &stateJSON)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job state: %w", err)
+	}
+
+	var state research.ResearchState
+	if stateJSON != nil {
+		if err := json.Unmarshal(stateJSON, &state); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal state: %w", err)
+		}
+	}
+	if state.Topic == "" {
+		state.Topic = topic
+	}
+	return newJobState(jobID, status, &state), nil
+}
+
+// newJobState builds the JobState of a ResearchState, with empty lists instead of null
+func newJobState(jobID uuid.UUID, status string, state *research.ResearchState) *JobState {
+	js := &JobState{
+		JobID:              jobID,
+		Status:             status,
+		Topic:              state.Topic,
+		Iteration:          state.Iteration,
+		MaxIterations:      state.MaxIterations,
+		Focus:              state.Focus,
+		FactCount:          len(state.AccumulatedFacts),
+		SourceCount:        len(state.IndexedItems),
+		Seeded:             state.Seeded,
+		IndexedItems:       state.IndexedItems,
+		SourceStats:        state.SourceStats,
+		QueryYields:        state.QueryYields,
+		ScrapeFailures:     state.ScrapeFailures,
+		CompletedSubTopics: state.CompletedSubTopics,
+	}
+	if js.IndexedItems == nil {
+		js.IndexedItems = []research.SearchResult{}
+	}
+	if js.SourceStats == nil {
+		js.SourceStats = []research.SourceStats{}
+	}
+	if js.QueryYields == nil {
+		js.QueryYields = []research.QueryYield{}
+	}
+	if js.ScrapeFailures == nil {
+		js.ScrapeFailures = []research.ScrapeFailure{}
+	}
+	if js.CompletedSubTopics == nil {
+		js.CompletedSubTopics = []string{}
+	}
+	return js
+}
@@ -0,0 +1,41 @@
+package server
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/mikeboe/research-helper/pkg/research"
+)
+
+func TestNewJobState(t *testing.T) {
+	id := uuid.New()
+	state := &research.ResearchState{
+		Topic:            "graph neural networks",
+		Iteration:        2,
+		MaxIterations:    5,
+		Focus:            "message passing limits",
+		AccumulatedFacts: []string{"a", "b", "c"},
+		IndexedItems:     []research.SearchResult{{Title: "GCN"}},
+	}
+
+	js := newJobState(id, "running", state)
+	if js.FactCount != 3 || js.SourceCount != 1 || js.Focus != "message passing limits" || js.Iteration != 2 {
+		t.Errorf("unexpected state: %+v", js)
+	}
+
+	// Lists are empty arrays rather than null for jobs without state
+	data, err := json.Marshal(newJobState(id, "pending", &research.ResearchState{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"indexed_items", "source_stats", "query_yields", "scrape_failures", "completed_sub_topics"} {
+		if _, ok := decoded[key].([]interface{}); !ok {
+			t.Errorf("%s = %v, want an empty array", key, decoded[key])
+		}
+	}
+}
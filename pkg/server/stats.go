@@ -0,0 +1,80 @@
+package server
+
+import (
+	"context"
+	"fmt"
+)
+
+// JobStats summarizes all research jobs for dashboards
+type JobStats struct {
+	TotalJobs      int            `json:"total_jobs"`
+	ByStatus       map[string]int `json:"by_status"`
+	SuccessRate    float64        `json:"success_rate"`    // Completed share of finished (completed or failed) jobs, 0 without any
+	AvgIterations  float64        `json:"avg_iterations"`  // Over jobs with persisted state
+	SourcesIndexed int64          `json:"sources_indexed"` // Summed over the persisted states of all jobs
+	LogEntries     int64          `json:"log_entries"`
+	ErrorLogs      int64          `json:"error_logs"`
+}
+
+// GetStats aggregates the job counts, iterations and sources of all jobs
+func (s *Service) GetStats(ctx context.Context) (*JobStats, error) {
+	conn, err := s.DB.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stats: %w", err)
+	}
+	defer conn.Release()
+
+	stats := &JobStats{ByStatus: map[string]int{}}
+
+	rows, err := conn.Query(ctx, "SELECT status, COUNT(*) FROM research_jobs GROUP BY status")
+	if err != nil {
+		return nil, fmt.Errorf("failed to count jobs: %w", err)
+	}
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan job count: %w", err)
+		}
+		stats.ByStatus[status] = count
+		stats.TotalJobs += count
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to count jobs: %w", err)
+	}
+	stats.SuccessRate = successRate(stats.ByStatus)
+
+	// IndexedItems is null in states of jobs that indexed nothing yet
+	err = conn.QueryRow(ctx, `
+		SELECT
+			COALESCE(AVG((state->>'Iteration')::int), 0),
+			COALESCE(SUM(CASE WHEN jsonb_typeof(state->'IndexedItems') = 'array'
+				THEN jsonb_array_length(state->'IndexedItems') ELSE 0 END), 0)
+		FROM research_jobs
+		WHERE state IS NOT NULL
+	`).Scan(&stats.AvgIterations, &stats.SourcesIndexed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate job states: %w", err)
+	}
+
+	err = conn.QueryRow(ctx, `
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE level = 'ERROR')
+		FROM research_logs
+	`).Scan(&stats.LogEntries, &stats.ErrorLogs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count logs: %w", err)
+	}
+
+	return stats, nil
+}
+
+// successRate is the share of finished jobs that completed
+func successRate(byStatus map[string]int) float64 {
+	finished := byStatus["completed"] + byStatus["failed"]
+	if finished == 0 {
+		return 0
+	}
+	return float64(byStatus["completed"]) / float64(finished)
+}
@@ -0,0 +1,22 @@
+package server
+
+import "testing"
+
+func TestSuccessRate(t *testing.T) {
+	tests := []struct {
+		name     string
+		byStatus map[string]int
+		want     float64
+	}{
+		{"no jobs", map[string]int{}, 0},
+		{"only running", map[string]int{"running": 2}, 0},
+		{"mixed", map[string]int{"completed": 3, "failed": 1, "running": 5}, 0.75},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := successRate(tt.byStatus); got != tt.want {
+				t.Errorf("successRate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
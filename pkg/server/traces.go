@@ -0,0 +1,103 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tmc/langchaingo/llms"
+
+	"github.com/mikeboe/research-helper/pkg/clients"
+	"github.com/mikeboe/research-helper/pkg/research"
+)
+
+type TraceEntry struct {
+	ID         int                   `json:"id"`
+	Timestamp  time.Time             `json:"timestamp"`
+	Phase      string                `json:"phase"`
+	Iteration  int                   `json:"iteration"`
+	Prompts    []llms.MessageContent `json:"prompts"`
+	JSONMode   bool                  `json:"json_mode"`
+	Response   *string               `json:"response"`
+	Error      *string               `json:"error,omitempty"`
+	DurationMs int64                 `json:"duration_ms"`
+}
+
+// saveTrace persists an LLM call recorded by the engine
+func (s *Service) saveTrace(jobID uuid.UUID, trace research.LLMTrace) error {
+	promptsJSON, err := json.Marshal(trace.Prompts)
+	if err != nil {
+		return fmt.Errorf("failed to marshal prompts: %w", err)
+	}
+
+	query := `
+		INSERT INTO llm_traces (job_id, phase, iteration, prompts, json_mode, response, error, duration_ms)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	_, err = s.DB.Pool.Exec(context.Background(), query, jobID, trace.Phase, trace.Iteration,
+		promptsJSON, trace.JSONMode, trace.Response, trace.Error, trace.Duration.Milliseconds())
+	if err != nil {
+		return fmt.Errorf("failed to save trace: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) GetJobTraces(ctx context.Context, jobID uuid.UUID) ([]TraceEntry, error) {
+	query := `
+		SELECT id, timestamp, phase, iteration, prompts, json_mode, response, error, duration_ms
+		FROM llm_traces
+		WHERE job_id = $1
+		ORDER BY id ASC
+	`
+	rows, err := s.DB.Pool.Query(ctx, query, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get traces: %w", err)
+	}
+	defer rows.Close()
+
+	var traces []TraceEntry
+	for rows.Next() {
+		var t TraceEntry
+		var promptsJSON []byte
+		if err := rows.Scan(&t.ID, &t.Timestamp, &t.Phase, &t.Iteration, &promptsJSON, &t.JSONMode, &t.Response, &t.Error, &t.DurationMs); err != nil {
+			continue
+		}
+		if err := json.Unmarshal(promptsJSON, &t.Prompts); err != nil {
+			continue
+		}
+		traces = append(traces, t)
+	}
+	return traces, nil
+}
+
+// ReplayTrace re-sends the recorded prompts of a trace to the model that served its phase and returns the new response.
+// The replay is not persisted.
+func (s *Service) ReplayTrace(ctx context.Context, jobID uuid.UUID, traceID int) (string, error) {
+	query := `
+		SELECT phase, iteration, prompts, json_mode
+		FROM llm_traces
+		WHERE job_id = $1 AND id = $2
+	`
+	var trace research.LLMTrace
+	var promptsJSON []byte
+	err := s.DB.Pool.QueryRow(ctx, query, jobID, traceID).Scan(&trace.Phase, &trace.Iteration, &promptsJSON, &trace.JSONMode)
+	if err != nil {
+		return "", fmt.Errorf("failed to get trace: %w", err)
+	}
+	if err := json.Unmarshal(promptsJSON, &trace.Prompts); err != nil {
+		return "", fmt.Errorf("failed to unmarshal prompts: %w", err)
+	}
+
+	model := s.c.ReasoningModel
+	if research.UsesFastModel(trace.Phase) {
+		model = s.c.FastModel
+	}
+	llm, err := clients.GoogleAi(clients.ModelType(model), s.c.GoogleApiKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to init LLM: %w", err)
+	}
+
+	return research.Replay(ctx, llm, trace)
+}
@@ -1,14 +1,33 @@
 package splitter
 
 import (
+	"fmt"
+
 	"github.com/tmc/langchaingo/textsplitter"
 )
 
+// Splitter types accepted by New
+const (
+	TypeRecursive = "recursive" // Splits on paragraphs, lines, then words; used for indexing
+	TypeMarkdown  = "markdown"  // Splits on markdown headings and blocks first
+)
+
 // TextSplitter wraps the langchaingo text splitter
 type TextSplitter struct {
 	splitter textsplitter.TextSplitter
 }
 
+// New creates a splitter of the given type, defaulting to TypeRecursive
+func New(splitterType string, chunkSize, chunkOverlap int) (*TextSplitter, error) {
+	switch splitterType {
+	case "", TypeRecursive:
+		return NewRecursiveCharacterTextSplitter(chunkSize, chunkOverlap), nil
+	case TypeMarkdown:
+		return NewMarkdownTextSplitter(chunkSize, chunkOverlap), nil
+	}
+	return nil, fmt.Errorf("unknown splitter type %q (want %s or %s)", splitterType, TypeRecursive, TypeMarkdown)
+}
+
 // NewRecursiveCharacterTextSplitter creates a new recursive character text splitter
 func NewRecursiveCharacterTextSplitter(chunkSize, chunkOverlap int) *TextSplitter {
 	ts := textsplitter.NewRecursiveCharacter(
@@ -19,6 +38,16 @@ func NewRecursiveCharacterTextSplitter(chunkSize, chunkOverlap int) *TextSplitte
 	return &TextSplitter{splitter: ts}
 }
 
+// NewMarkdownTextSplitter creates a new markdown-aware text splitter
+func NewMarkdownTextSplitter(chunkSize, chunkOverlap int) *TextSplitter {
+	ts := textsplitter.NewMarkdownTextSplitter(
+		textsplitter.WithChunkSize(chunkSize),
+		textsplitter.WithChunkOverlap(chunkOverlap),
+	)
+
+	return &TextSplitter{splitter: ts}
+}
+
 // SplitText splits text into chunks
 func (ts *TextSplitter) SplitText(text string) ([]string, error) {
 	return ts.splitter.SplitText(text)
@@ -0,0 +1,91 @@
+package vectorstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// CuratedField is the metadata field marking documents a user has reviewed as trustworthy.
+// PromoteToCollection sets it on every copy and promotes marked documents by default.
+const CuratedField = "curated"
+
+// ErrSameCollection is returned when documents are promoted into their own collection
+var ErrSameCollection = errors.New("target collection must differ from the source collection")
+
+// CuratedFilter matches the documents marked with CuratedField
+func CuratedFilter() map[string]interface{} {
+	return map[string]interface{}{CuratedField: true}
+}
+
+// MarkCurated sets CuratedField to curated on the documents matching a metadata filter
+// (see GetContentByMetadata) and returns how many were updated.
+func (vs *PGVectorStore) MarkCurated(ctx context.Context, filter map[string]interface{}, curated bool) (int64, error) {
+	var args []interface{}
+	whereClause, err := vs.writeFilterClause(filter, &args)
+	if err != nil {
+		return 0, err
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{CuratedField: curated})
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal metadata updates: %w", err)
+	}
+	args = append(args, patch)
+
+	query := fmt.Sprintf("UPDATE %s SET metadata = COALESCE(metadata, '{}'::jsonb) || $%d WHERE %s",
+		pgx.Identifier{vs.tableName}.Sanitize(), len(args), whereClause)
+	result, err := vs.pool.Exec(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark documents: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
+
+// PromoteToCollection copies the documents matching a metadata filter into the target
+// collection, which must already exist with the same embedding dimension. Copies keep their
+// IDs, so promoting a document again replaces its earlier copy. CuratedField is set on
+// every copy and promoted_from records the source collection. It returns how many
+// documents were copied.
+func (vs *PGVectorStore) PromoteToCollection(ctx context.Context, filter map[string]interface{}, target string) (int64, error) {
+	if !isValidTableName(target) {
+		return 0, ErrInvalidTableName
+	}
+	if target == vs.tableName {
+		return 0, ErrSameCollection
+	}
+
+	var args []interface{}
+	whereClause, err := vs.writeFilterClause(filter, &args)
+	if err != nil {
+		return 0, err
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{CuratedField: true, "promoted_from": vs.tableName})
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal metadata updates: %w", err)
+	}
+	args = append(args, patch)
+
+	result, err := vs.pool.Exec(ctx, promoteQuery(vs.tableName, target, whereClause, len(args)), args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to promote documents to %s: %w", target, err)
+	}
+	return result.RowsAffected(), nil
+}
+
+// promoteQuery builds the SQL copying the rows of source matching whereClause into target,
+// merging the JSON object in argument $patchArg into their metadata
+func promoteQuery(source, target, whereClause string, patchArg int) string {
+	return fmt.Sprintf(`
+		INSERT INTO %[2]s (id, content, metadata, embedding)
+		SELECT id, content, COALESCE(metadata, '{}'::jsonb) || $%[4]d, embedding
+		FROM %[1]s
+		WHERE %[3]s
+		ON CONFLICT (id) DO UPDATE
+		SET content = EXCLUDED.content, metadata = EXCLUDED.metadata, embedding = EXCLUDED.embedding
+	`, pgx.Identifier{source}.Sanitize(), pgx.Identifier{target}.Sanitize(), whereClause, patchArg)
+}
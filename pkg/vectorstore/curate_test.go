@@ -0,0 +1,68 @@
+package vectorstore
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPromoteQuery(t *testing.T) {
+	query := promoteQuery("raw", "curated_papers", "metadata->>'job_id' = $1", 2)
+
+	for _, want := range []string{
+		`INSERT INTO "curated_papers"`,
+		`FROM "raw"`,
+		"WHERE metadata->>'job_id' = $1",
+		"|| $2",
+		"ON CONFLICT (id) DO UPDATE",
+	} {
+		if !strings.Contains(query, want) {
+			t.Errorf("query missing %q:\n%s", want, query)
+		}
+	}
+}
+
+func TestPromoteToCollectionRejectsTarget(t *testing.T) {
+	vs := &PGVectorStore{tableName: "raw"}
+
+	if _, err := vs.PromoteToCollection(t.Context(), CuratedFilter(), "raw"); err != ErrSameCollection {
+		t.Errorf("same collection: got %v, want ErrSameCollection", err)
+	}
+	if _, err := vs.PromoteToCollection(t.Context(), CuratedFilter(), "bad-name"); err != ErrInvalidTableName {
+		t.Errorf("invalid name: got %v, want ErrInvalidTableName", err)
+	}
+}
+
+func TestWritesRejectFiltersMatchingEverything(t *testing.T) {
+	vs := &PGVectorStore{tableName: "raw"}
+	for _, filter := range []map[string]interface{}{nil, {}, {"$and": []interface{}{}}, {"$or": []interface{}{map[string]interface{}{}}}} {
+		if _, err := vs.DeleteByMetadata(t.Context(), filter); !errors.Is(err, ErrInvalidFilter) {
+			t.Errorf("DeleteByMetadata(%v) error = %v, want ErrInvalidFilter", filter, err)
+		}
+		if _, err := vs.MarkCurated(t.Context(), filter, true); !errors.Is(err, ErrInvalidFilter) {
+			t.Errorf("MarkCurated(%v) error = %v, want ErrInvalidFilter", filter, err)
+		}
+		if _, err := vs.PromoteToCollection(t.Context(), filter, "curated"); !errors.Is(err, ErrInvalidFilter) {
+			t.Errorf("PromoteToCollection(%v) error = %v, want ErrInvalidFilter", filter, err)
+		}
+	}
+}
+
+func TestMatchesEverything(t *testing.T) {
+	tests := []struct {
+		filter map[string]interface{}
+		want   bool
+	}{
+		{map[string]interface{}{}, true},
+		{map[string]interface{}{"$and": []interface{}{map[string]interface{}{}}}, true},
+		{map[string]interface{}{"job_id": "x"}, false},
+		{map[string]interface{}{"$or": []interface{}{map[string]interface{}{"a": 1}, map[string]interface{}{}}}, true},
+		{map[string]interface{}{"$or": []interface{}{map[string]interface{}{"a": 1}}}, false},
+		{map[string]interface{}{"$not": map[string]interface{}{"a": 1}}, false},
+	}
+	for _, tt := range tests {
+		if got := matchesEverything(tt.filter); got != tt.want {
+			t.Errorf("matchesEverything(%v) = %v, want %v", tt.filter, got, tt.want)
+		}
+	}
+}
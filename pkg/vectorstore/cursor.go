@@ -0,0 +1,138 @@
+package vectorstore
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrInvalidCursor is returned for continuation cursors not produced by GetContentByMetadataPage
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// MetadataPage is one page of documents matching a metadata filter
+type MetadataPage struct {
+	Documents  []Document
+	NextCursor string // Continues after the last document; empty on the last page
+}
+
+// GetContentByMetadataPage returns up to limit documents matching filter (see
+// GetContentByMetadata), ordered by ID and starting after cursor; an empty cursor starts
+// at the beginning. Pages are keyed by document ID rather than offset, so documents added
+// or deleted between calls don't shift the remaining pages.
+func (vs *PGVectorStore) GetContentByMetadataPage(ctx context.Context, filter map[string]interface{}, cursor string, limit int) (MetadataPage, error) {
+	if limit <= 0 {
+		return MetadataPage{}, fmt.Errorf("page limit must be positive, got %d", limit)
+	}
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return MetadataPage{}, err
+	}
+	filter, err = NormalizeMetadataFilter(filter)
+	if err != nil {
+		return MetadataPage{}, err
+	}
+
+	var args []interface{}
+	whereClause, err := vs.buildMetadataQuery(filter, &args)
+	if err != nil {
+		return MetadataPage{}, fmt.Errorf("failed to build metadata query: %w", err)
+	}
+	if after != "" {
+		args = append(args, after)
+		whereClause = fmt.Sprintf("(%s) AND id > $%d", whereClause, len(args))
+	}
+	// One extra row tells whether another page follows
+	args = append(args, limit+1)
+
+	query := fmt.Sprintf(`
+		SELECT id, content, metadata
+		FROM %s
+		WHERE %s
+		ORDER BY id
+		LIMIT $%d
+	`, pgx.Identifier{vs.tableName}.Sanitize(), whereClause, len(args))
+
+	rows, err := vs.pool.Query(ctx, query, args...)
+	if err != nil {
+		return MetadataPage{}, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	var page MetadataPage
+	for rows.Next() {
+		var doc Document
+		var metadataJSON []byte
+
+		if err := rows.Scan(&doc.ID, &doc.Content, &metadataJSON); err != nil {
+			return MetadataPage{}, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		if err := json.Unmarshal(metadataJSON, &doc.Metadata); err != nil {
+			return MetadataPage{}, fmt.Errorf("failed to unmarshal metadata: %w", err)
+		}
+
+		page.Documents = append(page.Documents, doc)
+	}
+
+	if err := rows.Err(); err != nil {
+		return MetadataPage{}, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	if len(page.Documents) > limit {
+		page.Documents = page.Documents[:limit]
+		page.NextCursor = CursorAfter(page.Documents[limit-1].ID)
+	}
+	return page, nil
+}
+
+// StreamContentByMetadata yields the documents matching filter one page of pageSize at a
+// time, so only a page is held in memory. Iteration stops at the first error.
+func (vs *PGVectorStore) StreamContentByMetadata(ctx context.Context, filter map[string]interface{}, pageSize int) iter.Seq2[Document, error] {
+	return func(yield func(Document, error) bool) {
+		cursor := ""
+		for {
+			page, err := vs.GetContentByMetadataPage(ctx, filter, cursor, pageSize)
+			if err != nil {
+				yield(Document{}, err)
+				return
+			}
+			for _, doc := range page.Documents {
+				if !yield(doc, nil) {
+					return
+				}
+			}
+			if page.NextCursor == "" {
+				return
+			}
+			cursor = page.NextCursor
+		}
+	}
+}
+
+// CursorAfter makes an opaque cursor continuing after the document with the given ID, e.g.
+// to end a page of GetContentByMetadataPage early
+func CursorAfter(id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(id))
+}
+
+// decodeCursor returns the document ID a cursor continues after, or "" for an empty cursor
+func decodeCursor(cursor string) (string, error) {
+	if cursor == "" {
+		return "", nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", fmt.Errorf("%w: %q", ErrInvalidCursor, cursor)
+	}
+	id, err := uuid.Parse(string(raw))
+	if err != nil {
+		return "", fmt.Errorf("%w: %q", ErrInvalidCursor, cursor)
+	}
+	return id.String(), nil
+}
@@ -0,0 +1,26 @@
+package vectorstore
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	id := "0b6a3f5e-8f1d-4c1e-9a57-3c2d8e4b7f10"
+	got, err := decodeCursor(CursorAfter(id))
+	if err != nil || got != id {
+		t.Errorf("decodeCursor(CursorAfter(%q)) = %q, %v", id, got, err)
+	}
+
+	if got, err := decodeCursor(""); err != nil || got != "" {
+		t.Errorf("empty cursor = %q, %v; want the start", got, err)
+	}
+}
+
+func TestDecodeCursorRejectsForeignValues(t *testing.T) {
+	for _, cursor := range []string{"not base64!", CursorAfter("1; DROP TABLE x"), "0b6a3f5e-8f1d-4c1e-9a57-3c2d8e4b7f10"} {
+		if _, err := decodeCursor(cursor); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("decodeCursor(%q) error = %v, want ErrInvalidCursor", cursor, err)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package vectorstore
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidFilter is returned for metadata filters that are not structurally valid
+var ErrInvalidFilter = errors.New("invalid filter")
+
+const (
+	// MaxFilterDepth is how deeply $and, $or and $not may be nested in a metadata filter
+	MaxFilterDepth = 8
+	// MaxFilterConditions caps the field conditions of a metadata filter, each of which
+	// becomes a query parameter
+	MaxFilterConditions = 100
+)
+
+// NormalizeMetadataFilter checks the structure of a GetContentByMetadata filter and returns
+// it in canonical form, so malformed filters (often constructed by LLM clients) fail with a
+// description of the problem instead of an SQL error or a silently empty result.
+//
+// Keys are metadata fields matched by equality, or one of the operators $and and $or (a list
+// of conditions) and $not (a single condition). Operators are case-insensitive, and $and/$or
+// given a single condition instead of a list are accepted. Comparison operators such as $gt
+// on a field are rejected, since fields only support exact matches. Filters nested deeper than
+// MaxFilterDepth or with more than MaxFilterConditions field conditions are rejected.
+func NormalizeMetadataFilter(filter map[string]interface{}) (map[string]interface{}, error) {
+	conditions := 0
+	return normalizeFilter(filter, "filter", 0, &conditions)
+}
+
+func normalizeFilter(filter map[string]interface{}, path string, depth int, conditions *int) (map[string]interface{}, error) {
+	if depth > MaxFilterDepth {
+		return nil, filterError(path, "nested deeper than %d levels", MaxFilterDepth)
+	}
+	out := make(map[string]interface{}, len(filter))
+	for key, value := range filter {
+		name := strings.TrimSpace(key)
+		if name == "" {
+			return nil, filterError(path, "empty field name")
+		}
+
+		if !strings.HasPrefix(name, "$") {
+			if _, dup := out[name]; dup {
+				return nil, filterError(path, "field %q is given more than once", name)
+			}
+			if err := checkFieldValue(value, path+"."+name); err != nil {
+				return nil, err
+			}
+			if *conditions++; *conditions > MaxFilterConditions {
+				return nil, filterError(path, "more than %d conditions", MaxFilterConditions)
+			}
+			out[name] = value
+			continue
+		}
+
+		op := strings.ToLower(name)
+		opPath := path + "." + op
+		switch op {
+		case "$and", "$or":
+			var items []interface{}
+			switch v := value.(type) {
+			case []interface{}:
+				items = v
+			case map[string]interface{}:
+				items = []interface{}{v} // A single condition
+			default:
+				return nil, filterError(opPath, "must be a list of conditions, got %s", jsonType(value))
+			}
+			if len(items) == 0 {
+				return nil, filterError(opPath, "must contain at least one condition")
+			}
+
+			list, _ := out[op].([]interface{}) // "$and" and "$AND" in one object are merged
+			for i, item := range items {
+				itemPath := fmt.Sprintf("%s[%d]", opPath, i)
+				sub, ok := item.(map[string]interface{})
+				if !ok {
+					return nil, filterError(itemPath, "must be a JSON object, got %s", jsonType(item))
+				}
+				normalized, err := normalizeFilter(sub, itemPath, depth+1, conditions)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, normalized)
+			}
+			out[op] = list
+
+		case "$not":
+			if _, dup := out[op]; dup {
+				return nil, filterError(opPath, "is given more than once; wrap the conditions in $or")
+			}
+			sub, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, filterError(opPath, "must be a JSON object, got %s", jsonType(value))
+			}
+			if len(sub) == 0 {
+				return nil, filterError(opPath, "must contain at least one condition")
+			}
+			normalized, err := normalizeFilter(sub, opPath, depth+1, conditions)
+			if err != nil {
+				return nil, err
+			}
+			out[op] = normalized
+
+		default:
+			return nil, filterError(path, "unknown operator %s; supported operators are $and, $or and $not", name)
+		}
+	}
+	return out, nil
+}
+
+// checkFieldValue rejects operators nested in a field value, e.g. {"year": {"$gt": 2020}},
+// which would otherwise be matched literally and never match anything
+func checkFieldValue(value interface{}, path string) error {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, nested := range v {
+			if strings.HasPrefix(strings.TrimSpace(key), "$") {
+				return filterError(path, "uses operator %s, but fields only support exact matches; combine conditions with $and, $or and $not", key)
+			}
+			if err := checkFieldValue(nested, path+"."+key); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			if err := checkFieldValue(item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func filterError(path, format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s: %s", ErrInvalidFilter, path, fmt.Sprintf(format, args...))
+}
+
+// jsonType names the JSON type of a decoded value for error messages
+func jsonType(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "a string"
+	case float64, int, int64:
+		return "a number"
+	case bool:
+		return "a boolean"
+	case []interface{}:
+		return "a list"
+	case map[string]interface{}:
+		return "an object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
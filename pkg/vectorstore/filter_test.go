@@ -0,0 +1,87 @@
+package vectorstore
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeMetadataFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  map[string]interface{}
+		want    map[string]interface{}
+		wantErr string
+	}{
+		{
+			name:   "Empty filter",
+			filter: map[string]interface{}{},
+			want:   map[string]interface{}{},
+		},
+		{
+			name:   "Field with object value",
+			filter: map[string]interface{}{"source": "doc1", "meta": map[string]interface{}{"a": 1.0}},
+			want:   map[string]interface{}{"source": "doc1", "meta": map[string]interface{}{"a": 1.0}},
+		},
+		{
+			name: "Operator case and single condition",
+			filter: map[string]interface{}{
+				"$OR":  map[string]interface{}{"a": 1.0},
+				"$Not": map[string]interface{}{"b": 2.0},
+			},
+			want: map[string]interface{}{
+				"$or":  []interface{}{map[string]interface{}{"a": 1.0}},
+				"$not": map[string]interface{}{"b": 2.0},
+			},
+		},
+		{
+			name:    "Unknown operator",
+			filter:  map[string]interface{}{"$in": []interface{}{"a"}},
+			wantErr: "filter: unknown operator $in",
+		},
+		{
+			name:    "Comparison on field",
+			filter:  map[string]interface{}{"$and": []interface{}{map[string]interface{}{"year": map[string]interface{}{"$gt": 2020.0}}}},
+			wantErr: "filter.$and[0].year: uses operator $gt",
+		},
+		{
+			name:    "Non-object list item",
+			filter:  map[string]interface{}{"$or": []interface{}{"a"}},
+			wantErr: "filter.$or[0]: must be a JSON object, got a string",
+		},
+		{
+			name:    "Empty list",
+			filter:  map[string]interface{}{"$and": []interface{}{}},
+			wantErr: "filter.$and: must contain at least one condition",
+		},
+		{
+			name:    "Not with list",
+			filter:  map[string]interface{}{"$not": []interface{}{}},
+			wantErr: "filter.$not: must be a JSON object, got a list",
+		},
+		{
+			name:    "Empty field name",
+			filter:  map[string]interface{}{" ": "x"},
+			wantErr: "filter: empty field name",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeMetadataFilter(tt.filter)
+			if tt.wantErr != "" {
+				if !errors.Is(err, ErrInvalidFilter) || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("NormalizeMetadataFilter() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NormalizeMetadataFilter() unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NormalizeMetadataFilter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package vectorstore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// documentNamespace is the UUIDv5 namespace for document IDs. Changing it changes every derived ID.
+var documentNamespace = uuid.NewSHA1(uuid.NameSpaceURL, []byte("https://github.com/mikeboe/research-helper/documents"))
+
+// DocumentID derives a stable UUIDv5 for a chunk from its source, position and content.
+// Re-indexing the same chunk yields the same ID, so AddDocuments upserts it instead of
+// creating a duplicate row.
+func DocumentID(source string, chunkIndex int, content string) string {
+	hash := sha256.Sum256([]byte(content))
+	name := fmt.Sprintf("%s\x00%d\x00%s", source, chunkIndex, hex.EncodeToString(hash[:]))
+	return uuid.NewSHA1(documentNamespace, []byte(name)).String()
+}
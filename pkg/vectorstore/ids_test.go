@@ -0,0 +1,22 @@
+package vectorstore
+
+import "testing"
+
+func TestDocumentID(t *testing.T) {
+	id := DocumentID("http://arxiv.org/pdf/1234", 0, "chunk content")
+
+	if got := DocumentID("http://arxiv.org/pdf/1234", 0, "chunk content"); got != id {
+		t.Errorf("DocumentID not stable: %s != %s", got, id)
+	}
+
+	variants := map[string]string{
+		"source":  DocumentID("http://arxiv.org/pdf/5678", 0, "chunk content"),
+		"index":   DocumentID("http://arxiv.org/pdf/1234", 1, "chunk content"),
+		"content": DocumentID("http://arxiv.org/pdf/1234", 0, "other content"),
+	}
+	for field, other := range variants {
+		if other == id {
+			t.Errorf("changing %s did not change the id", field)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package vectorstore
+
+import (
+	"fmt"
+	"math"
+)
+
+// IndexType identifies the pgvector index a collection is searched with
+type IndexType string
+
+const (
+	// IndexHNSW builds an HNSW graph: the best recall and speed, but memory-hungry to build.
+	// Requires pgvector 0.5.0+.
+	IndexHNSW IndexType = "hnsw"
+	// IndexIVFFlat clusters vectors into lists. Cheaper to build, lower recall, and it must be
+	// built after the collection has data (see PostgresDB.BuildIndex).
+	IndexIVFFlat IndexType = "ivfflat"
+	// IndexNone skips the index; searches scan the whole collection (exact, slow on large collections)
+	IndexNone IndexType = "none"
+)
+
+// ParseIndexType validates an index type name. An empty string selects the default:
+// HNSW, or IVFFlat on pgvector versions without HNSW support.
+func ParseIndexType(s string) (IndexType, error) {
+	switch IndexType(s) {
+	case "", IndexHNSW, IndexIVFFlat, IndexNone:
+		return IndexType(s), nil
+	default:
+		return "", fmt.Errorf("invalid index type %q: must be one of %s, %s, %s", s, IndexHNSW, IndexIVFFlat, IndexNone)
+	}
+}
+
+// IVFFlatLists returns the number of IVFFlat lists for a collection of rows vectors,
+// following the pgvector recommendation of rows/1000 up to a million rows and sqrt(rows) above
+func IVFFlatLists(rows int) int {
+	lists := rows / 1000
+	if rows > 1_000_000 {
+		lists = int(math.Sqrt(float64(rows)))
+	}
+	return max(lists, 1)
+}
@@ -0,0 +1,21 @@
+package vectorstore
+
+import "testing"
+
+func TestIVFFlatLists(t *testing.T) {
+	tests := []struct {
+		rows int
+		want int
+	}{
+		{0, 1},
+		{500, 1},
+		{25_000, 25},
+		{1_000_000, 1000},
+		{4_000_000, 2000},
+	}
+	for _, tt := range tests {
+		if got := IVFFlatLists(tt.rows); got != tt.want {
+			t.Errorf("IVFFlatLists(%d) = %d, want %d", tt.rows, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package vectorstore
+
+import "fmt"
+
+// DistanceMetric identifies the pgvector distance operator used for similarity search
+type DistanceMetric string
+
+const (
+	// MetricCosine uses cosine distance (<=>). Distance is in [0, 2], similarity in [-1, 1].
+	MetricCosine DistanceMetric = "cosine"
+	// MetricL2 uses Euclidean distance (<->). Similarity is 1/(1+distance), in (0, 1].
+	MetricL2 DistanceMetric = "l2"
+	// MetricInnerProduct uses negative inner product (<#>). Similarity is the inner product.
+	MetricInnerProduct DistanceMetric = "inner_product"
+)
+
+// ParseDistanceMetric validates a metric name. An empty string is returned as is: the
+// collection keeps its recorded metric, and new collections use cosine.
+func ParseDistanceMetric(s string) (DistanceMetric, error) {
+	switch DistanceMetric(s) {
+	case "", MetricCosine, MetricL2, MetricInnerProduct:
+		return DistanceMetric(s), nil
+	default:
+		return "", fmt.Errorf("invalid distance metric %q: must be one of %s, %s, %s", s, MetricCosine, MetricL2, MetricInnerProduct)
+	}
+}
+
+// SimilarityScore describes how closely a search result matched the query
+type SimilarityScore struct {
+	Metric DistanceMetric `json:"metric"`
+	// Distance is the raw value returned by the distance operator (lower is closer)
+	Distance float64 `json:"distance"`
+	// Similarity is derived from Distance for the metric (higher is closer)
+	Similarity float64 `json:"similarity"`
+	// Recency and Boosted are set by recency-boosted searches (see Recency): the recency
+	// factor of the document (0 without a publication date) and the score it was ranked by
+	Recency float64 `json:"recency,omitempty"`
+	Boosted float64 `json:"boosted,omitempty"`
+}
+
+// Operator returns the pgvector SQL operator for the metric
+func (m DistanceMetric) Operator() string {
+	switch m {
+	case MetricL2:
+		return "<->"
+	case MetricInnerProduct:
+		return "<#>"
+	default:
+		return "<=>"
+	}
+}
+
+// IndexOps returns the pgvector operator class an index must use to serve the metric
+func (m DistanceMetric) IndexOps() string {
+	switch m {
+	case MetricL2:
+		return "vector_l2_ops"
+	case MetricInnerProduct:
+		return "vector_ip_ops"
+	default:
+		return "vector_cosine_ops"
+	}
+}
+
+// similarity converts a raw distance into a similarity for the metric
+func (m DistanceMetric) similarity(distance float64) float64 {
+	switch m {
+	case MetricL2:
+		return 1 / (1 + distance)
+	case MetricInnerProduct:
+		return -distance
+	default:
+		return 1 - distance
+	}
+}
+
+// newScore builds a SimilarityScore from a raw distance
+func (m DistanceMetric) newScore(distance float64) SimilarityScore {
+	return SimilarityScore{
+		Metric:     m,
+		Distance:   distance,
+		Similarity: m.similarity(distance),
+	}
+}
@@ -0,0 +1,58 @@
+package vectorstore
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSimilarityQueryUsesCollectionMetric(t *testing.T) {
+	tests := []struct {
+		metric DistanceMetric
+		op     string
+	}{
+		{MetricCosine, "<=>"},
+		{MetricL2, "<->"},
+		{MetricInnerProduct, "<#>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.metric), func(t *testing.T) {
+			for _, filtered := range []bool{false, true} {
+				query := similarityQuery("papers", tt.metric, filtered)
+				if got := strings.Count(query, tt.op); got != 2 {
+					t.Errorf("filtered=%v: query uses %s %d times, want 2 (select and order by):\n%s", filtered, tt.op, got, query)
+				}
+			}
+		})
+	}
+}
+
+func TestParseDistanceMetric(t *testing.T) {
+	if m, err := ParseDistanceMetric(""); err != nil || m != "" {
+		t.Errorf("ParseDistanceMetric(\"\") = %q, %v; want empty, to keep the recorded metric", m, err)
+	}
+	if m, err := ParseDistanceMetric("l2"); err != nil || m != MetricL2 {
+		t.Errorf("ParseDistanceMetric(\"l2\") = %q, %v; want l2", m, err)
+	}
+	if _, err := ParseDistanceMetric("manhattan"); err == nil {
+		t.Error("ParseDistanceMetric(\"manhattan\") succeeded, want error")
+	}
+}
+
+func TestMetricSimilarity(t *testing.T) {
+	tests := []struct {
+		metric   DistanceMetric
+		distance float64
+		want     float64
+	}{
+		{MetricCosine, 0.25, 0.75},
+		{MetricL2, 0, 1},
+		{MetricL2, 3, 0.25},
+		{MetricInnerProduct, -0.8, 0.8},
+	}
+	for _, tt := range tests {
+		if got := tt.metric.similarity(tt.distance); got != tt.want {
+			t.Errorf("%s similarity(%v) = %v, want %v", tt.metric, tt.distance, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package vectorstore
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// maxJSONLLine bounds a single exported document line (content plus a full embedding)
+const maxJSONLLine = 16 << 20
+
+// ReadJSONL reads documents written by Export, one JSON object per line. Blank lines are skipped.
+func ReadJSONL(r io.Reader) ([]Document, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxJSONLLine)
+
+	var docs []Document
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var doc Document
+		if err := json.Unmarshal(scanner.Bytes(), &doc); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		docs = append(docs, doc)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read documents: %w", err)
+	}
+	return docs, nil
+}
+
+// SearchDocuments ranks documents by cosine similarity to the query embedding in memory,
+// without a database. Documents without an embedding of the query's dimension are skipped.
+func SearchDocuments(docs []Document, queryEmbedding []float32, topK int) []SimilaritySearchResult {
+	var results []SimilaritySearchResult
+	for _, doc := range docs {
+		if len(doc.Embedding) == 0 || len(doc.Embedding) != len(queryEmbedding) {
+			continue
+		}
+		results = append(results, SimilaritySearchResult{
+			Document: doc,
+			Score:    MetricCosine.newScore(cosineDistance(queryEmbedding, doc.Embedding)),
+		})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score.Distance < results[j].Score.Distance
+	})
+	if topK > 0 && len(results) > topK {
+		results = results[:topK]
+	}
+	return results
+}
+
+// cosineDistance returns 1 - cos(a, b), matching pgvector's <=> operator.
+// Zero vectors are treated as maximally distant from everything.
+func cosineDistance(a, b []float32) float64 {
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 2
+	}
+	return 1 - dot/(math.Sqrt(normA)*math.Sqrt(normB))
+}
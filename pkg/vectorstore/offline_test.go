@@ -0,0 +1,52 @@
+package vectorstore
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestReadJSONL(t *testing.T) {
+	input := `{"id":"1","content":"a","metadata":{"source":"x"},"embedding":[1,0]}
+
+{"id":"2","content":"b","metadata":{}}
+`
+	docs, err := ReadJSONL(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadJSONL: %v", err)
+	}
+	if len(docs) != 2 {
+		t.Fatalf("got %d documents, want 2", len(docs))
+	}
+	if docs[0].Metadata["source"] != "x" || len(docs[0].Embedding) != 2 {
+		t.Errorf("docs[0] = %+v, want source x with a 2-dim embedding", docs[0])
+	}
+	if docs[1].Embedding != nil {
+		t.Errorf("docs[1].Embedding = %v, want nil", docs[1].Embedding)
+	}
+
+	if _, err := ReadJSONL(strings.NewReader("{not json}\n")); err == nil {
+		t.Error("ReadJSONL(invalid) succeeded, want error")
+	}
+}
+
+func TestSearchDocuments(t *testing.T) {
+	docs := []Document{
+		{ID: "orthogonal", Embedding: []float32{0, 1}},
+		{ID: "same", Embedding: []float32{2, 0}},
+		{ID: "none"},
+		{ID: "wrong dimension", Embedding: []float32{1, 0, 0}},
+		{ID: "close", Embedding: []float32{1, 1}},
+	}
+
+	results := SearchDocuments(docs, []float32{1, 0}, 2)
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+	if results[0].Document.ID != "same" || results[1].Document.ID != "close" {
+		t.Errorf("order = %s, %s, want same, close", results[0].Document.ID, results[1].Document.ID)
+	}
+	if math.Abs(results[0].Score.Similarity-1) > 1e-9 || results[0].Score.Metric != MetricCosine {
+		t.Errorf("top score = %+v, want cosine similarity 1", results[0].Score)
+	}
+}
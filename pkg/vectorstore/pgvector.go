@@ -3,11 +3,15 @@ package vectorstore
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/pgvector/pgvector-go"
 )
@@ -20,10 +24,20 @@ type Document struct {
 	Embedding []float32              `json:"embedding,omitempty"`
 }
 
+var (
+	// ErrInvalidTableName is returned for collection names that are not safe table names
+	ErrInvalidTableName = errors.New("invalid table name: must contain only alphanumeric characters and underscores, start with a letter or underscore, and be 1-63 characters long")
+	// ErrDocumentNotFound is returned when no document has the requested ID
+	ErrDocumentNotFound = errors.New("document not found")
+)
+
 // PGVectorStore handles pgvector operations
 type PGVectorStore struct {
 	pool      *pgxpool.Pool
 	tableName string
+	efSearch  int       // hnsw.ef_search for similarity searches, 0 keeps the server setting
+	recency   Recency   // Recency boost of similarity searches, disabled by default
+	retention Retention // Document limit enforced by PruneToRetention, unlimited by default
 }
 
 // isValidTableName validates that a table name contains only safe characters
@@ -38,7 +52,7 @@ func isValidTableName(name string) bool {
 // NewPGVectorStore creates a new PGVector store
 func NewPGVectorStore(pool *pgxpool.Pool, tableName string) (*PGVectorStore, error) {
 	if !isValidTableName(tableName) {
-		return nil, fmt.Errorf("invalid table name: must contain only alphanumeric characters and underscores, start with a letter or underscore, and be 1-63 characters long")
+		return nil, ErrInvalidTableName
 	}
 	return &PGVectorStore{
 		pool:      pool,
@@ -46,12 +60,43 @@ func NewPGVectorStore(pool *pgxpool.Pool, tableName string) (*PGVectorStore, err
 	}, nil
 }
 
-// AddDocuments adds documents with embeddings to the vector store
+// MaxEFSearch is the largest hnsw.ef_search pgvector accepts
+const MaxEFSearch = 1000
+
+// ValidateEFSearch checks an hnsw.ef_search setting against pgvector's range of 1 to
+// MaxEFSearch. 0 keeps the server setting.
+func ValidateEFSearch(efSearch int) error {
+	if efSearch < 0 || efSearch > MaxEFSearch {
+		return fmt.Errorf("invalid hnsw.ef_search %d: must be between 1 and %d, or 0 for the server default", efSearch, MaxEFSearch)
+	}
+	return nil
+}
+
+// WithEFSearch sets hnsw.ef_search for the store's similarity searches. Higher values
+// improve HNSW recall at the cost of latency; pgvector accepts 1 to 1000 and defaults to 40.
+// It must be at least topK for a search to return topK results. 0 keeps the server setting.
+func (vs *PGVectorStore) WithEFSearch(efSearch int) *PGVectorStore {
+	vs.efSearch = efSearch
+	return vs
+}
+
+// AddDocuments adds documents with embeddings to the vector store.
+// Documents without an ID get a random one from the database. Documents with an ID
+// (e.g. from DocumentID) replace any existing row with that ID.
+// Inserts don't prune the collection; callers apply its retention limit with PruneToRetention
+// once a batch of inserts is done.
 func (vs *PGVectorStore) AddDocuments(ctx context.Context, docs []Document) error {
+	table := pgx.Identifier{vs.tableName}.Sanitize()
 	query := fmt.Sprintf(`
 		INSERT INTO %s (content, metadata, embedding)
 		VALUES ($1, $2, $3)
-	`, pgx.Identifier{vs.tableName}.Sanitize())
+	`, table)
+	upsertQuery := fmt.Sprintf(`
+		INSERT INTO %s (id, content, metadata, embedding)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (id) DO UPDATE
+		SET content = EXCLUDED.content, metadata = EXCLUDED.metadata, embedding = EXCLUDED.embedding
+	`, table)
 
 	batch := &pgx.Batch{}
 	for _, doc := range docs {
@@ -61,7 +106,11 @@ func (vs *PGVectorStore) AddDocuments(ctx context.Context, docs []Document) erro
 		}
 
 		embedding := pgvector.NewVector(doc.Embedding)
-		batch.Queue(query, doc.Content, metadataJSON, embedding)
+		if doc.ID != "" {
+			batch.Queue(upsertQuery, doc.ID, doc.Content, metadataJSON, embedding)
+		} else {
+			batch.Queue(query, doc.Content, metadataJSON, embedding)
+		}
 	}
 
 	br := vs.pool.SendBatch(ctx, batch)
@@ -72,43 +121,68 @@ func (vs *PGVectorStore) AddDocuments(ctx context.Context, docs []Document) erro
 			return fmt.Errorf("failed to insert document: %w", err)
 		}
 	}
-
+	if err := br.Close(); err != nil {
+		return fmt.Errorf("failed to insert documents: %w", err)
+	}
 	return nil
 }
 
 // SimilaritySearchResult represents a search result with score
 type SimilaritySearchResult struct {
 	Document Document
-	Score    float64
+	Score    SimilarityScore
 }
 
 // SimilaritySearch performs a similarity search
 func (vs *PGVectorStore) SimilaritySearch(ctx context.Context, queryEmbedding []float32, topK int, sourceFilter string) ([]SimilaritySearchResult, error) {
-	var query string
-	var args []interface{}
+	var sources []string
+	if sourceFilter != "" {
+		sources = []string{sourceFilter}
+	}
+	return vs.SimilaritySearchSources(ctx, queryEmbedding, topK, sources)
+}
+
+// SimilaritySearchSources performs a similarity search restricted to documents from any of
+// the given sources. An empty list searches the whole collection.
+// The distance operator is the one recorded for the collection when it was created.
+// With a recency boost (see WithRecency) more candidates are fetched and re-ranked by
+// boosted score.
+func (vs *PGVectorStore) SimilaritySearchSources(ctx context.Context, queryEmbedding []float32, topK int, sources []string) ([]SimilaritySearchResult, error) {
+	metric, err := vs.Metric(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := topK
+	if vs.recency.Weight > 0 {
+		limit = topK * recencyCandidates
+	}
 
 	embedding := pgvector.NewVector(queryEmbedding)
+	query := similarityQuery(vs.tableName, metric, len(sources) > 0)
+	args := []interface{}{embedding, limit}
+	if len(sources) > 0 {
+		args = []interface{}{embedding, sources, limit}
+	}
 
-	if sourceFilter != "" {
-		query = fmt.Sprintf(`
-			SELECT id, content, metadata, 1 - (embedding <=> $1) as similarity
-			FROM %s
-			WHERE metadata->>'source' = $2
-			ORDER BY embedding <=> $1
-			LIMIT $3
-		`, pgx.Identifier{vs.tableName}.Sanitize())
-		args = []interface{}{embedding, sourceFilter, topK}
-	} else {
-		query = fmt.Sprintf(`
-			SELECT id, content, metadata, 1 - (embedding <=> $1) as similarity
-			FROM %s
-			ORDER BY embedding <=> $1
-			LIMIT $2
-		`, pgx.Identifier{vs.tableName}.Sanitize())
-		args = []interface{}{embedding, topK}
+	// SET LOCAL only lasts for the transaction, so the setting doesn't leak to other
+	// users of the pooled connection
+	var q interface {
+		Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	} = vs.pool
+	if vs.efSearch > 0 {
+		tx, err := vs.pool.Begin(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		defer tx.Rollback(ctx) // Read only, nothing to commit
+		if _, err := tx.Exec(ctx, fmt.Sprintf("SET LOCAL hnsw.ef_search = %d", vs.efSearch)); err != nil {
+			return nil, fmt.Errorf("failed to set hnsw.ef_search: %w", err)
+		}
+		q = tx
 	}
 
-	rows, err := vs.pool.Query(ctx, query, args...)
+	rows, err := q.Query(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute similarity search: %w", err)
 	}
@@ -118,9 +192,9 @@ func (vs *PGVectorStore) SimilaritySearch(ctx context.Context, queryEmbedding []
 	for rows.Next() {
 		var doc Document
 		var metadataJSON []byte
-		var similarity float64
+		var distance float64
 
-		if err := rows.Scan(&doc.ID, &doc.Content, &metadataJSON, &similarity); err != nil {
+		if err := rows.Scan(&doc.ID, &doc.Content, &metadataJSON, &distance); err != nil {
 			return nil, fmt.Errorf("failed to scan row: %w", err)
 		}
 
@@ -130,7 +204,7 @@ func (vs *PGVectorStore) SimilaritySearch(ctx context.Context, queryEmbedding []
 
 		results = append(results, SimilaritySearchResult{
 			Document: doc,
-			Score:    similarity,
+			Score:    metric.newScore(distance),
 		})
 	}
 
@@ -138,9 +212,68 @@ func (vs *PGVectorStore) SimilaritySearch(ctx context.Context, queryEmbedding []
 		return nil, fmt.Errorf("error iterating rows: %w", err)
 	}
 
+	if vs.recency.Weight > 0 {
+		vs.recency.rank(results, time.Now())
+		if len(results) > topK {
+			results = results[:topK]
+		}
+	}
+
+	if vs.retention.tracksAccess() && len(results) > 0 {
+		ids := make([]string, len(results))
+		for i, r := range results {
+			ids[i] = r.Document.ID
+		}
+		// The search already succeeded; a missed access count only skews eviction slightly
+		if err := vs.recordAccess(ctx, ids); err != nil {
+			slog.Warn("Failed to record document access", "collection", vs.tableName, "error", err)
+		}
+	}
+
 	return results, nil
 }
 
+// similarityQuery builds the similarity search SQL for a metric. With filtered set the
+// arguments are (embedding, sources, topK), otherwise (embedding, topK).
+func similarityQuery(tableName string, metric DistanceMetric, filtered bool) string {
+	if filtered {
+		return fmt.Sprintf(`
+			SELECT id, content, metadata, embedding %[2]s $1 as distance
+			FROM %[1]s
+			WHERE metadata->>'source' = ANY($2)
+			ORDER BY embedding %[2]s $1
+			LIMIT $3
+		`, pgx.Identifier{tableName}.Sanitize(), metric.Operator())
+	}
+	return fmt.Sprintf(`
+		SELECT id, content, metadata, embedding %[2]s $1 as distance
+		FROM %[1]s
+		ORDER BY embedding %[2]s $1
+		LIMIT $2
+	`, pgx.Identifier{tableName}.Sanitize(), metric.Operator())
+}
+
+// Metric returns the distance metric recorded for the collection in collection_metadata.
+// Collections without a record (created before metrics were recorded) use cosine.
+func (vs *PGVectorStore) Metric(ctx context.Context) (DistanceMetric, error) {
+	var metric string
+	err := vs.pool.QueryRow(ctx, `SELECT metric FROM collection_metadata WHERE collection = $1`, vs.tableName).Scan(&metric)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return MetricCosine, nil
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "42P01" { // undefined_table
+		return MetricCosine, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get collection metric: %w", err)
+	}
+	if metric == "" {
+		return MetricCosine, nil
+	}
+	return ParseDistanceMetric(metric)
+}
+
 // GetContentBySource retrieves all documents for a specific source
 func (vs *PGVectorStore) GetContentBySource(ctx context.Context, source string) ([]Document, error) {
 	query := fmt.Sprintf(`
@@ -178,9 +311,166 @@ func (vs *PGVectorStore) GetContentBySource(ctx context.Context, source string)
 	return documents, nil
 }
 
+// GetChunks returns up to limit text chunks of a source in reading order, starting at
+// offset, together with the total number of chunks of the source. Title and figure
+// caption documents are not part of the text and are excluded. Chunks indexed without
+// a chunk_index are ordered by insertion time.
+func (vs *PGVectorStore) GetChunks(ctx context.Context, source string, offset, limit int) ([]Document, int, error) {
+	query := fmt.Sprintf(`
+		SELECT id, content, metadata, COUNT(*) OVER() AS total
+		FROM %s
+		WHERE metadata->>'source' = $1
+			AND NOT metadata ? 'chunk_type'
+			AND COALESCE(metadata->>'type', '') <> 'figure_caption'
+		ORDER BY (metadata->>'chunk_index')::int NULLS LAST, created_at, id
+		OFFSET $2
+		LIMIT $3
+	`, pgx.Identifier{vs.tableName}.Sanitize())
+
+	rows, err := vs.pool.Query(ctx, query, source, offset, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	var documents []Document
+	total := 0
+	for rows.Next() {
+		var doc Document
+		var metadataJSON []byte
+
+		if err := rows.Scan(&doc.ID, &doc.Content, &metadataJSON, &total); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		if err := json.Unmarshal(metadataJSON, &doc.Metadata); err != nil {
+			return nil, 0, fmt.Errorf("failed to unmarshal metadata: %w", err)
+		}
+
+		documents = append(documents, doc)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return documents, total, nil
+}
+
+// Export streams every document of the collection to fn, oldest first. Embeddings are
+// only read when withEmbeddings is set. Iteration stops at the first error from fn.
+func (vs *PGVectorStore) Export(ctx context.Context, withEmbeddings bool, fn func(Document) error) error {
+	columns := "id, content, metadata"
+	if withEmbeddings {
+		columns += ", embedding"
+	}
+	query := fmt.Sprintf(`SELECT %s FROM %s ORDER BY created_at, id`, columns, pgx.Identifier{vs.tableName}.Sanitize())
+
+	rows, err := vs.pool.Query(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var doc Document
+		var metadataJSON []byte
+		var embedding pgvector.Vector
+
+		dest := []interface{}{&doc.ID, &doc.Content, &metadataJSON}
+		if withEmbeddings {
+			dest = append(dest, &embedding)
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return fmt.Errorf("failed to scan row: %w", err)
+		}
+		if err := json.Unmarshal(metadataJSON, &doc.Metadata); err != nil {
+			return fmt.Errorf("failed to unmarshal metadata: %w", err)
+		}
+		if withEmbeddings {
+			doc.Embedding = embedding.Slice()
+		}
+
+		if err := fn(doc); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating rows: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a single document by its ID. It returns ErrDocumentNotFound if
+// no such document exists, including when the collection itself does not exist.
+func (vs *PGVectorStore) GetByID(ctx context.Context, id string) (Document, error) {
+	query := fmt.Sprintf(`
+		SELECT id, content, metadata
+		FROM %s
+		WHERE id = $1
+	`, pgx.Identifier{vs.tableName}.Sanitize())
+
+	var doc Document
+	var metadataJSON []byte
+	err := vs.pool.QueryRow(ctx, query, id).Scan(&doc.ID, &doc.Content, &metadataJSON)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Document{}, ErrDocumentNotFound
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "42P01" { // undefined_table
+		return Document{}, ErrDocumentNotFound
+	}
+	if err != nil {
+		return Document{}, fmt.Errorf("failed to get document: %w", err)
+	}
+
+	if err := json.Unmarshal(metadataJSON, &doc.Metadata); err != nil {
+		return Document{}, fmt.Errorf("failed to unmarshal metadata: %w", err)
+	}
+
+	return doc, nil
+}
+
+// GetMetadataValues returns the distinct string values stored under a metadata key
+func (vs *PGVectorStore) GetMetadataValues(ctx context.Context, key string) ([]string, error) {
+	query := fmt.Sprintf(`
+		SELECT DISTINCT metadata->>$1
+		FROM %s
+		WHERE metadata ? $1
+	`, pgx.Identifier{vs.tableName}.Sanitize())
+
+	rows, err := vs.pool.Query(ctx, query, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	var values []string
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		values = append(values, value)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return values, nil
+}
+
 // GetContentByMetadata retrieves documents matching a complex JSON filter
-// Supports logical operators $and, $or, $not within the filter map
+// Supports logical operators $and, $or, $not within the filter map.
+// Malformed filters fail with ErrInvalidFilter (see NormalizeMetadataFilter).
 func (vs *PGVectorStore) GetContentByMetadata(ctx context.Context, filter map[string]interface{}) ([]Document, error) {
+	filter, err := NormalizeMetadataFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
 	var args []interface{}
 	whereClause, err := vs.buildMetadataQuery(filter, &args)
 	if err != nil {
@@ -222,8 +512,77 @@ func (vs *PGVectorStore) GetContentByMetadata(ctx context.Context, filter map[st
 	return documents, nil
 }
 
-// buildMetadataQuery recursively builds a SQL WHERE clause for list of conditions
+// DeleteByMetadata deletes the documents matching a metadata filter (see GetContentByMetadata)
+// and returns how many were removed.
+func (vs *PGVectorStore) DeleteByMetadata(ctx context.Context, filter map[string]interface{}) (int64, error) {
+	var args []interface{}
+	whereClause, err := vs.writeFilterClause(filter, &args)
+	if err != nil {
+		return 0, err
+	}
+
+	query := fmt.Sprintf("DELETE FROM %s WHERE %s", pgx.Identifier{vs.tableName}.Sanitize(), whereClause)
+	result, err := vs.pool.Exec(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete documents: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
+
+// writeFilterClause normalizes the filter of a statement that modifies documents and builds
+// its WHERE clause. A filter matching every document, such as {} or {"$and": []}, is
+// rejected with ErrInvalidFilter, so a missing filter can't update or delete a whole collection.
+func (vs *PGVectorStore) writeFilterClause(filter map[string]interface{}, args *[]interface{}) (string, error) {
+	filter, err := NormalizeMetadataFilter(filter)
+	if err != nil {
+		return "", err
+	}
+	if matchesEverything(filter) {
+		return "", fmt.Errorf("%w: a filter matching every document is not allowed here", ErrInvalidFilter)
+	}
+	whereClause, err := vs.buildMetadataQuery(filter, args)
+	if err != nil {
+		return "", fmt.Errorf("failed to build metadata query: %w", err)
+	}
+	return whereClause, nil
+}
+
+// matchesEverything reports whether a normalized filter has no condition restricting it, as
+// buildMetadataQuery treats empty objects and operator lists as TRUE
+func matchesEverything(filter map[string]interface{}) bool {
+	for key, value := range filter {
+		switch key {
+		case "$and", "$or":
+			list, _ := value.([]interface{})
+			anyAll := len(list) == 0
+			allAll := true
+			for _, item := range list {
+				sub, _ := item.(map[string]interface{})
+				m := matchesEverything(sub)
+				anyAll = anyAll || m
+				allAll = allAll && m
+			}
+			if (key == "$and" && !allAll) || (key == "$or" && !anyAll) {
+				return false
+			}
+		default:
+			// Fields and $not (which never negates to everything) restrict the match
+			return false
+		}
+	}
+	return true
+}
+
+// buildMetadataQuery recursively builds a SQL WHERE clause for list of conditions.
+// Nesting and the number of conditions are capped by MaxFilterDepth and MaxFilterConditions.
 func (vs *PGVectorStore) buildMetadataQuery(filter map[string]interface{}, args *[]interface{}) (string, error) {
+	return vs.buildMetadataQueryDepth(filter, args, 0)
+}
+
+func (vs *PGVectorStore) buildMetadataQueryDepth(filter map[string]interface{}, args *[]interface{}, depth int) (string, error) {
+	if depth > MaxFilterDepth {
+		return "", fmt.Errorf("%w: nested deeper than %d levels", ErrInvalidFilter, MaxFilterDepth)
+	}
 	if len(filter) == 0 {
 		return "TRUE", nil
 	}
@@ -243,7 +602,7 @@ func (vs *PGVectorStore) buildMetadataQuery(filter map[string]interface{}, args
 				if !ok {
 					return "", fmt.Errorf("item in %s list must be a JSON object", key)
 				}
-				subQuery, err := vs.buildMetadataQuery(subMap, args)
+				subQuery, err := vs.buildMetadataQueryDepth(subMap, args, depth+1)
 				if err != nil {
 					return "", err
 				}
@@ -265,7 +624,7 @@ func (vs *PGVectorStore) buildMetadataQuery(filter map[string]interface{}, args
 			if !ok {
 				return "", fmt.Errorf("value for $not must be a JSON object")
 			}
-			subQuery, err := vs.buildMetadataQuery(subMap, args)
+			subQuery, err := vs.buildMetadataQueryDepth(subMap, args, depth+1)
 			if err != nil {
 				return "", err
 			}
@@ -278,6 +637,9 @@ func (vs *PGVectorStore) buildMetadataQuery(filter map[string]interface{}, args
 			if err != nil {
 				return "", fmt.Errorf("failed to marshal metadata pair: %w", err)
 			}
+			if len(*args) >= MaxFilterConditions {
+				return "", fmt.Errorf("%w: more than %d conditions", ErrInvalidFilter, MaxFilterConditions)
+			}
 			*args = append(*args, jsonBytes)
 			conditions = append(conditions, fmt.Sprintf("metadata @> $%d", len(*args)))
 		}
@@ -290,6 +652,24 @@ func (vs *PGVectorStore) buildMetadataQuery(filter map[string]interface{}, args
 	return strings.Join(conditions, " AND "), nil
 }
 
+// Compact rebuilds the collection's indexes (including the HNSW index) and reclaims
+// dead tuples left behind by deletes and re-indexing. REINDEX locks the table
+// against writes while it runs, so avoid calling this during active indexing.
+func (vs *PGVectorStore) Compact(ctx context.Context) error {
+	table := pgx.Identifier{vs.tableName}.Sanitize()
+
+	if _, err := vs.pool.Exec(ctx, fmt.Sprintf("REINDEX TABLE %s", table)); err != nil {
+		return fmt.Errorf("failed to reindex %s: %w", vs.tableName, err)
+	}
+
+	// VACUUM cannot run inside a transaction block, so it is sent as its own statement
+	if _, err := vs.pool.Exec(ctx, fmt.Sprintf("VACUUM ANALYZE %s", table)); err != nil {
+		return fmt.Errorf("failed to vacuum %s: %w", vs.tableName, err)
+	}
+
+	return nil
+}
+
 // UpdateMetadata updates specific fields in the metadata for a document with the given ID.
 // It merges the provided updates with the existing metadata using the JSONB concatenation operator (||).
 // Existing keys will be overwritten, and new keys will be added.
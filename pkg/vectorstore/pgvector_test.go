@@ -1,6 +1,9 @@
 package vectorstore
 
-import "testing"
+import (
+	"errors"
+	"testing"
+)
 
 func TestIsValidTableName(t *testing.T) {
 	tests := []struct {
@@ -169,3 +172,64 @@ func TestBuildMetadataQuery(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildMetadataQueryLimits(t *testing.T) {
+	vs := &PGVectorStore{}
+
+	nested := func(levels int) map[string]interface{} {
+		filter := map[string]interface{}{"a": 1}
+		for i := 0; i < levels; i++ {
+			filter = map[string]interface{}{"$not": filter}
+		}
+		return filter
+	}
+	wide := func(n int) map[string]interface{} {
+		list := make([]interface{}, n)
+		for i := range list {
+			list[i] = map[string]interface{}{"a": i}
+		}
+		return map[string]interface{}{"$or": list}
+	}
+
+	tests := []struct {
+		name    string
+		filter  map[string]interface{}
+		wantErr bool
+	}{
+		{"Max depth", nested(MaxFilterDepth), false},
+		{"Too deep", nested(MaxFilterDepth + 1), true},
+		{"Max conditions", wide(MaxFilterConditions), false},
+		{"Too many conditions", wide(MaxFilterConditions + 1), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var args []interface{}
+			_, err := vs.buildMetadataQuery(tt.filter, &args)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("buildMetadataQuery() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidFilter) {
+				t.Errorf("buildMetadataQuery() error = %v, want ErrInvalidFilter", err)
+			}
+
+			_, err = NormalizeMetadataFilter(tt.filter)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NormalizeMetadataFilter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateEFSearch(t *testing.T) {
+	for _, n := range []int{0, 1, 40, MaxEFSearch} {
+		if err := ValidateEFSearch(n); err != nil {
+			t.Errorf("ValidateEFSearch(%d) = %v, want nil", n, err)
+		}
+	}
+	for _, n := range []int{-1, MaxEFSearch + 1} {
+		if err := ValidateEFSearch(n); err == nil {
+			t.Errorf("ValidateEFSearch(%d) succeeded, want error", n)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package vectorstore
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// recencyCandidates is how many times topK results a recency-boosted search fetches by
+// similarity before re-ranking, so newer documents just outside topK can move up
+const recencyCandidates = 4
+
+// DefaultRecencyHalfLife is the age at which a document gets half the recency boost of one
+// published today
+const DefaultRecencyHalfLife = 5 * 365 * 24 * time.Hour
+
+// Recency configures the recency boost of similarity searches. A document's ranking score is
+// its similarity plus Weight × 0.5^(age / HalfLife), so at equal relevance newer documents
+// rank higher. Documents without a publication date get no boost.
+type Recency struct {
+	Weight   float64       // Boost for a document published today (0 = disabled)
+	HalfLife time.Duration // Age at which the boost halves (0 = DefaultRecencyHalfLife)
+}
+
+// WithRecency sets the recency boost of the store's similarity searches
+func (vs *PGVectorStore) WithRecency(r Recency) *PGVectorStore {
+	vs.recency = r
+	return vs
+}
+
+// decay returns the recency factor in (0, 1] of a document published at published
+func (r Recency) decay(published, now time.Time) float64 {
+	halfLife := r.HalfLife
+	if halfLife <= 0 {
+		halfLife = DefaultRecencyHalfLife
+	}
+	age := now.Sub(published)
+	if age < 0 {
+		age = 0
+	}
+	return math.Pow(0.5, float64(age)/float64(halfLife))
+}
+
+// rank sets the recency and boosted scores of the results and sorts them by boosted score,
+// keeping the similarity order among equal scores
+func (r Recency) rank(results []SimilaritySearchResult, now time.Time) {
+	for i := range results {
+		score := &results[i].Score
+		score.Boosted = score.Similarity
+		if published, ok := PublishedAt(results[i].Document.Metadata); ok {
+			score.Recency = r.decay(published, now)
+			score.Boosted += r.Weight * score.Recency
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score.Boosted > results[j].Score.Boosted
+	})
+}
+
+// PublishedAt returns the publication date recorded in document metadata: the published
+// field (RFC 3339 or YYYY-MM-DD), else the start of the year field
+func PublishedAt(metadata map[string]interface{}) (time.Time, bool) {
+	if s, ok := metadata["published"].(string); ok && s != "" {
+		for _, layout := range []string{time.RFC3339, time.DateOnly} {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t, true
+			}
+		}
+	}
+
+	var year int
+	switch v := metadata["year"].(type) {
+	case string:
+		year, _ = strconv.Atoi(v)
+	case float64:
+		year = int(v)
+	}
+	if year <= 0 {
+		return time.Time{}, false
+	}
+	return time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC), true
+}
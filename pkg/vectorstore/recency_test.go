@@ -0,0 +1,67 @@
+package vectorstore
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestPublishedAt(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata map[string]interface{}
+		want     time.Time
+		ok       bool
+	}{
+		{"rfc3339", map[string]interface{}{"published": "2017-06-12T17:57:34Z"}, time.Date(2017, 6, 12, 17, 57, 34, 0, time.UTC), true},
+		{"date", map[string]interface{}{"published": "2021-03-04"}, time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC), true},
+		{"year fallback", map[string]interface{}{"published": "soon", "year": "2019"}, time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), true},
+		{"numeric year", map[string]interface{}{"year": float64(2015)}, time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC), true},
+		{"missing", map[string]interface{}{"source": "x"}, time.Time{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := PublishedAt(tt.metadata)
+			if ok != tt.ok || !got.Equal(tt.want) {
+				t.Errorf("PublishedAt() = %v, %v, want %v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestRecencyRank(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	result := func(id, published string, similarity float64) SimilaritySearchResult {
+		meta := map[string]interface{}{}
+		if published != "" {
+			meta["published"] = published
+		}
+		return SimilaritySearchResult{Document: Document{ID: id, Metadata: meta}, Score: SimilarityScore{Similarity: similarity}}
+	}
+	results := []SimilaritySearchResult{
+		result("old", "2015-01-01", 0.80),
+		result("undated", "", 0.80),
+		result("new", "2025-01-01", 0.78),
+		result("far", "2024-01-01", 0.50),
+	}
+
+	Recency{Weight: 0.05, HalfLife: DefaultRecencyHalfLife}.rank(results, now)
+
+	var order []string
+	for _, r := range results {
+		order = append(order, r.Document.ID)
+	}
+	want := []string{"new", "old", "undated", "far"}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("order = %v, want %v", order, want)
+		}
+	}
+	if results[0].Score.Recency != 1 || math.Abs(results[0].Score.Boosted-0.83) > 1e-9 {
+		t.Errorf("new: recency %v boosted %v, want 1 and 0.83", results[0].Score.Recency, results[0].Score.Boosted)
+	}
+	if results[2].Score.Recency != 0 {
+		t.Errorf("undated recency = %v, want 0", results[2].Score.Recency)
+	}
+}
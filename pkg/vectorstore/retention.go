@@ -0,0 +1,113 @@
+package vectorstore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Eviction selects which documents Prune removes from a collection over its limit
+type Eviction string
+
+const (
+	// EvictOldest removes the documents indexed first
+	EvictOldest Eviction = "oldest"
+	// EvictLeastAccessed removes the documents returned by the fewest similarity searches,
+	// oldest access first. Accesses are only counted by stores with this policy.
+	EvictLeastAccessed Eviction = "least_accessed"
+)
+
+// EvictionGracePeriod is how long newly indexed documents are exempt from EvictLeastAccessed,
+// which would otherwise evict them first since no search could have returned them yet.
+// Within it they're only evicted once every older document is gone.
+const EvictionGracePeriod = 24 * time.Hour
+
+// ParseEviction validates an eviction policy name. An empty string selects EvictOldest.
+func ParseEviction(s string) (Eviction, error) {
+	switch Eviction(s) {
+	case "":
+		return EvictOldest, nil
+	case EvictOldest, EvictLeastAccessed:
+		return Eviction(s), nil
+	default:
+		return "", fmt.Errorf("invalid eviction policy %q: must be one of %s, %s", s, EvictOldest, EvictLeastAccessed)
+	}
+}
+
+// Retention caps the number of documents in a collection
+type Retention struct {
+	MaxDocuments int      // Documents kept per collection (0 = unlimited)
+	Evict        Eviction // Which documents go first (default: EvictOldest)
+}
+
+// WithRetention sets the document limit of the collection, enforced by PruneToRetention.
+// With EvictLeastAccessed similarity searches also record which documents they return.
+func (vs *PGVectorStore) WithRetention(r Retention) *PGVectorStore {
+	vs.retention = r
+	return vs
+}
+
+// PruneToRetention applies the limit set by WithRetention, see Prune
+func (vs *PGVectorStore) PruneToRetention(ctx context.Context) (int64, error) {
+	return vs.Prune(ctx, vs.retention)
+}
+
+// tracksAccess reports whether similarity searches record which documents they return
+func (r Retention) tracksAccess() bool {
+	return r.MaxDocuments > 0 && r.Evict == EvictLeastAccessed
+}
+
+// Prune deletes documents until the collection holds at most policy.MaxDocuments, choosing
+// them by policy.Evict, and returns how many were removed. A zero limit removes nothing.
+// Curated documents (see CuratedField) are never evicted, so a collection holding more of
+// them than the limit stays above it.
+func (vs *PGVectorStore) Prune(ctx context.Context, policy Retention) (int64, error) {
+	if policy.MaxDocuments <= 0 {
+		return 0, nil
+	}
+	evict, err := ParseEviction(string(policy.Evict))
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := vs.pool.Exec(ctx, pruneQuery(vs.tableName, evict), policy.MaxDocuments)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune %s: %w", vs.tableName, err)
+	}
+	return result.RowsAffected(), nil
+}
+
+// pruneQuery builds the SQL deleting the rows of a table beyond the limit in argument $1,
+// in eviction order. Curated rows count towards the limit but aren't deleted.
+func pruneQuery(tableName string, evict Eviction) string {
+	order := "created_at ASC, id"
+	if evict == EvictLeastAccessed {
+		// false sorts first, so documents within the grace period go last
+		order = fmt.Sprintf("created_at > NOW() - make_interval(secs => %d), access_count ASC, accessed_at ASC NULLS FIRST, created_at ASC, id",
+			int64(EvictionGracePeriod.Seconds()))
+	}
+	return fmt.Sprintf(`
+		DELETE FROM %[1]s
+		WHERE id IN (
+			SELECT id FROM %[1]s
+			WHERE NOT (metadata @> '{"%[3]s": true}')
+			ORDER BY %[2]s
+			LIMIT GREATEST((SELECT COUNT(*) FROM %[1]s) - $1, 0)
+		)
+	`, pgx.Identifier{tableName}.Sanitize(), order, CuratedField)
+}
+
+// recordAccess counts a search hit on the documents with the given IDs
+func (vs *PGVectorStore) recordAccess(ctx context.Context, ids []string) error {
+	query := fmt.Sprintf(`
+		UPDATE %s
+		SET access_count = access_count + 1, accessed_at = NOW()
+		WHERE id = ANY($1::uuid[])
+	`, pgx.Identifier{vs.tableName}.Sanitize())
+	if _, err := vs.pool.Exec(ctx, query, ids); err != nil {
+		return fmt.Errorf("failed to record access: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package vectorstore
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseEviction(t *testing.T) {
+	for _, s := range []string{"", "oldest", "least_accessed"} {
+		if _, err := ParseEviction(s); err != nil {
+			t.Errorf("ParseEviction(%q): %v", s, err)
+		}
+	}
+	if got, _ := ParseEviction(""); got != EvictOldest {
+		t.Errorf("default eviction = %q, want %q", got, EvictOldest)
+	}
+	if _, err := ParseEviction("random"); err == nil {
+		t.Error("ParseEviction(random) succeeded, want error")
+	}
+}
+
+func TestPruneQuery(t *testing.T) {
+	tests := []struct {
+		evict Eviction
+		order string
+	}{
+		{EvictOldest, "ORDER BY created_at ASC, id"},
+		{EvictLeastAccessed, "ORDER BY created_at > NOW() - make_interval(secs => 86400), access_count ASC, accessed_at ASC NULLS FIRST"},
+	}
+	for _, tt := range tests {
+		query := pruneQuery("papers", tt.evict)
+		if !strings.Contains(query, tt.order) {
+			t.Errorf("%s: query missing %q:\n%s", tt.evict, tt.order, query)
+		}
+		if !strings.Contains(query, `LIMIT GREATEST((SELECT COUNT(*) FROM "papers") - $1, 0)`) {
+			t.Errorf("%s: query doesn't limit to the excess documents:\n%s", tt.evict, query)
+		}
+		if !strings.Contains(query, `WHERE NOT (metadata @> '{"curated": true}')`) {
+			t.Errorf("%s: query evicts curated documents:\n%s", tt.evict, query)
+		}
+	}
+}
+
+func TestRetentionTracksAccess(t *testing.T) {
+	if (Retention{Evict: EvictLeastAccessed}).tracksAccess() {
+		t.Error("unlimited collection tracks access")
+	}
+	if (Retention{MaxDocuments: 10, Evict: EvictOldest}).tracksAccess() {
+		t.Error("oldest-first eviction tracks access")
+	}
+	if !(Retention{MaxDocuments: 10, Evict: EvictLeastAccessed}).tracksAccess() {
+		t.Error("least-accessed eviction doesn't track access")
+	}
+}
@@ -0,0 +1,76 @@
+package vectorstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrSchemaMismatch is returned when a table named like a collection exists but wasn't
+// created as one, e.g. by an older schema or another application
+var ErrSchemaMismatch = errors.New("table is not a compatible collection")
+
+// expectedColumns are the columns every query of the store relies on, by Postgres type name.
+// The access columns are added to existing collections by CreateEmbeddingsTable, so they
+// are not required.
+var expectedColumns = map[string]string{
+	"id":         "uuid",
+	"content":    "text",
+	"metadata":   "jsonb",
+	"embedding":  "vector",
+	"created_at": "timestamptz",
+}
+
+// Verify checks that the collection's table has the columns the store expects, with the
+// expected types. A missing table passes, as it is created on first use; an incompatible
+// one fails with ErrSchemaMismatch listing every missing or mistyped column.
+func (vs *PGVectorStore) Verify(ctx context.Context) error {
+	rows, err := vs.pool.Query(ctx, `
+		SELECT column_name, udt_name
+		FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = $1
+	`, vs.tableName)
+	if err != nil {
+		return fmt.Errorf("failed to read columns of %s: %w", vs.tableName, err)
+	}
+	defer rows.Close()
+
+	columns := make(map[string]string)
+	for rows.Next() {
+		var name, typ string
+		if err := rows.Scan(&name, &typ); err != nil {
+			return fmt.Errorf("failed to scan column: %w", err)
+		}
+		columns[name] = typ
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating columns: %w", err)
+	}
+
+	if len(columns) == 0 {
+		return nil
+	}
+	if problems := schemaMismatches(columns); len(problems) > 0 {
+		return fmt.Errorf("%w: %s: %s. Use another collection name or migrate the table", ErrSchemaMismatch, vs.tableName, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// schemaMismatches lists the expected columns that are missing from columns or have
+// another type, sorted by column name
+func schemaMismatches(columns map[string]string) []string {
+	var problems []string
+	for name, want := range expectedColumns {
+		got, ok := columns[name]
+		switch {
+		case !ok:
+			problems = append(problems, fmt.Sprintf("missing column %s (%s)", name, want))
+		case got != want:
+			problems = append(problems, fmt.Sprintf("column %s is %s, want %s", name, got, want))
+		}
+	}
+	sort.Strings(problems)
+	return problems
+}
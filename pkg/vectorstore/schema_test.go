@@ -0,0 +1,33 @@
+package vectorstore
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSchemaMismatches(t *testing.T) {
+	compatible := map[string]string{
+		"id": "uuid", "content": "text", "metadata": "jsonb", "embedding": "vector", "created_at": "timestamptz",
+		"access_count": "int4", "accessed_at": "timestamptz",
+	}
+
+	tests := []struct {
+		name    string
+		columns map[string]string
+		want    []string
+	}{
+		{"compatible", compatible, nil},
+		{"older schema", map[string]string{"id": "int4", "content": "text", "created_at": "timestamptz"}, []string{
+			"column id is int4, want uuid",
+			"missing column embedding (vector)",
+			"missing column metadata (jsonb)",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := schemaMismatches(tt.columns); !slices.Equal(got, tt.want) {
+				t.Errorf("schemaMismatches() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}